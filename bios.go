@@ -0,0 +1,76 @@
+package hal
+
+// ApplyTime selects when staged BIOS settings are applied
+type ApplyTime int
+
+const (
+	// ApplyTimeOnReset applies the settings on the next reset of the server
+	ApplyTimeOnReset ApplyTime = iota
+	// ApplyTimeImmediate applies the settings right away, settings which require a reset still take effect on the next one
+	ApplyTimeImmediate
+	// ApplyTimeAtMaintenanceWindowStart applies the settings at the start of the maintenance window configured in the BMC
+	ApplyTimeAtMaintenanceWindowStart
+)
+
+var applyTimes = [...]string{
+	ApplyTimeOnReset:                  "ONRESET",
+	ApplyTimeImmediate:                "IMMEDIATE",
+	ApplyTimeAtMaintenanceWindowStart: "ATMAINTENANCEWINDOWSTART",
+}
+
+func (a ApplyTime) String() string { return applyTimes[a] }
+
+// BIOSAttributeType the type of the value of a BIOS attribute
+type BIOSAttributeType int
+
+const (
+	// BIOSAttributeTypeUnknown the BMC does not describe the attribute
+	BIOSAttributeTypeUnknown BIOSAttributeType = iota
+	// BIOSAttributeTypeEnumeration the value is one of the allowed values
+	BIOSAttributeTypeEnumeration
+	// BIOSAttributeTypeString free form text
+	BIOSAttributeTypeString
+	// BIOSAttributeTypeInteger a number within the bounds of the attribute
+	BIOSAttributeTypeInteger
+	// BIOSAttributeTypeBoolean true or false
+	BIOSAttributeTypeBoolean
+	// BIOSAttributeTypePassword a password, its value is never reported
+	BIOSAttributeTypePassword
+)
+
+var biosAttributeTypes = [...]string{
+	BIOSAttributeTypeUnknown:     "UNKNOWN",
+	BIOSAttributeTypeEnumeration: "ENUMERATION",
+	BIOSAttributeTypeString:      "STRING",
+	BIOSAttributeTypeInteger:     "INTEGER",
+	BIOSAttributeTypeBoolean:     "BOOLEAN",
+	BIOSAttributeTypePassword:    "PASSWORD",
+}
+
+func (t BIOSAttributeType) String() string { return biosAttributeTypes[t] }
+
+// BIOSAttribute a setting of the BIOS.
+// DisplayName, Type, AllowedValues, the bounds, ReadOnly and ResetRequired are taken from the attribute registry,
+// they are left empty if the BMC does not provide one.
+type BIOSAttribute struct {
+	Name        string
+	DisplayName string
+	Type        BIOSAttributeType
+	// Value which is currently applied, strings, float64 and bool as decoded from json
+	Value any
+	// PendingValue is staged and differs from Value, nil if no change is pending
+	PendingValue any
+	// AllowedValues of an enumeration
+	AllowedValues []string
+	// LowerBound and UpperBound of an integer, nil if not limited
+	LowerBound *uint64
+	UpperBound *uint64
+	ReadOnly   bool
+	// ResetRequired the server must be reset for a change to take effect
+	ResetRequired bool
+}
+
+// Pending returns whether a change of the attribute is staged
+func (a BIOSAttribute) Pending() bool {
+	return a.PendingValue != nil
+}
@@ -0,0 +1,54 @@
+package hal
+
+import "fmt"
+
+// BootOverride selects the target the server boots from
+type BootOverride struct {
+	// Target the server boots from, zero if no override is in effect
+	Target BootTarget
+	// Persistent keeps the override for all following boots, otherwise it only applies to the next boot
+	Persistent bool
+	// Mode the target is booted in, FirmwareModeUnknown leaves the mode of the server untouched where possible
+	Mode FirmwareMode
+}
+
+// DefaultBootOverride returns the override BootFrom applies for the given target:
+// PXE and disk are persistent, all other targets only apply to the next boot, everything boots in UEFI mode.
+func DefaultBootOverride(target BootTarget) BootOverride {
+	return BootOverride{
+		Target:     target,
+		Persistent: target == BootTargetPXE || target == BootTargetDisk,
+		Mode:       FirmwareModeUEFI,
+	}
+}
+
+func (b BootOverride) String() string {
+	if b.Target == 0 {
+		return "NONE"
+	}
+	persistence := "ONCE"
+	if b.Persistent {
+		persistence = "PERSISTENT"
+	}
+	return fmt.Sprintf("%s %s %s", b.Target, persistence, b.Mode)
+}
+
+// BootOption is an entry of the boot order of the firmware
+type BootOption struct {
+	// ID of the option, e.g. Boot0001
+	ID          string
+	DisplayName string
+	// UEFIDevicePath in the text representation of the UEFI specification, empty for legacy options
+	UEFIDevicePath string
+	Enabled        bool
+}
+
+// BootConfiguration describes how the server boots
+type BootConfiguration struct {
+	// Override which is currently in effect, the Target is zero if there is none
+	Override BootOverride
+	// Options in boot order, options which are not part of the boot order follow them
+	Options []BootOption
+	// Mode the server boots in
+	Mode FirmwareMode
+}
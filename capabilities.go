@@ -0,0 +1,169 @@
+package hal
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrNotSupported is returned by every InBand and OutBand method the vendor implementation does not support.
+	// Use Capabilities to find out in advance which operations are available.
+	ErrNotSupported = fmt.Errorf("operation not supported")
+)
+
+type (
+	// Transport the protocol or tool which backs an operation
+	Transport int
+	// Operation a single capability of a InBand or OutBand connection,
+	// power actions, boot targets and firmware updates are reported separately
+	Operation int
+	// PowerAction a power control action of the server
+	PowerAction int
+	// UpdateMethod a firmware update which can be applied to the server
+	UpdateMethod int
+)
+
+const (
+	// TransportUnknown the transport is not known
+	TransportUnknown Transport = iota
+	// TransportRedfish the operation uses the Redfish API of the BMC
+	TransportRedfish
+	// TransportIPMI the operation uses IPMI, either locally via ipmitool or over lan
+	TransportIPMI
+	// TransportSUM the operation uses the Supermicro Update Manager
+	TransportSUM
+	// TransportSSH the operation uses a ssh connection to the BMC
+	TransportSSH
+	// TransportLocal the operation uses local facilities of the machine, e.g. dmi or the kernel
+	TransportLocal
+)
+const (
+	// OperationUUID read the machine UUID
+	OperationUUID Operation = iota + 1
+	// OperationPowerState read the power state of the server
+	OperationPowerState
+	// OperationIdentifyLED switch the identify LED
+	OperationIdentifyLED
+	// OperationFirmware read the firmware mode of the server
+	OperationFirmware
+	// OperationSetFirmware set the firmware mode of the server
+	OperationSetFirmware
+	// OperationConsole open the serial console of the server
+	OperationConsole
+	// OperationConfigureBIOS configure the required BIOS options
+	OperationConfigureBIOS
+	// OperationEnsureBootOrder ensure the boot order
+	OperationEnsureBootOrder
+	// OperationEvents stream and subscribe to the events of the BMC
+	OperationEvents
+	// OperationSEL read and clear the system event log
+	OperationSEL
+	// OperationSensors read the temperature, fan, voltage and current sensors
+	OperationSensors
+	// OperationFRU read the inventory of the field replaceable units
+	OperationFRU
+	// OperationVirtualMedia insert and eject images of virtual media slots
+	OperationVirtualMedia
+	// OperationBootConfiguration read the boot override, the boot options and the boot mode
+	OperationBootConfiguration
+	// OperationBIOSAttributes read and stage BIOS attributes
+	OperationBIOSAttributes
+	// OperationBIOSProfile detect and correct the drift of the BIOS settings from a profile
+	OperationBIOSProfile
+)
+const (
+	// PowerActionOn power on the server
+	PowerActionOn PowerAction = iota + 1
+	// PowerActionOff power off the server
+	PowerActionOff
+	// PowerActionReset hard reset the server
+	PowerActionReset
+	// PowerActionCycle power cycle the server
+	PowerActionCycle
+)
+const (
+	// UpdateMethodBIOS update the BIOS from a url
+	UpdateMethodBIOS UpdateMethod = iota + 1
+	// UpdateMethodBMC update the BMC firmware from a url
+	UpdateMethodBMC
+)
+
+var (
+	transports = [...]string{
+		TransportUnknown: "UNKNOWN",
+		TransportRedfish: "REDFISH",
+		TransportIPMI:    "IPMI",
+		TransportSUM:     "SUM",
+		TransportSSH:     "SSH",
+		TransportLocal:   "LOCAL",
+	}
+	operations = [...]string{
+		OperationUUID:              "UUID",
+		OperationPowerState:        "POWERSTATE",
+		OperationIdentifyLED:       "IDENTIFYLED",
+		OperationFirmware:          "FIRMWARE",
+		OperationSetFirmware:       "SETFIRMWARE",
+		OperationConsole:           "CONSOLE",
+		OperationConfigureBIOS:     "CONFIGUREBIOS",
+		OperationEnsureBootOrder:   "ENSUREBOOTORDER",
+		OperationEvents:            "EVENTS",
+		OperationSEL:               "SEL",
+		OperationSensors:           "SENSORS",
+		OperationFRU:               "FRU",
+		OperationVirtualMedia:      "VIRTUALMEDIA",
+		OperationBootConfiguration: "BOOTCONFIGURATION",
+		OperationBIOSAttributes:    "BIOSATTRIBUTES",
+		OperationBIOSProfile:       "BIOSPROFILE",
+	}
+	powerActions = [...]string{
+		PowerActionOn:    "ON",
+		PowerActionOff:   "OFF",
+		PowerActionReset: "RESET",
+		PowerActionCycle: "CYCLE",
+	}
+	updateMethods = [...]string{
+		UpdateMethodBIOS: "BIOS",
+		UpdateMethodBMC:  "BMC",
+	}
+)
+
+// Stringer
+func (t Transport) String() string    { return transports[t] }
+func (o Operation) String() string    { return operations[o] }
+func (p PowerAction) String() string  { return powerActions[p] }
+func (u UpdateMethod) String() string { return updateMethods[u] }
+
+// Capabilities describes what a InBand or OutBand implementation really supports and which transport backs it.
+// Everything which is not listed is not supported and the corresponding method returns ErrNotSupported.
+type Capabilities struct {
+	Operations    map[Operation]Transport
+	BootTargets   map[BootTarget]Transport
+	PowerActions  map[PowerAction]Transport
+	UpdateMethods map[UpdateMethod]Transport
+	// EmulatedPowerActions lists the power actions which are listed in PowerActions
+	// but are carried out by a different power action, e.g. PowerOn issues a reset.
+	EmulatedPowerActions map[PowerAction]PowerAction
+}
+
+// Supports returns whether the given operation is supported
+func (c Capabilities) Supports(op Operation) bool {
+	_, ok := c.Operations[op]
+	return ok
+}
+
+// SupportsBootTarget returns whether the server can be set to boot from the given target
+func (c Capabilities) SupportsBootTarget(target BootTarget) bool {
+	_, ok := c.BootTargets[target]
+	return ok
+}
+
+// SupportsPowerAction returns whether the given power action is supported, emulated power actions are supported as well
+func (c Capabilities) SupportsPowerAction(action PowerAction) bool {
+	_, ok := c.PowerActions[action]
+	return ok
+}
+
+// SupportsUpdate returns whether the given firmware update is supported
+func (c Capabilities) SupportsUpdate(method UpdateMethod) bool {
+	_, ok := c.UpdateMethods[method]
+	return ok
+}
@@ -0,0 +1,68 @@
+package hal
+
+import (
+	"testing"
+)
+
+func TestTransport_String(t *testing.T) {
+	tests := []struct {
+		name string
+		tr   Transport
+		want string
+	}{
+		{name: "REDFISH", tr: TransportRedfish, want: "REDFISH"},
+		{name: "IPMI", tr: TransportIPMI, want: "IPMI"},
+		{name: "SUM", tr: TransportSUM, want: "SUM"},
+		{name: "SSH", tr: TransportSSH, want: "SSH"},
+		{name: "LOCAL", tr: TransportLocal, want: "LOCAL"},
+		{name: "UNKNOWN", tr: TransportUnknown, want: "UNKNOWN"},
+	}
+	for i := range tests {
+		tt := tests[i]
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tr.String(); got != tt.want {
+				t.Errorf("Transport.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilities_Supports(t *testing.T) {
+	c := Capabilities{
+		Operations: map[Operation]Transport{
+			OperationUUID: TransportRedfish,
+		},
+		BootTargets: map[BootTarget]Transport{
+			BootTargetPXE: TransportIPMI,
+		},
+		PowerActions: map[PowerAction]Transport{
+			PowerActionOn:    TransportRedfish,
+			PowerActionReset: TransportRedfish,
+		},
+		EmulatedPowerActions: map[PowerAction]PowerAction{
+			PowerActionOn: PowerActionReset,
+		},
+	}
+
+	if !c.Supports(OperationUUID) {
+		t.Errorf("Capabilities.Supports(%s) = false, want true", OperationUUID)
+	}
+	if c.Supports(OperationConsole) {
+		t.Errorf("Capabilities.Supports(%s) = true, want false", OperationConsole)
+	}
+	if !c.SupportsBootTarget(BootTargetPXE) {
+		t.Errorf("Capabilities.SupportsBootTarget(%s) = false, want true", BootTargetPXE)
+	}
+	if c.SupportsBootTarget(BootTargetBIOS) {
+		t.Errorf("Capabilities.SupportsBootTarget(%s) = true, want false", BootTargetBIOS)
+	}
+	if !c.SupportsPowerAction(PowerActionOn) {
+		t.Errorf("Capabilities.SupportsPowerAction(%s) = false, want true", PowerActionOn)
+	}
+	if c.SupportsPowerAction(PowerActionCycle) {
+		t.Errorf("Capabilities.SupportsPowerAction(%s) = true, want false", PowerActionCycle)
+	}
+	if c.SupportsUpdate(UpdateMethodBIOS) {
+		t.Errorf("Capabilities.SupportsUpdate(%s) = true, want false", UpdateMethodBIOS)
+	}
+}
@@ -1,81 +1,107 @@
 package connect
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"github.com/metal-stack/go-hal/internal/vendors/dell"
-	"github.com/metal-stack/go-hal/internal/vendors/gigabyte"
-
-	"github.com/metal-stack/go-hal/internal/vendors/vagrant"
-	"github.com/metal-stack/go-hal/pkg/logger"
-
 	"github.com/metal-stack/go-hal"
 	"github.com/metal-stack/go-hal/internal/dmi"
 	"github.com/metal-stack/go-hal/internal/redfish"
-	"github.com/metal-stack/go-hal/internal/vendors/lenovo"
-	"github.com/metal-stack/go-hal/internal/vendors/supermicro"
+	"github.com/metal-stack/go-hal/internal/vendors/generic"
 	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
 )
 
 var (
 	errorUnknownVendor = fmt.Errorf("vendor unknown")
 )
 
+// OutBandOption configures how the outband connection is established
+type OutBandOption func(*outBandConfig)
+
+type outBandConfig struct {
+	genericRedfish bool
+}
+
+// WithGenericRedfish forces the generic Redfish implementation regardless of the detected vendor
+func WithGenericRedfish() OutBandOption {
+	return func(c *outBandConfig) {
+		c.genericRedfish = true
+	}
+}
+
 // InBand will detect the board and choose the correct inband hal implementation
 func InBand(log logger.Logger) (hal.InBand, error) {
+	return InBandContext(context.Background(), log)
+}
+
+// InBandContext will detect the board and choose the correct inband hal implementation,
+// the given context bounds the initial BMC inspection.
+func InBandContext(ctx context.Context, log logger.Logger) (hal.InBand, error) {
 	b, err := dmi.BoardInfo()
 	if err != nil {
 		b = api.VagrantBoard
 	}
 	b.Vendor = api.GuessVendor(b.VendorString)
 	log.Debugw("connect", "vendor", b)
-	switch b.Vendor {
-	case api.VendorLenovo:
-		return lenovo.InBand(b, log)
-	case api.VendorSupermicro, api.VendorNovarion:
-		return supermicro.InBand(b, log)
-	case api.VendorVagrant:
-		return vagrant.InBand(b, log)
-	case api.VendorGigabyte:
-		return gigabyte.InBand(b, log)
-	case api.VendorDell:
-		return dell.InBand(b, log)
-	case api.VendorUnknown:
-		fallthrough
-	default:
+	v, ok := lookup(b, func(v Vendor) bool { return v.InBand != nil })
+	if !ok {
 		log.Errorw("connect", "unknown vendor", b.Vendor)
 		return nil, errorUnknownVendor
 	}
+	if v.Vendor != api.VendorUnknown {
+		b.Vendor = v.Vendor
+	}
+	log.Debugw("connect", "implementation", v.Name)
+	return v.InBand(ctx, b, log)
+}
+
+// OutBand will detect the board and choose the correct outband hal implementation,
+// boards of unknown vendors are connected with the generic Redfish implementation.
+func OutBand(ip string, ipmiPort int, user, password string, log logger.Logger, connectionTimeout *time.Duration, opts ...OutBandOption) (hal.OutBand, error) {
+	return OutBandContext(context.Background(), ip, ipmiPort, user, password, log, connectionTimeout, opts...)
 }
 
-// OutBand will detect the board and choose the correct outband hal implementation
-func OutBand(ip string, ipmiPort int, user, password string, log logger.Logger, connectionTimeout *time.Duration) (hal.OutBand, error) {
-	r, err := redfish.New("https://"+ip, user, password, true, log, connectionTimeout)
+// OutBandContext will detect the board and choose the correct outband hal implementation,
+// the given context bounds the redfish connection establishment and board detection.
+func OutBandContext(ctx context.Context, ip string, ipmiPort int, user, password string, log logger.Logger, connectionTimeout *time.Duration, opts ...OutBandOption) (hal.OutBand, error) {
+	config := &outBandConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	r, err := redfish.New(ctx, "https://"+ip, user, password, true, log, connectionTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("unable to establish redfish connection for ip:%s user:%s error:%w", ip, user, err)
 	}
-	b, err := r.BoardInfo()
+	b, err := r.BoardInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get board info via redfish for ip:%s user:%s error:%w", ip, user, err)
 	}
 	b.Vendor = api.GuessVendor(b.VendorString)
 	log.Debugw("connect", "board", b)
-	switch b.Vendor {
-	case api.VendorLenovo:
-		return lenovo.OutBand(r, b), nil
-	case api.VendorSupermicro, api.VendorNovarion:
-		return supermicro.OutBand(r, b, ip, ipmiPort, user, password, log)
-	case api.VendorVagrant:
-		return vagrant.OutBand(b, ip, ipmiPort, user, password), nil
-	case api.VendorGigabyte:
-		return gigabyte.OutBand(r, b), nil
-	case api.VendorDell:
-		return dell.OutBand(r, b, user, password, ip, log), nil
-	case api.VendorUnknown:
-		fallthrough
-	default:
-		log.Errorw("connect", "unknown vendor", b.Vendor)
-		return nil, errorUnknownVendor
+	if config.genericRedfish {
+		return generic.OutBand(ctx, r, b, user, password, ip, log), nil
+	}
+
+	conn := &OutBandConnection{
+		Board:    b,
+		IP:       ip,
+		IPMIPort: ipmiPort,
+		User:     user,
+		Password: password,
+		Log:      log,
+		redfish:  r,
+	}
+	v, ok := lookup(b, func(v Vendor) bool { return v.OutBand != nil })
+	if !ok {
+		log.Infow("connect", "unknown vendor, using generic redfish", b.VendorString)
+		return conn.Generic(ctx), nil
+	}
+	if v.Vendor != api.VendorUnknown {
+		b.Vendor = v.Vendor
 	}
+	log.Debugw("connect", "implementation", v.Name)
+	return v.OutBand(ctx, conn)
 }
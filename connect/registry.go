@@ -0,0 +1,165 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/vendors/generic"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+type (
+	// Matcher reports whether a vendor implementation is responsible for the given board
+	Matcher func(board *api.Board) bool
+
+	// InBandConstructor creates the in-band implementation for the detected board
+	InBandConstructor func(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error)
+
+	// OutBandConstructor creates the out-band implementation for the detected board
+	OutBandConstructor func(ctx context.Context, conn *OutBandConnection) (hal.OutBand, error)
+
+	// Vendor describes a vendor implementation which is chosen by InBand and OutBand if its matcher matches the board.
+	// If several vendors match, the one with the highest priority wins, on equal priority the last registered one.
+	// The built-in vendors are registered with priority 0.
+	Vendor struct {
+		// Name identifies the registration in logs
+		Name string
+		// Vendor is assigned to the boards which are matched by this registration, VendorUnknown leaves the guessed vendor
+		Vendor api.Vendor
+		// Priority of this registration over other registrations which match the same board
+		Priority int
+		// Match selects the boards this registration is responsible for
+		Match Matcher
+		// InBand creates the in-band implementation, nil if the vendor is only supported out-of-band
+		InBand InBandConstructor
+		// OutBand creates the out-band implementation, nil if the vendor is only supported in-band
+		OutBand OutBandConstructor
+	}
+
+	// OutBandConnection holds everything which is known about the BMC when the out-band implementation is created
+	OutBandConnection struct {
+		Board    *api.Board
+		IP       string
+		IPMIPort int
+		User     string
+		Password string
+		Log      logger.Logger
+
+		redfish *redfish.APIClient
+	}
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     []Vendor
+)
+
+// Register adds a vendor implementation which is considered by InBand and OutBand from now on
+func Register(v Vendor) error {
+	if v.Match == nil {
+		return fmt.Errorf("vendor %q has no matcher", v.Name)
+	}
+	if v.InBand == nil && v.OutBand == nil {
+		return fmt.Errorf("vendor %q has neither an inband nor an outband constructor", v.Name)
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry = append(registry, v)
+	return nil
+}
+
+// lookup returns the registration responsible for the board which provides the required constructor
+func lookup(board *api.Board, has func(Vendor) bool) (Vendor, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	var (
+		found Vendor
+		ok    bool
+	)
+	for _, v := range registry {
+		if !has(v) || !v.Match(board) {
+			continue
+		}
+		if !ok || v.Priority >= found.Priority {
+			found = v
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// Generic returns the generic Redfish implementation for this connection.
+// Vendors which only differ in a few operations can embed it and override just those.
+func (c *OutBandConnection) Generic(ctx context.Context) hal.OutBand {
+	return generic.OutBand(ctx, c.redfish, c.Board, c.User, c.Password, c.IP, c.Log)
+}
+
+// MatchVendor matches boards for which the given vendor was guessed from the manufacturer
+func MatchVendor(vendors ...api.Vendor) Matcher {
+	return func(board *api.Board) bool {
+		for _, v := range vendors {
+			if board.Vendor == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchManufacturer matches boards whose manufacturer contains the given string, ignoring case
+func MatchManufacturer(manufacturer string) Matcher {
+	manufacturer = strings.ToLower(strings.TrimSpace(manufacturer))
+	return func(board *api.Board) bool {
+		return strings.Contains(strings.ToLower(board.VendorString), manufacturer)
+	}
+}
+
+// MatchModel matches boards whose model matches the given regular expression, it panics if the expression is invalid
+func MatchModel(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return func(board *api.Board) bool {
+		return re.MatchString(board.Model)
+	}
+}
+
+// MatchDMI matches if the given dmi field, e.g. product_name, matches the given regular expression.
+// The dmi table is only read in-band, therefore this matcher never matches out-of-band.
+// It panics if the expression is invalid.
+func MatchDMI(field, expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return func(board *api.Board) bool {
+		value, ok := board.DMI[field]
+		return ok && re.MatchString(value)
+	}
+}
+
+// MatchAll matches if all given matchers match
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(board *api.Board) bool {
+		for _, m := range matchers {
+			if !m(board) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny matches if at least one of the given matchers matches
+func MatchAny(matchers ...Matcher) Matcher {
+	return func(board *api.Board) bool {
+		for _, m := range matchers {
+			if m(board) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package connect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func withRegistry(t *testing.T, vendors ...Vendor) {
+	registryLock.Lock()
+	saved := registry
+	registry = nil
+	registryLock.Unlock()
+	t.Cleanup(func() {
+		registryLock.Lock()
+		registry = saved
+		registryLock.Unlock()
+	})
+	for _, v := range vendors {
+		require.NoError(t, Register(v))
+	}
+}
+
+func inBandConstructor(context.Context, *api.Board, logger.Logger) (hal.InBand, error) {
+	return nil, nil
+}
+
+func outBandConstructor(context.Context, *OutBandConnection) (hal.OutBand, error) {
+	return nil, nil
+}
+
+func TestRegister(t *testing.T) {
+	withRegistry(t)
+
+	require.Error(t, Register(Vendor{Name: "no matcher", InBand: inBandConstructor}))
+	require.Error(t, Register(Vendor{Name: "no constructor", Match: MatchManufacturer("acme")}))
+	require.NoError(t, Register(Vendor{Name: "acme", Match: MatchManufacturer("acme"), OutBand: outBandConstructor}))
+}
+
+func TestLookup(t *testing.T) {
+	withRegistry(t,
+		Vendor{Name: "acme", Match: MatchManufacturer("acme"), InBand: inBandConstructor, OutBand: outBandConstructor},
+		Vendor{Name: "acme-x1", Priority: 10, Match: MatchAll(MatchManufacturer("acme"), MatchModel(`^X1-`)), InBand: inBandConstructor},
+		Vendor{Name: "acme-override", Match: MatchManufacturer("acme"), OutBand: outBandConstructor},
+		Vendor{Name: "acme-dmi", Priority: 20, Match: MatchDMI("product_name", `^Rack 9$`), InBand: inBandConstructor},
+	)
+
+	inband := func(v Vendor) bool { return v.InBand != nil }
+	outband := func(v Vendor) bool { return v.OutBand != nil }
+
+	tests := []struct {
+		name  string
+		board *api.Board
+		has   func(Vendor) bool
+		want  string
+	}{
+		{name: "plain match", board: &api.Board{VendorString: "ACME Inc.", Model: "A2"}, has: inband, want: "acme"},
+		{name: "higher priority wins", board: &api.Board{VendorString: "ACME Inc.", Model: "X1-2U"}, has: inband, want: "acme-x1"},
+		{name: "higher priority without constructor is skipped", board: &api.Board{VendorString: "ACME Inc.", Model: "X1-2U"}, has: outband, want: "acme-override"},
+		{name: "last registered wins on equal priority", board: &api.Board{VendorString: "ACME Inc.", Model: "A2"}, has: outband, want: "acme-override"},
+		{name: "dmi field", board: &api.Board{VendorString: "ACME Inc.", DMI: map[string]string{"product_name": "Rack 9"}}, has: inband, want: "acme-dmi"},
+		{name: "no match", board: &api.Board{VendorString: "Other"}, has: inband, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := lookup(tt.board, tt.has)
+			require.Equal(t, tt.want != "", ok)
+			require.Equal(t, tt.want, v.Name)
+		})
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	tests := []struct {
+		vendorString string
+		want         string
+	}{
+		{vendorString: "Supermicro", want: "supermicro"},
+		{vendorString: "Novarion-Systems", want: "supermicro"},
+		{vendorString: "Lenovo", want: "lenovo"},
+		{vendorString: "Dell Inc.", want: "dell"},
+		{vendorString: "Giga Computing", want: "gigabyte"},
+		{vendorString: "vagrant", want: "vagrant"},
+		{vendorString: "HPE", want: "hpe"},
+		{vendorString: "ASRockRack", want: "megarac"},
+		{vendorString: "ASRock Rack", want: "megarac"},
+		{vendorString: "American Megatrends Inc.", want: "megarac"},
+		{vendorString: "AMI", want: "megarac"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vendorString, func(t *testing.T) {
+			b := &api.Board{VendorString: tt.vendorString}
+			b.Vendor = api.GuessVendor(b.VendorString)
+			v, ok := lookup(b, func(v Vendor) bool { return v.OutBand != nil })
+			require.True(t, ok)
+			require.Equal(t, tt.want, v.Name)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+// rollbackTimeout bounds the restore of the previous password, which must also happen if the context of the rotation is done
+const rollbackTimeout = 30 * time.Second
+
+// PasswordChanger changes the password of the user, e.g. api.BMCConnection.ChangePasswordContext in-band
+// or api.OutBandBMCConnection.ChangePassword out-of-band
+type PasswordChanger func(ctx context.Context, user api.BMCUser, newPassword string) error
+
+// PasswordVerifier returns an error if the user is not able to authenticate with the given password
+type PasswordVerifier func(ctx context.Context, user api.BMCUser, password string) error
+
+// PasswordRotation is the outcome of a rotation, Password is the password which is valid afterwards
+type PasswordRotation struct {
+	User             api.BMCUser
+	Password         string
+	PreviousPassword string
+	// Rotated is true if the new password was applied and verified
+	Rotated bool
+	// RolledBack is true if the new password failed verification and the previous password was restored
+	RolledBack bool
+	Time       time.Time
+}
+
+// VerifyWithBMC verifies the password by testing it against the local BMC.
+// Vendors which do not implement NeedsPasswordChange always fail, they must be verified with VerifyWithOutBandLogin.
+func VerifyWithBMC(conn api.BMCConnection) PasswordVerifier {
+	return func(ctx context.Context, user api.BMCUser, password string) error {
+		needsChange, err := conn.NeedsPasswordChangeContext(ctx, user, password)
+		if err != nil {
+			return err
+		}
+		if needsChange {
+			return fmt.Errorf("password of user %s is not accepted by the bmc", user.Name)
+		}
+		return nil
+	}
+}
+
+// VerifyWithOutBandLogin verifies the password by logging in to the Redfish service of the BMC with a fresh session
+func VerifyWithOutBandLogin(ip string, log logger.Logger, connectionTimeout *time.Duration) PasswordVerifier {
+	return func(ctx context.Context, user api.BMCUser, password string) error {
+		r, err := redfish.New(ctx, "https://"+ip, user.Name, password, true, log, connectionTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to login to %s with user %s: %w", ip, user.Name, err)
+		}
+		r.Logout()
+		return nil
+	}
+}
+
+// RotatePassword changes the password of the user to a new one generated with the given constraints, usually those of
+// board.PasswordConstraints(), and verifies it. If the verification fails, the current password is restored.
+// An error is returned if the rotation did not happen, the result tells which password is valid in either case.
+// The out-of-band connection may rotate the password of its own user, it logs in again with the new password.
+func RotatePassword(ctx context.Context, change PasswordChanger, constraints *api.PasswordConstraints, user api.BMCUser, current string, verify PasswordVerifier) (*PasswordRotation, error) {
+	if constraints == nil {
+		return nil, fmt.Errorf("no password constraints given")
+	}
+	if change == nil {
+		return nil, fmt.Errorf("no password changer given")
+	}
+	if verify == nil {
+		return nil, fmt.Errorf("no password verifier given")
+	}
+	newPassword, err := constraints.Generate(user.Name)
+	if err != nil {
+		return nil, fmt.Errorf("password generation failed for user %s: %w", user.Name, err)
+	}
+
+	result := &PasswordRotation{
+		User:             user,
+		Password:         current,
+		PreviousPassword: current,
+		Time:             time.Now(),
+	}
+	err = change(ctx, user, newPassword)
+	if err != nil {
+		return result, fmt.Errorf("unable to change password of user %s: %w", user.Name, err)
+	}
+	result.Password = newPassword
+
+	verifyErr := verify(ctx, user, newPassword)
+	if verifyErr == nil {
+		result.Rotated = true
+		return result, nil
+	}
+	verifyErr = fmt.Errorf("verification of new password of user %s failed: %w", user.Name, verifyErr)
+
+	// verification fails most likely because ctx is done, the rollback must still reach the bmc
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	err = change(rollbackCtx, user, current)
+	if err != nil {
+		// the new password was applied and is still the one to persist
+		return result, errors.Join(verifyErr, fmt.Errorf("unable to roll back password of user %s: %w", user.Name, err))
+	}
+	result.Password = current
+	result.RolledBack = true
+	return result, verifyErr
+}
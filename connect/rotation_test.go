@@ -0,0 +1,158 @@
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal/internal/outband"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// fakeBMC accepts only its current password, all changes from the failFrom'th on fail
+type fakeBMC struct {
+	api.BMCConnection
+	password string
+	changes  int
+	failFrom int
+}
+
+func (f *fakeBMC) ChangePasswordContext(ctx context.Context, _ api.BMCUser, newPassword string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.changes++
+	if f.failFrom > 0 && f.changes >= f.failFrom {
+		return errors.New("bmc unreachable")
+	}
+	f.password = newPassword
+	return nil
+}
+
+func (f *fakeBMC) NeedsPasswordChangeContext(_ context.Context, _ api.BMCUser, password string) (bool, error) {
+	return password != f.password, nil
+}
+
+func TestRotatePassword(t *testing.T) {
+	user := api.BMCUser{Name: "metal", Id: "2"}
+	constraints := api.VendorSupermicro.PasswordConstraints()
+
+	bmc := &fakeBMC{password: "old"}
+	result, err := RotatePassword(t.Context(), bmc.ChangePasswordContext, constraints, user, "old", VerifyWithBMC(bmc))
+	require.NoError(t, err)
+	require.True(t, result.Rotated)
+	require.False(t, result.RolledBack)
+	require.Equal(t, "old", result.PreviousPassword)
+	require.Equal(t, bmc.password, result.Password)
+	require.Len(t, result.Password, constraints.Length)
+
+	// verification fails, the previous password is restored
+	bmc = &fakeBMC{password: "old"}
+	result, err = RotatePassword(t.Context(), bmc.ChangePasswordContext, constraints, user, "old", func(context.Context, api.BMCUser, string) error {
+		return errors.New("login failed")
+	})
+	require.EqualError(t, err, "verification of new password of user metal failed: login failed")
+	require.False(t, result.Rotated)
+	require.True(t, result.RolledBack)
+	require.Equal(t, "old", result.Password)
+	require.Equal(t, "old", bmc.password)
+
+	// verification times out, the rollback is not bound to the context of the rotation
+	bmc = &fakeBMC{password: "old"}
+	ctx, cancel := context.WithCancel(t.Context())
+	result, err = RotatePassword(ctx, bmc.ChangePasswordContext, constraints, user, "old", func(context.Context, api.BMCUser, string) error {
+		cancel()
+		return context.DeadlineExceeded
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, result.RolledBack)
+	require.Equal(t, "old", result.Password)
+	require.Equal(t, "old", bmc.password)
+
+	// rollback fails, the new password remains
+	bmc = &fakeBMC{password: "old", failFrom: 2}
+	result, err = RotatePassword(t.Context(), bmc.ChangePasswordContext, constraints, user, "old", func(context.Context, api.BMCUser, string) error {
+		return errors.New("login failed")
+	})
+	require.ErrorContains(t, err, "unable to roll back password of user metal: bmc unreachable")
+	require.False(t, result.RolledBack)
+	require.Equal(t, bmc.password, result.Password)
+	require.NotEqual(t, "old", result.Password)
+
+	// change fails, nothing happened
+	bmc = &fakeBMC{password: "old", failFrom: 1}
+	result, err = RotatePassword(t.Context(), bmc.ChangePasswordContext, constraints, user, "old", VerifyWithBMC(bmc))
+	require.EqualError(t, err, "unable to change password of user metal: bmc unreachable")
+	require.False(t, result.Rotated)
+	require.Equal(t, "old", result.Password)
+
+	_, err = RotatePassword(t.Context(), bmc.ChangePasswordContext, constraints, user, "old", nil)
+	require.EqualError(t, err, "no password verifier given")
+	_, err = RotatePassword(t.Context(), nil, constraints, user, "old", VerifyWithBMC(bmc))
+	require.EqualError(t, err, "no password changer given")
+}
+
+func TestRotatePasswordOfOutBandUser(t *testing.T) {
+	const account = "/redfish/v1/AccountService/Accounts/2"
+	srv := redfishtest.NewServer(t, "../internal/redfish/testdata/accounts.json")
+
+	// the account only accepts requests with its current password
+	var (
+		mu       sync.Mutex
+		password = "old"
+		auth     []string
+	)
+	srv.Handle(http.MethodPatch, account, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, p, _ := r.BasicAuth()
+		auth = append(auth, p)
+		if p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Password string
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		password = body.Password
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r, err := redfish.New(t.Context(), srv.URL, "root", "old", true, redfishtest.Logger(), nil)
+	require.NoError(t, err)
+	ob := outband.New(r, nil, &api.Board{}, "127.0.0.1", 623, "root", "old")
+	user := api.BMCUser{Name: "root", Id: "2"}
+	constraints := api.VendorSupermicro.PasswordConstraints()
+
+	// verification fails, the rollback must be authenticated with the new password
+	var newPassword string
+	result, err := RotatePassword(t.Context(), ob.ChangePassword, constraints, user, "old", func(_ context.Context, _ api.BMCUser, password string) error {
+		newPassword = password
+		return errors.New("login failed")
+	})
+	require.EqualError(t, err, "verification of new password of user root failed: login failed")
+	require.True(t, result.RolledBack)
+	mu.Lock()
+	require.Equal(t, "old", password)
+	require.Equal(t, []string{"old", newPassword}, auth)
+	mu.Unlock()
+
+	result, err = RotatePassword(t.Context(), ob.ChangePassword, constraints, user, "old", func(context.Context, api.BMCUser, string) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, result.Rotated)
+	mu.Lock()
+	require.Equal(t, result.Password, password)
+	mu.Unlock()
+	_, _, _, ipmiPassword := ob.IPMIConnection()
+	require.Equal(t, result.Password, ipmiPassword)
+}
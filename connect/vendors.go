@@ -0,0 +1,104 @@
+package connect
+
+import (
+	"context"
+	"strings"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/vendors/dell"
+	"github.com/metal-stack/go-hal/internal/vendors/gigabyte"
+	"github.com/metal-stack/go-hal/internal/vendors/hpe"
+	"github.com/metal-stack/go-hal/internal/vendors/lenovo"
+	"github.com/metal-stack/go-hal/internal/vendors/megarac"
+	"github.com/metal-stack/go-hal/internal/vendors/supermicro"
+	"github.com/metal-stack/go-hal/internal/vendors/vagrant"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// builtin vendors which ship with go-hal
+var builtin = []Vendor{
+	{
+		Name:   "lenovo",
+		Vendor: api.VendorLenovo,
+		Match:  MatchVendor(api.VendorLenovo),
+		InBand: lenovo.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return lenovo.OutBand(c.redfish, c.Board), nil
+		},
+	},
+	{
+		Name:   "supermicro",
+		Vendor: api.VendorUnknown, // keep Novarion boards identified as such
+		Match:  MatchVendor(api.VendorSupermicro, api.VendorNovarion),
+		InBand: supermicro.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return supermicro.OutBand(c.redfish, c.Board, c.IP, c.IPMIPort, c.User, c.Password, c.Log)
+		},
+	},
+	{
+		Name:   "vagrant",
+		Vendor: api.VendorVagrant,
+		Match:  MatchVendor(api.VendorVagrant),
+		InBand: vagrant.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return vagrant.OutBand(c.Board, c.IP, c.IPMIPort, c.User, c.Password), nil
+		},
+	},
+	{
+		Name:   "gigabyte",
+		Vendor: api.VendorGigabyte,
+		Match:  MatchVendor(api.VendorGigabyte),
+		InBand: gigabyte.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return gigabyte.OutBand(c.redfish, c.Board), nil
+		},
+	},
+	{
+		Name:   "dell",
+		Vendor: api.VendorDell,
+		Match:  MatchVendor(api.VendorDell),
+		InBand: dell.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return dell.OutBand(c.redfish, c.Board, c.User, c.Password, c.IP, c.Log), nil
+		},
+	},
+	{
+		Name:   "hpe",
+		Vendor: api.VendorHPE,
+		Match:  MatchVendor(api.VendorHPE),
+		InBand: hpe.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return hpe.OutBand(c.redfish, c.Board, c.User, c.Password, c.IP, c.Log), nil
+		},
+	},
+	{
+		Name:   "megarac",
+		Vendor: api.VendorUnknown, // other vendors build on MegaRAC as well, only ASRock Rack is known by name
+		Match: MatchAny(
+			MatchVendor(api.VendorASRockRack),
+			MatchManufacturer("ASRock"),
+			MatchManufacturer("American Megatrends"),
+			// a bare "AMI" must not be matched as substring, it is part of too many names
+			func(b *api.Board) bool { return strings.EqualFold(strings.TrimSpace(b.VendorString), "AMI") },
+		),
+		InBand: megarac.InBand,
+		OutBand: func(_ context.Context, c *OutBandConnection) (hal.OutBand, error) {
+			return megarac.OutBand(c.redfish, c.Board, c.IP, c.IPMIPort, c.User, c.Password, c.Log)
+		},
+	},
+}
+
+// LoadSupermicroBoards adds the board models of the given catalog file to the built-in catalog of Supermicro boards
+// whose BIOS can be configured, entries for built-in board models replace them. See boards.json of the supermicro
+// vendor for the format.
+func LoadSupermicroBoards(path string) error {
+	return supermicro.LoadBoards(path)
+}
+
+func init() {
+	for _, v := range builtin {
+		if err := Register(v); err != nil {
+			panic(err)
+		}
+	}
+}
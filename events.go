@@ -0,0 +1,64 @@
+package hal
+
+import (
+	"time"
+)
+
+// EventKind classifies the events of a BMC
+type EventKind int
+
+const (
+	// EventKindOther any event which is not classified further
+	EventKindOther EventKind = iota
+	// EventKindPowerState the power state of the server changed
+	EventKindPowerState
+	// EventKindLog an entry was added to a log of the BMC, e.g. the SEL
+	EventKindLog
+	// EventKindTask a task of the BMC, e.g. a firmware update, changed its state
+	EventKindTask
+)
+
+var eventKinds = [...]string{
+	EventKindOther:      "OTHER",
+	EventKindPowerState: "POWERSTATE",
+	EventKindLog:        "LOG",
+	EventKindTask:       "TASK",
+}
+
+func (k EventKind) String() string { return eventKinds[k] }
+
+// Event a notification of the BMC
+type Event struct {
+	// ID identifies the event, it is unique for the BMC
+	ID   string
+	Kind EventKind
+	// Timestamp is the time the event occurred as reported by the BMC, zero if not reported
+	Timestamp   time.Time
+	Severity    string
+	MessageID   string
+	Message     string
+	MessageArgs []string
+	// Origin is the Redfish resource the event refers to, e.g. /redfish/v1/Systems/1
+	Origin string
+
+	// PowerState is the new power state of the server, only set for EventKindPowerState
+	PowerState PowerState
+	// LogEntry is the Redfish resource of the created log entry, only set for EventKindLog
+	LogEntry string
+	// TaskState is the new Redfish task state, e.g. Completed or Exception, only set for EventKindTask
+	TaskState string
+}
+
+// EventSubscription a subscription which lets the BMC push events to a destination
+type EventSubscription struct {
+	// ID is the Redfish resource of the subscription, it is assigned by the BMC
+	ID string
+	// Destination is the url events are sent to
+	Destination string
+	// Context is sent along with every event, it defaults to go-hal
+	Context string
+	// RegistryPrefixes limits the events to these message registries, e.g. TaskEvent, all if empty
+	RegistryPrefixes []string
+	// ResourceTypes limits the events to these origin resource types, e.g. ComputerSystem, all if empty
+	ResourceTypes []string
+}
@@ -1,9 +1,11 @@
 package hal
 
 import (
-	"github.com/gliderlabs/ssh"
+	"context"
 	"strings"
 
+	"github.com/gliderlabs/ssh"
+
 	"github.com/google/uuid"
 	"github.com/metal-stack/go-hal/pkg/api"
 )
@@ -34,6 +36,14 @@ const (
 	BootTargetDisk
 	// BootTargetBIOS the server boots into Bios
 	BootTargetBIOS
+	// BootTargetCD the server boots from a CD or DVD, virtual CDs of the BMC included
+	BootTargetCD
+	// BootTargetUSB the server boots from a USB stick or other removable media
+	BootTargetUSB
+	// BootTargetHTTP the server boots via UEFI HTTP boot
+	BootTargetHTTP
+	// BootTargetUEFIShell the server boots into the UEFI shell
+	BootTargetUEFIShell
 )
 const (
 	// IdentifyLEDStateUnknown the LED is unknown
@@ -59,9 +69,13 @@ var (
 		PowerUnknownState: "UNKNOWN",
 	}
 	bootTargets = [...]string{
-		BootTargetPXE:  "PXE",
-		BootTargetDisk: "DISK",
-		BootTargetBIOS: "BIOS",
+		BootTargetPXE:       "PXE",
+		BootTargetDisk:      "DISK",
+		BootTargetBIOS:      "BIOS",
+		BootTargetCD:        "CD",
+		BootTargetUSB:       "USB",
+		BootTargetHTTP:      "HTTP",
+		BootTargetUEFIShell: "UEFISHELL",
 	}
 	ledStates = [...]string{
 		IdentifyLEDStateOn:      "ON",
@@ -92,6 +106,8 @@ func GuessPowerState(powerState string) PowerState {
 }
 
 // InBand get and set settings from the server via the inband interface.
+// Every method which talks to the hardware has a Context variant which aborts the call once the given context is done,
+// the method without context uses context.Background().
 type InBand interface {
 	// Board return board information of the current connection
 	Board() *api.Board
@@ -99,84 +115,185 @@ type InBand interface {
 	// UUID get the machine UUID
 	// current usage in metal-hammer
 	UUID() (*uuid.UUID, error)
+	UUIDContext(ctx context.Context) (*uuid.UUID, error)
 
 	// PowerOff set power state of the server to off
 	PowerOff() error
+	PowerOffContext(ctx context.Context) error
 	// PowerReset reset the power state of the server
 	PowerReset() error
+	PowerResetContext(ctx context.Context) error
 	// PowerCycle cycle the power state of the server
 	PowerCycle() error
+	PowerCycleContext(ctx context.Context) error
 
 	// IdentifyLEDState get the identify LED state
 	IdentifyLEDState(IdentifyLEDState) error
+	IdentifyLEDStateContext(ctx context.Context, state IdentifyLEDState) error
 	// IdentifyLEDOn set the identify LED to on
 	IdentifyLEDOn() error
+	IdentifyLEDOnContext(ctx context.Context) error
 	// IdentifyLEDOff set the identify LED to off
 	IdentifyLEDOff() error
+	IdentifyLEDOffContext(ctx context.Context) error
 
 	// BootFrom set the boot order of the server to the specified target
 	BootFrom(BootTarget) error
+	BootFromContext(ctx context.Context, target BootTarget) error
+	// SetBootOverride sets the boot target of the server together with its persistence and firmware mode
+	SetBootOverride(ctx context.Context, override BootOverride) error
+	// BootOverride returns the boot override which is currently in effect, the Target is zero if there is none
+	BootOverride(ctx context.Context) (*BootOverride, error)
+	// BootConfiguration returns the boot override, the boot options in boot order and the boot mode,
+	// it allows to verify the boot configuration before the server is rebooted
+	BootConfiguration(ctx context.Context) (*BootConfiguration, error)
 
 	// Firmware get the FirmwareMode of the server
 	Firmware() (FirmwareMode, error)
+	FirmwareContext(ctx context.Context) (FirmwareMode, error)
 	// SetFirmware set the FirmwareMode of the server
 	SetFirmware(FirmwareMode) error
+	SetFirmwareContext(ctx context.Context, mode FirmwareMode) error
 
 	// Describe print a basic information about this connection
 	Describe() string
 
+	// Capabilities returns the operations this connection supports and the transport which backs each of them
+	Capabilities() Capabilities
+
+	// SEL returns the system event log entries with a record id greater than after, sorted by record id.
+	// Pass 0 to read all entries.
+	SEL(ctx context.Context, after uint16) ([]SELEntry, error)
+	// ClearSEL clears the system event log
+	ClearSEL(ctx context.Context) error
+	// Sensors returns the current readings of the temperature, fan, voltage and current sensors
+	Sensors(ctx context.Context) ([]Sensor, error)
+	// FRUs returns the inventory of all field replaceable units the BMC knows, e.g. mainboard, power supplies and backplanes
+	FRUs(ctx context.Context) ([]api.FRU, error)
+
 	// TODO add MachineFRU, BiosVersion, BMCVersion, BMC{IP, MAC, Interface}
 
 	// BMCConnection returns a connection to the BMC
 	BMCConnection() api.BMCConnection
 
 	// ConfigureBIOS configures the BIOS regarding certain required options.
-	// It returns whether the system needs to be rebooted afterwards
+	// It returns whether the system needs to be rebooted afterwards, ErrNotSupported if the BIOS of this server is not configured by go-hal
 	ConfigureBIOS() (bool, error)
+	ConfigureBIOSContext(ctx context.Context) (bool, error)
 
-	// EnsureBootOrder ensures the boot order
+	// EnsureBootOrder ensures the bootloader installed with the given id is booted before PXE
 	EnsureBootOrder(bootloaderID string) error
+	EnsureBootOrderContext(ctx context.Context, bootloaderID string) error
+
+	// BIOSDrift returns the settings of the profile whose current value differs, nothing is changed
+	BIOSDrift(ctx context.Context, profile BIOSProfile) ([]BIOSSettingDrift, error)
+	// ApplyBIOSProfile changes only the settings which differ from the profile.
+	// It returns whether the system needs to be rebooted afterwards
+	ApplyBIOSProfile(ctx context.Context, profile BIOSProfile) (bool, error)
 }
 
 // OutBand get and set settings from the server via the out of band interface.
+// Every method which talks to the BMC has a Context variant which aborts the call once the given context is done,
+// the method without context uses context.Background().
 type OutBand interface {
 	// Board return board information of the current connection
 	Board() *api.Board
 	// UUID get the machine uuid
 	// current usage in ipmi-catcher
 	UUID() (*uuid.UUID, error)
+	UUIDContext(ctx context.Context) (*uuid.UUID, error)
 
 	// PowerState returns the power state of the server
 	PowerState() (PowerState, error)
+	PowerStateContext(ctx context.Context) (PowerState, error)
 	// PowerOff set power state of the server to off
 	PowerOff() error
+	PowerOffContext(ctx context.Context) error
 	// PowerOn set power state of the server to on
 	PowerOn() error
+	PowerOnContext(ctx context.Context) error
 	// PowerReset reset the power state of the server
 	PowerReset() error
+	PowerResetContext(ctx context.Context) error
 	// PowerCycle cycle the power state of the server
 	PowerCycle() error
+	PowerCycleContext(ctx context.Context) error
 
 	// IdentifyLEDState get the identify LED state
 	IdentifyLEDState(IdentifyLEDState) error
+	IdentifyLEDStateContext(ctx context.Context, state IdentifyLEDState) error
 	// IdentifyLEDOn set the identify LED to on
 	IdentifyLEDOn() error
+	IdentifyLEDOnContext(ctx context.Context) error
 	// IdentifyLEDOff set the identify LED to off
 	IdentifyLEDOff() error
+	IdentifyLEDOffContext(ctx context.Context) error
 
 	// BootFrom set the boot order of the server to the specified target
 	BootFrom(BootTarget) error
+	BootFromContext(ctx context.Context, target BootTarget) error
+	// SetBootOverride sets the boot target of the server together with its persistence and firmware mode
+	SetBootOverride(ctx context.Context, override BootOverride) error
+	// BootOverride returns the boot override which is currently in effect, the Target is zero if there is none
+	BootOverride(ctx context.Context) (*BootOverride, error)
+	// BootConfiguration returns the boot override, the boot options in boot order and the boot mode,
+	// it allows to verify the boot configuration before the server is rebooted
+	BootConfiguration(ctx context.Context) (*BootConfiguration, error)
 
 	// Describe print a basic information about this connection
 	Describe() string
 
+	// Capabilities returns the operations this connection supports and the transport which backs each of them
+	Capabilities() Capabilities
+
+	// SEL returns the system event log entries with a record id greater than after, sorted by record id.
+	// Pass 0 to read all entries.
+	SEL(ctx context.Context, after uint16) ([]SELEntry, error)
+	// ClearSEL clears the system event log
+	ClearSEL(ctx context.Context) error
+	// Sensors returns the current readings of the temperature, fan, voltage and current sensors
+	Sensors(ctx context.Context) ([]Sensor, error)
+	// FRUs returns the inventory of all field replaceable units the BMC knows, e.g. mainboard, power supplies and backplanes
+	FRUs(ctx context.Context) ([]api.FRU, error)
+
 	IPMIConnection() (ip string, port int, user, password string)
 
 	Console(ssh.Session) error
+	ConsoleContext(ctx context.Context, s ssh.Session) error
 
 	UpdateBIOS(url string) error
+	UpdateBIOSContext(ctx context.Context, url string) error
 
 	UpdateBMC(url string) error
+	UpdateBMCContext(ctx context.Context, url string) error
+
+	// Events streams the events of the BMC until ctx is done, then the channel is closed.
+	// The stream is re-established if the BMC drops it, events which were already delivered are not delivered again.
+	Events(ctx context.Context) (<-chan Event, error)
+	// EventSubscriptions lists the subscriptions which push events to other destinations
+	EventSubscriptions(ctx context.Context) ([]EventSubscription, error)
+	// CreateEventSubscription lets the BMC push events to the destination of s and returns the id of the subscription
+	CreateEventSubscription(ctx context.Context, s EventSubscription) (string, error)
+	// DeleteEventSubscription removes the subscription with the given id
+	DeleteEventSubscription(ctx context.Context, id string) error
+
+	// VirtualMedia lists the virtual media slots of the BMC
+	VirtualMedia(ctx context.Context) ([]VirtualMedia, error)
+	// InsertVirtualMedia inserts the image into the slot with the given id, an empty id selects the first CD or DVD slot
+	InsertVirtualMedia(ctx context.Context, id string, image VirtualMediaImage) error
+	// EjectVirtualMedia ejects the image of the slot with the given id, an empty id selects the first CD or DVD slot
+	EjectVirtualMedia(ctx context.Context, id string) error
+
+	// BIOSAttributes returns the BIOS attributes sorted by name with their applied and their pending values
+	BIOSAttributes(ctx context.Context) ([]BIOSAttribute, error)
+	// SetBIOSAttributes stages the attributes to be applied at applyTime. They are validated against the attribute
+	// registry of the BMC before, ErrNotSupported is returned if the BMC does not offer applyTime.
+	SetBIOSAttributes(ctx context.Context, attributes map[string]any, applyTime ApplyTime) error
+	// BIOSDrift returns the settings of the profile whose applied value differs, nothing is changed
+	BIOSDrift(ctx context.Context, profile BIOSProfile) ([]BIOSSettingDrift, error)
+	// ApplyBIOSProfile stages only the settings which differ from the profile, they are applied on the next reset.
+	// It returns whether the system needs to be rebooted afterwards
+	ApplyBIOSProfile(ctx context.Context, profile BIOSProfile) (bool, error)
 
 	// Returns a connection to the BMC
 	BMCConnection() api.OutBandBMCConnection
@@ -33,6 +33,10 @@ func TestBootTarget_String(t *testing.T) {
 		{name: "BIOS", b: BootTargetBIOS, want: "BIOS"},
 		{name: "DISK", b: BootTargetDisk, want: "DISK"},
 		{name: "PXE", b: BootTargetPXE, want: "PXE"},
+		{name: "CD", b: BootTargetCD, want: "CD"},
+		{name: "USB", b: BootTargetUSB, want: "USB"},
+		{name: "HTTP", b: BootTargetHTTP, want: "HTTP"},
+		{name: "UEFISHELL", b: BootTargetUEFIShell, want: "UEFISHELL"},
 	}
 	for i := range tests {
 		tt := tests[i]
@@ -1,9 +1,11 @@
 package console
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"strings"
@@ -92,7 +94,9 @@ func Open(s ssh.Session, cmd *exec.Cmd) error {
 	return err
 }
 
-func OverSSH(log logger.Logger, s ssh.Session, username, password, host string, port int, command string) error {
+// OverSSH connects to the ssh server of the BMC, runs the given command if any and pipes the session from and to s.
+// The connection is closed once ctx is done.
+func OverSSH(ctx context.Context, log logger.Logger, s ssh.Session, username, password, host string, port int, command string) error {
 	clientConfig := &cryptossh.ClientConfig{
 		User: username,
 		Auth: []cryptossh.AuthMethod{
@@ -110,10 +114,22 @@ func OverSSH(log logger.Logger, s ssh.Session, username, password, host string,
 		Timeout:         10 * time.Second,                  // TODO put a reasonable timeout? Make configurable?
 	}
 
-	client, err := cryptossh.Dial("tcp", fmt.Sprintf("%s:%d", host, port), clientConfig)
+	addr := fmt.Sprintf("%s:%d", host, port)
+	dialer := net.Dialer{Timeout: clientConfig.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
-		return fmt.Errorf("failed to connect to iDRAC SSH: %w", err)
+		return fmt.Errorf("failed to connect to BMC SSH: %w", err)
 	}
+	c, chans, reqs, err := cryptossh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to connect to BMC SSH: %w", err)
+	}
+	client := cryptossh.NewClient(c, chans, reqs)
+	stop := context.AfterFunc(ctx, func() {
+		_ = client.Close()
+	})
+	defer stop()
 	defer func() {
 		if err := client.Close(); err != nil {
 			log.Infow("failed to close client session: %v", err)
@@ -146,8 +162,10 @@ func OverSSH(log logger.Logger, s ssh.Session, username, password, host string,
 	session.Stdout = s
 	session.Stderr = s
 
-	if err := session.Run(command); err != nil {
-		log.Infow("failed to run command", "command", command, "error", err)
+	if command != "" {
+		if err := session.Run(command); err != nil {
+			log.Infow("failed to run command", "command", command, "error", err)
+		}
 	}
 
 	if err := session.Shell(); err != nil {
@@ -3,12 +3,14 @@ package dmi
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/metal-stack/go-hal/pkg/api"
 )
 
 const (
+	dmiDir        = "/sys/class/dmi/id"
 	boardVendor   = "/sys/class/dmi/id/board_vendor"
 	boardName     = "/sys/class/dmi/id/board_name"
 	boardSerial   = "/sys/class/dmi/id/board_serial"
@@ -44,9 +46,30 @@ func BoardInfo() (*api.Board, error) {
 		SerialNumber: bserial,
 		PartNumber:   pserial,
 		BiosVersion:  version,
+		DMI:          fields(dmiDir),
 	}, nil
 }
 
+// fields returns all readable dmi fields in dir, unreadable fields like serials for non-root users are skipped
+func fields(dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	result := map[string]string{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || e.Name() == "uevent" || e.Name() == "modalias" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		result[e.Name()] = strings.TrimSpace(string(content))
+	}
+	return result
+}
+
 func dmi(path string) (string, error) {
 	if _, err := os.Stat(path); !os.IsNotExist(err) {
 		content, err := os.ReadFile(path)
@@ -0,0 +1,244 @@
+package efi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// BootVariableAttributes are the attributes of BootOrder, BootNext and the load options: non volatile, boot service and runtime access
+	BootVariableAttributes = uint32(0x00000007)
+
+	// ESPDir is where the EFI system partition is usually mounted
+	ESPDir = "/boot/efi"
+
+	bootNext = "BootNext"
+
+	// immutableFlag is FS_IMMUTABLE_FL of linux/fs.h
+	immutableFlag = uint32(0x00000010)
+)
+
+// loaders are the file names grub-install puts below \EFI\<bootloader-id>\, the shim is preferred for secure boot
+var loaders = []string{"shimx64.efi", "shimaa64.efi", "grubx64.efi", "grubaa64.efi"}
+
+// Set writes the variable, the immutable flag efivarfs puts on most variables is cleared beforehand
+func (v *Vars) Set(name, guid string, attributes uint32, data []byte) error {
+	path := v.path(name, guid)
+	err := clearImmutable(path)
+	if err != nil {
+		return fmt.Errorf("unable to make efi variable %s writable %w", name, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open efi variable %s %w", name, err)
+	}
+	// efivarfs expects the attributes and the data in a single write
+	_, err = f.Write(append(binary.LittleEndian.AppendUint32(nil, attributes), data...))
+	closeErr := f.Close()
+	if err != nil {
+		return fmt.Errorf("unable to write efi variable %s %w", name, err)
+	}
+	return closeErr
+}
+
+// Delete removes the variable, it is not an error if it does not exist
+func (v *Vars) Delete(name, guid string) error {
+	path := v.path(name, guid)
+	err := clearImmutable(path)
+	if err != nil {
+		return fmt.Errorf("unable to make efi variable %s writable %w", name, err)
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to delete efi variable %s %w", name, err)
+	}
+	return nil
+}
+
+// clearImmutable removes the immutable flag of the file, file systems which do not support flags are ignored
+func clearImmutable(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	flags, err := unix.IoctlGetUint32(int(f.Fd()), unix.FS_IOC_GETFLAGS)
+	if errors.Is(err, unix.ENOTTY) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if flags&immutableFlag == 0 {
+		return nil
+	}
+	return unix.IoctlSetPointerInt(int(f.Fd()), unix.FS_IOC_SETFLAGS, int(flags&^immutableFlag))
+}
+
+// SetBootOrder writes the boot order
+func (v *Vars) SetBootOrder(order []uint16) error {
+	var data []byte
+	for _, number := range order {
+		data = binary.LittleEndian.AppendUint16(data, number)
+	}
+	return v.Set(bootOrder, GlobalVariable, BootVariableAttributes, data)
+}
+
+// BootNext returns the load option which is booted once on the next boot, false if none is set
+func (v *Vars) BootNext() (uint16, bool, error) {
+	_, data, err := v.Get(bootNext, GlobalVariable)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if len(data) != 2 {
+		return 0, false, fmt.Errorf("invalid boot next:%v", data)
+	}
+	return binary.LittleEndian.Uint16(data), true, nil
+}
+
+// SetBootNext boots the given load option once on the next boot
+func (v *Vars) SetBootNext(number uint16) error {
+	return v.Set(bootNext, GlobalVariable, BootVariableAttributes, binary.LittleEndian.AppendUint16(nil, number))
+}
+
+// DeleteBootNext removes a pending one-time boot
+func (v *Vars) DeleteBootNext() error {
+	return v.Delete(bootNext, GlobalVariable)
+}
+
+// SetLoadOption writes the load option to its Boot#### variable
+func (v *Vars) SetLoadOption(option *LoadOption) error {
+	return v.Set(option.Name(), GlobalVariable, BootVariableAttributes, option.Bytes())
+}
+
+// DeleteLoadOption removes the load option and its entry from the boot order
+func (v *Vars) DeleteLoadOption(number uint16) error {
+	order, err := v.BootOrder()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(order, number) {
+		err = v.SetBootOrder(slices.DeleteFunc(order, func(n uint16) bool { return n == number }))
+		if err != nil {
+			return err
+		}
+	}
+	return v.Delete(bootOptionName(number), GlobalVariable)
+}
+
+// AddLoadOption creates an active load option for the file path. An existing load option with the same file path
+// is reused and only written if it changes, otherwise the lowest free number is taken. The boot order is not changed.
+func (v *Vars) AddLoadOption(description string, filePath DevicePath) (*LoadOption, error) {
+	options, err := v.LoadOptions()
+	if err != nil {
+		return nil, err
+	}
+	option := &LoadOption{
+		Description: description,
+		FilePath:    filePath,
+	}
+	numbers := map[uint16]bool{}
+	existing := false
+	for _, o := range options {
+		numbers[o.Number] = true
+		if !existing && bytes.Equal(o.FilePath, filePath) {
+			option = o
+			existing = true
+		}
+	}
+	if existing && option.Active() && option.Description == description {
+		return option, nil
+	}
+	if !existing {
+		for numbers[option.Number] {
+			if option.Number == 0xFFFF {
+				return nil, fmt.Errorf("no free load option number")
+			}
+			option.Number++
+		}
+	}
+	option.Description = description
+	option.Attributes |= LoadOptionActive
+
+	err = v.SetLoadOption(option)
+	if err != nil {
+		return nil, err
+	}
+	return option, nil
+}
+
+// MoveFirst puts the load option at the front of the boot order, the boot order is only written if it changes
+func (v *Vars) MoveFirst(number uint16) error {
+	order, err := v.BootOrder()
+	if err != nil {
+		return err
+	}
+	if len(order) > 0 && order[0] == number {
+		return nil
+	}
+	order = slices.DeleteFunc(order, func(n uint16) bool { return n == number })
+	return v.SetBootOrder(append([]uint16{number}, order...))
+}
+
+// Bytes encodes the load option as EFI_LOAD_OPTION
+func (o *LoadOption) Bytes() []byte {
+	data := binary.LittleEndian.AppendUint32(nil, o.Attributes)
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(o.FilePath)))
+	for _, c := range utf16.Encode([]rune(o.Description)) {
+		data = binary.LittleEndian.AppendUint16(data, c)
+	}
+	data = append(data, 0, 0)
+	data = append(data, o.FilePath...)
+	return append(data, o.OptionalData...)
+}
+
+// EnsureFirst puts the load option of the bootloader installed with the given id first in the boot order.
+// The load option is found by its description or by a file path below \EFI\<bootloaderID>\, if there is none
+// it is created for the first of the loaders which exists in the directory of the bootloader on the ESP mounted at esp.
+func (v *Vars) EnsureFirst(bootloaderID, esp string) (*LoadOption, error) {
+	options, err := v.LoadOptions()
+	if err != nil {
+		return nil, err
+	}
+	dir := strings.ToLower(`\EFI\` + bootloaderID + `\`)
+	for _, option := range options {
+		if option.Description == bootloaderID || strings.Contains(strings.ToLower(option.FilePath.String()), dir) {
+			return option, v.MoveFirst(option.Number)
+		}
+	}
+
+	for _, loader := range loaders {
+		path := filepath.Join(esp, "EFI", bootloaderID, loader)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		filePath, err := FileDevicePath(path)
+		if err != nil {
+			return nil, err
+		}
+		option, err := v.AddLoadOption(bootloaderID, filePath)
+		if err != nil {
+			return nil, err
+		}
+		return option, v.MoveFirst(option.Number)
+	}
+	return nil, fmt.Errorf("no load option and no loader on %s found for bootloader %s", esp, bootloaderID)
+}
@@ -0,0 +1,199 @@
+package efi
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVars_SetAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	vars := NewVars(dir)
+
+	require.NoError(t, vars.Set("Test", GlobalVariable, BootVariableAttributes, []byte{1, 2}))
+	attributes, data, err := vars.Get("Test", GlobalVariable)
+	require.NoError(t, err)
+	require.Equal(t, BootVariableAttributes, attributes)
+	require.Equal(t, []byte{1, 2}, data)
+
+	// shorter data must not leave the rest of the old value behind
+	require.NoError(t, vars.Set("Test", GlobalVariable, BootVariableAttributes, []byte{3}))
+	_, data, err = vars.Get("Test", GlobalVariable)
+	require.NoError(t, err)
+	require.Equal(t, []byte{3}, data)
+
+	require.NoError(t, vars.Delete("Test", GlobalVariable))
+	require.NoError(t, vars.Delete("Test", GlobalVariable))
+	_, _, err = vars.Get("Test", GlobalVariable)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestVars_BootNext(t *testing.T) {
+	vars := NewVars(t.TempDir())
+
+	_, ok, err := vars.BootNext()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, vars.SetBootNext(0x000A))
+	next, ok, err := vars.BootNext()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint16(0x000A), next)
+
+	require.NoError(t, vars.DeleteBootNext())
+	_, ok, err = vars.BootNext()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestLoadOption_Bytes(t *testing.T) {
+	data := loadOption(t, LoadOptionActive, "debian", debianPath)
+	option, err := ParseLoadOption(3, data)
+	require.NoError(t, err)
+	require.Equal(t, data, option.Bytes())
+
+	option.OptionalData = []byte{0xde, 0xad}
+	parsed, err := ParseLoadOption(3, option.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, option, parsed)
+}
+
+func TestVars_AddLoadOption(t *testing.T) {
+	dir := t.TempDir()
+	writeVar(t, dir, "BootOrder", []byte{0x01, 0x00, 0x00, 0x00})
+	writeVar(t, dir, "Boot0000", loadOption(t, LoadOptionActive, "UEFI Shell", "7fff0400"))
+	writeVar(t, dir, "Boot0001", loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath))
+	vars := NewVars(dir)
+
+	debian, err := hex.DecodeString(debianPath)
+	require.NoError(t, err)
+
+	option, err := vars.AddLoadOption("debian", debian)
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), option.Number)
+
+	stored, err := vars.LoadOption(2)
+	require.NoError(t, err)
+	require.Equal(t, "debian", stored.Description)
+	require.True(t, stored.Active())
+	require.Equal(t, DevicePath(debian), stored.FilePath)
+
+	// the boot order is not touched
+	order, err := vars.BootOrder()
+	require.NoError(t, err)
+	require.Equal(t, []uint16{1, 0}, order)
+
+	// a load option with the same file path is reused and activated
+	writeVar(t, dir, "Boot0002", loadOption(t, 0, "old", debianPath))
+	option, err = vars.AddLoadOption("metal", debian)
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), option.Number)
+	stored, err = vars.LoadOption(2)
+	require.NoError(t, err)
+	require.Equal(t, "metal", stored.Description)
+	require.True(t, stored.Active())
+}
+
+func TestVars_MoveFirstAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	writeVar(t, dir, "BootOrder", []byte{0x01, 0x00, 0x03, 0x00, 0x02, 0x00})
+	writeVar(t, dir, "Boot0003", loadOption(t, LoadOptionActive, "debian", debianPath))
+	vars := NewVars(dir)
+
+	require.NoError(t, vars.MoveFirst(3))
+	order, err := vars.BootOrder()
+	require.NoError(t, err)
+	require.Equal(t, []uint16{3, 1, 2}, order)
+
+	require.NoError(t, vars.MoveFirst(5))
+	order, err = vars.BootOrder()
+	require.NoError(t, err)
+	require.Equal(t, []uint16{5, 3, 1, 2}, order)
+
+	require.NoError(t, vars.DeleteLoadOption(3))
+	order, err = vars.BootOrder()
+	require.NoError(t, err)
+	require.Equal(t, []uint16{5, 1, 2}, order)
+	_, err = vars.LoadOption(3)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestVars_EnsureFirst(t *testing.T) {
+	tests := []struct {
+		name         string
+		bootloaderID string
+		options      map[string][]byte
+		wantNumber   uint16
+		wantPath     string
+		wantErr      string
+	}{
+		{
+			name:         "found by description",
+			bootloaderID: "metal-ubuntu",
+			options: map[string][]byte{
+				"Boot0001": loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath),
+				"Boot0004": loadOption(t, LoadOptionActive, "metal-ubuntu", "7fff0400"),
+			},
+			wantNumber: 4,
+		},
+		{
+			name:         "found by file path",
+			bootloaderID: "Debian",
+			options: map[string][]byte{
+				"Boot0001": loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath),
+				"Boot0003": loadOption(t, LoadOptionActive, "UEFI OS", debianPath),
+			},
+			wantNumber: 3,
+			wantPath:   `HD(1,GPT,8F9C4A4D-5C3F-4A0B-8E3F-7F6A1B2C3D4E,0x800,0x100000)/\EFI\debian\grubx64.efi`,
+		},
+		{
+			name:         "created from the esp",
+			bootloaderID: "debian",
+			options: map[string][]byte{
+				"Boot0001": loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath),
+			},
+			wantNumber: 0,
+			wantPath:   `HD(1,GPT,8F9C4A4D-5C3F-4A0B-8E3F-7F6A1B2C3D4E,0x800,0x100000)/\EFI\debian\grubx64.efi`,
+		},
+		{
+			name:         "not installed",
+			bootloaderID: "metal-ubuntu",
+			options: map[string][]byte{
+				"Boot0001": loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath),
+			},
+			wantErr: "no load option and no loader on ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			esp := fakeESP(t, debianPartition)
+			loader := filepath.Join(esp, "EFI", "debian", "grubx64.efi")
+			require.NoError(t, os.MkdirAll(filepath.Dir(loader), 0755))
+			require.NoError(t, os.WriteFile(loader, nil, 0600))
+
+			dir := t.TempDir()
+			writeVar(t, dir, "BootOrder", []byte{0x01, 0x00})
+			for name, data := range tt.options {
+				writeVar(t, dir, name, data)
+			}
+			vars := NewVars(dir)
+
+			option, err := vars.EnsureFirst(tt.bootloaderID, esp)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNumber, option.Number)
+			require.Equal(t, tt.wantPath, option.FilePath.String())
+
+			order, err := vars.BootOrder()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNumber, order[0])
+		})
+	}
+}
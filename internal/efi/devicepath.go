@@ -0,0 +1,175 @@
+package efi
+
+// https://uefi.org/specs/UEFI/2.10/10_Protocols_Device_Path_Protocol.html
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"unicode/utf16"
+)
+
+// DevicePath is a binary EFI device path, a sequence of nodes terminated by an end node
+type DevicePath []byte
+
+const (
+	hardwareDevicePath  = 0x01
+	acpiDevicePath      = 0x02
+	messagingDevicePath = 0x03
+	mediaDevicePath     = 0x04
+	bbsDevicePath       = 0x05
+	endDevicePath       = 0x7F
+
+	endInstance = 0x01
+	endEntire   = 0xFF
+
+	pciRootHID  = 0x0A0341D0
+	pcieRootHID = 0x0A0841D0
+)
+
+// String returns the text representation of the device path as the UEFI shell and Redfish show it,
+// nodes which are not known are shown as Path(type,subtype,data)
+func (p DevicePath) String() string {
+	var nodes []string
+	rest := []byte(p)
+	for len(rest) >= 4 {
+		typ, subType := rest[0], rest[1]
+		length := int(binary.LittleEndian.Uint16(rest[2:]))
+		if length < 4 || length > len(rest) {
+			nodes = append(nodes, fmt.Sprintf("Path(%d,%d,%s)", typ, subType, hex.EncodeToString(rest[4:])))
+			break
+		}
+		data := rest[4:length]
+		rest = rest[length:]
+
+		if typ == endDevicePath {
+			if subType == endEntire {
+				break
+			}
+			if subType == endInstance {
+				nodes = append(nodes, ",")
+				continue
+			}
+		}
+		nodes = append(nodes, nodeString(typ, subType, data))
+	}
+	return strings.ReplaceAll(strings.Join(nodes, "/"), "/,/", ",")
+}
+
+func nodeString(typ, subType uint8, data []byte) string {
+	le := binary.LittleEndian
+	switch {
+	case typ == hardwareDevicePath && subType == 0x01 && len(data) >= 2:
+		return fmt.Sprintf("Pci(0x%X,0x%X)", data[1], data[0])
+	case typ == acpiDevicePath && subType == 0x01 && len(data) >= 8:
+		hid, uid := le.Uint32(data), le.Uint32(data[4:])
+		switch hid {
+		case pciRootHID:
+			return fmt.Sprintf("PciRoot(0x%X)", uid)
+		case pcieRootHID:
+			return fmt.Sprintf("PcieRoot(0x%X)", uid)
+		}
+		return fmt.Sprintf("Acpi(%s,0x%X)", eisaID(hid), uid)
+	case typ == messagingDevicePath && subType == 0x02 && len(data) >= 4:
+		return fmt.Sprintf("Scsi(0x%X,0x%X)", le.Uint16(data), le.Uint16(data[2:]))
+	case typ == messagingDevicePath && subType == 0x05 && len(data) >= 2:
+		return fmt.Sprintf("USB(0x%X,0x%X)", data[0], data[1])
+	case typ == messagingDevicePath && subType == 0x0B && len(data) >= 33:
+		size := 32
+		if data[32] == 0x00 || data[32] == 0x01 {
+			// ethernet
+			size = 6
+		}
+		return fmt.Sprintf("MAC(%s,0x%X)", hex.EncodeToString(data[:size]), data[32])
+	case typ == messagingDevicePath && subType == 0x0C && len(data) >= 15:
+		return fmt.Sprintf("IPv4(%s,%s,%s,%s)", net.IP(data[4:8]), protocol(le.Uint16(data[12:])), ipv4Origin(data[14]), net.IP(data[:4]))
+	case typ == messagingDevicePath && subType == 0x0D && len(data) >= 38:
+		return fmt.Sprintf("IPv6(%s,%s,%s,%s)", net.IP(data[16:32]), protocol(le.Uint16(data[36:])), ipv6Origin(data, 38), net.IP(data[:16]))
+	case typ == messagingDevicePath && subType == 0x12 && len(data) >= 6:
+		return fmt.Sprintf("Sata(0x%X,0x%X,0x%X)", le.Uint16(data), le.Uint16(data[2:]), le.Uint16(data[4:]))
+	case typ == messagingDevicePath && subType == 0x17 && len(data) >= 12:
+		eui := make([]string, 8)
+		for i := range eui {
+			eui[i] = fmt.Sprintf("%02X", data[11-i])
+		}
+		return fmt.Sprintf("NVMe(0x%X,%s)", le.Uint32(data), strings.Join(eui, "-"))
+	case typ == messagingDevicePath && subType == 0x18:
+		return fmt.Sprintf("Uri(%s)", data)
+	case typ == mediaDevicePath && subType == 0x01 && len(data) >= 38:
+		number, start, size := le.Uint32(data), le.Uint64(data[4:]), le.Uint64(data[12:])
+		switch data[37] {
+		case 0x01:
+			return fmt.Sprintf("HD(%d,MBR,0x%08X,0x%X,0x%X)", number, le.Uint32(data[20:]), start, size)
+		case 0x02:
+			return fmt.Sprintf("HD(%d,GPT,%s,0x%X,0x%X)", number, guidString(data[20:36]), start, size)
+		}
+		return fmt.Sprintf("HD(%d,%d,0,0x%X,0x%X)", number, data[37], start, size)
+	case typ == mediaDevicePath && subType == 0x02 && len(data) >= 20:
+		return fmt.Sprintf("CDROM(0x%X,0x%X,0x%X)", le.Uint32(data), le.Uint64(data[4:]), le.Uint64(data[12:]))
+	case typ == mediaDevicePath && subType == 0x04:
+		return utf16String(data)
+	case typ == mediaDevicePath && subType == 0x06 && len(data) >= 16:
+		return fmt.Sprintf("FvFile(%s)", guidString(data))
+	case typ == mediaDevicePath && subType == 0x07 && len(data) >= 16:
+		return fmt.Sprintf("Fv(%s)", guidString(data))
+	case typ == bbsDevicePath && subType == 0x01 && len(data) >= 4:
+		return fmt.Sprintf("BBS(0x%X,%s)", le.Uint16(data), strings.TrimRight(string(data[4:]), "\x00"))
+	}
+	return fmt.Sprintf("Path(%d,%d,%s)", typ, subType, hex.EncodeToString(data))
+}
+
+// eisaID returns the text of a compressed EISA id, e.g. PNP0A03
+func eisaID(id uint32) string {
+	vendor := uint16(id)
+	return fmt.Sprintf("%c%c%c%04X", '@'+(vendor>>10)&0x1F, '@'+(vendor>>5)&0x1F, '@'+vendor&0x1F, id>>16)
+}
+
+func protocol(p uint16) string {
+	switch p {
+	case 6:
+		return "TCP"
+	case 17:
+		return "UDP"
+	}
+	return fmt.Sprintf("0x%X", p)
+}
+
+func ipv4Origin(static uint8) string {
+	if static != 0 {
+		return "Static"
+	}
+	return "DHCP"
+}
+
+func ipv6Origin(data []byte, offset int) string {
+	if len(data) <= offset {
+		return "Static"
+	}
+	switch data[offset] {
+	case 1:
+		return "StatelessAutoConfigure"
+	case 2:
+		return "StatefulAutoConfigure"
+	}
+	return "Static"
+}
+
+// guidString formats a GUID in its registry format, the first three fields are stored little endian
+func guidString(b []byte) string {
+	le := binary.LittleEndian
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X", le.Uint32(b), le.Uint16(b[4:]), le.Uint16(b[6:]), b[8:10], b[10:16])
+}
+
+func utf16String(data []byte) string {
+	var chars []uint16
+	for i := 0; i+1 < len(data); i += 2 {
+		c := binary.LittleEndian.Uint16(data[i:])
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	return string(utf16.Decode(chars))
+}
@@ -0,0 +1,187 @@
+package efi
+
+// https://uefi.org/specs/UEFI/2.10/03_Boot_Manager.html
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+)
+
+const (
+	// VarsDir is the mount point of efivarfs
+	VarsDir = "/sys/firmware/efi/efivars"
+
+	// GlobalVariable is the vendor GUID of the variables defined by the UEFI specification
+	GlobalVariable = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+	// LoadOptionActive marks a load option which is tried by the boot manager
+	LoadOptionActive = uint32(0x00000001)
+
+	bootOrder = "BootOrder"
+)
+
+// Vars reads EFI variables through an efivarfs mount
+type Vars struct {
+	dir string
+}
+
+// NewVars returns access to the EFI variables of the efivarfs mounted at dir, usually VarsDir
+func NewVars(dir string) *Vars {
+	return &Vars{dir: dir}
+}
+
+// LoadOption is a Boot#### variable
+type LoadOption struct {
+	Number       uint16
+	Attributes   uint32
+	Description  string
+	FilePath     DevicePath
+	OptionalData []byte
+}
+
+// Name returns the name of the variable of the load option, e.g. Boot0001
+func (o *LoadOption) Name() string {
+	return bootOptionName(o.Number)
+}
+
+// Active returns whether the boot manager tries the load option
+func (o *LoadOption) Active() bool {
+	return o.Attributes&LoadOptionActive != 0
+}
+
+func (v *Vars) path(name, guid string) string {
+	return filepath.Join(v.dir, name+"-"+guid)
+}
+
+func bootOptionName(number uint16) string {
+	return fmt.Sprintf("Boot%04X", number)
+}
+
+// Get returns the attributes and the data of the variable, fs.ErrNotExist is returned if it is not set
+func (v *Vars) Get(name, guid string) (uint32, []byte, error) {
+	raw, err := os.ReadFile(v.path(name, guid))
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to read efi variable %s %w", name, err)
+	}
+	// efivarfs prepends the attributes of the variable to its data
+	if len(raw) < 4 {
+		return 0, nil, fmt.Errorf("efi variable %s is too short:%v", name, raw)
+	}
+	return binary.LittleEndian.Uint32(raw), raw[4:], nil
+}
+
+// BootOrder returns the numbers of the load options in boot order, it is empty if the variable is not set
+func (v *Vars) BootOrder() ([]uint16, error) {
+	_, data, err := v.Get(bootOrder, GlobalVariable)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid boot order:%v", data)
+	}
+	order := make([]uint16, len(data)/2)
+	for i := range order {
+		order[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return order, nil
+}
+
+// LoadOption returns the load option with the given number
+func (v *Vars) LoadOption(number uint16) (*LoadOption, error) {
+	_, data, err := v.Get(bootOptionName(number), GlobalVariable)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLoadOption(number, data)
+}
+
+// LoadOptions returns all load options, the ones in the boot order come first and in boot order,
+// the others follow sorted by number. Entries of the boot order without load option are skipped.
+func (v *Vars) LoadOptions() ([]*LoadOption, error) {
+	order, err := v.BootOrder()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(v.dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list efi variables %w", err)
+	}
+	var numbers []uint16
+	for _, entry := range entries {
+		number, ok := parseBootOptionName(entry.Name())
+		if ok && !slices.Contains(order, number) {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+
+	var options []*LoadOption
+	for _, number := range append(order, numbers...) {
+		option, err := v.LoadOption(number)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// parseBootOptionName returns the number of a Boot#### variable file
+func parseBootOptionName(file string) (uint16, bool) {
+	name := "Boot0000-" + GlobalVariable
+	if len(file) != len(name) || file[:4] != "Boot" || file[8:] != name[8:] {
+		return 0, false
+	}
+	number, err := strconv.ParseUint(file[4:8], 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(number), true
+}
+
+// ParseLoadOption decodes an EFI_LOAD_OPTION
+func ParseLoadOption(number uint16, data []byte) (*LoadOption, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("load option %s is too short", bootOptionName(number))
+	}
+	option := &LoadOption{
+		Number:     number,
+		Attributes: binary.LittleEndian.Uint32(data),
+	}
+	filePathLength := int(binary.LittleEndian.Uint16(data[4:]))
+
+	rest := data[6:]
+	var description []uint16
+	for {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("description of load option %s is not terminated", bootOptionName(number))
+		}
+		c := binary.LittleEndian.Uint16(rest)
+		rest = rest[2:]
+		if c == 0 {
+			break
+		}
+		description = append(description, c)
+	}
+	option.Description = string(utf16.Decode(description))
+
+	if len(rest) < filePathLength {
+		return nil, fmt.Errorf("file path of load option %s is truncated", bootOptionName(number))
+	}
+	option.FilePath = DevicePath(rest[:filePathLength])
+	option.OptionalData = rest[filePathLength:]
+	return option, nil
+}
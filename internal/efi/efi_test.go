@@ -0,0 +1,111 @@
+package efi
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+// debianPath is HD(1,GPT,...)/\EFI\debian\grubx64.efi as written by efibootmgr
+const debianPath = "04012a0001000000000800000000000000001000000000004d4a9c8f3f5c0b4a8e3f7f6a1b2c3d4e0202" +
+	"04043400" + "5c004500460049005c00640065006200690061006e005c0067007200750062007800360034002e0065006600690000007fff0400"
+
+// pxePath is PciRoot(0x0)/Pci(0x1C,0x0)/MAC(0cc47a000001,0x1)/IPv4(0.0.0.0,0x0,DHCP,0.0.0.0)
+const pxePath = "02010c00d041030a00000000" + "0101060000" + "1c" +
+	"030b2500" + "0cc47a000001" + "0000000000000000000000000000000000000000000000000000" + "01" +
+	"030c1b00" + "00000000" + "00000000" + "0000" + "0000" + "0000" + "00" + "00000000" + "00000000" +
+	"7fff0400"
+
+func loadOption(t *testing.T, attributes uint32, description, path string) []byte {
+	filePath, err := hex.DecodeString(path)
+	require.NoError(t, err)
+	data := binary.LittleEndian.AppendUint32(nil, attributes)
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(filePath)))
+	for _, c := range utf16.Encode([]rune(description)) {
+		data = binary.LittleEndian.AppendUint16(data, c)
+	}
+	data = append(data, 0, 0)
+	return append(data, filePath...)
+}
+
+func writeVar(t *testing.T, dir, name string, data []byte) {
+	raw := binary.LittleEndian.AppendUint32(nil, 0x07)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-"+GlobalVariable), append(raw, data...), 0600))
+}
+
+func TestVars_LoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeVar(t, dir, "BootOrder", []byte{0x03, 0x00, 0x01, 0x00, 0x07, 0x00})
+	writeVar(t, dir, "Boot0001", loadOption(t, LoadOptionActive, "UEFI PXE IPv4", pxePath))
+	writeVar(t, dir, "Boot0003", loadOption(t, LoadOptionActive, "debian", debianPath))
+	writeVar(t, dir, "Boot000A", loadOption(t, 0, "UEFI Shell", "7fff0400"))
+	// variables of other vendors are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Boot0002-12345678-1234-1234-1234-123456789abc"), []byte{7, 0, 0, 0}, 0600))
+
+	vars := NewVars(dir)
+	order, err := vars.BootOrder()
+	require.NoError(t, err)
+	require.Equal(t, []uint16{3, 1, 7}, order)
+
+	options, err := vars.LoadOptions()
+	require.NoError(t, err)
+	require.Len(t, options, 3)
+
+	require.Equal(t, "Boot0003", options[0].Name())
+	require.Equal(t, "debian", options[0].Description)
+	require.True(t, options[0].Active())
+	require.Equal(t, `HD(1,GPT,8F9C4A4D-5C3F-4A0B-8E3F-7F6A1B2C3D4E,0x800,0x100000)/\EFI\debian\grubx64.efi`, options[0].FilePath.String())
+
+	require.Equal(t, "Boot0001", options[1].Name())
+	require.Equal(t, "PciRoot(0x0)/Pci(0x1C,0x0)/MAC(0cc47a000001,0x1)/IPv4(0.0.0.0,0x0,DHCP,0.0.0.0)", options[1].FilePath.String())
+
+	require.Equal(t, "Boot000A", options[2].Name())
+	require.False(t, options[2].Active())
+	require.Empty(t, options[2].FilePath.String())
+}
+
+func TestVars_WithoutBootOrder(t *testing.T) {
+	vars := NewVars(t.TempDir())
+	order, err := vars.BootOrder()
+	require.NoError(t, err)
+	require.Empty(t, order)
+
+	options, err := vars.LoadOptions()
+	require.NoError(t, err)
+	require.Empty(t, options)
+}
+
+func TestParseLoadOption(t *testing.T) {
+	_, err := ParseLoadOption(1, []byte{1, 0, 0, 0, 4, 0, 'a', 0})
+	require.Error(t, err)
+
+	// the file path is longer than the remaining data
+	_, err = ParseLoadOption(1, []byte{1, 0, 0, 0, 8, 0, 0, 0, 0x7f, 0xff, 0x04, 0x00})
+	require.Error(t, err)
+}
+
+func TestDevicePath_String(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "nvme", path: "02010c00d041030a00000000" + "0101060000" + "01" + "03171000" + "01000000" + "0807060504030201" + "7fff0400", want: "PciRoot(0x0)/Pci(0x1,0x0)/NVMe(0x1,01-02-03-04-05-06-07-08)"},
+		{name: "uri", path: "03181200" + hex.EncodeToString([]byte("http://x/a.efi")) + "7fff0400", want: "Uri(http://x/a.efi)"},
+		{name: "unknown node", path: "0a0b0600abcd" + "7fff0400", want: "Path(10,11,abcd)"},
+		{name: "acpi", path: "02010c00d041010500000000" + "7fff0400", want: "Acpi(PNP0501,0x0)"},
+		{name: "truncated", path: "04011000", want: "Path(4,1,)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := hex.DecodeString(tt.path)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, DevicePath(path).String())
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package efi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	sysDevBlock = "/sys/dev/block"
+	devDir      = "/dev"
+	mountInfo   = "/proc/self/mountinfo"
+)
+
+const (
+	gptSignature = "EFI PART"
+
+	hardDriveNodeLength = 42
+	gptPartitionFormat  = 0x02
+	gptSignatureType    = 0x02
+)
+
+// Partition is a GPT partition, start and size are given in logical blocks of the disk
+type Partition struct {
+	Number uint32
+	Start  uint64
+	Size   uint64
+	// GUID is the unique partition GUID in its on-disk byte order
+	GUID [16]byte
+}
+
+// FileDevicePath returns the device path of a file on a GPT partitioned disk, e.g. the bootloader on the ESP:
+// HD(number,GPT,guid,start,size)/\EFI\debian\grubx64.efi
+func FileDevicePath(path string) (DevicePath, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var st unix.Stat_t
+	err = unix.Stat(path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat %s %w", path, err)
+	}
+	device := fmt.Sprintf("%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev))
+
+	mountPoint, err := mountPointOf(device)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(mountPoint, path)
+	if err != nil {
+		return nil, err
+	}
+	partition, err := partitionOf(device)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileDevicePath(*partition, "/"+rel), nil
+}
+
+// NewFileDevicePath returns the device path of the file at path on the partition
+func NewFileDevicePath(partition Partition, path string) DevicePath {
+	hd := []byte{mediaDevicePath, 0x01}
+	hd = binary.LittleEndian.AppendUint16(hd, hardDriveNodeLength)
+	hd = binary.LittleEndian.AppendUint32(hd, partition.Number)
+	hd = binary.LittleEndian.AppendUint64(hd, partition.Start)
+	hd = binary.LittleEndian.AppendUint64(hd, partition.Size)
+	hd = append(hd, partition.GUID[:]...)
+	hd = append(hd, gptPartitionFormat, gptSignatureType)
+
+	var name []byte
+	for _, c := range utf16.Encode([]rune(strings.ReplaceAll(path, "/", `\`))) {
+		name = binary.LittleEndian.AppendUint16(name, c)
+	}
+	name = append(name, 0, 0)
+	file := []byte{mediaDevicePath, 0x04}
+	file = binary.LittleEndian.AppendUint16(file, uint16(4+len(name)))
+	file = append(file, name...)
+
+	end := []byte{endDevicePath, endEntire, 0x04, 0x00}
+
+	return DevicePath(append(append(hd, file...), end...))
+}
+
+// mountPointOf returns where the block device is mounted
+func mountPointOf(device string) (string, error) {
+	f, err := os.Open(mountInfo)
+	if err != nil {
+		return "", fmt.Errorf("unable to read mounts %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 4 && fields[2] == device && fields[3] == "/" {
+			return unescapeMountPoint(fields[4]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no mount of device %s found", device)
+}
+
+// unescapeMountPoint reverts the octal escaping of spaces, tabs, newlines and backslashes in mountinfo
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			c, err := strconv.ParseUint(s[i+1:i+4], 8, 8)
+			if err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// partitionOf reads the GPT entry of the partition block device from its disk
+func partitionOf(device string) (*Partition, error) {
+	sysPath, err := filepath.EvalSymlinks(filepath.Join(sysDevBlock, device))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find block device %s %w", device, err)
+	}
+	number, err := readSysUint(filepath.Join(sysPath, "partition"))
+	if err != nil {
+		return nil, fmt.Errorf("block device %s is not a partition %w", device, err)
+	}
+	diskPath := filepath.Dir(sysPath)
+	blockSize, err := readSysUint(filepath.Join(diskPath, "queue", "logical_block_size"))
+	if err != nil {
+		return nil, err
+	}
+
+	disk, err := os.Open(filepath.Join(devDir, filepath.Base(diskPath)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open disk of partition %s %w", device, err)
+	}
+	defer func() {
+		_ = disk.Close()
+	}()
+	return readGPTPartition(disk, int64(blockSize), uint32(number))
+}
+
+func readSysUint(path string) (uint64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+}
+
+// readGPTPartition reads the entry of the partition from the primary GPT of the disk
+func readGPTPartition(disk io.ReaderAt, blockSize int64, number uint32) (*Partition, error) {
+	header := make([]byte, 92)
+	_, err := disk.ReadAt(header, blockSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gpt header %w", err)
+	}
+	if string(header[:8]) != gptSignature {
+		return nil, fmt.Errorf("disk is not gpt partitioned")
+	}
+	entriesLBA := binary.LittleEndian.Uint64(header[72:])
+	entries := binary.LittleEndian.Uint32(header[80:])
+	entrySize := binary.LittleEndian.Uint32(header[84:])
+	if number == 0 || number > entries || entrySize < 128 {
+		return nil, fmt.Errorf("partition %d not found in gpt with %d entries", number, entries)
+	}
+
+	entry := make([]byte, 128)
+	_, err = disk.ReadAt(entry, int64(entriesLBA)*blockSize+int64(number-1)*int64(entrySize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gpt entry of partition %d %w", number, err)
+	}
+	if bytes.Equal(entry[:16], make([]byte, 16)) {
+		return nil, fmt.Errorf("gpt entry of partition %d is unused", number)
+	}
+	first := binary.LittleEndian.Uint64(entry[32:])
+	last := binary.LittleEndian.Uint64(entry[40:])
+	partition := &Partition{
+		Number: number,
+		Start:  first,
+		Size:   last - first + 1,
+	}
+	copy(partition.GUID[:], entry[16:32])
+	return partition, nil
+}
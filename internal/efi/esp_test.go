@@ -0,0 +1,109 @@
+package efi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+var debianPartition = Partition{
+	Number: 1,
+	Start:  0x800,
+	Size:   0x100000,
+	GUID:   [16]byte{0x4d, 0x4a, 0x9c, 0x8f, 0x3f, 0x5c, 0x0b, 0x4a, 0x8e, 0x3f, 0x7f, 0x6a, 0x1b, 0x2c, 0x3d, 0x4e},
+}
+
+// gptImage returns a disk with 512 byte blocks and the partition in the given gpt entry
+func gptImage(partition Partition) []byte {
+	disk := make([]byte, 512*4)
+	header := disk[512:]
+	copy(header, gptSignature)
+	binary.LittleEndian.PutUint64(header[72:], 2)
+	binary.LittleEndian.PutUint32(header[80:], 4)
+	binary.LittleEndian.PutUint32(header[84:], 128)
+
+	entry := disk[2*512+int(partition.Number-1)*128:]
+	// EFI system partition type
+	copy(entry, []byte{0x28, 0x73, 0x2a, 0xc1, 0x1f, 0xf8, 0xd2, 0x11, 0xba, 0x4b, 0x00, 0xa0, 0xc9, 0x3e, 0xc9, 0x3b})
+	copy(entry[16:], partition.GUID[:])
+	binary.LittleEndian.PutUint64(entry[32:], partition.Start)
+	binary.LittleEndian.PutUint64(entry[40:], partition.Start+partition.Size-1)
+	return disk
+}
+
+// fakeESP pretends the returned directory is the mount point of the partition on a gpt disk sda
+func fakeESP(t *testing.T, partition Partition) string {
+	esp := t.TempDir()
+	var st unix.Stat_t
+	require.NoError(t, unix.Stat(esp, &st))
+	device := fmt.Sprintf("%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev))
+
+	root := t.TempDir()
+	part := filepath.Join(root, "devices", "sda", fmt.Sprintf("sda%d", partition.Number))
+	require.NoError(t, os.MkdirAll(part, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "devices", "sda", "queue"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(part, "partition"), fmt.Appendf(nil, "%d\n", partition.Number), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "devices", "sda", "queue", "logical_block_size"), []byte("512\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "block"), 0755))
+	require.NoError(t, os.Symlink(part, filepath.Join(root, "block", device)))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dev"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dev", "sda"), gptImage(partition), 0600))
+
+	mounts := fmt.Sprintf("22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw\n"+
+		"30 22 %s / %s rw,relatime shared:7 - vfat /dev/sda%d rw\n", device, esp, partition.Number)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mountinfo"), []byte(mounts), 0600))
+
+	oldSysDevBlock, oldDevDir, oldMountInfo := sysDevBlock, devDir, mountInfo
+	sysDevBlock, devDir, mountInfo = filepath.Join(root, "block"), filepath.Join(root, "dev"), filepath.Join(root, "mountinfo")
+	t.Cleanup(func() {
+		sysDevBlock, devDir, mountInfo = oldSysDevBlock, oldDevDir, oldMountInfo
+	})
+	return esp
+}
+
+func TestNewFileDevicePath(t *testing.T) {
+	expected, err := hex.DecodeString(debianPath)
+	require.NoError(t, err)
+
+	path := NewFileDevicePath(debianPartition, "/EFI/debian/grubx64.efi")
+	require.Equal(t, DevicePath(expected), path)
+}
+
+func TestReadGPTPartition(t *testing.T) {
+	disk := bytes.NewReader(gptImage(debianPartition))
+
+	partition, err := readGPTPartition(disk, 512, 1)
+	require.NoError(t, err)
+	require.Equal(t, debianPartition, *partition)
+
+	_, err = readGPTPartition(disk, 512, 2)
+	require.EqualError(t, err, "gpt entry of partition 2 is unused")
+	_, err = readGPTPartition(disk, 512, 5)
+	require.EqualError(t, err, "partition 5 not found in gpt with 4 entries")
+	_, err = readGPTPartition(bytes.NewReader(make([]byte, 2048)), 512, 1)
+	require.EqualError(t, err, "disk is not gpt partitioned")
+}
+
+func TestFileDevicePath(t *testing.T) {
+	esp := fakeESP(t, debianPartition)
+	loader := filepath.Join(esp, "EFI", "debian", "grubx64.efi")
+	require.NoError(t, os.MkdirAll(filepath.Dir(loader), 0755))
+	require.NoError(t, os.WriteFile(loader, nil, 0600))
+
+	path, err := FileDevicePath(loader)
+	require.NoError(t, err)
+	require.Equal(t, `HD(1,GPT,8F9C4A4D-5C3F-4A0B-8E3F-7F6A1B2C3D4E,0x800,0x100000)/\EFI\debian\grubx64.efi`, path.String())
+}
+
+func TestUnescapeMountPoint(t *testing.T) {
+	require.Equal(t, "/boot/efi", unescapeMountPoint("/boot/efi"))
+	require.Equal(t, "/mnt/my esp\\", unescapeMountPoint(`/mnt/my\040esp\134`))
+}
@@ -1,10 +1,14 @@
 package inband
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/metal-stack/go-hal"
 	"github.com/metal-stack/go-hal/internal/bios"
 	"github.com/metal-stack/go-hal/internal/dmi"
+	"github.com/metal-stack/go-hal/internal/efi"
 	"github.com/metal-stack/go-hal/internal/ipmi"
 	"github.com/metal-stack/go-hal/internal/kernel"
 	"github.com/metal-stack/go-hal/pkg/api"
@@ -16,14 +20,14 @@ type InBand struct {
 	board    *api.Board
 }
 
-func New(board *api.Board, inspectBMC bool, log logger.Logger) (*InBand, error) {
-	i, err := ipmi.New(log)
+func New(ctx context.Context, board *api.Board, inspectBMC bool, log logger.Logger) (*InBand, error) {
+	i, err := ipmi.NewInBand(log)
 	if err != nil {
 		return nil, err
 	}
 
 	if inspectBMC {
-		bmc, err := i.BMC()
+		bmc, err := i.BMC(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -46,6 +50,10 @@ func (ib *InBand) Board() *api.Board {
 }
 
 func (ib *InBand) UUID() (*uuid.UUID, error) {
+	return ib.UUIDContext(context.Background())
+}
+
+func (ib *InBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
 	u, err := dmi.MachineUUID()
 	if err != nil {
 		return nil, err
@@ -58,6 +66,10 @@ func (ib *InBand) UUID() (*uuid.UUID, error) {
 }
 
 func (ib *InBand) Firmware() (hal.FirmwareMode, error) {
+	return ib.FirmwareContext(context.Background())
+}
+
+func (ib *InBand) FirmwareContext(ctx context.Context) (hal.FirmwareMode, error) {
 	var firmware hal.FirmwareMode
 	switch kernel.Firmware() {
 	case kernel.BIOS:
@@ -69,3 +81,148 @@ func (ib *InBand) Firmware() (hal.FirmwareMode, error) {
 	}
 	return firmware, nil
 }
+
+// Capabilities returns what every in-band connection offers through dmi, the kernel and the local BMC.
+// Vendors which differ from that override it.
+func (ib *InBand) Capabilities() hal.Capabilities {
+	c := hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportLocal,
+			hal.OperationFirmware:          hal.TransportLocal,
+			hal.OperationIdentifyLED:       hal.TransportIPMI,
+			hal.OperationSEL:               hal.TransportIPMI,
+			hal.OperationSensors:           hal.TransportIPMI,
+			hal.OperationFRU:               hal.TransportIPMI,
+			hal.OperationBootConfiguration: hal.TransportIPMI,
+			hal.OperationEnsureBootOrder:   hal.TransportLocal,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:  hal.TransportIPMI,
+			hal.BootTargetDisk: hal.TransportIPMI,
+			hal.BootTargetBIOS: hal.TransportIPMI,
+			hal.BootTargetCD:   hal.TransportIPMI,
+			hal.BootTargetUSB:  hal.TransportIPMI,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOff:   hal.TransportIPMI,
+			hal.PowerActionReset: hal.TransportIPMI,
+			hal.PowerActionCycle: hal.TransportIPMI,
+		},
+	}
+	// the boot order is kept in EFI variables, they do not exist if the server booted in legacy mode
+	if kernel.Firmware() != kernel.EFI {
+		delete(c.Operations, hal.OperationEnsureBootOrder)
+	}
+	return c
+}
+
+// SetBootOverride sets the boot flags of the local BMC
+func (ib *InBand) SetBootOverride(ctx context.Context, override hal.BootOverride) error {
+	return ib.IpmiTool.SetBootOverride(ctx, override, ib.board.Vendor)
+}
+
+// BootOverride reads the boot flags of the local BMC
+func (ib *InBand) BootOverride(ctx context.Context) (*hal.BootOverride, error) {
+	return ib.IpmiTool.BootOverride(ctx, ib.board.Vendor)
+}
+
+// BootConfiguration reads the boot flags of the local BMC and the boot options from the EFI variables,
+// there are no boot options if the server booted in legacy mode
+func (ib *InBand) BootConfiguration(ctx context.Context) (*hal.BootConfiguration, error) {
+	mode, err := ib.FirmwareContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	override, err := ib.BootOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := &hal.BootConfiguration{
+		Override: *override,
+		Mode:     mode,
+	}
+	if mode != hal.FirmwareModeUEFI {
+		return config, nil
+	}
+
+	options, err := efi.NewVars(efi.VarsDir).LoadOptions()
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		config.Options = append(config.Options, hal.BootOption{
+			ID:             option.Name(),
+			DisplayName:    option.Description,
+			UEFIDevicePath: option.FilePath.String(),
+			Enabled:        option.Active(),
+		})
+	}
+	return config, nil
+}
+
+func (ib *InBand) EnsureBootOrder(bootloaderID string) error {
+	return ib.EnsureBootOrderContext(context.Background(), bootloaderID)
+}
+
+// EnsureBootOrderContext puts the load option of the bootloader first in the EFI boot order,
+// ErrNotSupported is returned if the server booted in legacy mode
+func (ib *InBand) EnsureBootOrderContext(ctx context.Context, bootloaderID string) error {
+	if kernel.Firmware() != kernel.EFI {
+		return hal.ErrNotSupported
+	}
+	_, err := efi.NewVars(efi.VarsDir).EnsureFirst(bootloaderID, efi.ESPDir)
+	return err
+}
+
+// BIOSDrift is not supported, the BIOS settings are only accessible with vendor tools in-band
+func (ib *InBand) BIOSDrift(context.Context, hal.BIOSProfile) ([]hal.BIOSSettingDrift, error) {
+	return nil, hal.ErrNotSupported
+}
+
+// ApplyBIOSProfile is not supported, the BIOS settings are only accessible with vendor tools in-band
+func (ib *InBand) ApplyBIOSProfile(context.Context, hal.BIOSProfile) (bool, error) {
+	return false, hal.ErrNotSupported
+}
+
+// SEL reads the system event log of the local BMC
+func (ib *InBand) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	return ib.IpmiTool.SEL(ctx, after)
+}
+
+// ClearSEL clears the system event log of the local BMC
+func (ib *InBand) ClearSEL(ctx context.Context) error {
+	return ib.IpmiTool.ClearSEL(ctx)
+}
+
+// Sensors reads the sensors of the SDR repository of the local BMC
+func (ib *InBand) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	return ib.IpmiTool.Sensors(ctx)
+}
+
+// FRUs reads the FRU inventory of the local BMC
+func (ib *InBand) FRUs(ctx context.Context) ([]api.FRU, error) {
+	return ib.IpmiTool.FRUs(ctx)
+}
+
+// LanConfig reads the network configuration of the local BMC
+func (ib *InBand) LanConfig(ctx context.Context) (*api.BMCLanConfig, error) {
+	return ib.IpmiTool.GetBMCLanConfig(ctx, ib.board.Vendor)
+}
+
+// SetLanConfig writes the network configuration of the local BMC and verifies it by reading it back
+func (ib *InBand) SetLanConfig(ctx context.Context, config api.BMCLanConfig) error {
+	err := ib.IpmiTool.SetBMCLanConfig(ctx, ib.board.Vendor, config)
+	if err != nil {
+		return err
+	}
+	actual, err := ib.IpmiTool.GetBMCLanConfig(ctx, ib.board.Vendor)
+	if err != nil {
+		return fmt.Errorf("unable to read back the lan config %w", err)
+	}
+	return config.Verify(actual)
+}
+
+// ListUsers lists the users of the local BMC
+func (ib *InBand) ListUsers(ctx context.Context) ([]api.BMCAccount, error) {
+	return ib.IpmiTool.ListUsers(ctx)
+}
@@ -0,0 +1,120 @@
+package ipmi
+
+import (
+	"fmt"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// boot flags parameter, see table 28-14 Boot Option Parameters
+const (
+	bootFlagsValid      = uint8(0x80)
+	bootFlagsPersistent = uint8(0x40)
+	bootFlagsEFI        = uint8(0x20)
+
+	bootDeviceMask = uint8(0x3C)
+
+	pxeQualifier = uint8(0x04)
+
+	hdQualifier           = uint8(0x08)
+	supermicroHDQualifier = uint8(0x24)
+
+	cdQualifier        = uint8(0x14)
+	biosQualifier      = uint8(0x18)
+	removableQualifier = uint8(0x3C)
+
+	bootFlagsSize = 5
+)
+
+// GetBootOrderQualifiers returns the qualifiers needed to set the given boot order according to the given vendor
+func GetBootOrderQualifiers(bootTarget hal.BootTarget, vendor api.Vendor) (uefiQualifier, bootDevQualifier uint8) {
+	uefiQualifier, bootDevQualifier, _ = bootOverrideQualifiers(hal.DefaultBootOverride(bootTarget), vendor)
+	return
+}
+
+// bootOverrideQualifiers returns the first two bytes of the boot flags parameter for the given override.
+// IPMI always selects the firmware mode, an unknown mode boots in UEFI mode.
+func bootOverrideQualifiers(override hal.BootOverride, vendor api.Vendor) (uefiQualifier, bootDevQualifier uint8, err error) {
+	uefiQualifier = bootFlagsValid
+	if override.Persistent {
+		uefiQualifier |= bootFlagsPersistent
+	}
+	if override.Mode != hal.FirmwareModeLegacy {
+		uefiQualifier |= bootFlagsEFI
+	}
+
+	switch override.Target {
+	case hal.BootTargetPXE:
+		bootDevQualifier = pxeQualifier
+	case hal.BootTargetDisk:
+		bootDevQualifier = hdQualifier
+		if vendor == api.VendorSupermicro || vendor == api.VendorNovarion {
+			bootDevQualifier = supermicroHDQualifier
+		}
+	case hal.BootTargetBIOS:
+		bootDevQualifier = biosQualifier
+	case hal.BootTargetCD:
+		bootDevQualifier = cdQualifier
+	case hal.BootTargetUSB:
+		bootDevQualifier = removableQualifier
+	case hal.BootTargetHTTP, hal.BootTargetUEFIShell:
+		return 0, 0, fmt.Errorf("boot target %s %w via ipmi", override.Target, hal.ErrNotSupported)
+	default:
+		return 0, 0, fmt.Errorf("unknown boot target:%d", override.Target)
+	}
+	return uefiQualifier, bootDevQualifier, nil
+}
+
+// decodeBootFlags returns the override which is described by the boot flags parameter
+func decodeBootFlags(flags []byte, vendor api.Vendor) (*hal.BootOverride, error) {
+	if len(flags) < 2 {
+		return nil, fmt.Errorf("unexpected boot flags:%v", flags)
+	}
+	if flags[0]&bootFlagsValid == 0 {
+		return &hal.BootOverride{}, nil
+	}
+
+	override := &hal.BootOverride{
+		Persistent: flags[0]&bootFlagsPersistent != 0,
+		Mode:       hal.FirmwareModeLegacy,
+	}
+	if flags[0]&bootFlagsEFI != 0 {
+		override.Mode = hal.FirmwareModeUEFI
+	}
+
+	device := flags[1] & bootDeviceMask
+	switch {
+	case device == 0:
+		// the flags are valid but no device is forced
+		return &hal.BootOverride{}, nil
+	case device == pxeQualifier:
+		override.Target = hal.BootTargetPXE
+	case device == hdQualifier:
+		override.Target = hal.BootTargetDisk
+	case device == supermicroHDQualifier && (vendor == api.VendorSupermicro || vendor == api.VendorNovarion):
+		override.Target = hal.BootTargetDisk
+	case device == biosQualifier:
+		override.Target = hal.BootTargetBIOS
+	case device == cdQualifier:
+		override.Target = hal.BootTargetCD
+	case device == removableQualifier:
+		override.Target = hal.BootTargetUSB
+	default:
+		return nil, fmt.Errorf("unknown boot device selector:%X", device)
+	}
+	return override, nil
+}
+
+// readBootOverride reads the boot flags parameter of the system boot options
+func readBootOverride(send rawSender, vendor api.Vendor) (*hal.BootOverride, error) {
+	resp, err := send(ChassisNetworkFunction, GetSystemBootOptions, BootFlags, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get boot flags %w", err)
+	}
+	// the first byte is the parameter version, the second one the parameter selector
+	if len(resp) < 2+bootFlagsSize {
+		return nil, fmt.Errorf("unexpected boot flags response:%v", resp)
+	}
+	return decodeBootFlags(resp[2:2+bootFlagsSize], vendor)
+}
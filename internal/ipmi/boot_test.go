@@ -0,0 +1,75 @@
+package ipmi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+func TestBootOverrideQualifiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		override    hal.BootOverride
+		vendor      api.Vendor
+		wantUEFI    uint8
+		wantBootDev uint8
+		wantErr     bool
+	}{
+		{name: "once uefi cd", override: hal.BootOverride{Target: hal.BootTargetCD, Mode: hal.FirmwareModeUEFI}, wantUEFI: 0xA0, wantBootDev: 0x14},
+		{name: "persistent legacy usb", override: hal.BootOverride{Target: hal.BootTargetUSB, Persistent: true, Mode: hal.FirmwareModeLegacy}, wantUEFI: 0xC0, wantBootDev: 0x3C},
+		{name: "once legacy pxe", override: hal.BootOverride{Target: hal.BootTargetPXE, Mode: hal.FirmwareModeLegacy}, wantUEFI: 0x80, wantBootDev: 0x04},
+		{name: "unknown mode boots uefi", override: hal.BootOverride{Target: hal.BootTargetDisk, Persistent: true}, vendor: api.VendorSupermicro, wantUEFI: 0xE0, wantBootDev: 0x24},
+		{name: "http boot", override: hal.BootOverride{Target: hal.BootTargetHTTP}, wantErr: true},
+		{name: "no target", override: hal.BootOverride{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uefiQualifier, bootDevQualifier, err := bootOverrideQualifiers(tt.override, tt.vendor)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUEFI, uefiQualifier)
+			require.Equal(t, tt.wantBootDev, bootDevQualifier)
+		})
+	}
+}
+
+func TestReadBootOverride(t *testing.T) {
+	var flags []byte
+	send := func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+		require.Equal(t, ChassisNetworkFunction, netFn)
+		require.Equal(t, GetSystemBootOptions, command)
+		require.Equal(t, []uint8{BootFlags, 0, 0}, data)
+		return append([]byte{0x01, BootFlags}, flags...), nil
+	}
+
+	flags = []byte{0xE0, 0x24, 0, 0, 0}
+	override, err := readBootOverride(send, api.VendorSupermicro)
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{Target: hal.BootTargetDisk, Persistent: true, Mode: hal.FirmwareModeUEFI}, override)
+
+	flags = []byte{0x80, 0x18, 0, 0, 0}
+	override, err = readBootOverride(send, api.VendorLenovo)
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{Target: hal.BootTargetBIOS, Mode: hal.FirmwareModeLegacy}, override)
+
+	// the BMC clears the valid bit once a one-time override was used
+	flags = []byte{0x20, 0x14, 0, 0, 0}
+	override, err = readBootOverride(send, api.VendorLenovo)
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{}, override)
+
+	// 0x24 is the primary remote media for everyone except supermicro
+	flags = []byte{0xA0, 0x24, 0, 0, 0}
+	_, err = readBootOverride(send, api.VendorLenovo)
+	require.Error(t, err)
+
+	flags = []byte{0xA0}
+	_, err = readBootOverride(send, api.VendorLenovo)
+	require.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package ipmi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// Client talks to the BMC out of band with IPMI v2.0 over LAN, also known as lanplus
+type Client struct {
+	session *lanSession
+}
+
+// OpenClientConnection establishes a lanplus session with cipher suite 17 and falls back to cipher suite 3 for BMCs which do not support it
+func OpenClientConnection(ctx context.Context, ip string, port int, user, password string) (*Client, error) {
+	session, err := openLanSession(ctx, net.JoinHostPort(ip, strconv.Itoa(port)), user, password, cipherSuite17, cipherSuite3)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{session: session}, nil
+}
+
+// Close closes the session, it is not bound to a context because it is usually deferred
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), lanplusTimeout)
+	defer cancel()
+	return c.session.close(ctx)
+}
+
+// Run executes a raw command as built by the Raw* builders and returns the response data formatted like ipmitool does
+func (c *Client) Run(ctx context.Context, args ...string) (string, error) {
+	return runRaw(c.rawSender(ctx), args)
+}
+
+// Control executes the given chassis control function
+func (c *Client) Control(ctx context.Context, fn ChassisControlFunction) error {
+	_, err := c.Run(ctx, RawChassisControl(fn)...)
+	if err != nil {
+		return fmt.Errorf("unable to set chassis control function:%X %w", fn, err)
+	}
+	return nil
+}
+
+func (c *Client) SetSystemBoot(ctx context.Context, param uint8, data ...uint8) error {
+	_, err := c.session.send(ctx, ChassisNetworkFunction, SetSystemBootOptions, append([]uint8{param}, data...)...)
+	return err
+}
+
+func (c *Client) SetChassisIdentifyLEDState(ctx context.Context, state hal.IdentifyLEDState) error {
+	switch state {
+	case hal.IdentifyLEDStateOff:
+		return c.SetChassisIdentifyLEDOff(ctx)
+	case hal.IdentifyLEDStateOn:
+		return c.SetChassisIdentifyLEDOn(ctx)
+	case hal.IdentifyLEDStateUnknown:
+		fallthrough
+	default:
+		return fmt.Errorf("unknown identify LED state: %s", state)
+	}
+}
+
+func (c *Client) SetChassisIdentifyLEDOff(ctx context.Context) error {
+	_, err := c.Run(ctx, RawChassisIdentifyOff()...)
+	return err
+}
+
+func (c *Client) SetChassisIdentifyLEDOn(ctx context.Context) error {
+	_, err := c.Run(ctx, RawChassisIdentifyOn()...)
+	return err
+}
+
+func (c *Client) SetBootOrder(ctx context.Context, bootTarget hal.BootTarget, vendor api.Vendor) error {
+	return c.SetBootOverride(ctx, hal.DefaultBootOverride(bootTarget), vendor)
+}
+
+// SetBootOverride sets the boot flags to the given override
+func (c *Client) SetBootOverride(ctx context.Context, override hal.BootOverride, vendor api.Vendor) error {
+	uefiQualifier, bootDevQualifier, err := bootOverrideQualifiers(override, vendor)
+	if err != nil {
+		return err
+	}
+
+	useProgress := true
+	// set set-in-progress flag
+	err = c.SetSystemBoot(ctx, SetInProgress, 1)
+	if err != nil {
+		useProgress = false
+	}
+
+	err = c.SetSystemBoot(ctx, BootInfoAcknowledge, 1, 1)
+	if err != nil {
+		if useProgress {
+			// set-in-progress = set-complete
+			_ = c.SetSystemBoot(ctx, SetInProgress, 0)
+		}
+		return err
+	}
+
+	err = c.SetSystemBoot(ctx, BootFlags, uefiQualifier, bootDevQualifier, 0, 0, 0)
+	if err == nil {
+		if useProgress {
+			// set-in-progress = commit-write
+			_ = c.SetSystemBoot(ctx, SetInProgress, 2)
+		}
+	}
+
+	if useProgress {
+		// set-in-progress = set-complete
+		_ = c.SetSystemBoot(ctx, SetInProgress, 0)
+	}
+
+	return err
+}
+
+// BootOverride reads the boot override from the boot flags
+func (c *Client) BootOverride(ctx context.Context, vendor api.Vendor) (*hal.BootOverride, error) {
+	return readBootOverride(c.rawSender(ctx), vendor)
+}
+
+// SEL returns the system event log entries with a record id greater than after
+func (c *Client) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	return readSEL(ctx, c.Run, after)
+}
+
+// ClearSEL clears the system event log
+func (c *Client) ClearSEL(ctx context.Context) error {
+	return clearSEL(ctx, c.Run)
+}
+
+// Sensors returns the readings of the temperature, fan, voltage and current sensors of the SDR repository
+func (c *Client) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	return readSensors(c.rawSender(ctx))
+}
+
+// FRUs returns the decoded inventory of the FRU of the BMC and of all FRU devices listed in the SDR repository
+func (c *Client) FRUs(ctx context.Context) ([]api.FRU, error) {
+	return readFRUs(c.rawSender(ctx))
+}
+
+// LanConfig returns the network configuration of the BMC
+func (c *Client) LanConfig(ctx context.Context, vendor api.Vendor) (*api.BMCLanConfig, error) {
+	return readBMCLanConfig(c.rawSender(ctx), vendor)
+}
+
+// SetLanConfig writes the network configuration of the BMC
+func (c *Client) SetLanConfig(ctx context.Context, vendor api.Vendor, config api.BMCLanConfig) error {
+	return writeBMCLanConfig(c.rawSender(ctx), vendor, config)
+}
+
+func (c *Client) rawSender(ctx context.Context) rawSender {
+	return func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+		return c.session.send(ctx, netFn, command, data...)
+	}
+}
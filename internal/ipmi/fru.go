@@ -0,0 +1,341 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-platform-mgt-fru-info-storage-def-v1-0-rev-1-3-spec-update.pdf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// 34 FRU Inventory Device Commands, 43.8 SDR Type 11h, FRU Device Locator Record
+
+const (
+	// fruReadChunk is the number of bytes read at once, like for the SDR many BMCs reject larger reads
+	fruReadChunk = 16
+
+	fruHeaderSize    = 8
+	fruFormatVersion = 0x01
+	fruEndOfFields   = 0xC1
+	// fruEndOfList marks the last record of the multi record area
+	fruEndOfList             = 0x80
+	fruMultiRecordHeaderSize = 5
+
+	sdrFRUDeviceLocatorRecord = 0x11
+	// sdrLogicalFRUDevice marks locators of devices which are accessed by FRU device id
+	sdrLogicalFRUDevice = 0x80
+)
+
+// fruEpoch is the start of the board manufacturing date
+var fruEpoch = time.Date(1996, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fruChassisTypes are the SMBIOS chassis types
+var fruChassisTypes = []string{
+	0x01: "Other",
+	0x02: "Unknown",
+	0x03: "Desktop",
+	0x04: "Low Profile Desktop",
+	0x05: "Pizza Box",
+	0x06: "Mini Tower",
+	0x07: "Tower",
+	0x08: "Portable",
+	0x09: "LapTop",
+	0x0A: "Notebook",
+	0x0B: "Hand Held",
+	0x0C: "Docking Station",
+	0x0D: "All in One",
+	0x0E: "Sub Notebook",
+	0x0F: "Space-saving",
+	0x10: "Lunch Box",
+	0x11: "Main Server Chassis",
+	0x12: "Expansion Chassis",
+	0x13: "SubChassis",
+	0x14: "Bus Expansion Chassis",
+	0x15: "Peripheral Chassis",
+	0x16: "RAID Chassis",
+	0x17: "Rack Mount Chassis",
+	0x18: "Sealed-case PC",
+	0x19: "Multi-system Chassis",
+	0x1A: "Compact PCI",
+	0x1B: "Advanced TCA",
+	0x1C: "Blade",
+	0x1D: "Blade Enclosure",
+	0x1E: "Tablet",
+	0x1F: "Convertible",
+	0x20: "Detachable",
+	0x21: "IoT Gateway",
+	0x22: "Embedded PC",
+	0x23: "Mini PC",
+	0x24: "Stick PC",
+}
+
+// readFRUs reads the FRU of the BMC and of all logical FRU devices of the SDR repository.
+// Devices which cannot be read, e.g. locators of empty slots, are skipped.
+func readFRUs(send rawSender) ([]api.FRU, error) {
+	fru, err := readFRU(send, 0)
+	if err != nil {
+		return nil, err
+	}
+	fru.Name = "Builtin FRU Device"
+	frus := []api.FRU{*fru}
+
+	err = walkSDR(send, func(record []byte) {
+		id, name, ok := decodeFRULocator(record)
+		if !ok || id == 0 {
+			return
+		}
+		fru, err := readFRU(send, id)
+		if err != nil {
+			return
+		}
+		fru.Name = name
+		frus = append(frus, *fru)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return frus, nil
+}
+
+// decodeFRULocator returns the device id and name of a FRU device locator of a logical FRU device of the BMC
+func decodeFRULocator(record []byte) (uint8, string, bool) {
+	if len(record) < 16 || record[3] != sdrFRUDeviceLocatorRecord {
+		return 0, "", false
+	}
+	if record[5] != bmcSlaveAddress || record[7]&sdrLogicalFRUDevice == 0 || record[7]&0x18 != 0 {
+		return 0, "", false
+	}
+	nameLength := int(record[15] & 0x1F)
+	name := record[16:min(len(record), 16+nameLength)]
+	return record[6], strings.TrimRight(string(name), "\x00 "), true
+}
+
+// readFRU reads and decodes the FRU inventory of the given device
+func readFRU(send rawSender, id uint8) (*api.FRU, error) {
+	info, err := send(StorageNetworkFunction, GetFRUInventoryAreaInfo, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get fru %d inventory area info %w", id, err)
+	}
+	if len(info) < 3 {
+		return nil, fmt.Errorf("unexpected fru %d inventory area info response:%v", id, info)
+	}
+	size := int(info[0]) | int(info[1])<<8
+	// some devices are accessed by words instead of bytes
+	wordSize := 1
+	if info[2]&0x01 != 0 {
+		wordSize = 2
+	}
+
+	data := make([]byte, 0, size)
+	for offset := 0; offset < size; {
+		count := min(fruReadChunk, size-offset) / wordSize
+		o := offset / wordSize
+		resp, err := send(StorageNetworkFunction, ReadFRUData, id, uint8(o), uint8(o>>8), uint8(count))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read fru %d at offset %d %w", id, offset, err)
+		}
+		if len(resp) < 1 || int(resp[0]) == 0 || len(resp) < 1+int(resp[0])*wordSize {
+			return nil, fmt.Errorf("unexpected fru %d data response:%v", id, resp)
+		}
+		n := int(resp[0]) * wordSize
+		data = append(data, resp[1:1+n]...)
+		offset += n
+	}
+
+	fru, err := DecodeFRU(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode fru %d %w", id, err)
+	}
+	fru.DeviceID = id
+	return fru, nil
+}
+
+// DecodeFRU decodes the common header, the chassis, board and product info areas and the multi record area of a FRU.
+// Checksum errors of the areas are tolerated, many vendors get them wrong.
+func DecodeFRU(data []byte) (*api.FRU, error) {
+	if len(data) < fruHeaderSize {
+		return nil, fmt.Errorf("fru too short, %d bytes", len(data))
+	}
+	header := data[:fruHeaderSize]
+	if header[0]&0x0F != fruFormatVersion {
+		return nil, fmt.Errorf("unsupported fru format version 0x%02x", header[0])
+	}
+	if checksum(header) != 0 {
+		return nil, fmt.Errorf("invalid fru common header checksum")
+	}
+
+	fru := &api.FRU{}
+	if a := fruArea(data, header[2]); a != nil {
+		fields := fruFields(a[3:])
+		fru.Chassis = &api.FRUChassis{
+			Type:         fruChassisType(a[2]),
+			PartNumber:   field(fields, 0),
+			SerialNumber: field(fields, 1),
+			Custom:       custom(fields, 2),
+		}
+	}
+	if a := fruArea(data, header[3]); a != nil && len(a) >= 6 {
+		fields := fruFields(a[6:])
+		fru.Board = &api.FRUBoard{
+			Manufacturer: field(fields, 0),
+			ProductName:  field(fields, 1),
+			SerialNumber: field(fields, 2),
+			PartNumber:   field(fields, 3),
+			FRUFileID:    field(fields, 4),
+			Custom:       custom(fields, 5),
+		}
+		minutes := int(a[3]) | int(a[4])<<8 | int(a[5])<<16
+		if minutes != 0 {
+			fru.Board.MfgDate = fruEpoch.Add(time.Duration(minutes) * time.Minute)
+		}
+	}
+	if a := fruArea(data, header[4]); a != nil {
+		fields := fruFields(a[3:])
+		fru.Product = &api.FRUProduct{
+			Manufacturer: field(fields, 0),
+			Name:         field(fields, 1),
+			PartNumber:   field(fields, 2),
+			Version:      field(fields, 3),
+			SerialNumber: field(fields, 4),
+			AssetTag:     field(fields, 5),
+			FRUFileID:    field(fields, 6),
+			Custom:       custom(fields, 7),
+		}
+	}
+	fru.MultiRecords = fruMultiRecords(data, header[5])
+	return fru, nil
+}
+
+// fruArea returns the info area at the given offset in multiples of 8 bytes, nil if the area is absent or truncated
+func fruArea(data []byte, offset uint8) []byte {
+	start := int(offset) * 8
+	if offset == 0 || start+2 > len(data) {
+		return nil
+	}
+	end := start + int(data[start+1])*8
+	if end > len(data) || end-start < 3 {
+		return nil
+	}
+	return data[start:end]
+}
+
+// fruFields decodes the type/length encoded fields up to the end marker
+func fruFields(data []byte) []string {
+	var fields []string
+	for len(data) > 0 && data[0] != fruEndOfFields {
+		length := int(data[0] & 0x3F)
+		if 1+length > len(data) {
+			break
+		}
+		fields = append(fields, fruString(data[0]>>6, data[1:1+length]))
+		data = data[1+length:]
+	}
+	return fields
+}
+
+// fruString decodes a field according to its type code
+func fruString(typeCode uint8, b []byte) string {
+	switch typeCode {
+	case 0x00: // binary or unspecified
+		return hex.EncodeToString(b)
+	case 0x01: // BCD plus
+		const digits = "0123456789 -.???"
+		var s strings.Builder
+		for _, c := range b {
+			s.WriteByte(digits[c>>4])
+			s.WriteByte(digits[c&0x0F])
+		}
+		return strings.TrimSpace(s.String())
+	case 0x02: // 6-bit ASCII, packed
+		var s strings.Builder
+		for bit := 0; bit+6 <= len(b)*8; bit += 6 {
+			v := uint16(b[bit/8])
+			if bit/8+1 < len(b) {
+				v |= uint16(b[bit/8+1]) << 8
+			}
+			s.WriteByte(byte(v>>(bit%8)&0x3F) + 0x20)
+		}
+		return strings.TrimSpace(s.String())
+	default: // 8-bit ASCII
+		return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	}
+}
+
+func fruMultiRecords(data []byte, offset uint8) []api.FRUMultiRecord {
+	var records []api.FRUMultiRecord
+	if offset == 0 {
+		return nil
+	}
+	for start := int(offset) * 8; start+fruMultiRecordHeaderSize <= len(data); {
+		header := data[start : start+fruMultiRecordHeaderSize]
+		if checksum(header) != 0 {
+			break
+		}
+		end := start + fruMultiRecordHeaderSize + int(header[2])
+		if end > len(data) {
+			break
+		}
+		records = append(records, api.FRUMultiRecord{
+			Type: header[0],
+			Data: append([]byte(nil), data[start+fruMultiRecordHeaderSize:end]...),
+		})
+		if header[1]&fruEndOfList != 0 {
+			break
+		}
+		start = end
+	}
+	return records
+}
+
+func fruChassisType(t uint8) string {
+	if int(t) < len(fruChassisTypes) && fruChassisTypes[t] != "" {
+		return fruChassisTypes[t]
+	}
+	return fmt.Sprintf("Unknown 0x%02x", t)
+}
+
+func field(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+func custom(fields []string, i int) []string {
+	if i < len(fields) {
+		return fields[i:]
+	}
+	return nil
+}
+
+// checksum returns zero for valid zero checksum data
+func checksum(b []byte) uint8 {
+	var sum uint8
+	for _, c := range b {
+		sum += c
+	}
+	return sum
+}
+
+// toFru converts to the fields which are reported as part of the BMC details
+func toFru(fru *api.FRU) Fru {
+	var f Fru
+	if fru.Chassis != nil {
+		f.ChassisPartNumber = fru.Chassis.PartNumber
+		f.ChassisPartSerial = fru.Chassis.SerialNumber
+	}
+	if fru.Board != nil {
+		f.BoardMfg = fru.Board.Manufacturer
+		f.BoardMfgSerial = fru.Board.SerialNumber
+		f.BoardPartNumber = fru.Board.PartNumber
+	}
+	if fru.Product != nil {
+		f.ProductManufacturer = fru.Product.Manufacturer
+		f.ProductPartNumber = fru.Product.PartNumber
+		f.ProductSerial = fru.Product.SerialNumber
+	}
+	return f
+}
@@ -0,0 +1,161 @@
+package ipmi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func ascii(s string) []byte {
+	return append([]byte{0xC0 | uint8(len(s))}, s...)
+}
+
+// fruInfoArea builds an info area with version, length and the given prefix, padded and checksummed
+func fruInfoArea(prefix []byte, fields ...[]byte) []byte {
+	a := append([]byte{0x01, 0x00}, prefix...)
+	for _, f := range fields {
+		a = append(a, f...)
+	}
+	a = append(a, fruEndOfFields)
+	for (len(a)+1)%8 != 0 {
+		a = append(a, 0)
+	}
+	a[1] = uint8((len(a) + 1) / 8)
+	return append(a, -checksum(a))
+}
+
+// fruImage builds a FRU with the given areas, nil areas are omitted
+func fruImage(chassis, board, product, multi []byte) []byte {
+	header := make([]byte, fruHeaderSize)
+	header[0] = fruFormatVersion
+	var areas []byte
+	for i, a := range [][]byte{chassis, board, product, multi} {
+		if a == nil {
+			continue
+		}
+		header[2+i] = uint8((fruHeaderSize + len(areas)) / 8)
+		areas = append(areas, a...)
+		for len(areas)%8 != 0 {
+			areas = append(areas, 0)
+		}
+	}
+	header[7] = -checksum(header[:7])
+	return append(header, areas...)
+}
+
+func multiRecord(recordType uint8, last bool, data []byte) []byte {
+	h := []byte{recordType, 0x02, uint8(len(data)), -checksum(data), 0}
+	if last {
+		h[1] |= fruEndOfList
+	}
+	h[4] = -checksum(h[:4])
+	return append(h, data...)
+}
+
+func TestDecodeFRU(t *testing.T) {
+	mainboard := fruImage(
+		fruInfoArea([]byte{0x17}, ascii("CSE-819UTS-R1K02P-T"), ascii("C8190LK20AB0123")),
+		// 2024-02-01 12:00 UTC is 14772240 minutes after 1996-01-01
+		fruInfoArea([]byte{0x19, 0x10, 0x68, 0xE1}, ascii("Supermicro"), ascii("X12DPU-6"), ascii("OM21AS001234"), ascii("X12DPU-6"), ascii(""), ascii("board custom")),
+		fruInfoArea([]byte{0x19}, ascii("Supermicro"), ascii("SYS-120U-TNR"), ascii("SYS-120U-TNR"), ascii("0123456789"), ascii("S123456X1"), ascii("metal-01"), ascii("")),
+		multiRecord(0x00, true, []byte{0xE8, 0x03}),
+	)
+
+	got, err := DecodeFRU(mainboard)
+	require.NoError(t, err)
+	require.Equal(t, &api.FRU{
+		Chassis: &api.FRUChassis{
+			Type:         "Rack Mount Chassis",
+			PartNumber:   "CSE-819UTS-R1K02P-T",
+			SerialNumber: "C8190LK20AB0123",
+		},
+		Board: &api.FRUBoard{
+			MfgDate:      time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC),
+			Manufacturer: "Supermicro",
+			ProductName:  "X12DPU-6",
+			SerialNumber: "OM21AS001234",
+			PartNumber:   "X12DPU-6",
+			Custom:       []string{"board custom"},
+		},
+		Product: &api.FRUProduct{
+			Manufacturer: "Supermicro",
+			Name:         "SYS-120U-TNR",
+			PartNumber:   "SYS-120U-TNR",
+			Version:      "0123456789",
+			SerialNumber: "S123456X1",
+			AssetTag:     "metal-01",
+		},
+		MultiRecords: []api.FRUMultiRecord{{Type: 0x00, Data: []byte{0xE8, 0x03}}},
+	}, got)
+
+	// a power supply with product area only
+	psu := fruImage(nil, nil, fruInfoArea([]byte{0x00}, ascii("DELTA"), ascii("PWS-1K02A-1R"), ascii("PWS-1K02A-1R"), ascii("REV1.0"), ascii("P1K0212345")), nil)
+	got, err = DecodeFRU(psu)
+	require.NoError(t, err)
+	require.Nil(t, got.Chassis)
+	require.Nil(t, got.Board)
+	require.Equal(t, "P1K0212345", got.Product.SerialNumber)
+
+	broken := append([]byte(nil), mainboard...)
+	broken[7]++
+	_, err = DecodeFRU(broken)
+	require.Error(t, err)
+
+	_, err = DecodeFRU([]byte{0x01, 0x00})
+	require.Error(t, err)
+}
+
+func TestFRUString(t *testing.T) {
+	require.Equal(t, "a1b2", fruString(0x00, []byte{0xA1, 0xB2}))
+	require.Equal(t, "12-3.4", fruString(0x01, []byte{0x12, 0xB3, 0xC4}))
+	require.Equal(t, "IPMI", fruString(0x02, []byte{0x29, 0xDC, 0xA6}))
+	require.Equal(t, "Dell Inc.", fruString(0x03, []byte("Dell Inc.\x00")))
+}
+
+func TestReadFRUs(t *testing.T) {
+	locator := func(id uint16, deviceID uint8, logical bool, name string) []byte {
+		r := make([]byte, 16, 16+len(name))
+		r[0], r[1], r[2], r[3] = uint8(id), uint8(id>>8), 0x51, sdrFRUDeviceLocatorRecord
+		r[4] = uint8(16 + len(name) - sdrHeaderSize)
+		r[5] = bmcSlaveAddress
+		r[6] = deviceID
+		if logical {
+			r[7] = sdrLogicalFRUDevice
+		}
+		r[15] = 0xC0 | uint8(len(name))
+		return append(r, name...)
+	}
+	psu := func(serial string) []byte {
+		return fruImage(nil, nil, fruInfoArea([]byte{0x00}, ascii("DELTA"), ascii("PWS-1K02A-1R"), ascii("PWS-1K02A-1R"), ascii("REV1.0"), ascii(serial)), nil)
+	}
+	bmc := &fakeBMC{
+		records: [][]byte{
+			locator(0, 0, true, "Builtin FRU"),
+			locator(1, 1, true, "PSU1 FRU"),
+			locator(2, 2, true, "PSU2 FRU"),
+			locator(3, 0x50, false, "DIMM A1"),
+		},
+		frus: map[uint8][]byte{
+			0: fruImage(nil, fruInfoArea([]byte{0x19, 0, 0, 0}, ascii("Supermicro"), ascii("X12DPU-6"), ascii("OM21AS001234"), ascii("X12DPU-6")), nil, nil),
+			1: psu("P1K0212345"),
+			// PSU2 is not plugged
+		},
+	}
+
+	frus, err := readFRUs(bmc.send)
+	require.NoError(t, err)
+	require.Len(t, frus, 2)
+	require.Equal(t, uint8(0), frus[0].DeviceID)
+	require.Equal(t, "Builtin FRU Device", frus[0].Name)
+	require.Equal(t, "OM21AS001234", frus[0].Board.SerialNumber)
+	require.True(t, frus[0].Board.MfgDate.IsZero())
+	require.Equal(t, uint8(1), frus[1].DeviceID)
+	require.Equal(t, "PSU1 FRU", frus[1].Name)
+	require.Equal(t, "P1K0212345", frus[1].Product.SerialNumber)
+
+	fru, err := readFRU(bmc.send, 0)
+	require.NoError(t, err)
+	require.Equal(t, Fru{BoardMfg: "Supermicro", BoardMfgSerial: "OM21AS001234", BoardPartNumber: "X12DPU-6"}, toFru(fru))
+}
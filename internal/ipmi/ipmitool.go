@@ -4,6 +4,7 @@ package ipmi
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,15 +13,11 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/metal-stack/go-hal"
 	"github.com/metal-stack/go-hal/internal/console"
 	"github.com/metal-stack/go-hal/pkg/logger"
 
-	"github.com/sethvargo/go-password/password"
-
-	"github.com/avast/retry-go/v4"
 	"github.com/gliderlabs/ssh"
 	"github.com/metal-stack/go-hal/pkg/api"
 )
@@ -35,23 +32,35 @@ const (
 // IpmiTool defines methods to interact with IPMI
 type IpmiTool interface {
 	DevicePresent() bool
-	NewCommand(arg ...string) (*exec.Cmd, error)
-	Run(arg ...string) (string, error)
-	CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string, constraints *api.PasswordConstraints, apiType ApiType) (pwd string, err error)
-	ChangePassword(user api.BMCUser, newPassword string, apiType ApiType) error
-	NeedsPasswordChange(user api.BMCUser, password string) (b bool, e error)
-	SetUserEnabled(user api.BMCUser, enabled bool, apiType ApiType) error
-	GetLanConfig() (LanConfig, error)
-	SetBootOrder(target hal.BootTarget, vendor api.Vendor) error
-	SetChassisControl(ChassisControlFunction) error
-	SetChassisIdentifyLEDState(hal.IdentifyLEDState) error
-	SetChassisIdentifyLEDOn() error
-	SetChassisIdentifyLEDOff() error
-	GetFru() (Fru, error)
-	GetSession() (Session, error)
-	BMC() (*api.BMC, error)
-	OpenConsole(s ssh.Session) error
-}
+	NewCommand(ctx context.Context, arg ...string) (*exec.Cmd, error)
+	Run(ctx context.Context, arg ...string) (string, error)
+	CreateUser(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string, constraints *api.PasswordConstraints, apiType ApiType) (pwd string, err error)
+	ChangePassword(ctx context.Context, user api.BMCUser, newPassword string, constraints *api.PasswordConstraints, apiType ApiType) error
+	NeedsPasswordChange(ctx context.Context, user api.BMCUser, password string) (b bool, e error)
+	SetUserEnabled(ctx context.Context, user api.BMCUser, enabled bool, apiType ApiType) error
+	ListUsers(ctx context.Context) ([]api.BMCAccount, error)
+	GetLanConfig(ctx context.Context) (LanConfig, error)
+	GetBMCLanConfig(ctx context.Context, vendor api.Vendor) (*api.BMCLanConfig, error)
+	SetBMCLanConfig(ctx context.Context, vendor api.Vendor, config api.BMCLanConfig) error
+	SetBootOrder(ctx context.Context, target hal.BootTarget, vendor api.Vendor) error
+	SetBootOverride(ctx context.Context, override hal.BootOverride, vendor api.Vendor) error
+	BootOverride(ctx context.Context, vendor api.Vendor) (*hal.BootOverride, error)
+	SetChassisControl(ctx context.Context, fn ChassisControlFunction) error
+	SetChassisIdentifyLEDState(ctx context.Context, state hal.IdentifyLEDState) error
+	SetChassisIdentifyLEDOn(ctx context.Context) error
+	SetChassisIdentifyLEDOff(ctx context.Context) error
+	SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error)
+	ClearSEL(ctx context.Context) error
+	Sensors(ctx context.Context) ([]hal.Sensor, error)
+	FRUs(ctx context.Context) ([]api.FRU, error)
+	GetFru(ctx context.Context) (Fru, error)
+	GetSession(ctx context.Context) (Session, error)
+	BMC(ctx context.Context) (*api.BMC, error)
+	OpenConsole(ctx context.Context, s ssh.Session) error
+}
+
+// runFunc executes ipmitool style commands, raw commands are built by the Raw* builders
+type runFunc func(ctx context.Context, args ...string) (string, error)
 
 // Ipmitool is used to query and modify the IPMI based BMC from the host os
 type Ipmitool struct {
@@ -64,13 +73,14 @@ type Ipmitool struct {
 	log      logger.Logger
 }
 
-func (i *Ipmitool) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
-	passwordSize := len(password)
-	if passwordSize != 16 && passwordSize != 20 {
-		return false, fmt.Errorf("expected value is either 16 or 20")
+// NeedsPasswordChange tests the password of the given user, passwords longer than 16 characters are tested in the 20 byte format
+func (i *Ipmitool) NeedsPasswordChange(ctx context.Context, user api.BMCUser, password string) (bool, error) {
+	passwordSize, err := testPasswordSize(password)
+	if err != nil {
+		return false, err
 	}
 
-	output, err := i.Run("user", "test", user.Id, strconv.Itoa(passwordSize), password)
+	output, err := i.Run(ctx, "user", "test", user.Id, strconv.Itoa(passwordSize), password)
 	if err != nil {
 		if strings.Contains(output, "Failure: password incorrect") {
 			return true, fmt.Errorf("password for user %s with id %s incorrect: %w change necessary", user.Name, user.Id, err)
@@ -147,24 +157,29 @@ func NewOutBand(ip string, port int, user, password string, log logger.Logger) (
 }
 
 // BMC returns the BMC struct
-func (i *Ipmitool) BMC() (*api.BMC, error) {
-	lan, err := i.GetLanConfig()
+func (i *Ipmitool) BMC(ctx context.Context) (*api.BMC, error) {
+	lan, err := i.GetLanConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	fru, err := i.GetFru()
+	fru, err := i.GetFru(ctx)
 	if err != nil {
 		// FIXME
 		i.log.Errorw("unable to get fru:%s", err)
 		// return nil, err
 	}
-	info, err := i.GetBMCInfo()
+	info, err := i.GetBMCInfo(ctx)
 	if err != nil {
 		// FIXME
 		i.log.Errorw("unable to get bmcinfo:%s", err)
 		// return nil, err
 	}
-	bmc := &api.BMC{
+	return newBMC(lan, fru, info), nil
+}
+
+// newBMC merges the details of the BMC
+func newBMC(lan LanConfig, fru Fru, info BMCInfo) *api.BMC {
+	return &api.BMC{
 		IP:                  lan.IP,
 		MAC:                 lan.Mac,
 		BoardMfg:            fru.BoardMfg,
@@ -177,7 +192,6 @@ func (i *Ipmitool) BMC() (*api.BMC, error) {
 		ProductSerial:       fru.ProductSerial,
 		FirmwareRevision:    info.FirmwareRevision,
 	}
-	return bmc, nil
 }
 
 // DevicePresent returns true if the IPMI device is present, which is required to talk to the BMC
@@ -190,17 +204,17 @@ func (i *Ipmitool) DevicePresent() bool {
 	return len(matches) > 0
 }
 
-// NewCommand returns a new ipmitool command with the given arguments
-func (i *Ipmitool) NewCommand(args ...string) (*exec.Cmd, error) {
+// NewCommand returns a new ipmitool command with the given arguments, the command is killed once ctx is done
+func (i *Ipmitool) NewCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
 	path, err := exec.LookPath(i.command)
 	if err != nil {
 		return nil, fmt.Errorf("unable to locate program:%s in path %w", i.command, err)
 	}
-	return exec.Command(path, args...), nil
+	return exec.CommandContext(ctx, path, args...), nil
 }
 
 // Run executes ipmitool with given arguments and returns the outcome
-func (i *Ipmitool) Run(args ...string) (string, error) {
+func (i *Ipmitool) Run(ctx context.Context, args ...string) (string, error) {
 	if i.outband {
 		err := os.Setenv("IPMITOOL_PASSWORD", i.password)
 		if err != nil {
@@ -211,7 +225,7 @@ func (i *Ipmitool) Run(args ...string) (string, error) {
 		}()
 		args = append([]string{"-I", "lanplus", "-H", i.ip, "-p", strconv.Itoa(i.port), "-U", i.user, "-E"}, args...)
 	}
-	cmd, err := i.NewCommand(args...)
+	cmd, err := i.NewCommand(ctx, args...)
 	if err != nil {
 		return "", err
 	}
@@ -222,22 +236,19 @@ func (i *Ipmitool) Run(args ...string) (string, error) {
 	return string(output), err
 }
 
-// GetFru returns the Field Replaceable Unit information
-func (i *Ipmitool) GetFru() (Fru, error) {
-	config := &Fru{}
-	cmdOutput, err := i.Run("fru")
+// GetFru returns the Field Replaceable Unit information of the BMC
+func (i *Ipmitool) GetFru(ctx context.Context) (Fru, error) {
+	fru, err := readFRU(i.rawSender(ctx), 0)
 	if err != nil {
-		return *config, fmt.Errorf("unable to execute ipmitool 'fru':%v %w", cmdOutput, err)
+		return Fru{}, fmt.Errorf("unable to read fru:%w", err)
 	}
-	fruMap := i.output2Map(cmdOutput)
-	from(config, fruMap)
-	return *config, nil
+	return toFru(fru), nil
 }
 
 // GetBMCInfo returns the BMC info
-func (i *Ipmitool) GetBMCInfo() (BMCInfo, error) {
+func (i *Ipmitool) GetBMCInfo(ctx context.Context) (BMCInfo, error) {
 	bmc := &BMCInfo{}
-	cmdOutput, err := i.Run("bmc", "info")
+	cmdOutput, err := i.Run(ctx, "bmc", "info")
 	if err != nil {
 		return *bmc, fmt.Errorf("unable to execute ipmitool 'bmc info':%v %w", cmdOutput, err)
 	}
@@ -247,9 +258,9 @@ func (i *Ipmitool) GetBMCInfo() (BMCInfo, error) {
 }
 
 // GetLanConfig returns the LAN config
-func (i *Ipmitool) GetLanConfig() (LanConfig, error) {
+func (i *Ipmitool) GetLanConfig(ctx context.Context) (LanConfig, error) {
 	config := &LanConfig{}
-	cmdOutput, err := i.Run("lan", "print")
+	cmdOutput, err := i.Run(ctx, "lan", "print")
 	if err != nil {
 		return *config, fmt.Errorf("unable to execute ipmitool 'lan print':%v %w", cmdOutput, err)
 	}
@@ -259,9 +270,9 @@ func (i *Ipmitool) GetLanConfig() (LanConfig, error) {
 }
 
 // GetSession returns the session
-func (i *Ipmitool) GetSession() (Session, error) {
+func (i *Ipmitool) GetSession(ctx context.Context) (Session, error) {
 	session := &Session{}
-	cmdOutput, err := i.Run("session", "info", "all")
+	cmdOutput, err := i.Run(ctx, "session", "info", "all")
 	if err != nil {
 		return *session, fmt.Errorf("unable to execute ipmitool 'session info all':%v %w", cmdOutput, err)
 	}
@@ -270,46 +281,21 @@ func (i *Ipmitool) GetSession() (Session, error) {
 	return *session, nil
 }
 
-type bmcRequest struct {
-	username                   string
-	uid                        string
-	privilege                  api.IpmiPrivilege
-	disableUserArgs            []string
-	enableUserArgs             []string
-	setUsernameArgs            []string
-	setUserPrivilegeArgs       []string
-	enableSOLPayloadAccessArgs []string
-	setPasswordFunc            func() (string, error)
-}
-
 // CreateUser creates an IPMI user with given privilege level and either the given password or - if empty - a generated one with respect to the given password constraints
-func (i *Ipmitool) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string, pc *api.PasswordConstraints, apiType ApiType) (string, error) {
+func (i *Ipmitool) CreateUser(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string, pc *api.PasswordConstraints, apiType ApiType) (string, error) {
+	u := i.users()
 	switch apiType {
 	case LowLevel:
-		id, err := strconv.Atoi(user.Id)
-		if err != nil {
-			return "", fmt.Errorf("invalid uid of user %s: %s %w", user.Name, user.Id, err)
-		}
-		userID := uint8(id)             // nolint:gosec
-		cn := uint8(user.ChannelNumber) // nolint:gosec
-		return i.createUser(bmcRequest{
-			username:                   user.Name,
-			uid:                        user.Id,
-			privilege:                  privilege,
-			disableUserArgs:            RawDisableUser(userID),
-			enableUserArgs:             RawEnableUser(userID),
-			setUsernameArgs:            RawSetUserName(userID, user.Name),
-			setUserPrivilegeArgs:       RawUserAccess(cn, userID, privilege),
-			enableSOLPayloadAccessArgs: RawEnableUserSOLPayloadAccess(cn, userID),
-			setPasswordFunc: func() (string, error) {
-				return i.createPasswordRaw(user.Name, userID, password, pc)
-			},
-		})
+		return u.createUserRaw(ctx, user, privilege, password, pc)
 	case HighLevel:
 		fallthrough
 	default:
+		passwd, err := userPassword(user, password, pc)
+		if err != nil {
+			return "", err
+		}
 		cn := strconv.Itoa(user.ChannelNumber)
-		return i.createUser(bmcRequest{
+		return u.createUser(ctx, bmcRequest{
 			username:                   user.Name,
 			uid:                        user.Id,
 			privilege:                  privilege,
@@ -319,35 +305,26 @@ func (i *Ipmitool) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, pas
 			setUserPrivilegeArgs:       []string{"channel", "setaccess", cn, user.Id, "link=on", "ipmi=on", "callin=on", fmt.Sprintf("privilege=%d", privilege)},
 			enableSOLPayloadAccessArgs: []string{"sol", "payload", "enable", cn, user.Id},
 			setPasswordFunc: func() (string, error) {
-				return i.createPassword(user.Name, user.Id, password, pc)
+				return u.createPassword(ctx, user.Name, user.Id, passwd)
 			},
 		})
 	}
 }
 
-// ChangePassword of the given user
-func (i *Ipmitool) ChangePassword(user api.BMCUser, newPassword string, apiType ApiType) error {
+// ChangePassword of the given user, the new password is validated against the given password constraints first
+func (i *Ipmitool) ChangePassword(ctx context.Context, user api.BMCUser, newPassword string, pc *api.PasswordConstraints, apiType ApiType) error {
+	u := i.users()
 	switch apiType {
 	case LowLevel:
-		id, err := strconv.Atoi(user.Id)
-		if err != nil {
-			return fmt.Errorf("invalid uid of user %s: %s %w", user.Name, user.Id, err)
-		}
-		userID := uint8(id) // nolint:gosec
-		_, err = i.changePassword(bmcRequest{
-			username:        user.Name,
-			uid:             user.Id,
-			disableUserArgs: RawDisableUser(userID),
-			enableUserArgs:  RawEnableUser(userID),
-			setPasswordFunc: func() (string, error) {
-				return newPassword, nil
-			},
-		})
-		return err
+		return u.changePasswordRaw(ctx, user, newPassword, pc)
 	case HighLevel:
 		fallthrough
 	default:
-		_, err := i.changePassword(bmcRequest{
+		err := validatePassword(user, newPassword, pc)
+		if err != nil {
+			return err
+		}
+		_, err = u.changePassword(ctx, bmcRequest{
 			username:        user.Name,
 			uid:             user.Id,
 			disableUserArgs: []string{"user", "disable", user.Id},
@@ -361,24 +338,15 @@ func (i *Ipmitool) ChangePassword(user api.BMCUser, newPassword string, apiType
 }
 
 // SetUserEnabled enable the given user
-func (i *Ipmitool) SetUserEnabled(user api.BMCUser, enabled bool, apiType ApiType) error {
+func (i *Ipmitool) SetUserEnabled(ctx context.Context, user api.BMCUser, enabled bool, apiType ApiType) error {
+	u := i.users()
 	switch apiType {
 	case LowLevel:
-		id, err := strconv.Atoi(user.Id)
-		if err != nil {
-			return fmt.Errorf("invalid uid of user %s: %s %w", user.Name, user.Id, err)
-		}
-		userID := uint8(id) // nolint:gosec
-		return i.setUserEnabled(bmcRequest{
-			username:        user.Name,
-			uid:             user.Id,
-			disableUserArgs: RawDisableUser(userID),
-			enableUserArgs:  RawEnableUser(userID),
-		}, enabled)
+		return u.setUserEnabledRaw(ctx, user, enabled)
 	case HighLevel:
 		fallthrough
 	default:
-		return i.setUserEnabled(bmcRequest{
+		return u.setUserEnabled(ctx, bmcRequest{
 			username:        user.Name,
 			uid:             user.Id,
 			disableUserArgs: []string{"user", "disable", user.Id},
@@ -387,124 +355,36 @@ func (i *Ipmitool) SetUserEnabled(user api.BMCUser, enabled bool, apiType ApiTyp
 	}
 }
 
-func (i *Ipmitool) createUser(req bmcRequest) (string, error) {
-	out, err := i.Run(req.setUsernameArgs...)
-	if err != nil {
-		return "", fmt.Errorf("failed set username for user %s with id %s: %s %w", req.username, req.uid, out, err)
-	}
-
-	pw, err := i.changePassword(req)
-	if err != nil {
-		return "", err
-	}
-
-	out, err = i.Run(req.setUserPrivilegeArgs...)
-	if err != nil {
-		return "", fmt.Errorf("failed to set privilege %d for user %s with id %s: %s %w", req.privilege, req.username, req.uid, out, err)
-	}
-
-	out, err = i.Run(req.enableSOLPayloadAccessArgs...)
-	if err != nil {
-		return "", fmt.Errorf("failed to set enable user SOL payload access for user %s with id %s: %s %w", req.username, req.uid, out, err)
-	}
-
-	return pw, nil
+func (i *Ipmitool) users() userManager {
+	return userManager{run: i.Run, log: i.log}
 }
 
-func (i *Ipmitool) changePassword(req bmcRequest) (string, error) {
-	pw, err := req.setPasswordFunc()
-	if err != nil {
-		return "", fmt.Errorf("failed to set password %s for user %s with id %s %w", pw, req.username, req.uid, err)
-	}
-
-	err = i.setUserEnabled(req, true)
-	if err != nil {
-		return "", err
-	}
-
-	return pw, nil
+// SetBootOrder sets the boot order to given target with the default override of the target
+func (i *Ipmitool) SetBootOrder(ctx context.Context, target hal.BootTarget, vendor api.Vendor) error {
+	return i.SetBootOverride(ctx, hal.DefaultBootOverride(target), vendor)
 }
 
-func (i *Ipmitool) setUserEnabled(req bmcRequest, enabled bool) error {
-	if enabled {
-		err := retry.Do(
-			func() error {
-				out, err := i.Run(req.enableUserArgs...)
-				if err != nil {
-					return fmt.Errorf("failed to enable user %s with id %s: %s %w", req.username, req.uid, out, err)
-				}
-				return nil
-			},
-			retry.OnRetry(func(n uint, err error) {
-				i.log.Infow("retry ipmi enable user", "user", req.username, "id", req.uid, "retry", n, "cause", err)
-			}),
-			retry.Delay(1*time.Second),
-			retry.Attempts(30),
-		)
+// SetBootOverride sets the boot flags to the given override
+func (i *Ipmitool) SetBootOverride(ctx context.Context, override hal.BootOverride, vendor api.Vendor) error {
+	args, err := RawSetBootOverride(override, vendor)
+	if err != nil {
 		return err
 	}
-
-	out, err := i.Run(req.disableUserArgs...)
+	out, err := i.Run(ctx, args...)
 	if err != nil {
-		return fmt.Errorf("failed to disable user %s with id %s: %s %w", req.username, req.uid, out, err)
+		return fmt.Errorf("unable to set boot override:%s out:%v %w", override, out, err)
 	}
-
 	return nil
 }
 
-func (i *Ipmitool) createPassword(username, uid string, passwd string, pc *api.PasswordConstraints) (string, error) {
-	s := func(pw string) []string {
-		return []string{"user", "set", "password", uid, pw}
-	}
-	return i.createPw(username, uid, passwd, pc, s)
-}
-
-func (i *Ipmitool) createPasswordRaw(username string, uid uint8, passwd string, pc *api.PasswordConstraints) (string, error) {
-	s := func(pw string) []string {
-		return RawSetUserPassword(uid, pw)
-	}
-	return i.createPw(username, strconv.Itoa(int(uid)), passwd, pc, s)
-}
-
-func (i *Ipmitool) createPw(username, uid, passwd string, pc *api.PasswordConstraints, setPasswordArgs func(string) []string) (string, error) {
-	err := retry.Do(
-		func() error {
-			pwd := passwd
-			if pwd == "" && pc != nil {
-				gen, err := password.Generate(pc.Length, pc.NumDigits, pc.NumSymbols, pc.NoUpper, pc.AllowRepeat)
-				if err != nil {
-					return fmt.Errorf("password generation failed for user:%s id:%s %w", username, uid, err)
-				}
-				pwd = gen
-			}
-			out, err := i.Run(setPasswordArgs(pwd)...)
-			if err != nil {
-				return fmt.Errorf("ipmi password creation failed for user:%s id:%s output:%s %w", username, uid, out, err)
-			}
-			passwd = pwd
-			return nil
-		},
-		retry.OnRetry(func(n uint, err error) {
-			i.log.Infow("retry ipmi password creation", "user", username, "id", uid, "retry", n, "cause", err)
-		}),
-		retry.Delay(1*time.Second),
-		retry.Attempts(30),
-	)
-	return passwd, err
-}
-
-// SetBootOrder persistently sets the boot order to given target
-func (i *Ipmitool) SetBootOrder(target hal.BootTarget, vendor api.Vendor) error {
-	out, err := i.Run(RawSetSystemBootOptions(target, vendor)...)
-	if err != nil {
-		return fmt.Errorf("unable to persistently set boot order:%s out:%v %w", target, out, err)
-	}
-	return nil
+// BootOverride reads the boot override from the boot flags
+func (i *Ipmitool) BootOverride(ctx context.Context, vendor api.Vendor) (*hal.BootOverride, error) {
+	return readBootOverride(i.rawSender(ctx), vendor)
 }
 
 // SetChassisControl executes the given chassis control function
-func (i *Ipmitool) SetChassisControl(fn ChassisControlFunction) error {
-	_, err := i.Run(RawChassisControl(fn)...)
+func (i *Ipmitool) SetChassisControl(ctx context.Context, fn ChassisControlFunction) error {
+	_, err := i.Run(ctx, RawChassisControl(fn)...)
 	if err != nil {
 		return fmt.Errorf("unable to set chassis control function:%X %w", fn, err)
 	}
@@ -512,12 +392,12 @@ func (i *Ipmitool) SetChassisControl(fn ChassisControlFunction) error {
 }
 
 // SetChassisIdentifyLEDState sets the chassis identify LED to given state
-func (i *Ipmitool) SetChassisIdentifyLEDState(state hal.IdentifyLEDState) error {
+func (i *Ipmitool) SetChassisIdentifyLEDState(ctx context.Context, state hal.IdentifyLEDState) error {
 	switch state {
 	case hal.IdentifyLEDStateOn:
-		return i.SetChassisIdentifyLEDOn()
+		return i.SetChassisIdentifyLEDOn(ctx)
 	case hal.IdentifyLEDStateOff:
-		return i.SetChassisIdentifyLEDOff()
+		return i.SetChassisIdentifyLEDOff(ctx)
 	case hal.IdentifyLEDStateUnknown:
 		fallthrough
 	default:
@@ -526,8 +406,8 @@ func (i *Ipmitool) SetChassisIdentifyLEDState(state hal.IdentifyLEDState) error
 }
 
 // SetChassisIdentifyLEDOn turns on the chassis identify LED
-func (i *Ipmitool) SetChassisIdentifyLEDOn() error {
-	_, err := i.Run(RawChassisIdentifyOn()...)
+func (i *Ipmitool) SetChassisIdentifyLEDOn(ctx context.Context) error {
+	_, err := i.Run(ctx, RawChassisIdentifyOn()...)
 	if err != nil {
 		return fmt.Errorf("unable to turn on the chassis identify LED %w", err)
 	}
@@ -535,16 +415,61 @@ func (i *Ipmitool) SetChassisIdentifyLEDOn() error {
 }
 
 // SetChassisIdentifyLEDOff turns off the chassis identify LED
-func (i *Ipmitool) SetChassisIdentifyLEDOff() error {
-	_, err := i.Run(RawChassisIdentifyOff()...)
+func (i *Ipmitool) SetChassisIdentifyLEDOff(ctx context.Context) error {
+	_, err := i.Run(ctx, RawChassisIdentifyOff()...)
 	if err != nil {
 		return fmt.Errorf("unable to turn off the chassis identify LED %w", err)
 	}
 	return nil
 }
 
+// SEL returns the system event log entries with a record id greater than after
+func (i *Ipmitool) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	return readSEL(ctx, i.Run, after)
+}
+
+// ClearSEL clears the system event log
+func (i *Ipmitool) ClearSEL(ctx context.Context) error {
+	return clearSEL(ctx, i.Run)
+}
+
+// ListUsers returns the users of the LAN channel
+func (i *Ipmitool) ListUsers(ctx context.Context) ([]api.BMCAccount, error) {
+	return readUsers(i.rawSender(ctx))
+}
+
+// GetBMCLanConfig returns the network configuration of the BMC, the port selection is only read from vendors which support it
+func (i *Ipmitool) GetBMCLanConfig(ctx context.Context, vendor api.Vendor) (*api.BMCLanConfig, error) {
+	return readBMCLanConfig(i.rawSender(ctx), vendor)
+}
+
+// SetBMCLanConfig writes the network configuration of the BMC
+func (i *Ipmitool) SetBMCLanConfig(ctx context.Context, vendor api.Vendor, config api.BMCLanConfig) error {
+	return writeBMCLanConfig(i.rawSender(ctx), vendor, config)
+}
+
+// Sensors returns the readings of the temperature, fan, voltage and current sensors of the SDR repository
+func (i *Ipmitool) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	return readSensors(i.rawSender(ctx))
+}
+
+// FRUs returns the decoded inventory of the FRU of the BMC and of all FRU devices listed in the SDR repository
+func (i *Ipmitool) FRUs(ctx context.Context) ([]api.FRU, error) {
+	return readFRUs(i.rawSender(ctx))
+}
+
+func (i *Ipmitool) rawSender(ctx context.Context) rawSender {
+	return func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+		out, err := i.Run(ctx, rawCommand(append([]uint8{netFn, command}, data...)...)...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to execute raw command %X %X:%v %w", netFn, command, out, err)
+		}
+		return parseRawOutput(out)
+	}
+}
+
 // OpenConsole connect to the serian console and put the in/out into a ssh stream
-func (i *Ipmitool) OpenConsole(s ssh.Session) error {
+func (i *Ipmitool) OpenConsole(ctx context.Context, s ssh.Session) error {
 	_, err := io.WriteString(s, "Exit with ~.\n")
 	if err != nil {
 		return fmt.Errorf("failed to write to console %w", err)
@@ -556,7 +481,7 @@ func (i *Ipmitool) OpenConsole(s ssh.Session) error {
 	defer func() {
 		_ = os.Unsetenv("IPMITOOL_PASSWORD")
 	}()
-	cmd, err := i.NewCommand("-I", "lanplus", "-H", i.ip, "-p", strconv.Itoa(i.port), "-U", i.user, "-E", "sol", "activate")
+	cmd, err := i.NewCommand(ctx, "-I", "lanplus", "-H", i.ip, "-p", strconv.Itoa(i.port), "-U", i.user, "-E", "sol", "activate")
 	if err != nil {
 		return err
 	}
@@ -585,6 +510,19 @@ func (i *Ipmitool) output2Map(cmdOutput string) map[string]string {
 	return result
 }
 
+// parseRawOutput parses the hex bytes ipmitool prints for raw commands
+func parseRawOutput(cmdOutput string) ([]byte, error) {
+	var result []byte
+	for _, f := range strings.Fields(cmdOutput) {
+		b, err := strconv.ParseUint(f, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse raw output %q: %w", cmdOutput, err)
+		}
+		result = append(result, byte(b))
+	}
+	return result, nil
+}
+
 // from uses reflection to fill a struct based on the tags on it
 func from(target any, input map[string]string) {
 	val := reflect.ValueOf(target).Elem()
@@ -597,39 +535,3 @@ func from(target any, input map[string]string) {
 		valueField.SetString(input[ipmitoolKey])
 	}
 }
-
-const (
-	nextBootUEFIQualifier   = uint8(0xA0)
-	persistentUEFIQualifier = uint8(0xE0)
-
-	pxeQualifier = uint8(0x04)
-
-	hdQualifier           = uint8(0x08)
-	supermicroHDQualifier = uint8(0x24)
-
-	biosQualifier = uint8(0x18)
-)
-
-// GetBootOrderQualifiers returns the qualifiers needed to set the given boot order according to the given vendor
-func GetBootOrderQualifiers(bootTarget hal.BootTarget, vendor api.Vendor) (uefiQualifier, bootDevQualifier uint8) {
-	switch bootTarget {
-	case hal.BootTargetPXE:
-		uefiQualifier = persistentUEFIQualifier
-		bootDevQualifier = pxeQualifier
-	case hal.BootTargetDisk:
-		uefiQualifier = persistentUEFIQualifier
-		switch vendor {
-		case api.VendorSupermicro, api.VendorNovarion:
-			bootDevQualifier = supermicroHDQualifier
-		case api.VendorLenovo, api.VendorDell, api.VendorVagrant, api.VendorUnknown, api.VendorGigabyte:
-			fallthrough
-		default:
-			bootDevQualifier = hdQualifier
-		}
-	case hal.BootTargetBIOS:
-		uefiQualifier = nextBootUEFIQualifier
-		bootDevQualifier = biosQualifier
-	}
-
-	return
-}
@@ -231,7 +231,7 @@ func TestGetLanConfig(t *testing.T) {
 		tt := tests[i]
 		t.Run(tt.name, func(t *testing.T) {
 			i := &Ipmitool{command: "/bin/true"}
-			got, err := i.GetLanConfig()
+			got, err := i.GetLanConfig(t.Context())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLanConfig() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -335,7 +335,7 @@ func TestIpmitool_Run(t *testing.T) {
 				password: tt.fields.password,
 				outband:  tt.fields.outband,
 			}
-			got, err := i.Run(tt.args...)
+			got, err := i.Run(t.Context(), tt.args...)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Ipmitool.Run() error = %v, wantErr %v", err, tt.wantErr)
 				return
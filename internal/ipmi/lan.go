@@ -0,0 +1,349 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-second-gen-interface-spec-v2-rev1-1.pdf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// 22.24 Get Channel Info, 23.1 Set LAN Configuration Parameters, 23.2 Get LAN Configuration Parameters
+
+const (
+	lanParameterSetInProgress       = 0
+	lanParameterIPAddress           = 3
+	lanParameterIPAddressSource     = 4
+	lanParameterMACAddress          = 5
+	lanParameterSubnetMask          = 6
+	lanParameterDefaultGateway      = 12
+	lanParameterVLANID              = 20
+	lanParameterIPv6Enables         = 51
+	lanParameterIPv6Status          = 55
+	lanParameterIPv6StaticAddresses = 56
+
+	ipAddressSourceStatic = 0x01
+	ipAddressSourceDHCP   = 0x02
+	// ipv6Enables4And6 enables IPv6 addressing in addition to IPv4
+	ipv6Enables4And6 = 0x02
+	vlanEnabled      = 0x80
+	ipv6Enabled      = 0x80
+	// completionCodeParameterNotSupported is returned for parameters like the IPv6 ones by BMCs which do not implement them
+	completionCodeParameterNotSupported = 0x80
+
+	channelMediumLAN = 0x04
+	maxChannel       = 0x0B
+)
+
+// supermicroNetworkFunction and the following command select the port of Supermicro BMCs, which has no standard parameter
+const (
+	supermicroNetworkFunction NetworkFunction = 0x30
+	supermicroLanInterface    uint8           = 0x70
+	supermicroLanInterfaceSub uint8           = 0x0C
+)
+
+var supermicroNICs = map[uint8]api.BMCNIC{
+	0x00: api.BMCNICDedicated,
+	0x01: api.BMCNICShared,
+	0x02: api.BMCNICFailover,
+}
+
+// readLanConfig reads the ip and mac of the first LAN channel of the BMC
+func readLanConfig(send rawSender) (LanConfig, error) {
+	channel, err := lanChannel(send)
+	if err != nil {
+		return LanConfig{}, err
+	}
+	ip, err := readLanParameter(send, channel, lanParameterIPAddress, net.IPv4len)
+	if err != nil {
+		return LanConfig{}, err
+	}
+	mac, err := readLanParameter(send, channel, lanParameterMACAddress, 6)
+	if err != nil {
+		return LanConfig{}, err
+	}
+	return LanConfig{
+		IP:  net.IP(ip).String(),
+		Mac: net.HardwareAddr(mac).String(),
+	}, nil
+}
+
+// lanChannel returns the first channel with 802.3 LAN as medium, like ipmitool lan print does
+func lanChannel(send rawSender) (uint8, error) {
+	for channel := uint8(1); channel <= maxChannel; channel++ {
+		resp, err := send(AppNetworkFunction, GetChannelInfo, channel)
+		if err != nil {
+			continue
+		}
+		if len(resp) >= 2 && resp[1]&0x7F == channelMediumLAN {
+			return channel, nil
+		}
+	}
+	return 0, fmt.Errorf("no lan channel found")
+}
+
+func readLanParameter(send rawSender, channel, parameter uint8, size int) ([]byte, error) {
+	resp, err := send(TransportNetworkFunction, GetLANConfigurationParameters, channel, parameter, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get lan parameter %d of channel %d %w", parameter, channel, err)
+	}
+	// the first byte is the parameter revision
+	if len(resp) < 1+size {
+		return nil, fmt.Errorf("unexpected lan parameter %d response:%v", parameter, resp)
+	}
+	return resp[1 : 1+size], nil
+}
+
+func setLanParameter(send rawSender, channel, parameter uint8, data ...uint8) error {
+	_, err := send(TransportNetworkFunction, SetLANConfigurationParameters, append([]uint8{channel, parameter}, data...)...)
+	if err != nil {
+		return fmt.Errorf("unable to set lan parameter %d of channel %d %w", parameter, channel, err)
+	}
+	return nil
+}
+
+// readBMCLanConfig reads the network configuration of the first LAN channel of the BMC
+func readBMCLanConfig(send rawSender, vendor api.Vendor) (*api.BMCLanConfig, error) {
+	channel, err := lanChannel(send)
+	if err != nil {
+		return nil, err
+	}
+	config := &api.BMCLanConfig{}
+
+	source, err := readLanParameter(send, channel, lanParameterIPAddressSource, 1)
+	if err != nil {
+		return nil, err
+	}
+	switch source[0] & 0x0F {
+	case ipAddressSourceStatic:
+		config.IPSource = api.BMCIPSourceStatic
+	case ipAddressSourceDHCP:
+		config.IPSource = api.BMCIPSourceDHCP
+	}
+
+	for parameter, field := range map[uint8]*string{
+		lanParameterIPAddress:      &config.IP,
+		lanParameterSubnetMask:     &config.Netmask,
+		lanParameterDefaultGateway: &config.Gateway,
+	} {
+		ip, err := readLanParameter(send, channel, parameter, net.IPv4len)
+		if err != nil {
+			return nil, err
+		}
+		*field = net.IP(ip).String()
+	}
+	mac, err := readLanParameter(send, channel, lanParameterMACAddress, 6)
+	if err != nil {
+		return nil, err
+	}
+	config.MAC = net.HardwareAddr(mac).String()
+
+	vlan, err := readLanParameter(send, channel, lanParameterVLANID, 2)
+	if err != nil {
+		return nil, err
+	}
+	if vlan[1]&vlanEnabled != 0 {
+		config.VLANID = uint16(vlan[1]&0x0F)<<8 | uint16(vlan[0])
+	}
+
+	config.IPv6Addresses, err = readIPv6StaticAddresses(send, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if vendor == api.VendorSupermicro {
+		resp, err := send(supermicroNetworkFunction, supermicroLanInterface, supermicroLanInterfaceSub, 0x00)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get lan interface %w", err)
+		}
+		if len(resp) < 1 {
+			return nil, fmt.Errorf("unexpected lan interface response:%v", resp)
+		}
+		config.NIC = supermicroNICs[resp[0]]
+	}
+	return config, nil
+}
+
+// readIPv6StaticAddresses returns the enabled static IPv6 addresses, BMCs without IPv6 support have none
+func readIPv6StaticAddresses(send rawSender, channel uint8) ([]string, error) {
+	status, err := readLanParameter(send, channel, lanParameterIPv6Status, 1)
+	if err != nil {
+		var cce *CompletionCodeError
+		if errors.As(err, &cce) && cce.Code == completionCodeParameterNotSupported {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var addresses []string
+	for selector := range status[0] {
+		resp, err := send(TransportNetworkFunction, GetLANConfigurationParameters, channel, lanParameterIPv6StaticAddresses, selector, 0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get static ipv6 address %d of channel %d %w", selector, channel, err)
+		}
+		// revision, set selector, source, 16 bytes address and the prefix length
+		if len(resp) < 20 {
+			return nil, fmt.Errorf("unexpected static ipv6 address response:%v", resp)
+		}
+		if resp[2]&ipv6Enabled == 0 {
+			continue
+		}
+		addr := netip.AddrFrom16([16]byte(resp[3:19]))
+		addresses = append(addresses, netip.PrefixFrom(addr, int(resp[19])).String())
+	}
+	return addresses, nil
+}
+
+// writeBMCLanConfig applies the network configuration to the first LAN channel of the BMC
+func writeBMCLanConfig(send rawSender, vendor api.Vendor, config api.BMCLanConfig) error {
+	// validate everything before the first parameter is written
+	var ip, netmask, gateway net.IP
+	if config.IPSource == api.BMCIPSourceStatic {
+		for _, a := range []struct {
+			name  string
+			value string
+			ip    *net.IP
+		}{{"ip", config.IP, &ip}, {"netmask", config.Netmask, &netmask}, {"gateway", config.Gateway, &gateway}} {
+			*a.ip = net.ParseIP(a.value).To4()
+			if *a.ip == nil {
+				return fmt.Errorf("invalid %s %q", a.name, a.value)
+			}
+		}
+	} else if config.IPSource != api.BMCIPSourceDHCP {
+		return fmt.Errorf("unknown ip source %q", config.IPSource)
+	}
+	if config.VLANID > 4094 {
+		return fmt.Errorf("invalid vlan id %d", config.VLANID)
+	}
+	var ipv6 []netip.Prefix
+	for _, a := range config.IPv6Addresses {
+		prefix, err := netip.ParsePrefix(a)
+		if err != nil || !prefix.Addr().Is6() {
+			return fmt.Errorf("invalid ipv6 address %q", a)
+		}
+		ipv6 = append(ipv6, prefix)
+	}
+	var nic uint8
+	if config.NIC != "" {
+		if vendor != api.VendorSupermicro {
+			return fmt.Errorf("nic selection of %s %w", vendor, hal.ErrNotSupported)
+		}
+		var ok bool
+		nic, ok = supermicroNICMode(config.NIC)
+		if !ok {
+			return fmt.Errorf("unknown nic %q", config.NIC)
+		}
+	}
+
+	channel, err := lanChannel(send)
+	if err != nil {
+		return err
+	}
+
+	// set-in-progress is optional, BMCs which do not support it apply every parameter immediately
+	useProgress := setLanParameter(send, channel, lanParameterSetInProgress, 1) == nil
+	err = writeLanParameters(send, channel, config.IPSource, ip, netmask, gateway, config.VLANID, ipv6)
+	if useProgress {
+		if err == nil {
+			// set-in-progress = commit-write
+			_ = setLanParameter(send, channel, lanParameterSetInProgress, 2)
+		}
+		// set-in-progress = set-complete
+		_ = setLanParameter(send, channel, lanParameterSetInProgress, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	if config.NIC != "" {
+		_, err = send(supermicroNetworkFunction, supermicroLanInterface, supermicroLanInterfaceSub, 0x01, nic)
+		if err != nil {
+			return fmt.Errorf("unable to set lan interface %w", err)
+		}
+	}
+	return nil
+}
+
+func writeLanParameters(send rawSender, channel uint8, source api.BMCIPSource, ip, netmask, gateway net.IP, vlanID uint16, ipv6 []netip.Prefix) error {
+	if source == api.BMCIPSourceDHCP {
+		err := setLanParameter(send, channel, lanParameterIPAddressSource, ipAddressSourceDHCP)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := setLanParameter(send, channel, lanParameterIPAddressSource, ipAddressSourceStatic)
+		if err != nil {
+			return err
+		}
+		for _, p := range []struct {
+			parameter uint8
+			value     net.IP
+		}{{lanParameterIPAddress, ip}, {lanParameterSubnetMask, netmask}, {lanParameterDefaultGateway, gateway}} {
+			err = setLanParameter(send, channel, p.parameter, p.value...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	vlan := []uint8{uint8(vlanID), uint8(vlanID>>8) & 0x0F}
+	if vlanID != 0 {
+		vlan[1] |= vlanEnabled
+	}
+	err := setLanParameter(send, channel, lanParameterVLANID, vlan...)
+	if err != nil {
+		return err
+	}
+
+	return writeIPv6StaticAddresses(send, channel, ipv6)
+}
+
+// writeIPv6StaticAddresses replaces the static IPv6 addresses, unused slots are disabled
+func writeIPv6StaticAddresses(send rawSender, channel uint8, addresses []netip.Prefix) error {
+	status, err := readLanParameter(send, channel, lanParameterIPv6Status, 1)
+	if err != nil {
+		var cce *CompletionCodeError
+		if len(addresses) == 0 && errors.As(err, &cce) && cce.Code == completionCodeParameterNotSupported {
+			return nil
+		}
+		return err
+	}
+	slots := int(status[0])
+	if len(addresses) > slots {
+		return fmt.Errorf("bmc supports %d static ipv6 addresses, %d given", slots, len(addresses))
+	}
+	if len(addresses) > 0 {
+		err = setLanParameter(send, channel, lanParameterIPv6Enables, ipv6Enables4And6)
+		if err != nil {
+			return err
+		}
+	}
+	for selector := range slots {
+		data := make([]uint8, 19)
+		data[0] = uint8(selector)
+		if selector < len(addresses) {
+			addr := addresses[selector].Addr().As16()
+			data[1] = ipv6Enabled
+			copy(data[2:18], addr[:])
+			data[18] = uint8(addresses[selector].Bits())
+		}
+		err = setLanParameter(send, channel, lanParameterIPv6StaticAddresses, data...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func supermicroNICMode(nic api.BMCNIC) (uint8, bool) {
+	for mode, n := range supermicroNICs {
+		if n == nic {
+			return mode, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,171 @@
+package ipmi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// fakeLan stores the lan parameters of channel 1, ipv6Slots 0 emulates a BMC without IPv6 support
+type fakeLan struct {
+	parameters map[uint8][]byte
+	ipv6       map[uint8][]byte
+	ipv6Slots  uint8
+	nic        uint8
+	// committed records the set-in-progress values
+	committed []uint8
+}
+
+func newFakeLan(ipv6Slots uint8) *fakeLan {
+	return &fakeLan{
+		parameters: map[uint8][]byte{
+			lanParameterIPAddressSource: {ipAddressSourceDHCP},
+			lanParameterIPAddress:       {10, 0, 0, 42},
+			lanParameterMACAddress:      {0x3c, 0xec, 0xef, 0x01, 0x02, 0x03},
+			lanParameterSubnetMask:      {255, 255, 255, 0},
+			lanParameterDefaultGateway:  {10, 0, 0, 1},
+			lanParameterVLANID:          {0, 0},
+		},
+		ipv6:      map[uint8][]byte{},
+		ipv6Slots: ipv6Slots,
+	}
+}
+
+func (f *fakeLan) send(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+	switch {
+	case netFn == AppNetworkFunction && command == GetChannelInfo:
+		if data[0] != 1 {
+			return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: 0xCC}
+		}
+		return []byte{data[0], channelMediumLAN, 0x01, 0x80, 0xF2, 0x1B, 0x00, 0x00, 0x00}, nil
+	case netFn == TransportNetworkFunction && command == GetLANConfigurationParameters:
+		parameter := data[1]
+		switch {
+		case parameter == lanParameterIPv6Status && f.ipv6Slots > 0:
+			return []byte{0x11, f.ipv6Slots, 0x00, 0x03}, nil
+		case parameter == lanParameterIPv6StaticAddresses && f.ipv6Slots > 0:
+			address, ok := f.ipv6[data[2]]
+			if !ok {
+				address = make([]byte, 18)
+			}
+			return append([]byte{0x11, data[2]}, append(address, 0x00)...), nil
+		}
+		value, ok := f.parameters[parameter]
+		if !ok {
+			return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: completionCodeParameterNotSupported}
+		}
+		return append([]byte{0x11}, value...), nil
+	case netFn == TransportNetworkFunction && command == SetLANConfigurationParameters:
+		parameter := data[1]
+		switch {
+		case parameter == lanParameterSetInProgress:
+			f.committed = append(f.committed, data[2])
+		case parameter == lanParameterIPv6StaticAddresses && f.ipv6Slots > 0:
+			f.ipv6[data[2]] = append([]byte(nil), data[3:]...)
+		case parameter == lanParameterIPv6Enables && f.ipv6Slots > 0:
+		default:
+			if _, ok := f.parameters[parameter]; !ok {
+				return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: completionCodeParameterNotSupported}
+			}
+			f.parameters[parameter] = append([]byte(nil), data[2:]...)
+		}
+		return nil, nil
+	case netFn == supermicroNetworkFunction && command == supermicroLanInterface:
+		if data[1] == 0x01 {
+			f.nic = data[2]
+		}
+		return []byte{f.nic}, nil
+	}
+	return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: 0xC1}
+}
+
+func TestBMCLanConfig(t *testing.T) {
+	lan := newFakeLan(2)
+
+	config, err := readBMCLanConfig(lan.send, api.VendorSupermicro)
+	require.NoError(t, err)
+	require.Equal(t, &api.BMCLanConfig{
+		IPSource: api.BMCIPSourceDHCP,
+		IP:       "10.0.0.42",
+		Netmask:  "255.255.255.0",
+		Gateway:  "10.0.0.1",
+		MAC:      "3c:ec:ef:01:02:03",
+		NIC:      api.BMCNICDedicated,
+	}, config)
+
+	want := api.BMCLanConfig{
+		IPSource:      api.BMCIPSourceStatic,
+		IP:            "10.1.2.3",
+		Netmask:       "255.255.0.0",
+		Gateway:       "10.1.0.1",
+		VLANID:        0x123,
+		IPv6Addresses: []string{"2001:db8::10/64"},
+		NIC:           api.BMCNICFailover,
+	}
+	err = writeBMCLanConfig(lan.send, api.VendorSupermicro, want)
+	require.NoError(t, err)
+	require.Equal(t, []uint8{1, 2, 0}, lan.committed)
+	require.Equal(t, []byte{0x23, 0x81}, lan.parameters[lanParameterVLANID])
+
+	config, err = readBMCLanConfig(lan.send, api.VendorSupermicro)
+	require.NoError(t, err)
+	require.NoError(t, want.Verify(config))
+	require.Equal(t, "3c:ec:ef:01:02:03", config.MAC)
+
+	// back to dhcp, vlan and ipv6 addresses are removed
+	err = writeBMCLanConfig(lan.send, api.VendorSupermicro, api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP})
+	require.NoError(t, err)
+	config, err = readBMCLanConfig(lan.send, api.VendorSupermicro)
+	require.NoError(t, err)
+	require.Equal(t, api.BMCIPSourceDHCP, config.IPSource)
+	require.Zero(t, config.VLANID)
+	require.Empty(t, config.IPv6Addresses)
+	require.Equal(t, api.BMCNICFailover, config.NIC)
+}
+
+func TestBMCLanConfigWithoutIPv6(t *testing.T) {
+	lan := newFakeLan(0)
+
+	config, err := readBMCLanConfig(lan.send, api.VendorLenovo)
+	require.NoError(t, err)
+	require.Empty(t, config.IPv6Addresses)
+	require.Empty(t, config.NIC)
+
+	err = writeBMCLanConfig(lan.send, api.VendorLenovo, api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, VLANID: 10})
+	require.NoError(t, err)
+	require.Equal(t, []byte{10, 0x80}, lan.parameters[lanParameterVLANID])
+
+	err = writeBMCLanConfig(lan.send, api.VendorLenovo, api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, IPv6Addresses: []string{"2001:db8::10/64"}})
+	require.Error(t, err)
+}
+
+func TestWriteBMCLanConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		vendor  api.Vendor
+		config  api.BMCLanConfig
+		wantErr string
+	}{
+		{name: "no source", config: api.BMCLanConfig{}, wantErr: `unknown ip source ""`},
+		{name: "invalid ip", config: api.BMCLanConfig{IPSource: api.BMCIPSourceStatic, IP: "10.0.0", Netmask: "255.0.0.0", Gateway: "10.0.0.1"}, wantErr: `invalid ip "10.0.0"`},
+		{name: "ipv6 as ip", config: api.BMCLanConfig{IPSource: api.BMCIPSourceStatic, IP: "2001:db8::1", Netmask: "255.0.0.0", Gateway: "10.0.0.1"}, wantErr: `invalid ip "2001:db8::1"`},
+		{name: "missing gateway", config: api.BMCLanConfig{IPSource: api.BMCIPSourceStatic, IP: "10.0.0.2", Netmask: "255.0.0.0"}, wantErr: `invalid gateway ""`},
+		{name: "vlan", config: api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, VLANID: 4095}, wantErr: "invalid vlan id 4095"},
+		{name: "ipv6", config: api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, IPv6Addresses: []string{"2001:db8::10"}}, wantErr: `invalid ipv6 address "2001:db8::10"`},
+		{name: "unknown nic", vendor: api.VendorSupermicro, config: api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, NIC: "lom2"}, wantErr: `unknown nic "lom2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lan := newFakeLan(2)
+			err := writeBMCLanConfig(lan.send, tt.vendor, tt.config)
+			require.EqualError(t, err, tt.wantErr)
+			require.Empty(t, lan.committed, "nothing must be written")
+		})
+	}
+
+	err := writeBMCLanConfig(newFakeLan(2).send, api.VendorDell, api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, NIC: api.BMCNICShared})
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+}
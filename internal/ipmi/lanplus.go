@@ -0,0 +1,403 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-second-gen-interface-spec-v2-rev1-1.pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// 13.15 IPMI v2.0/RMCP+ Session Activation, 13.17 - 13.23 Open Session and RAKP Messages
+
+const (
+	// lanplusTimeout is how long to wait for a response before the request is sent again
+	lanplusTimeout      = 2 * time.Second
+	lanplusRetries      = 3
+	lanplusMaxPacketLen = 1024
+
+	maxUsernameLen = 16
+	maxPasswordLen = 20
+	// nameOnlyLookup lets the BMC look up the user by name only, not by name and privilege
+	nameOnlyLookup = 0x10
+)
+
+// 13.24 RMCP+ and RAKP Message Status Codes
+var rmcpPlusStatusCodes = map[uint8]string{
+	0x01: "insufficient resources to create a session",
+	0x02: "invalid session id",
+	0x03: "invalid payload type",
+	0x04: "invalid authentication algorithm",
+	0x05: "invalid integrity algorithm",
+	0x06: "no matching authentication payload",
+	0x07: "no matching integrity payload",
+	0x08: "inactive session id",
+	0x09: "invalid role",
+	0x0A: "unauthorized role or privilege level requested",
+	0x0B: "insufficient resources to create a session at the requested role",
+	0x0C: "invalid name length",
+	0x0D: "unauthorized name",
+	0x0E: "unauthorized GUID",
+	0x0F: "invalid integrity check value",
+	0x10: "invalid confidentiality algorithm",
+	0x11: "no cipher suite match with proposed security algorithms",
+	0x12: "illegal or unrecognized parameter",
+}
+
+// rmcpPlusStatusError is returned if the BMC rejects the session establishment
+type rmcpPlusStatusError struct {
+	status uint8
+}
+
+func (e *rmcpPlusStatusError) Error() string {
+	description, ok := rmcpPlusStatusCodes[e.status]
+	if !ok {
+		description = "unknown status code"
+	}
+	return fmt.Sprintf("session establishment failed with status 0x%02X: %s", e.status, description)
+}
+
+// algorithmRejected is true if another cipher suite might be accepted
+func (e *rmcpPlusStatusError) algorithmRejected() bool {
+	switch e.status {
+	case 0x04, 0x05, 0x06, 0x07, 0x10, 0x11:
+		return true
+	}
+	return false
+}
+
+// lanSession is an authenticated and encrypted IPMI v2.0 session, also known as lanplus.
+// It is not safe for concurrent use.
+type lanSession struct {
+	conn    net.Conn
+	timeout time.Duration
+	retries int
+
+	suite cipherSuite
+	// consoleID and bmcID are the session ids the remote console and the BMC chose,
+	// each side puts the id of the other one into the packets it sends
+	consoleID uint32
+	bmcID     uint32
+	seq       uint32
+	rqSeq     uint8
+	tag       uint8
+	k1, k2    []byte
+}
+
+// openLanSession establishes a session with the first of the cipher suites the BMC accepts
+func openLanSession(ctx context.Context, addr, user, password string, suites ...cipherSuite) (*lanSession, error) {
+	if len(user) > maxUsernameLen {
+		return nil, fmt.Errorf("username longer than %d bytes", maxUsernameLen)
+	}
+	if len(password) > maxPasswordLen {
+		return nil, fmt.Errorf("password longer than %d bytes", maxPasswordLen)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &lanSession{
+		conn:    conn,
+		timeout: lanplusTimeout,
+		retries: lanplusRetries,
+	}
+
+	err = s.channelAuthenticationCapabilities(ctx)
+	if err == nil {
+		for _, suite := range suites {
+			err = s.activate(ctx, suite, user, password)
+			var se *rmcpPlusStatusError
+			if err == nil || !errors.As(err, &se) || !se.algorithmRejected() {
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to open lanplus session to %s %w", addr, err)
+	}
+	return s, nil
+}
+
+// channelAuthenticationCapabilities checks that the BMC speaks IPMI v2.0, it is sent outside of a session
+func (s *lanSession) channelAuthenticationCapabilities(ctx context.Context) error {
+	rqSeq := s.nextRqSeq()
+	msg := lanMessage{to: bmcSlaveAddress, netFn: AppNetworkFunction, from: remoteConsoleAddress, seq: rqSeq, cmd: GetChannelAuthenticationCapabilities,
+		// request the IPMI v2.0 extended data for the current channel
+		data: []byte{0x8E, api.AdministratorPrivilege},
+	}
+	var resp []byte
+	err := s.exchange(ctx, func() ([]byte, error) {
+		return marshalV15(msg.marshal()), nil
+	}, func(b []byte) (bool, error) {
+		if len(b) < rmcpHeaderLen+v15SessionHeaderLen || !bytes.Equal(b[:rmcpHeaderLen], rmcpHeader) || b[rmcpHeaderLen] != authTypeNone {
+			return false, nil
+		}
+		m, err := unmarshalLanMessage(b[rmcpHeaderLen+v15SessionHeaderLen:])
+		if err != nil || m.seq != rqSeq || m.cmd != msg.cmd {
+			return false, nil
+		}
+		resp = bytes.Clone(m.data)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get channel authentication capabilities %w", err)
+	}
+	if len(resp) > 0 && resp[0] != 0 {
+		return &CompletionCodeError{NetFn: msg.netFn, Command: msg.cmd, Code: resp[0]}
+	}
+	if len(resp) < 9 || resp[2]&0x80 == 0 || resp[4]&0x02 == 0 {
+		return errors.New("bmc does not support ipmi v2.0")
+	}
+	return nil
+}
+
+// activate runs the open session request and the RAKP messages and raises the session privilege to administrator
+func (s *lanSession) activate(ctx context.Context, suite cipherSuite, user, password string) error {
+	consoleID, err := randomSessionID()
+	if err != nil {
+		return err
+	}
+	req := []byte{s.nextTag(), api.AdministratorPrivilege, 0, 0}
+	req = binary.LittleEndian.AppendUint32(req, consoleID)
+	req = append(req,
+		0x00, 0, 0, 0x08, suite.authentication, 0, 0, 0,
+		0x01, 0, 0, 0x08, suite.integrity, 0, 0, 0,
+		0x02, 0, 0, 0x08, suite.confidentiality, 0, 0, 0,
+	)
+	resp, err := s.handshake(ctx, payloadTypeOpenSessionRequest, req, payloadTypeOpenSessionResponse, 36)
+	if err != nil {
+		return fmt.Errorf("open session with cipher suite %d failed %w", suite.id, err)
+	}
+	if binary.LittleEndian.Uint32(resp[4:8]) != consoleID {
+		return errors.New("open session response for another session")
+	}
+	bmcID := binary.LittleEndian.Uint32(resp[8:12])
+	if resp[16] != suite.authentication || resp[24] != suite.integrity || resp[32] != suite.confidentiality {
+		return fmt.Errorf("bmc did not accept the algorithms of cipher suite %d", suite.id)
+	}
+
+	sidm := binary.LittleEndian.AppendUint32(nil, consoleID)
+	sidc := binary.LittleEndian.AppendUint32(nil, bmcID)
+	rm := make([]byte, 16)
+	if _, err := rand.Read(rm); err != nil {
+		return err
+	}
+	role := []byte{api.AdministratorPrivilege | nameOnlyLookup, uint8(len(user))}
+	kuid := []byte(password)
+
+	rakp1 := []byte{s.nextTag(), 0, 0, 0}
+	rakp1 = append(rakp1, sidc...)
+	rakp1 = append(rakp1, rm...)
+	rakp1 = append(rakp1, role[0], 0, 0, role[1])
+	rakp1 = append(rakp1, user...)
+	authLen := suite.hash().Size()
+	rakp2, err := s.handshake(ctx, payloadTypeRAKP1, rakp1, payloadTypeRAKP2, 40+authLen)
+	if err != nil {
+		return fmt.Errorf("rakp 1 failed %w", err)
+	}
+	rc, guid := rakp2[8:24], rakp2[24:40]
+	if !hmac.Equal(rakp2[40:40+authLen], suite.hmac(kuid, sidm, sidc, rm, rc, guid, role, []byte(user))) {
+		return fmt.Errorf("rakp 2 key exchange authentication code mismatch, the password of user %s is wrong", user)
+	}
+
+	// the BMC key is not supported, the session integrity key is therefore derived from the password
+	sik := suite.hmac(kuid, rm, rc, role, []byte(user))
+	rakp3 := []byte{s.nextTag(), 0, 0, 0}
+	rakp3 = append(rakp3, sidc...)
+	rakp3 = append(rakp3, suite.hmac(kuid, rc, sidm, role, []byte(user))...)
+	rakp4, err := s.handshake(ctx, payloadTypeRAKP3, rakp3, payloadTypeRAKP4, 8+suite.integrityLen)
+	if err != nil {
+		return fmt.Errorf("rakp 3 failed %w", err)
+	}
+	if !hmac.Equal(rakp4[8:8+suite.integrityLen], suite.hmac(sik, rm, sidc, guid)[:suite.integrityLen]) {
+		return errors.New("rakp 4 integrity check value mismatch")
+	}
+
+	s.suite = suite
+	s.consoleID = consoleID
+	s.bmcID = bmcID
+	s.seq = 0
+	s.k1 = suite.hmac(sik, bytes.Repeat([]byte{0x01}, 20))
+	s.k2 = suite.hmac(sik, bytes.Repeat([]byte{0x02}, 20))
+
+	_, err = s.send(ctx, AppNetworkFunction, SetSessionPrivilegeLevel, api.AdministratorPrivilege)
+	if err != nil {
+		return fmt.Errorf("unable to set session privilege level %w", err)
+	}
+	return nil
+}
+
+// handshake sends one of the session establishment messages and returns the response to it
+func (s *lanSession) handshake(ctx context.Context, reqType uint8, req []byte, respType uint8, minLen int) ([]byte, error) {
+	var resp []byte
+	err := s.exchange(ctx, func() ([]byte, error) {
+		return rmcpPlusPacket{payloadType: reqType, payload: req}.marshal(cipherSuite{}, nil), nil
+	}, func(b []byte) (bool, error) {
+		p, err := unmarshalRMCPPlus(b, cipherSuite{}, nil)
+		if err != nil || p.payloadType != respType || len(p.payload) < 2 || p.payload[0] != req[0] {
+			return false, nil
+		}
+		if status := p.payload[1]; status != 0 {
+			return false, &rmcpPlusStatusError{status: status}
+		}
+		if len(p.payload) < minLen {
+			return false, fmt.Errorf("payload type 0x%02x too short, %d bytes", respType, len(p.payload))
+		}
+		resp = bytes.Clone(p.payload)
+		return true, nil
+	})
+	return resp, err
+}
+
+// send issues the command within the session and returns the response data without the completion code
+func (s *lanSession) send(ctx context.Context, netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+	rqSeq := s.nextRqSeq()
+	msg := lanMessage{to: bmcSlaveAddress, netFn: netFn, from: remoteConsoleAddress, seq: rqSeq, cmd: command, data: data}.marshal()
+	var resp []byte
+	err := s.exchange(ctx, func() ([]byte, error) {
+		payload, err := encryptAESCBC128(s.k2, msg)
+		if err != nil {
+			return nil, err
+		}
+		s.seq++
+		return rmcpPlusPacket{
+			payloadType: payloadTypeIPMI | payloadEncrypted | payloadAuthenticated,
+			sessionID:   s.bmcID,
+			seq:         s.seq,
+			payload:     payload,
+		}.marshal(s.suite, s.k1), nil
+	}, func(b []byte) (bool, error) {
+		// packets which are not for this session or fail the integrity check are silently discarded
+		p, err := unmarshalRMCPPlus(b, s.suite, s.k1)
+		if err != nil || p.sessionID != s.consoleID || p.payloadType&payloadAuthenticated == 0 || p.payloadType&0x3F != payloadTypeIPMI {
+			return false, nil
+		}
+		plain := p.payload
+		if p.payloadType&payloadEncrypted != 0 {
+			plain, err = decryptAESCBC128(s.k2, p.payload)
+			if err != nil {
+				return false, nil
+			}
+		}
+		m, err := unmarshalLanMessage(plain)
+		if err != nil || m.seq != rqSeq || m.cmd != command || m.netFn != netFn|1 {
+			return false, nil
+		}
+		resp = bytes.Clone(m.data)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to send command %02X %02X %w", netFn, command, err)
+	}
+	if len(resp) < 1 {
+		return nil, fmt.Errorf("empty response to command %02X %02X", netFn, command)
+	}
+	if resp[0] != 0 {
+		return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: resp[0]}
+	}
+	return resp[1:], nil
+}
+
+// close closes the session on the BMC and the connection
+func (s *lanSession) close(ctx context.Context) error {
+	defer func() {
+		_ = s.conn.Close()
+	}()
+	_, err := s.send(ctx, AppNetworkFunction, CloseSession, binary.LittleEndian.AppendUint32(nil, s.bmcID)...)
+	return err
+}
+
+// exchange sends the packet built by build until a received packet is accepted by match.
+// build is called for every attempt because retries must not reuse session sequence numbers.
+// match must copy what it keeps of the packet.
+func (s *lanSession) exchange(ctx context.Context, build func() ([]byte, error), match func(b []byte) (bool, error)) error {
+	buf := make([]byte, lanplusMaxPacketLen)
+	// a canceled context interrupts the pending read instead of waiting for the read deadline
+	stop := context.AfterFunc(ctx, func() {
+		_ = s.conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+	for range s.retries {
+		packet, err := build()
+		if err != nil {
+			return err
+		}
+		if _, err := s.conn.Write(packet); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(s.timeout)
+		d, capped := ctx.Deadline()
+		if capped = capped && d.Before(deadline); capped {
+			deadline = d
+		}
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+		// the context might have been canceled before the read deadline was set
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for {
+			n, err := s.conn.Read(buf)
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			ok, err := match(buf[:n])
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+		if capped {
+			// the read deadline might fire marginally before the context is done
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(d)):
+				return context.DeadlineExceeded
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("no response after %d attempts", s.retries)
+}
+
+func (s *lanSession) nextRqSeq() uint8 {
+	s.rqSeq = (s.rqSeq + 1) & 0x3F
+	return s.rqSeq
+}
+
+func (s *lanSession) nextTag() uint8 {
+	s.tag++
+	return s.tag
+}
+
+// randomSessionID returns a session id, 0 is reserved for messages outside of a session
+func randomSessionID() (uint32, error) {
+	b := make([]byte, 4)
+	for {
+		if _, err := rand.Read(b); err != nil {
+			return 0, err
+		}
+		if id := binary.LittleEndian.Uint32(b); id != 0 {
+			return id, nil
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package ipmi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+// bmcSimulator answers lanplus sessions on a local UDP port, the IPMI commands within a session are passed to handle
+type bmcSimulator struct {
+	conn     *net.UDPConn
+	user     string
+	password string
+	suites   []cipherSuite
+	handle   func(netFn NetworkFunction, command uint8, data []byte) []byte
+
+	mu sync.Mutex
+	// drop is the number of IPMI requests within the session which are not answered
+	drop      int
+	suite     cipherSuite
+	consoleID uint32
+	bmcID     uint32
+	seq       uint32
+	seqs      []uint32
+	rm, rc    []byte
+	guid      []byte
+	role      []byte
+	k1, k2    []byte
+	privilege uint8
+	closed    bool
+}
+
+func newBMCSimulator(t *testing.T, user, password string, suites ...cipherSuite) *bmcSimulator {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	s := &bmcSimulator{
+		conn:     conn,
+		user:     user,
+		password: password,
+		suites:   suites,
+		bmcID:    0x0A0B0C0D,
+		rc:       bytes.Repeat([]byte{0x5C}, 16),
+		guid:     bytes.Repeat([]byte{0x6D}, 16),
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	go s.serve()
+	return s
+}
+
+func (s *bmcSimulator) port() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (s *bmcSimulator) serve() {
+	buf := make([]byte, lanplusMaxPacketLen)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		resp := s.reply(buf[:n])
+		s.mu.Unlock()
+		for _, r := range resp {
+			_, _ = s.conn.WriteToUDP(r, addr)
+		}
+	}
+}
+
+func (s *bmcSimulator) reply(b []byte) [][]byte {
+	if len(b) < rmcpHeaderLen+2 || !bytes.Equal(b[:rmcpHeaderLen], rmcpHeader) {
+		return nil
+	}
+	if b[rmcpHeaderLen] == authTypeNone {
+		m, err := unmarshalLanMessage(b[rmcpHeaderLen+v15SessionHeaderLen:])
+		if err != nil || m.cmd != GetChannelAuthenticationCapabilities {
+			return nil
+		}
+		// channel 1, IPMI v2.0 extended capabilities, RMCP+ supported
+		data := []byte{0x00, 0x01, 0x80, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00}
+		resp := lanMessage{to: remoteConsoleAddress, netFn: m.netFn | 1, from: bmcSlaveAddress, seq: m.seq, cmd: m.cmd, data: data}
+		return [][]byte{marshalV15(resp.marshal())}
+	}
+
+	switch b[rmcpHeaderLen+1] {
+	case payloadTypeOpenSessionRequest:
+		p, _ := unmarshalRMCPPlus(b, cipherSuite{}, nil)
+		return s.openSession(p.payload)
+	case payloadTypeRAKP1:
+		p, _ := unmarshalRMCPPlus(b, cipherSuite{}, nil)
+		return s.rakp1(p.payload)
+	case payloadTypeRAKP3:
+		p, _ := unmarshalRMCPPlus(b, cipherSuite{}, nil)
+		return s.rakp3(p.payload)
+	case payloadTypeIPMI | payloadEncrypted | payloadAuthenticated:
+		return s.message(b)
+	}
+	return nil
+}
+
+func (s *bmcSimulator) handshake(payloadType uint8, payload []byte) [][]byte {
+	return [][]byte{rmcpPlusPacket{payloadType: payloadType, payload: payload}.marshal(cipherSuite{}, nil)}
+}
+
+func (s *bmcSimulator) openSession(req []byte) [][]byte {
+	resp := []byte{req[0], 0x00, api.AdministratorPrivilege, 0}
+	resp = append(resp, req[4:8]...)
+	for _, suite := range s.suites {
+		if req[12] == suite.authentication && req[20] == suite.integrity && req[28] == suite.confidentiality {
+			s.suite = suite
+			s.consoleID = binary.LittleEndian.Uint32(req[4:8])
+			resp = binary.LittleEndian.AppendUint32(resp, s.bmcID)
+			resp = append(resp, req[8:32]...)
+			return s.handshake(payloadTypeOpenSessionResponse, resp)
+		}
+	}
+	resp[1] = 0x11
+	return s.handshake(payloadTypeOpenSessionResponse, resp)
+}
+
+func (s *bmcSimulator) rakp1(req []byte) [][]byte {
+	resp := []byte{req[0], 0x00, 0, 0}
+	resp = binary.LittleEndian.AppendUint32(resp, s.consoleID)
+	user := string(req[28:])
+	if user != s.user {
+		resp[1] = 0x0D
+		return s.handshake(payloadTypeRAKP2, resp)
+	}
+	s.rm = bytes.Clone(req[8:24])
+	s.role = []byte{req[24], req[27]}
+	sidm := binary.LittleEndian.AppendUint32(nil, s.consoleID)
+	sidc := binary.LittleEndian.AppendUint32(nil, s.bmcID)
+	resp = append(resp, s.rc...)
+	resp = append(resp, s.guid...)
+	resp = append(resp, s.suite.hmac([]byte(s.password), sidm, sidc, s.rm, s.rc, s.guid, s.role, []byte(user))...)
+	return s.handshake(payloadTypeRAKP2, resp)
+}
+
+func (s *bmcSimulator) rakp3(req []byte) [][]byte {
+	resp := []byte{req[0], 0x00, 0, 0}
+	resp = binary.LittleEndian.AppendUint32(resp, s.consoleID)
+	kuid := []byte(s.password)
+	sidm := binary.LittleEndian.AppendUint32(nil, s.consoleID)
+	sidc := binary.LittleEndian.AppendUint32(nil, s.bmcID)
+	if !bytes.Equal(req[8:], s.suite.hmac(kuid, s.rc, sidm, s.role, []byte(s.user))) {
+		resp[1] = 0x0F
+		return s.handshake(payloadTypeRAKP4, resp)
+	}
+	sik := s.suite.hmac(kuid, s.rm, s.rc, s.role, []byte(s.user))
+	s.k1 = s.suite.hmac(sik, bytes.Repeat([]byte{0x01}, 20))
+	s.k2 = s.suite.hmac(sik, bytes.Repeat([]byte{0x02}, 20))
+	resp = append(resp, s.suite.hmac(sik, s.rm, sidc, s.guid)[:s.suite.integrityLen]...)
+	return s.handshake(payloadTypeRAKP4, resp)
+}
+
+func (s *bmcSimulator) message(b []byte) [][]byte {
+	p, err := unmarshalRMCPPlus(b, s.suite, s.k1)
+	if err != nil || p.sessionID != s.bmcID {
+		return nil
+	}
+	plain, err := decryptAESCBC128(s.k2, p.payload)
+	if err != nil {
+		return nil
+	}
+	m, err := unmarshalLanMessage(plain)
+	if err != nil {
+		return nil
+	}
+	s.seqs = append(s.seqs, p.seq)
+	if s.drop > 0 {
+		s.drop--
+		return nil
+	}
+
+	var data []byte
+	switch {
+	case m.netFn == AppNetworkFunction && m.cmd == SetSessionPrivilegeLevel:
+		s.privilege = m.data[0]
+		data = []byte{0x00, m.data[0]}
+	case m.netFn == AppNetworkFunction && m.cmd == CloseSession:
+		s.closed = binary.LittleEndian.Uint32(m.data) == s.bmcID
+		data = []byte{0x00}
+	default:
+		data = s.handle(m.netFn, m.cmd, m.data)
+	}
+	resp := lanMessage{to: remoteConsoleAddress, netFn: m.netFn | 1, from: bmcSlaveAddress, seq: m.seq, cmd: m.cmd, data: data}
+	payload, err := encryptAESCBC128(s.k2, resp.marshal())
+	if err != nil {
+		return nil
+	}
+	s.seq++
+	packet := rmcpPlusPacket{
+		payloadType: payloadTypeIPMI | payloadEncrypted | payloadAuthenticated,
+		sessionID:   s.consoleID,
+		seq:         s.seq,
+		payload:     payload,
+	}.marshal(s.suite, s.k1)
+
+	// a packet with a broken integrity check value precedes the response and must be discarded
+	broken := bytes.Clone(packet)
+	broken[len(broken)-1] ^= 0xFF
+	return [][]byte{broken, packet}
+}
+
+func TestClient(t *testing.T) {
+	bmc := &fakeBMC{
+		// an oem record without data, the builtin FRU is read without a locator
+		records: [][]byte{{0x00, 0x00, 0x51, 0xC0, 0x00}},
+		frus: map[uint8][]byte{
+			0: fruImage(nil, fruInfoArea([]byte{0x19, 0, 0, 0}, ascii("Supermicro"), ascii("X12DPU-6"), ascii("OM21AS001234"), ascii("X12DPU-6")), nil, nil),
+		},
+	}
+	var requests []fakeRequest
+	sim := newBMCSimulator(t, "metal", "secret", cipherSuite3, cipherSuite17)
+	sim.handle = func(netFn NetworkFunction, command uint8, data []byte) []byte {
+		requests = append(requests, fakeRequest{netFn: netFn, command: command, data: bytes.Clone(data)})
+		if netFn == ChassisNetworkFunction {
+			return []byte{0x00}
+		}
+		resp, err := bmc.send(netFn, command, data...)
+		if err != nil {
+			return []byte{0xC1}
+		}
+		return append([]byte{0x00}, resp...)
+	}
+	ctx := context.Background()
+
+	client, err := OpenClientConnection(ctx, "127.0.0.1", sim.port(), "metal", "secret")
+	require.NoError(t, err)
+	sim.mu.Lock()
+	require.Equal(t, uint8(17), sim.suite.id)
+	require.Equal(t, uint8(api.AdministratorPrivilege), sim.privilege)
+	sim.mu.Unlock()
+
+	err = client.Control(ctx, ChassisControlPowerCycle)
+	require.NoError(t, err)
+	sim.mu.Lock()
+	require.Equal(t, fakeRequest{netFn: ChassisNetworkFunction, command: ChassisControl, data: []byte{ChassisControlPowerCycle}}, requests[0])
+	sim.mu.Unlock()
+
+	frus, err := client.FRUs(ctx)
+	require.NoError(t, err)
+	require.Len(t, frus, 1)
+	require.Equal(t, "OM21AS001234", frus[0].Board.SerialNumber)
+
+	_, err = client.Run(ctx, "raw", "0x08", "0x01")
+	var cce *CompletionCodeError
+	require.ErrorAs(t, err, &cce)
+	require.Equal(t, uint8(0xC1), cce.Code)
+
+	// the first attempt is not answered, the retry uses the next session sequence number
+	client.session.timeout = 100 * time.Millisecond
+	sim.mu.Lock()
+	sim.drop = 1
+	sim.mu.Unlock()
+	err = client.SetChassisIdentifyLEDOn(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Close())
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	require.True(t, sim.closed)
+	for i := range sim.seqs {
+		require.Equal(t, uint32(i+1), sim.seqs[i])
+	}
+}
+
+func TestClientCipherSuiteFallback(t *testing.T) {
+	sim := newBMCSimulator(t, "metal", "secret", cipherSuite3)
+	client, err := OpenClientConnection(context.Background(), "127.0.0.1", sim.port(), "metal", "secret")
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Close()
+	}()
+	require.Equal(t, uint8(3), client.session.suite.id)
+}
+
+func TestClientWrongCredentials(t *testing.T) {
+	sim := newBMCSimulator(t, "metal", "secret", cipherSuite17)
+	ctx := context.Background()
+
+	_, err := OpenClientConnection(ctx, "127.0.0.1", sim.port(), "metal", "wrong")
+	require.ErrorContains(t, err, "the password of user metal is wrong")
+
+	_, err = OpenClientConnection(ctx, "127.0.0.1", sim.port(), "root", "secret")
+	var se *rmcpPlusStatusError
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, uint8(0x0D), se.status)
+}
+
+func TestClientTimeout(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = OpenClientConnection(ctx, "127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port, "metal", "secret")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClientCanceled(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = OpenClientConnection(ctx, "127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port, "metal", "secret")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(start), lanplusTimeout, "cancellation must not wait for the read deadline")
+}
+
+func TestRMCPPlusPacket(t *testing.T) {
+	k1 := bytes.Repeat([]byte{0x11}, 32)
+	k2 := bytes.Repeat([]byte{0x22}, 32)
+	msg := lanMessage{to: bmcSlaveAddress, netFn: ChassisNetworkFunction, from: remoteConsoleAddress, seq: 5, cmd: ChassisControl, data: []byte{ChassisControlPowerUp}}
+
+	for _, suite := range []cipherSuite{cipherSuite3, cipherSuite17} {
+		payload, err := encryptAESCBC128(k2, msg.marshal())
+		require.NoError(t, err)
+		require.Zero(t, len(payload)%16)
+
+		b := rmcpPlusPacket{payloadType: payloadTypeIPMI | payloadEncrypted | payloadAuthenticated, sessionID: 0x01020304, seq: 7, payload: payload}.marshal(suite, k1)
+		// the integrity pad aligns the session header, payload, pad length and next header
+		require.Zero(t, (len(b)-rmcpHeaderLen-suite.integrityLen)%4)
+
+		p, err := unmarshalRMCPPlus(b, suite, k1)
+		require.NoError(t, err)
+		require.Equal(t, uint32(0x01020304), p.sessionID)
+		require.Equal(t, uint32(7), p.seq)
+
+		plain, err := decryptAESCBC128(k2, p.payload)
+		require.NoError(t, err)
+		m, err := unmarshalLanMessage(plain)
+		require.NoError(t, err)
+		require.Equal(t, msg, m)
+
+		b[rmcpHeaderLen+sessionHeaderLen] ^= 0x01
+		_, err = unmarshalRMCPPlus(b, suite, k1)
+		require.EqualError(t, err, "invalid rmcp+ integrity check value")
+	}
+
+	b := msg.marshal()
+	b[len(b)-1]++
+	_, err := unmarshalLanMessage(b)
+	require.EqualError(t, err, "invalid ipmi message checksum")
+}
@@ -0,0 +1,520 @@
+package ipmi
+
+// https://github.com/torvalds/linux/blob/master/include/uapi/linux/ipmi.h
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/sys/unix"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+const (
+	// OpenIPMIDevice is the device of the first BMC created by the ipmi_devintf kernel module
+	OpenIPMIDevice = "/dev/ipmi0"
+
+	ipmiSystemInterfaceAddrType = 0x0c
+	ipmiBMCChannel              = 0x0f
+	ipmiResponseRecvType        = 1
+	// ipmiMaxMsgLength is the largest message the driver delivers
+	ipmiMaxMsgLength = 272
+
+	// openIPMITimeout is used if the context has no earlier deadline, KCS interfaces can be really slow
+	openIPMITimeout = 30 * time.Second
+	// openIPMIPollInterval is how often the context is checked while waiting for a response
+	openIPMIPollInterval = 100 * time.Millisecond
+
+	completionCodePasswordMismatch = 0x80
+	completionCodePasswordSize     = 0x81
+)
+
+// ioctl numbers as built by the _IOR and _IOWR macros of asm-generic/ioctl.h
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+var (
+	ipmictlReceiveMsgTrunc = ioc(iocRead|iocWrite, 11, unsafe.Sizeof(ipmiRecv{}))
+	ipmictlSendCommand     = ioc(iocRead, 13, unsafe.Sizeof(ipmiReq{}))
+)
+
+func ioc(dir uintptr, nr uintptr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'i'<<8 | nr
+}
+
+// the following structs mirror the layout of their C counterparts
+
+type ipmiSystemInterfaceAddr struct {
+	addrType int32
+	channel  int16
+	lun      uint8
+}
+
+type ipmiMsg struct {
+	netFn   uint8
+	cmd     uint8
+	dataLen uint16
+	data    *byte
+}
+
+type ipmiReq struct {
+	addr    *byte
+	addrLen uint32
+	msgID   int
+	msg     ipmiMsg
+}
+
+type ipmiRecv struct {
+	recvType int32
+	addr     *byte
+	addrLen  uint32
+	msgID    int
+	msg      ipmiMsg
+}
+
+// device is an opened OpenIPMI character device
+type device interface {
+	ioctl(request uintptr, arg unsafe.Pointer) error
+	// wait returns true once a message can be received, false if none arrived within timeout
+	wait(timeout time.Duration) (bool, error)
+	Close() error
+}
+
+type fileDevice struct {
+	f *os.File
+}
+
+func openFileDevice(path string) (device, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &fileDevice{f: f}, nil
+}
+
+func (d *fileDevice) ioctl(request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (d *fileDevice) wait(timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(d.f.Fd()), Events: unix.POLLIN}} // nolint:gosec
+	for {
+		n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return n > 0, nil
+	}
+}
+
+func (d *fileDevice) Close() error {
+	return d.f.Close()
+}
+
+// CompletionCodeError is returned if the BMC completes a command with another completion code than success
+type CompletionCodeError struct {
+	NetFn   NetworkFunction
+	Command uint8
+	Code    uint8
+}
+
+func (e *CompletionCodeError) Error() string {
+	description, ok := completionCodes[e.Code]
+	if !ok {
+		description = "unknown completion code"
+	}
+	return fmt.Sprintf("command %02X %02X failed with completion code 0x%02X: %s", e.NetFn, e.Command, e.Code, description)
+}
+
+// 5.2 Completion Codes
+var completionCodes = map[uint8]string{
+	0xC0: "node busy",
+	0xC1: "invalid command",
+	0xC2: "command invalid for given LUN",
+	0xC3: "timeout while processing command",
+	0xC4: "out of space",
+	0xC5: "reservation canceled or invalid reservation id",
+	0xC6: "request data truncated",
+	0xC7: "request data length invalid",
+	0xC8: "request data field length limit exceeded",
+	0xC9: "parameter out of range",
+	0xCA: "cannot return number of requested data bytes",
+	0xCB: "requested sensor, data, or record not present",
+	0xCC: "invalid data field in request",
+	0xCD: "command illegal for specified sensor or record type",
+	0xCE: "command response could not be provided",
+	0xCF: "cannot execute duplicated request",
+	0xD0: "SDR repository in update mode",
+	0xD1: "device in firmware update mode",
+	0xD2: "BMC initialization in progress",
+	0xD3: "destination unavailable",
+	0xD4: "insufficient privilege level",
+	0xD5: "command not supported in present state",
+	0xD6: "command sub-function has been disabled or is unavailable",
+	0xFF: "unspecified error",
+}
+
+// OpenIPMI talks to the BMC through the ioctls of the OpenIPMI kernel driver, ipmitool is not required.
+// Every command opens the device on its own, commands can therefore be issued concurrently.
+type OpenIPMI struct {
+	path  string
+	open  func(path string) (device, error)
+	msgID atomic.Int64
+	log   logger.Logger
+}
+
+// NewOpenIPMI creates a new IpmiTool which uses the OpenIPMI device of the host
+func NewOpenIPMI(log logger.Logger) *OpenIPMI {
+	return &OpenIPMI{
+		path: OpenIPMIDevice,
+		open: openFileDevice,
+		log:  log,
+	}
+}
+
+// NewInBand creates a new IpmiTool for the BMC of the host, it uses the OpenIPMI device if present
+// and falls back to the ipmitool binary on hosts without the OpenIPMI driver
+func NewInBand(log logger.Logger) (IpmiTool, error) {
+	_, err := os.Stat(OpenIPMIDevice)
+	if err == nil {
+		return NewOpenIPMI(log), nil
+	}
+	log.Infow("openipmi device not available, falling back to ipmitool", "device", OpenIPMIDevice, "error", err)
+	return New(log)
+}
+
+// send issues the command and returns the response data without the completion code
+func (o *OpenIPMI) send(ctx context.Context, netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+	dev, err := o.open(o.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ipmi device %s %w", o.path, err)
+	}
+	defer func() {
+		_ = dev.Close()
+	}()
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+
+	addr := &ipmiSystemInterfaceAddr{addrType: ipmiSystemInterfaceAddrType, channel: ipmiBMCChannel}
+	pinner.Pin(addr)
+	msgID := int(o.msgID.Add(1))
+	req := &ipmiReq{
+		addr:    (*byte)(unsafe.Pointer(addr)),
+		addrLen: uint32(unsafe.Sizeof(*addr)),
+		msgID:   msgID,
+		msg:     ipmiMsg{netFn: netFn, cmd: command, dataLen: uint16(len(data))}, // nolint:gosec
+	}
+	if len(data) > 0 {
+		pinner.Pin(&data[0])
+		req.msg.data = &data[0]
+	}
+	err = dev.ioctl(ipmictlSendCommand, unsafe.Pointer(req))
+	if err != nil {
+		return nil, fmt.Errorf("unable to send command %02X %02X %w", netFn, command, err)
+	}
+
+	deadline := time.Now().Add(openIPMITimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	buf := make([]byte, ipmiMaxMsgLength)
+	pinner.Pin(&buf[0])
+	recvAddr := &ipmiSystemInterfaceAddr{}
+	pinner.Pin(recvAddr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("timeout waiting for the response to command %02X %02X", netFn, command)
+		}
+		ready, err := dev.wait(min(remaining, openIPMIPollInterval))
+		if err != nil {
+			return nil, fmt.Errorf("unable to wait for the response to command %02X %02X %w", netFn, command, err)
+		}
+		if !ready {
+			continue
+		}
+
+		recv := &ipmiRecv{
+			addr:    (*byte)(unsafe.Pointer(recvAddr)),
+			addrLen: uint32(unsafe.Sizeof(*recvAddr)),
+			msg:     ipmiMsg{data: &buf[0], dataLen: uint16(len(buf))},
+		}
+		err = dev.ioctl(ipmictlReceiveMsgTrunc, unsafe.Pointer(recv))
+		if err != nil {
+			return nil, fmt.Errorf("unable to receive the response to command %02X %02X %w", netFn, command, err)
+		}
+		// events and responses to commands of others sharing the device are not ours
+		if recv.recvType != ipmiResponseRecvType || recv.msgID != msgID {
+			continue
+		}
+		resp := buf[:min(int(recv.msg.dataLen), len(buf))]
+		if len(resp) < 1 {
+			return nil, fmt.Errorf("empty response to command %02X %02X", netFn, command)
+		}
+		if resp[0] != 0 {
+			return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: resp[0]}
+		}
+		return append([]byte(nil), resp[1:]...), nil
+	}
+}
+
+func (o *OpenIPMI) rawSender(ctx context.Context) rawSender {
+	return func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+		return o.send(ctx, netFn, command, data...)
+	}
+}
+
+func (o *OpenIPMI) users() userManager {
+	return userManager{run: o.Run, log: o.log}
+}
+
+// DevicePresent returns true if the IPMI device is present, which is required to talk to the BMC
+func (o *OpenIPMI) DevicePresent() bool {
+	_, err := os.Stat(o.path)
+	return err == nil
+}
+
+// NewCommand is not supported, there is no ipmitool involved
+func (o *OpenIPMI) NewCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("no ipmitool command with the openipmi driver %w", hal.ErrNotSupported)
+}
+
+// Run executes a raw command as built by the Raw* builders and returns the response data formatted like ipmitool does.
+// Other ipmitool commands are not supported.
+func (o *OpenIPMI) Run(ctx context.Context, args ...string) (string, error) {
+	return runRaw(o.rawSender(ctx), args)
+}
+
+// CreateUser creates an IPMI user with given privilege level and either the given password or - if empty - a generated one with respect to the given password constraints.
+// Raw commands are used regardless of the apiType.
+func (o *OpenIPMI) CreateUser(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string, pc *api.PasswordConstraints, apiType ApiType) (string, error) {
+	return o.users().createUserRaw(ctx, user, privilege, password, pc)
+}
+
+// ChangePassword of the given user, the new password is validated against the given password constraints first.
+// Raw commands are used regardless of the apiType.
+func (o *OpenIPMI) ChangePassword(ctx context.Context, user api.BMCUser, newPassword string, pc *api.PasswordConstraints, apiType ApiType) error {
+	return o.users().changePasswordRaw(ctx, user, newPassword, pc)
+}
+
+// SetUserEnabled enable the given user, raw commands are used regardless of the apiType
+func (o *OpenIPMI) SetUserEnabled(ctx context.Context, user api.BMCUser, enabled bool, apiType ApiType) error {
+	return o.users().setUserEnabledRaw(ctx, user, enabled)
+}
+
+// NeedsPasswordChange tests the password of the given user
+func (o *OpenIPMI) NeedsPasswordChange(ctx context.Context, user api.BMCUser, password string) (bool, error) {
+	_, err := testPasswordSize(password)
+	if err != nil {
+		return false, err
+	}
+	uid, err := userID(user)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = o.Run(ctx, RawTestUserPassword(uid, password)...)
+	if err != nil {
+		var cce *CompletionCodeError
+		if errors.As(err, &cce) && (cce.Code == completionCodePasswordMismatch || cce.Code == completionCodePasswordSize) {
+			return true, fmt.Errorf("password for user %s with id %s incorrect: %w change necessary", user.Name, user.Id, err)
+		}
+		return false, fmt.Errorf("error while testing user password for user %s with id %s: %w", user.Name, user.Id, err)
+	}
+	return false, nil
+}
+
+// GetLanConfig returns the LAN config
+func (o *OpenIPMI) GetLanConfig(ctx context.Context) (LanConfig, error) {
+	return readLanConfig(o.rawSender(ctx))
+}
+
+// ListUsers returns the users of the LAN channel
+func (o *OpenIPMI) ListUsers(ctx context.Context) ([]api.BMCAccount, error) {
+	return readUsers(o.rawSender(ctx))
+}
+
+// GetBMCLanConfig returns the network configuration of the BMC, the port selection is only read from vendors which support it
+func (o *OpenIPMI) GetBMCLanConfig(ctx context.Context, vendor api.Vendor) (*api.BMCLanConfig, error) {
+	return readBMCLanConfig(o.rawSender(ctx), vendor)
+}
+
+// SetBMCLanConfig writes the network configuration of the BMC
+func (o *OpenIPMI) SetBMCLanConfig(ctx context.Context, vendor api.Vendor, config api.BMCLanConfig) error {
+	return writeBMCLanConfig(o.rawSender(ctx), vendor, config)
+}
+
+// GetFru returns the Field Replaceable Unit information of the BMC
+func (o *OpenIPMI) GetFru(ctx context.Context) (Fru, error) {
+	fru, err := readFRU(o.rawSender(ctx), 0)
+	if err != nil {
+		return Fru{}, fmt.Errorf("unable to read fru:%w", err)
+	}
+	return toFru(fru), nil
+}
+
+// GetBMCInfo returns the firmware revision of the BMC formatted like ipmitool bmc info does
+func (o *OpenIPMI) GetBMCInfo(ctx context.Context) (BMCInfo, error) {
+	resp, err := o.send(ctx, AppNetworkFunction, GetDeviceID)
+	if err != nil {
+		return BMCInfo{}, fmt.Errorf("unable to get device id %w", err)
+	}
+	if len(resp) < 4 {
+		return BMCInfo{}, fmt.Errorf("unexpected device id response:%v", resp)
+	}
+	// the minor revision is BCD encoded
+	return BMCInfo{FirmwareRevision: fmt.Sprintf("%d.%02x", resp[2]&0x7F, resp[3])}, nil
+}
+
+// GetSession returns the user and privilege level of the current session
+func (o *OpenIPMI) GetSession(ctx context.Context) (Session, error) {
+	resp, err := o.send(ctx, AppNetworkFunction, GetSessionInfo, 0)
+	if err != nil {
+		return Session{}, fmt.Errorf("unable to get session info %w", err)
+	}
+	if len(resp) < 5 {
+		return Session{}, fmt.Errorf("unexpected session info response:%v", resp)
+	}
+	return Session{
+		UserID:    fmt.Sprintf("%d", resp[3]&0x3F),
+		Privilege: privilegeLevels[resp[4]&0x0F],
+	}, nil
+}
+
+var privilegeLevels = map[uint8]string{
+	api.CallbackPrivilege:      "CALLBACK",
+	api.UserPrivilege:          "USER",
+	api.OperatorPrivilege:      "OPERATOR",
+	api.AdministratorPrivilege: "ADMINISTRATOR",
+	api.OEMPrivilege:           "OEM",
+}
+
+// BMC returns the BMC struct
+func (o *OpenIPMI) BMC(ctx context.Context) (*api.BMC, error) {
+	lan, err := o.GetLanConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fru, err := o.GetFru(ctx)
+	if err != nil {
+		o.log.Errorw("unable to get fru", "error", err)
+	}
+	info, err := o.GetBMCInfo(ctx)
+	if err != nil {
+		o.log.Errorw("unable to get bmc info", "error", err)
+	}
+	return newBMC(lan, fru, info), nil
+}
+
+// SetBootOrder sets the boot order to given target with the default override of the target
+func (o *OpenIPMI) SetBootOrder(ctx context.Context, target hal.BootTarget, vendor api.Vendor) error {
+	return o.SetBootOverride(ctx, hal.DefaultBootOverride(target), vendor)
+}
+
+// SetBootOverride sets the boot flags to the given override
+func (o *OpenIPMI) SetBootOverride(ctx context.Context, override hal.BootOverride, vendor api.Vendor) error {
+	args, err := RawSetBootOverride(override, vendor)
+	if err != nil {
+		return err
+	}
+	_, err = o.Run(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("unable to set boot override:%s %w", override, err)
+	}
+	return nil
+}
+
+// BootOverride reads the boot override from the boot flags
+func (o *OpenIPMI) BootOverride(ctx context.Context, vendor api.Vendor) (*hal.BootOverride, error) {
+	return readBootOverride(o.rawSender(ctx), vendor)
+}
+
+// SetChassisControl executes the given chassis control function
+func (o *OpenIPMI) SetChassisControl(ctx context.Context, fn ChassisControlFunction) error {
+	_, err := o.Run(ctx, RawChassisControl(fn)...)
+	if err != nil {
+		return fmt.Errorf("unable to set chassis control function:%X %w", fn, err)
+	}
+	return nil
+}
+
+// SetChassisIdentifyLEDState sets the chassis identify LED to given state
+func (o *OpenIPMI) SetChassisIdentifyLEDState(ctx context.Context, state hal.IdentifyLEDState) error {
+	switch state {
+	case hal.IdentifyLEDStateOn:
+		return o.SetChassisIdentifyLEDOn(ctx)
+	case hal.IdentifyLEDStateOff:
+		return o.SetChassisIdentifyLEDOff(ctx)
+	case hal.IdentifyLEDStateUnknown:
+		fallthrough
+	default:
+		return fmt.Errorf("unknown identify LED state: %s", state)
+	}
+}
+
+// SetChassisIdentifyLEDOn turns on the chassis identify LED
+func (o *OpenIPMI) SetChassisIdentifyLEDOn(ctx context.Context) error {
+	_, err := o.Run(ctx, RawChassisIdentifyOn()...)
+	if err != nil {
+		return fmt.Errorf("unable to turn on the chassis identify LED %w", err)
+	}
+	return nil
+}
+
+// SetChassisIdentifyLEDOff turns off the chassis identify LED
+func (o *OpenIPMI) SetChassisIdentifyLEDOff(ctx context.Context) error {
+	_, err := o.Run(ctx, RawChassisIdentifyOff()...)
+	if err != nil {
+		return fmt.Errorf("unable to turn off the chassis identify LED %w", err)
+	}
+	return nil
+}
+
+// SEL returns the system event log entries with a record id greater than after
+func (o *OpenIPMI) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	return readSEL(ctx, o.Run, after)
+}
+
+// ClearSEL clears the system event log
+func (o *OpenIPMI) ClearSEL(ctx context.Context) error {
+	return clearSEL(ctx, o.Run)
+}
+
+// Sensors returns the readings of the temperature, fan, voltage and current sensors of the SDR repository
+func (o *OpenIPMI) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	return readSensors(o.rawSender(ctx))
+}
+
+// FRUs returns the decoded inventory of the FRU of the BMC and of all FRU devices listed in the SDR repository
+func (o *OpenIPMI) FRUs(ctx context.Context) ([]api.FRU, error) {
+	return readFRUs(o.rawSender(ctx))
+}
+
+// OpenConsole is not supported, serial over lan is only available out of band
+func (o *OpenIPMI) OpenConsole(ctx context.Context, s ssh.Session) error {
+	return fmt.Errorf("no serial over lan with the openipmi driver %w", hal.ErrNotSupported)
+}
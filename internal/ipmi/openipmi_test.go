@@ -0,0 +1,201 @@
+package ipmi
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+type fakeRequest struct {
+	netFn   NetworkFunction
+	command uint8
+	data    []byte
+}
+
+type fakeMessage struct {
+	recvType int32
+	msgID    int
+	netFn    NetworkFunction
+	command  uint8
+	data     []byte
+}
+
+// fakeDevice emulates the ioctls of the OpenIPMI driver, handle returns the completion code and the response data
+type fakeDevice struct {
+	handle   func(r fakeRequest) []byte
+	requests []fakeRequest
+	queue    []fakeMessage
+	opened   int
+}
+
+func (d *fakeDevice) open(string) (device, error) {
+	d.opened++
+	return d, nil
+}
+
+func (d *fakeDevice) ioctl(request uintptr, arg unsafe.Pointer) error {
+	switch request {
+	case ipmictlSendCommand:
+		req := (*ipmiReq)(arg)
+		addr := (*ipmiSystemInterfaceAddr)(unsafe.Pointer(req.addr))
+		if req.addrLen != uint32(unsafe.Sizeof(*addr)) || addr.addrType != ipmiSystemInterfaceAddrType || addr.channel != ipmiBMCChannel {
+			return unix.EINVAL
+		}
+		r := fakeRequest{netFn: req.msg.netFn, command: req.msg.cmd}
+		if req.msg.dataLen > 0 {
+			r.data = append([]byte(nil), unsafe.Slice(req.msg.data, req.msg.dataLen)...)
+		}
+		d.requests = append(d.requests, r)
+		resp := d.handle(r)
+		if resp == nil {
+			return nil
+		}
+		// an event and a late response to an earlier request are received before the response
+		d.queue = append(d.queue,
+			fakeMessage{recvType: 3, netFn: SensorEventNetworkFunction, data: []byte{0x00}},
+			fakeMessage{recvType: ipmiResponseRecvType, msgID: req.msgID - 1, netFn: r.netFn | 1, command: r.command, data: []byte{0xC3}},
+			fakeMessage{recvType: ipmiResponseRecvType, msgID: req.msgID, netFn: r.netFn | 1, command: r.command, data: resp},
+		)
+		return nil
+	case ipmictlReceiveMsgTrunc:
+		if len(d.queue) == 0 {
+			return unix.EAGAIN
+		}
+		m := d.queue[0]
+		d.queue = d.queue[1:]
+		recv := (*ipmiRecv)(arg)
+		recv.recvType = m.recvType
+		recv.msgID = m.msgID
+		recv.msg.netFn = m.netFn
+		recv.msg.cmd = m.command
+		recv.msg.dataLen = uint16(copy(unsafe.Slice(recv.msg.data, recv.msg.dataLen), m.data))
+		return nil
+	}
+	return unix.ENOTTY
+}
+
+func (d *fakeDevice) wait(time.Duration) (bool, error) {
+	return len(d.queue) > 0, nil
+}
+
+func (d *fakeDevice) Close() error {
+	return nil
+}
+
+func TestOpenIPMI(t *testing.T) {
+	bmc := &fakeBMC{
+		records: [][]byte{},
+		frus: map[uint8][]byte{
+			0: fruImage(nil, fruInfoArea([]byte{0x19, 0, 0, 0}, ascii("Supermicro"), ascii("X12DPU-6"), ascii("OM21AS001234"), ascii("X12DPU-6")), nil, nil),
+		},
+	}
+	dev := &fakeDevice{
+		handle: func(r fakeRequest) []byte {
+			switch {
+			case r.netFn == ChassisNetworkFunction && r.command == ChassisControl:
+				return []byte{0x00}
+			case r.netFn == AppNetworkFunction && r.command == GetDeviceID:
+				return []byte{0x00, 0x20, 0x01, 0x01, 0x73, 0x02, 0xBF}
+			case r.netFn == AppNetworkFunction && r.command == GetChannelInfo:
+				// channel 1 is IPMB, channel 2 LAN
+				medium := uint8(0x01)
+				if r.data[0] == 2 {
+					medium = channelMediumLAN
+				}
+				return []byte{0x00, r.data[0], medium, 0x01, 0x80, 0xF2, 0x1B, 0x00, 0x00, 0x00}
+			case r.netFn == TransportNetworkFunction && r.command == GetLANConfigurationParameters:
+				switch r.data[1] {
+				case lanParameterIPAddress:
+					return []byte{0x00, 0x11, 10, 0, 0, 42}
+				case lanParameterMACAddress:
+					return []byte{0x00, 0x11, 0x3c, 0xec, 0xef, 0x01, 0x02, 0x03}
+				}
+			case r.netFn == AppNetworkFunction && r.command == SetUserPassword && r.data[1] == 3:
+				if string(r.data[2:8]) == "secret" {
+					return []byte{0x00}
+				}
+				return []byte{completionCodePasswordMismatch}
+			case r.netFn == AppNetworkFunction && r.command == GetSelfTestResults:
+				// never answered
+				return nil
+			}
+			resp, err := bmc.send(r.netFn, r.command, r.data...)
+			if err != nil {
+				return []byte{0xC1}
+			}
+			return append([]byte{0x00}, resp...)
+		},
+	}
+	o := &OpenIPMI{
+		path: "/dev/fake",
+		open: dev.open,
+		log:  logger.NewSlog(slog.New(slog.DiscardHandler)),
+	}
+	ctx := context.Background()
+
+	err := o.SetChassisControl(ctx, ChassisControlPowerCycle)
+	require.NoError(t, err)
+	require.Equal(t, fakeRequest{netFn: ChassisNetworkFunction, command: ChassisControl, data: []byte{ChassisControlPowerCycle}}, dev.requests[0])
+	require.Empty(t, dev.queue)
+
+	out, err := o.Run(ctx, "raw", "0x06", "0x01")
+	require.NoError(t, err)
+	require.Equal(t, " 20 01 01 73 02 bf", out)
+
+	info, err := o.GetBMCInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "1.73", info.FirmwareRevision)
+
+	lan, err := o.GetLanConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, LanConfig{IP: "10.0.0.42", Mac: "3c:ec:ef:01:02:03"}, lan)
+
+	user := api.BMCUser{Name: "metal", Id: "2", ChannelNumber: 1}
+	_, err = o.NeedsPasswordChange(ctx, user, "0123456789abcdefghijk")
+	require.EqualError(t, err, "password of 21 characters exceeds the maximum of 20")
+	// shorter passwords are padded to the 16 byte format
+	needsChange, err := o.NeedsPasswordChange(ctx, user, "secret")
+	require.NoError(t, err)
+	require.False(t, needsChange)
+	needsChange, err = o.NeedsPasswordChange(ctx, user, "0123456789abcdef")
+	require.Error(t, err)
+	require.True(t, needsChange)
+
+	fru, err := o.GetFru(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "OM21AS001234", fru.BoardMfgSerial)
+
+	_, err = o.send(ctx, FirmwareNetworkFunction, 0x01)
+	var cce *CompletionCodeError
+	require.ErrorAs(t, err, &cce)
+	require.Equal(t, uint8(0xC1), cce.Code)
+	require.EqualError(t, err, "command 08 01 failed with completion code 0xC1: invalid command")
+
+	_, err = o.Run(ctx, "lan", "print")
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = o.send(ctx, AppNetworkFunction, GetSelfTestResults)
+	require.Error(t, err)
+
+	require.Equal(t, len(dev.requests), dev.opened)
+}
+
+func TestIoctlNumbers(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("the sizes of the structs differ on 32 bit architectures")
+	}
+	// as defined in linux/ipmi.h on 64 bit architectures
+	require.Equal(t, uintptr(0x8028690d), ipmictlSendCommand)
+	require.Equal(t, uintptr(0xc030690b), ipmictlReceiveMsgTrunc)
+}
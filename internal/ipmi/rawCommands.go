@@ -1,9 +1,11 @@
 package ipmi
 
 import (
+	"fmt"
 	"github.com/metal-stack/go-hal"
 	"github.com/metal-stack/go-hal/pkg/api"
 	"strconv"
+	"strings"
 )
 
 func RawUserAccess(channelNumber, uid uint8, privilege api.IpmiPrivilege) []string {
@@ -34,11 +36,36 @@ func RawSetUserPassword(uid uint8, password string) []string {
 	return rawCommand(args...)
 }
 
+// RawTestUserPassword tests the password of the user, passwords longer than 16 bytes are tested as 20 byte passwords
+func RawTestUserPassword(uid uint8, password string) []string {
+	size := 16
+	if len(password) > size {
+		size = 20
+		uid = setBit(uid, 7)
+	}
+	args := []uint8{AppNetworkFunction, SetUserPassword, uid, 3}
+	args = append(args, fixedBytes(password, size)...)
+	return rawCommand(args...)
+}
+
 func RawSetSystemBootOptions(target hal.BootTarget, vendor api.Vendor) []string {
 	uefiQualifier, bootDevQualifier := GetBootOrderQualifiers(target, vendor)
 	return rawCommand(ChassisNetworkFunction, SetSystemBootOptions, BootFlags, uefiQualifier, bootDevQualifier, 0, 0, 0)
 }
 
+// RawSetBootOverride sets the boot flags to the given override
+func RawSetBootOverride(override hal.BootOverride, vendor api.Vendor) ([]string, error) {
+	uefiQualifier, bootDevQualifier, err := bootOverrideQualifiers(override, vendor)
+	if err != nil {
+		return nil, err
+	}
+	return rawCommand(ChassisNetworkFunction, SetSystemBootOptions, BootFlags, uefiQualifier, bootDevQualifier, 0, 0, 0), nil
+}
+
+func RawGetSystemBootOptions(parameter uint8) []string {
+	return rawCommand(ChassisNetworkFunction, GetSystemBootOptions, parameter, 0, 0)
+}
+
 func RawChassisControl(fn ChassisControlFunction) []string {
 	return rawCommand(ChassisNetworkFunction, ChassisControl, fn)
 }
@@ -51,6 +78,26 @@ func RawChassisIdentifyOn() []string {
 	return rawCommand(ChassisNetworkFunction, ChassisIdentify, ChassisIdentifyForceOnIndefinitely, True)
 }
 
+func RawGetSELInfo() []string {
+	return rawCommand(StorageNetworkFunction, GetSELInfo)
+}
+
+func RawReserveSEL() []string {
+	return rawCommand(StorageNetworkFunction, ReserveSEL)
+}
+
+func RawGetSELEntry(reservationID, recordID uint16) []string {
+	// offset 0 and 0xFF bytes read the whole record, which does not require a reservation
+	return rawCommand(StorageNetworkFunction, GetSELEntry, uint8(reservationID), uint8(reservationID>>8), uint8(recordID), uint8(recordID>>8), 0, 0xFF)
+}
+
+func RawClearSEL(reservationID uint16) []string {
+	args := []uint8{StorageNetworkFunction, ClearSEL, uint8(reservationID), uint8(reservationID >> 8)}
+	args = append(args, selClear...)
+	args = append(args, SELEraseInitiate)
+	return rawCommand(args...)
+}
+
 func rawCommand(bytes ...uint8) []string {
 	uu := make([]string, len(bytes)+1)
 	uu[0] = "raw"
@@ -59,3 +106,37 @@ func rawCommand(bytes ...uint8) []string {
 	}
 	return uu
 }
+
+// parseRawCommand returns the network function, command and data of a raw command built by rawCommand
+func parseRawCommand(args []string) (NetworkFunction, uint8, []uint8, error) {
+	if len(args) < 3 || args[0] != "raw" {
+		return 0, 0, nil, fmt.Errorf("not a raw command:%v", args)
+	}
+	bytes := make([]uint8, len(args)-1)
+	for i, arg := range args[1:] {
+		b, err := strconv.ParseUint(arg, 0, 8)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("invalid byte %q in raw command:%v %w", arg, args, err)
+		}
+		bytes[i] = uint8(b)
+	}
+	return bytes[0], bytes[1], bytes[2:], nil
+}
+
+// runRaw sends a raw command built by rawCommand and formats the response data like ipmitool does.
+// It lets the native clients serve the commands which are shared with ipmitool.
+func runRaw(send rawSender, args []string) (string, error) {
+	netFn, command, data, err := parseRawCommand(args)
+	if err != nil {
+		return "", fmt.Errorf("only raw commands are supported without ipmitool: %w %w", err, hal.ErrNotSupported)
+	}
+	resp, err := send(netFn, command, data...)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for _, b := range resp {
+		fmt.Fprintf(&out, " %02x", b)
+	}
+	return out.String(), nil
+}
@@ -24,6 +24,9 @@ func TestRawCommands(t *testing.T) {
 	require.Equal(t, []string{"raw", "6", "71", "2", "0"}, RawDisableUser(uid))
 	require.Equal(t, []string{"raw", "6", "71", "2", "1"}, RawEnableUser(uid))
 	require.Equal(t, []string{"raw", "6", "71", "130", "2", "115", "101", "99", "114", "101", "116", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0"}, RawSetUserPassword(uid, userPassword))
+	require.Equal(t, []string{"raw", "6", "71", "2", "3", "115", "101", "99", "114", "101", "116", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0"}, RawTestUserPassword(uid, userPassword))
+	require.Len(t, RawTestUserPassword(uid, "0123456789abcdefXYZ"), 1+4+20)
+	require.Equal(t, "130", RawTestUserPassword(uid, "0123456789abcdefXYZ")[3])
 
 	require.Equal(t, []string{"raw", "0", "8", "5", "224", "4", "0", "0", "0"}, RawSetSystemBootOptions(hal.BootTargetPXE, api.VendorLenovo))
 	require.Equal(t, []string{"raw", "0", "8", "5", "224", "8", "0", "0", "0"}, RawSetSystemBootOptions(hal.BootTargetDisk, api.VendorLenovo))
@@ -37,9 +40,40 @@ func TestRawCommands(t *testing.T) {
 	require.Equal(t, []string{"raw", "0", "8", "5", "224", "8", "0", "0", "0"}, RawSetSystemBootOptions(hal.BootTargetDisk, api.VendorVagrant))
 	require.Equal(t, []string{"raw", "0", "8", "5", "160", "24", "0", "0", "0"}, RawSetSystemBootOptions(hal.BootTargetBIOS, api.VendorVagrant))
 
+	args, err := RawSetBootOverride(hal.BootOverride{Target: hal.BootTargetCD, Mode: hal.FirmwareModeUEFI}, api.VendorLenovo)
+	require.NoError(t, err)
+	require.Equal(t, []string{"raw", "0", "8", "5", "160", "20", "0", "0", "0"}, args)
+	_, err = RawSetBootOverride(hal.BootOverride{Target: hal.BootTargetUEFIShell}, api.VendorLenovo)
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	require.Equal(t, []string{"raw", "0", "9", "5", "0", "0"}, RawGetSystemBootOptions(BootFlags))
+
 	require.Equal(t, []string{"raw", "0", "2", "1"}, RawChassisControl(ChassisControlPowerUp))
 	require.Equal(t, []string{"raw", "0", "2", "3"}, RawChassisControl(ChassisControlHardReset))
 	require.Equal(t, []string{"raw", "0", "2", "2"}, RawChassisControl(ChassisControlPowerCycle))
 	require.Equal(t, []string{"raw", "0", "4", "0", "0"}, RawChassisIdentifyOff())
 	require.Equal(t, []string{"raw", "0", "4", "0", "1"}, RawChassisIdentifyOn())
+
+	require.Equal(t, []string{"raw", "10", "64"}, RawGetSELInfo())
+	require.Equal(t, []string{"raw", "10", "66"}, RawReserveSEL())
+	require.Equal(t, []string{"raw", "10", "67", "0", "0", "2", "1", "0", "255"}, RawGetSELEntry(0, 0x0102))
+	require.Equal(t, []string{"raw", "10", "71", "52", "18", "67", "76", "82", "170"}, RawClearSEL(0x1234))
+}
+
+func TestParseRawCommand(t *testing.T) {
+	netFn, command, data, err := parseRawCommand(RawGetSELEntry(0, 0x0102))
+	require.NoError(t, err)
+	require.Equal(t, StorageNetworkFunction, netFn)
+	require.Equal(t, GetSELEntry, command)
+	require.Equal(t, []uint8{0, 0, 2, 1, 0, 255}, data)
+
+	netFn, command, data, err = parseRawCommand([]string{"raw", "0x06", "0x01"})
+	require.NoError(t, err)
+	require.Equal(t, AppNetworkFunction, netFn)
+	require.Equal(t, GetDeviceID, command)
+	require.Empty(t, data)
+
+	_, _, _, err = parseRawCommand([]string{"lan", "print"})
+	require.Error(t, err)
+	_, _, _, err = parseRawCommand([]string{"raw", "6", "256"})
+	require.Error(t, err)
 }
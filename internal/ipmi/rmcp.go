@@ -0,0 +1,228 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-second-gen-interface-spec-v2-rev1-1.pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// 13.1 RMCP, 13.6 IPMI v2.0 RMCP+ Session Header, 13.8 IPMI LAN Message Format
+
+const (
+	rmcpVersion   = 0x06
+	rmcpNoAck     = 0xFF
+	rmcpClassIPMI = 0x07
+	rmcpHeaderLen = 4
+
+	authTypeNone     = 0x00
+	authTypeRMCPPlus = 0x06
+
+	payloadTypeIPMI                = 0x00
+	payloadTypeOpenSessionRequest  = 0x10
+	payloadTypeOpenSessionResponse = 0x11
+	payloadTypeRAKP1               = 0x12
+	payloadTypeRAKP2               = 0x13
+	payloadTypeRAKP3               = 0x14
+	payloadTypeRAKP4               = 0x15
+	payloadEncrypted               = 0x80
+	payloadAuthenticated           = 0x40
+
+	// sessionHeaderLen is the length of the RMCP+ session header from the auth type to the payload length
+	sessionHeaderLen = 12
+	// v15SessionHeaderLen is the length of the IPMI v1.5 session header without auth code
+	v15SessionHeaderLen = 10
+	integrityPad        = 0xFF
+	nextHeader          = 0x07
+
+	remoteConsoleAddress = 0x81
+	lanMessageMinLen     = 7
+)
+
+// 13.28 Authentication, Integrity and Confidentiality Algorithm Numbers
+const (
+	rakpHMACSHA1   = 0x01
+	rakpHMACSHA256 = 0x03
+	hmacSHA1_96    = 0x01
+	hmacSHA256_128 = 0x04
+	aesCBC128      = 0x01
+)
+
+// cipherSuite holds the algorithms of a cipher suite, 22.15.2 Cipher Suite IDs
+type cipherSuite struct {
+	id              uint8
+	authentication  uint8
+	integrity       uint8
+	confidentiality uint8
+	hash            func() hash.Hash
+	// integrityLen is the length of the truncated HMAC used for RAKP message 4 and the packet integrity
+	integrityLen int
+}
+
+var (
+	// cipherSuite3 is RAKP-HMAC-SHA1, HMAC-SHA1-96 and AES-CBC-128
+	cipherSuite3 = cipherSuite{id: 3, authentication: rakpHMACSHA1, integrity: hmacSHA1_96, confidentiality: aesCBC128, hash: sha1.New, integrityLen: 12}
+	// cipherSuite17 is RAKP-HMAC-SHA256, HMAC-SHA256-128 and AES-CBC-128
+	cipherSuite17 = cipherSuite{id: 17, authentication: rakpHMACSHA256, integrity: hmacSHA256_128, confidentiality: aesCBC128, hash: sha256.New, integrityLen: 16}
+)
+
+func (c cipherSuite) hmac(key []byte, data ...[]byte) []byte {
+	h := hmac.New(c.hash, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// rmcpHeader prepends every packet, IPMI messages are never acknowledged on RMCP level
+var rmcpHeader = []byte{rmcpVersion, 0x00, rmcpNoAck, rmcpClassIPMI}
+
+// lanMessage is an IPMI message as sent over LAN, requests and responses share the layout.
+// The data of a response starts with the completion code.
+type lanMessage struct {
+	to    uint8
+	netFn NetworkFunction
+	from  uint8
+	seq   uint8
+	cmd   uint8
+	data  []byte
+}
+
+func (m lanMessage) marshal() []byte {
+	b := make([]byte, 0, lanMessageMinLen+len(m.data))
+	b = append(b, m.to, m.netFn<<2)
+	b = append(b, -checksum(b))
+	b = append(b, m.from, m.seq<<2, m.cmd)
+	b = append(b, m.data...)
+	return append(b, -checksum(b[3:]))
+}
+
+func unmarshalLanMessage(b []byte) (lanMessage, error) {
+	if len(b) < lanMessageMinLen {
+		return lanMessage{}, fmt.Errorf("ipmi message too short, %d bytes", len(b))
+	}
+	if checksum(b[:3]) != 0 || checksum(b[3:]) != 0 {
+		return lanMessage{}, errors.New("invalid ipmi message checksum")
+	}
+	return lanMessage{
+		to:    b[0],
+		netFn: b[1] >> 2,
+		from:  b[3],
+		seq:   b[4] >> 2,
+		cmd:   b[5],
+		data:  b[6 : len(b)-1],
+	}, nil
+}
+
+// marshalV15 builds an unauthenticated IPMI v1.5 packet, it is only used outside of sessions
+func marshalV15(message []byte) []byte {
+	b := append([]byte(nil), rmcpHeader...)
+	b = append(b, authTypeNone, 0, 0, 0, 0, 0, 0, 0, 0, uint8(len(message)))
+	return append(b, message...)
+}
+
+// rmcpPlusPacket is an IPMI v2.0 packet
+type rmcpPlusPacket struct {
+	payloadType uint8
+	sessionID   uint32
+	seq         uint32
+	payload     []byte
+}
+
+// marshal builds the packet, the integrity trailer is added if the payload type is authenticated
+func (p rmcpPlusPacket) marshal(suite cipherSuite, k1 []byte) []byte {
+	b := append([]byte(nil), rmcpHeader...)
+	b = append(b, authTypeRMCPPlus, p.payloadType)
+	b = binary.LittleEndian.AppendUint32(b, p.sessionID)
+	b = binary.LittleEndian.AppendUint32(b, p.seq)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(p.payload))) // nolint:gosec
+	b = append(b, p.payload...)
+	if p.payloadType&payloadAuthenticated == 0 {
+		return b
+	}
+	// the integrity covers the session header up to the next header, which must be a multiple of 4
+	pad := (4 - (len(b)-rmcpHeaderLen+2)%4) % 4
+	b = append(b, bytes.Repeat([]byte{integrityPad}, pad)...)
+	b = append(b, uint8(pad), nextHeader)
+	return append(b, suite.hmac(k1, b[rmcpHeaderLen:])[:suite.integrityLen]...)
+}
+
+// unmarshalRMCPPlus decodes a packet and checks its integrity if it is authenticated
+func unmarshalRMCPPlus(b []byte, suite cipherSuite, k1 []byte) (rmcpPlusPacket, error) {
+	if len(b) < rmcpHeaderLen+sessionHeaderLen || !bytes.Equal(b[:rmcpHeaderLen], rmcpHeader) {
+		return rmcpPlusPacket{}, errors.New("no rmcp ipmi packet")
+	}
+	s := b[rmcpHeaderLen:]
+	if s[0] != authTypeRMCPPlus {
+		return rmcpPlusPacket{}, fmt.Errorf("unexpected auth type 0x%02x", s[0])
+	}
+	p := rmcpPlusPacket{
+		payloadType: s[1],
+		sessionID:   binary.LittleEndian.Uint32(s[2:6]),
+		seq:         binary.LittleEndian.Uint32(s[6:10]),
+	}
+	length := int(binary.LittleEndian.Uint16(s[10:12]))
+	if len(s) < sessionHeaderLen+length {
+		return rmcpPlusPacket{}, errors.New("rmcp+ payload truncated")
+	}
+	p.payload = s[sessionHeaderLen : sessionHeaderLen+length]
+	if p.payloadType&payloadAuthenticated == 0 {
+		return p, nil
+	}
+
+	if k1 == nil || len(s) < sessionHeaderLen+length+2+suite.integrityLen {
+		return rmcpPlusPacket{}, errors.New("rmcp+ integrity trailer missing")
+	}
+	authCode := s[len(s)-suite.integrityLen:]
+	if !hmac.Equal(authCode, suite.hmac(k1, s[:len(s)-suite.integrityLen])[:suite.integrityLen]) {
+		return rmcpPlusPacket{}, errors.New("invalid rmcp+ integrity check value")
+	}
+	return p, nil
+}
+
+// encryptAESCBC128 returns the random IV followed by the encrypted and padded data
+func encryptAESCBC128(k2, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(k2[:aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+	pad := (aes.BlockSize - (len(data)+1)%aes.BlockSize) % aes.BlockSize
+	plain := append([]byte(nil), data...)
+	for i := 1; i <= pad; i++ {
+		plain = append(plain, uint8(i))
+	}
+	plain = append(plain, uint8(pad))
+
+	out := make([]byte, aes.BlockSize+len(plain))
+	if _, err := rand.Read(out[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+	return out, nil
+}
+
+func decryptAESCBC128(k2, payload []byte) ([]byte, error) {
+	if len(payload) < 2*aes.BlockSize || len(payload)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted payload length %d", len(payload))
+	}
+	block, err := aes.NewCipher(k2[:aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(payload)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, payload[:aes.BlockSize]).CryptBlocks(plain, payload[aes.BlockSize:])
+	pad := int(plain[len(plain)-1])
+	if pad >= len(plain) {
+		return nil, errors.New("invalid confidentiality pad")
+	}
+	return plain[:len(plain)-1-pad], nil
+}
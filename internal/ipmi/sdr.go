@@ -0,0 +1,296 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-second-gen-interface-spec-v2-rev1-1.pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// 33 SDR Repository, 35.14 Get Sensor Reading, 43.1 SDR Type 01h, Full Sensor Record
+
+const (
+	sdrFullSensorRecord = 0x01
+	sdrHeaderSize       = 5
+	// sdrReadChunk is the number of bytes read at once, many BMCs reject larger partial reads
+	sdrReadChunk  = 16
+	sdrLastRecord = 0xFFFF
+	// sdrMinFullSensorRecord is the size of a full sensor record without id string
+	sdrMinFullSensorRecord = 48
+
+	bmcSlaveAddress = 0x20
+
+	thresholdEventType = 0x01
+	noAnalogReading    = 0x03
+
+	sensorScanningEnabled    = 0x40
+	sensorReadingUnavailable = 0x20
+)
+
+// rawSender sends a raw request to the BMC and returns the response data without the completion code
+type rawSender func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error)
+
+// sensorRecord the parts of a full sensor record which are needed to interpret the readings of the sensor
+type sensorRecord struct {
+	number        uint8
+	name          string
+	sensorType    hal.SensorType
+	unit          string
+	analogFormat  uint8
+	linearization uint8
+	m, b          int16
+	k1, k2        int8
+	// readable is the readable threshold mask, the bits are in the order of thresholds
+	readable uint8
+	// thresholds raw values in the order LNC, LC, LNR, UNC, UC, UNR
+	thresholds [6]uint8
+}
+
+var sdrSensorTypes = map[uint8]hal.SensorType{
+	0x01: hal.SensorTypeTemperature,
+	0x02: hal.SensorTypeVoltage,
+	0x03: hal.SensorTypeCurrent,
+	0x04: hal.SensorTypeFan,
+}
+
+// sdrUnits the base units of Table 43-15 which are used by the supported sensor types
+var sdrUnits = map[uint8]string{
+	1:  "C",
+	2:  "F",
+	3:  "K",
+	4:  "V",
+	5:  "A",
+	6:  "W",
+	18: "RPM",
+}
+
+// readSensors walks the SDR repository and reads every threshold based temperature, fan, voltage and current sensor of the BMC
+func readSensors(send rawSender) ([]hal.Sensor, error) {
+	var sensors []hal.Sensor
+	err := walkSDR(send, func(record []byte) {
+		r, ok := decodeSensorRecord(record)
+		if ok {
+			sensors = append(sensors, r.read(send))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sensors, nil
+}
+
+// walkSDR reads all records of the SDR repository and passes them to f
+func walkSDR(send rawSender, f func(record []byte)) error {
+	reservation, err := reserveSDR(send)
+	if err != nil {
+		return err
+	}
+
+	for id := uint16(0); id != sdrLastRecord; {
+		next, record, err := readSDR(send, reservation, id)
+		if err != nil {
+			// partial reads fail once the reservation was canceled, e.g. by another client
+			reservation, err = reserveSDR(send)
+			if err != nil {
+				return err
+			}
+			next, record, err = readSDR(send, reservation, id)
+			if err != nil {
+				return fmt.Errorf("unable to read sdr record %d %w", id, err)
+			}
+		}
+
+		f(record)
+		if next == id {
+			break
+		}
+		id = next
+	}
+	return nil
+}
+
+func reserveSDR(send rawSender) (uint16, error) {
+	resp, err := send(StorageNetworkFunction, ReserveSDRRepository)
+	if err != nil {
+		return 0, fmt.Errorf("unable to reserve sdr repository %w", err)
+	}
+	if len(resp) < 2 {
+		return 0, fmt.Errorf("unexpected sdr reservation response:%v", resp)
+	}
+	return uint16(resp[0]) | uint16(resp[1])<<8, nil
+}
+
+// readSDR reads the record with the given id in chunks and returns the id of the next record
+func readSDR(send rawSender, reservation, id uint16) (uint16, []byte, error) {
+	getSDR := func(offset, length uint8) (uint16, []byte, error) {
+		resp, err := send(StorageNetworkFunction, GetSDR, uint8(reservation), uint8(reservation>>8), uint8(id), uint8(id>>8), offset, length)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(resp) < 2+int(length) {
+			return 0, nil, fmt.Errorf("short sdr response:%v", resp)
+		}
+		return uint16(resp[0]) | uint16(resp[1])<<8, resp[2 : 2+int(length)], nil
+	}
+
+	next, record, err := getSDR(0, sdrHeaderSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	size := sdrHeaderSize + int(record[4])
+	for offset := sdrHeaderSize; offset < size; offset += sdrReadChunk {
+		_, data, err := getSDR(uint8(offset), uint8(min(sdrReadChunk, size-offset)))
+		if err != nil {
+			return 0, nil, err
+		}
+		record = append(record, data...)
+	}
+	return next, record, nil
+}
+
+// decodeSensorRecord returns false for all records which do not describe an analog threshold sensor
+// of a supported type owned by the BMC
+func decodeSensorRecord(record []byte) (*sensorRecord, bool) {
+	if len(record) < sdrMinFullSensorRecord || record[3] != sdrFullSensorRecord {
+		return nil, false
+	}
+	if record[5] != bmcSlaveAddress || record[6]&0x03 != 0 || record[13] != thresholdEventType {
+		return nil, false
+	}
+	sensorType, ok := sdrSensorTypes[record[12]]
+	if !ok {
+		return nil, false
+	}
+	r := &sensorRecord{
+		number:        record[7],
+		sensorType:    sensorType,
+		analogFormat:  record[20] >> 6,
+		linearization: record[23] & 0x7F,
+		m:             signExtend(uint16(record[24])|uint16(record[25]&0xC0)<<2, 10),
+		b:             signExtend(uint16(record[26])|uint16(record[27]&0xC0)<<2, 10),
+		k2:            int8(signExtend(uint16(record[29]>>4), 4)),
+		k1:            int8(signExtend(uint16(record[29]&0x0F), 4)),
+		readable:      record[18] & 0x3F,
+		thresholds:    [6]uint8{record[41], record[40], record[39], record[38], record[37], record[36]},
+	}
+	if r.analogFormat == noAnalogReading {
+		return nil, false
+	}
+	if record[20]&0x01 != 0 {
+		r.unit = "%"
+	} else {
+		r.unit = sdrUnits[record[21]]
+	}
+	nameLength := int(record[47] & 0x1F)
+	name := record[sdrMinFullSensorRecord:min(len(record), sdrMinFullSensorRecord+nameLength)]
+	r.name = strings.TrimRight(string(name), "\x00 ")
+	return r, true
+}
+
+// read gets the current reading of the sensor, a sensor which cannot be read is reported with unknown status
+func (r *sensorRecord) read(send rawSender) hal.Sensor {
+	s := hal.Sensor{
+		Name:   r.name,
+		Number: r.number,
+		Type:   r.sensorType,
+		Unit:   r.unit,
+		Status: hal.SensorStatusUnknown,
+	}
+	thresholds := []**float64{
+		&s.Thresholds.LowerNonCritical,
+		&s.Thresholds.LowerCritical,
+		&s.Thresholds.LowerNonRecoverable,
+		&s.Thresholds.UpperNonCritical,
+		&s.Thresholds.UpperCritical,
+		&s.Thresholds.UpperNonRecoverable,
+	}
+	for i, t := range thresholds {
+		if r.readable&(1<<i) != 0 {
+			v := r.value(r.thresholds[i])
+			*t = &v
+		}
+	}
+
+	resp, err := send(SensorEventNetworkFunction, GetSensorReading, r.number)
+	if err != nil || len(resp) < 2 {
+		// e.g. the sensor of an unpopulated cpu socket
+		return s
+	}
+	if resp[1]&sensorScanningEnabled == 0 || resp[1]&sensorReadingUnavailable != 0 {
+		return s
+	}
+	s.Reading = r.value(resp[0])
+	s.Status = hal.SensorStatusOK
+	if len(resp) > 2 {
+		s.Status = thresholdStatus(resp[2])
+	}
+	return s
+}
+
+// thresholdStatus maps the threshold comparison status of Get Sensor Reading
+func thresholdStatus(state uint8) hal.SensorStatus {
+	switch {
+	case state&0x24 != 0:
+		return hal.SensorStatusNonRecoverable
+	case state&0x12 != 0:
+		return hal.SensorStatusCritical
+	case state&0x09 != 0:
+		return hal.SensorStatusWarning
+	default:
+		return hal.SensorStatusOK
+	}
+}
+
+// value converts a raw reading with the formula of 36.3 Sensor Reading Conversion Formula
+func (r *sensorRecord) value(raw uint8) float64 {
+	var x float64
+	switch r.analogFormat {
+	case 1: // 1's complement
+		if raw&0x80 != 0 {
+			x = -float64(^raw)
+		} else {
+			x = float64(raw)
+		}
+	case 2: // 2's complement
+		x = float64(int8(raw))
+	default:
+		x = float64(raw)
+	}
+	y := (float64(r.m)*x + float64(r.b)*math.Pow10(int(r.k1))) * math.Pow10(int(r.k2))
+
+	switch r.linearization {
+	case 1:
+		y = math.Log(y)
+	case 2:
+		y = math.Log10(y)
+	case 3:
+		y = math.Log2(y)
+	case 4:
+		y = math.Exp(y)
+	case 5:
+		y = math.Pow(10, y)
+	case 6:
+		y = math.Exp2(y)
+	case 7:
+		y = 1 / y
+	case 8:
+		y = y * y
+	case 9:
+		y = y * y * y
+	case 10:
+		y = math.Sqrt(y)
+	case 11:
+		y = math.Cbrt(y)
+	}
+	// drop the noise of the floating point arithmetic, no sensor is that precise
+	return math.Round(y*1e6) / 1e6
+}
+
+// signExtend interprets the lowest bits of v as two's complement number
+func signExtend(v uint16, bits uint) int16 {
+	shift := 16 - bits
+	return int16(v<<shift) >> shift
+}
@@ -0,0 +1,162 @@
+package ipmi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/stretchr/testify/require"
+)
+
+type fullSensor struct {
+	number     uint8
+	sensorType uint8
+	units1     uint8
+	baseUnit   uint8
+	m, b       uint16
+	exponents  uint8
+	readable   uint8
+	// thresholds in the order UNR, UC, UNC, LNR, LC, LNC as in the record
+	thresholds [6]uint8
+	name       string
+}
+
+func (s fullSensor) record(id uint16) []byte {
+	r := make([]byte, sdrMinFullSensorRecord, sdrMinFullSensorRecord+len(s.name))
+	r[0], r[1], r[2], r[3] = uint8(id), uint8(id>>8), 0x51, sdrFullSensorRecord
+	r[4] = uint8(sdrMinFullSensorRecord + len(s.name) - sdrHeaderSize)
+	r[5] = bmcSlaveAddress
+	r[7] = s.number
+	r[12] = s.sensorType
+	r[13] = thresholdEventType
+	r[18] = s.readable
+	r[20] = s.units1
+	r[21] = s.baseUnit
+	r[24], r[25] = uint8(s.m), uint8(s.m>>2)&0xC0
+	r[26], r[27] = uint8(s.b), uint8(s.b>>2)&0xC0
+	r[29] = s.exponents
+	copy(r[36:42], s.thresholds[:])
+	r[47] = 0xC0 | uint8(len(s.name))
+	return append(r, s.name...)
+}
+
+// fakeBMC serves a SDR repository and the sensor readings via raw commands
+type fakeBMC struct {
+	records  [][]byte
+	readings map[uint8][]byte
+	frus     map[uint8][]byte
+	// cancelReservation cancels the first reservation on the first partial read
+	cancelReservation bool
+	reservation       uint16
+}
+
+func (f *fakeBMC) send(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+	switch {
+	case netFn == StorageNetworkFunction && command == ReserveSDRRepository:
+		f.reservation++
+		return []byte{uint8(f.reservation), uint8(f.reservation >> 8)}, nil
+	case netFn == StorageNetworkFunction && command == GetSDR:
+		reservation := uint16(data[0]) | uint16(data[1])<<8
+		id := uint16(data[2]) | uint16(data[3])<<8
+		offset, length := int(data[4]), int(data[5])
+		if offset > 0 && f.cancelReservation && reservation == 1 {
+			return nil, errors.New("reservation canceled")
+		}
+		if offset > 0 && length > sdrReadChunk {
+			return nil, errors.New("request data length invalid")
+		}
+		next := uint16(sdrLastRecord)
+		if int(id)+1 < len(f.records) {
+			next = id + 1
+		}
+		return append([]byte{uint8(next), uint8(next >> 8)}, f.records[id][offset:offset+length]...), nil
+	case netFn == StorageNetworkFunction && command == GetFRUInventoryAreaInfo:
+		fru, ok := f.frus[data[0]]
+		if !ok {
+			return nil, errors.New("requested sensor, data, or record not present")
+		}
+		return []byte{uint8(len(fru)), uint8(len(fru) >> 8), 0x00}, nil
+	case netFn == StorageNetworkFunction && command == ReadFRUData:
+		fru := f.frus[data[0]]
+		offset, count := int(data[1])|int(data[2])<<8, int(data[3])
+		if count > sdrReadChunk {
+			return nil, errors.New("request data length invalid")
+		}
+		return append([]byte{uint8(count)}, fru[offset:offset+count]...), nil
+	case netFn == SensorEventNetworkFunction && command == GetSensorReading:
+		reading, ok := f.readings[data[0]]
+		if !ok {
+			return nil, errors.New("requested sensor, data, or record not present")
+		}
+		return reading, nil
+	}
+	return nil, errors.New("invalid command")
+}
+
+func TestReadSensors(t *testing.T) {
+	compact := []byte{0x02, 0x00, 0x51, 0x02, 0x0B, 0x20, 0x00, 0x10, 0x07, 0x01, 0x7F, 0x68, 0x07, 0x6F, 0x00, 0x00}
+	bmc := &fakeBMC{
+		records: [][]byte{
+			fullSensor{number: 0x01, sensorType: 0x01, baseUnit: 1, m: 1, readable: 0x38, thresholds: [6]uint8{100, 95, 90}, name: "CPU1 Temp"}.record(0),
+			fullSensor{number: 0x02, sensorType: 0x01, baseUnit: 1, m: 1, readable: 0x38, thresholds: [6]uint8{100, 95, 90}, name: "CPU2 Temp"}.record(1),
+			compact,
+			// 0.06 V per bit
+			fullSensor{number: 0x20, sensorType: 0x02, baseUnit: 4, m: 6, exponents: 0xE0, readable: 0x3F, thresholds: [6]uint8{230, 222, 216, 170, 178, 184}, name: "12V"}.record(3),
+			fullSensor{number: 0x30, sensorType: 0x04, baseUnit: 18, m: 100, readable: 0x07, thresholds: [6]uint8{0, 0, 0, 2, 3, 5}, name: "FAN1"}.record(4),
+			// two's complement with negative offset
+			fullSensor{number: 0x40, sensorType: 0x03, units1: 0x80, baseUnit: 5, m: 1, b: 0x3FF, exponents: 0xF0, name: "PSU1 Current"}.record(5),
+			fullSensor{number: 0x50, sensorType: 0x01, baseUnit: 1, m: 1, readable: 0x38, thresholds: [6]uint8{100, 95, 90}, name: "Missing"}.record(6),
+		},
+		readings: map[uint8][]byte{
+			0x01: {97, 0xC0, 0x18},
+			0x02: {0, 0x60, 0x00},
+			0x20: {200, 0xC0, 0x00},
+			0x30: {4, 0xC0, 0x01},
+			0x40: {0xFE, 0xC0},
+		},
+		cancelReservation: true,
+	}
+	ptr := func(f float64) *float64 { return &f }
+
+	sensors, err := readSensors(bmc.send)
+	require.NoError(t, err)
+	require.Equal(t, []hal.Sensor{
+		{
+			Name: "CPU1 Temp", Number: 0x01, Type: hal.SensorTypeTemperature, Reading: 97, Unit: "C", Status: hal.SensorStatusCritical,
+			Thresholds: hal.SensorThresholds{UpperNonCritical: ptr(90), UpperCritical: ptr(95), UpperNonRecoverable: ptr(100)},
+		},
+		{
+			Name: "CPU2 Temp", Number: 0x02, Type: hal.SensorTypeTemperature, Unit: "C", Status: hal.SensorStatusUnknown,
+			Thresholds: hal.SensorThresholds{UpperNonCritical: ptr(90), UpperCritical: ptr(95), UpperNonRecoverable: ptr(100)},
+		},
+		{
+			Name: "12V", Number: 0x20, Type: hal.SensorTypeVoltage, Reading: 12, Unit: "V", Status: hal.SensorStatusOK,
+			Thresholds: hal.SensorThresholds{
+				LowerNonCritical: ptr(11.04), LowerCritical: ptr(10.68), LowerNonRecoverable: ptr(10.2),
+				UpperNonCritical: ptr(12.96), UpperCritical: ptr(13.32), UpperNonRecoverable: ptr(13.8),
+			},
+		},
+		{
+			Name: "FAN1", Number: 0x30, Type: hal.SensorTypeFan, Reading: 400, Unit: "RPM", Status: hal.SensorStatusWarning,
+			Thresholds: hal.SensorThresholds{LowerNonCritical: ptr(500), LowerCritical: ptr(300), LowerNonRecoverable: ptr(200)},
+		},
+		{
+			Name: "PSU1 Current", Number: 0x40, Type: hal.SensorTypeCurrent, Reading: -0.3, Unit: "A", Status: hal.SensorStatusOK,
+		},
+		{
+			Name: "Missing", Number: 0x50, Type: hal.SensorTypeTemperature, Unit: "C", Status: hal.SensorStatusUnknown,
+			Thresholds: hal.SensorThresholds{UpperNonCritical: ptr(90), UpperCritical: ptr(95), UpperNonRecoverable: ptr(100)},
+		},
+	}, sensors)
+	require.Equal(t, uint16(2), bmc.reservation)
+}
+
+func TestThresholdStatus(t *testing.T) {
+	require.Equal(t, hal.SensorStatusOK, thresholdStatus(0x00))
+	require.Equal(t, hal.SensorStatusWarning, thresholdStatus(0x01))
+	require.Equal(t, hal.SensorStatusWarning, thresholdStatus(0x08))
+	require.Equal(t, hal.SensorStatusCritical, thresholdStatus(0x03))
+	require.Equal(t, hal.SensorStatusCritical, thresholdStatus(0x18))
+	require.Equal(t, hal.SensorStatusNonRecoverable, thresholdStatus(0x07))
+	require.Equal(t, hal.SensorStatusNonRecoverable, thresholdStatus(0x38))
+}
@@ -0,0 +1,480 @@
+package ipmi
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/product-briefs/ipmi-second-gen-interface-spec-v2-rev1-1.pdf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// 31.4 Get SEL Entry, 32 SEL Record Formats
+
+const (
+	// SELRecordSize is the size of every SEL record
+	SELRecordSize = 16
+	// SELFirstRecord addresses the first record of the SEL
+	SELFirstRecord uint16 = 0x0000
+	// SELLastRecord addresses the last record of the SEL and marks the end of the SEL as next record id
+	SELLastRecord uint16 = 0xFFFF
+
+	// SELEraseInitiate starts the erasure of the SEL
+	SELEraseInitiate uint8 = 0xAA
+
+	selSystemEventRecord   = 0x02
+	selFirstOEMTimestamped = 0xC0
+	selFirstOEM            = 0xE0
+	// selPreInitTimestamp timestamps up to this value are relative to the initialization of the BMC
+	selPreInitTimestamp = 0x20000000
+	selUnspecifiedTime  = 0xFFFFFFFF
+)
+
+// selClear is the "CLR" signature of Clear SEL
+var selClear = []uint8{0x43, 0x4C, 0x52}
+
+type selOffset struct {
+	description string
+	severity    hal.SELSeverity
+}
+
+var (
+	// 42.2 Sensor Type Codes
+	sensorTypes = map[uint8]string{
+		0x01: "Temperature",
+		0x02: "Voltage",
+		0x03: "Current",
+		0x04: "Fan",
+		0x05: "Physical Security",
+		0x06: "Platform Security",
+		0x07: "Processor",
+		0x08: "Power Supply",
+		0x09: "Power Unit",
+		0x0A: "Cooling Device",
+		0x0B: "Other Units-based Sensor",
+		0x0C: "Memory",
+		0x0D: "Drive Slot",
+		0x0E: "POST Memory Resize",
+		0x0F: "System Firmware Progress",
+		0x10: "Event Logging Disabled",
+		0x11: "Watchdog 1",
+		0x12: "System Event",
+		0x13: "Critical Interrupt",
+		0x14: "Button / Switch",
+		0x15: "Module / Board",
+		0x16: "Microcontroller / Coprocessor",
+		0x17: "Add-in Card",
+		0x18: "Chassis",
+		0x19: "Chip Set",
+		0x1A: "Other FRU",
+		0x1B: "Cable / Interconnect",
+		0x1C: "Terminator",
+		0x1D: "System Boot / Restart Initiated",
+		0x1E: "Boot Error",
+		0x1F: "Base OS Boot / Installation Status",
+		0x20: "OS Stop / Shutdown",
+		0x21: "Slot / Connector",
+		0x22: "System ACPI Power State",
+		0x23: "Watchdog 2",
+		0x24: "Platform Alert",
+		0x25: "Entity Presence",
+		0x26: "Monitor ASIC / IC",
+		0x27: "LAN",
+		0x28: "Management Subsystem Health",
+		0x29: "Battery",
+		0x2A: "Session Audit",
+		0x2B: "Version Change",
+		0x2C: "FRU State",
+	}
+
+	// 42.1 Event/Reading Type Codes, table 42-2 threshold based states
+	thresholdOffsets = []selOffset{
+		{"Lower Non-critical going low", hal.SELSeverityWarning},
+		{"Lower Non-critical going high", hal.SELSeverityWarning},
+		{"Lower Critical going low", hal.SELSeverityCritical},
+		{"Lower Critical going high", hal.SELSeverityCritical},
+		{"Lower Non-recoverable going low", hal.SELSeverityCritical},
+		{"Lower Non-recoverable going high", hal.SELSeverityCritical},
+		{"Upper Non-critical going low", hal.SELSeverityWarning},
+		{"Upper Non-critical going high", hal.SELSeverityWarning},
+		{"Upper Critical going low", hal.SELSeverityCritical},
+		{"Upper Critical going high", hal.SELSeverityCritical},
+		{"Upper Non-recoverable going low", hal.SELSeverityCritical},
+		{"Upper Non-recoverable going high", hal.SELSeverityCritical},
+	}
+
+	// table 42-2 generic discrete states by event/reading type
+	genericOffsets = map[uint8][]selOffset{
+		0x02: {{"Transition to Idle", hal.SELSeverityInfo}, {"Transition to Active", hal.SELSeverityInfo}, {"Transition to Busy", hal.SELSeverityInfo}},
+		0x03: {{"State Deasserted", hal.SELSeverityInfo}, {"State Asserted", hal.SELSeverityInfo}},
+		0x04: {{"Predictive Failure deasserted", hal.SELSeverityInfo}, {"Predictive Failure asserted", hal.SELSeverityWarning}},
+		0x05: {{"Limit Not Exceeded", hal.SELSeverityInfo}, {"Limit Exceeded", hal.SELSeverityWarning}},
+		0x06: {{"Performance Met", hal.SELSeverityInfo}, {"Performance Lags", hal.SELSeverityWarning}},
+		0x07: {
+			{"Transition to OK", hal.SELSeverityInfo},
+			{"Transition to Non-Critical from OK", hal.SELSeverityWarning},
+			{"Transition to Critical from less severe", hal.SELSeverityCritical},
+			{"Transition to Non-recoverable from less severe", hal.SELSeverityCritical},
+			{"Transition to Non-Critical from more severe", hal.SELSeverityWarning},
+			{"Transition to Critical from Non-recoverable", hal.SELSeverityCritical},
+			{"Transition to Non-recoverable", hal.SELSeverityCritical},
+			{"Monitor", hal.SELSeverityInfo},
+			{"Informational", hal.SELSeverityInfo},
+		},
+		0x08: {{"Device Removed / Device Absent", hal.SELSeverityInfo}, {"Device Inserted / Device Present", hal.SELSeverityInfo}},
+		0x09: {{"Device Disabled", hal.SELSeverityInfo}, {"Device Enabled", hal.SELSeverityInfo}},
+		0x0A: {
+			{"Transition to Running", hal.SELSeverityInfo},
+			{"Transition to In Test", hal.SELSeverityInfo},
+			{"Transition to Power Off", hal.SELSeverityInfo},
+			{"Transition to On Line", hal.SELSeverityInfo},
+			{"Transition to Off Line", hal.SELSeverityWarning},
+			{"Transition to Off Duty", hal.SELSeverityInfo},
+			{"Transition to Degraded", hal.SELSeverityWarning},
+			{"Transition to Power Save", hal.SELSeverityInfo},
+			{"Install Error", hal.SELSeverityCritical},
+		},
+		0x0B: {
+			{"Fully Redundant", hal.SELSeverityInfo},
+			{"Redundancy Lost", hal.SELSeverityCritical},
+			{"Redundancy Degraded", hal.SELSeverityWarning},
+			{"Non-redundant: Sufficient Resources from Redundant", hal.SELSeverityWarning},
+			{"Non-redundant: Sufficient Resources from Insufficient Resources", hal.SELSeverityWarning},
+			{"Non-redundant: Insufficient Resources", hal.SELSeverityCritical},
+			{"Redundancy Degraded from Fully Redundant", hal.SELSeverityWarning},
+			{"Redundancy Degraded from Non-redundant", hal.SELSeverityWarning},
+		},
+		0x0C: {{"D0 Power State", hal.SELSeverityInfo}, {"D1 Power State", hal.SELSeverityInfo}, {"D2 Power State", hal.SELSeverityInfo}, {"D3 Power State", hal.SELSeverityInfo}},
+	}
+
+	// table 42-3 sensor-specific offsets of the sensor types which matter for diagnosis
+	sensorSpecificOffsets = map[uint8][]selOffset{
+		0x05: {
+			{"General Chassis Intrusion", hal.SELSeverityWarning},
+			{"Drive Bay intrusion", hal.SELSeverityWarning},
+			{"I/O Card area intrusion", hal.SELSeverityWarning},
+			{"Processor area intrusion", hal.SELSeverityWarning},
+			{"LAN Leash Lost", hal.SELSeverityWarning},
+			{"Unauthorized dock", hal.SELSeverityWarning},
+			{"FAN area intrusion", hal.SELSeverityWarning},
+		},
+		0x07: {
+			{"IERR", hal.SELSeverityCritical},
+			{"Thermal Trip", hal.SELSeverityCritical},
+			{"FRB1/BIST failure", hal.SELSeverityCritical},
+			{"FRB2/Hang in POST failure", hal.SELSeverityCritical},
+			{"FRB3/Processor Startup/Initialization failure", hal.SELSeverityCritical},
+			{"Configuration Error", hal.SELSeverityCritical},
+			{"SM BIOS Uncorrectable CPU-complex Error", hal.SELSeverityCritical},
+			{"Presence detected", hal.SELSeverityInfo},
+			{"Processor disabled", hal.SELSeverityWarning},
+			{"Terminator presence detected", hal.SELSeverityInfo},
+			{"Processor Automatically Throttled", hal.SELSeverityWarning},
+			{"Machine Check Exception (Uncorrectable)", hal.SELSeverityCritical},
+			{"Correctable Machine Check Error", hal.SELSeverityWarning},
+		},
+		0x08: {
+			{"Presence detected", hal.SELSeverityInfo},
+			{"Power Supply Failure detected", hal.SELSeverityCritical},
+			{"Predictive Failure", hal.SELSeverityWarning},
+			{"Power Supply input lost (AC/DC)", hal.SELSeverityCritical},
+			{"Power Supply input lost or out-of-range", hal.SELSeverityCritical},
+			{"Power Supply input out-of-range, but present", hal.SELSeverityWarning},
+			{"Configuration error", hal.SELSeverityCritical},
+			{"Power Supply Inactive", hal.SELSeverityInfo},
+		},
+		0x09: {
+			{"Power Off / Power Down", hal.SELSeverityInfo},
+			{"Power Cycle", hal.SELSeverityInfo},
+			{"240VA Power Down", hal.SELSeverityWarning},
+			{"Interlock Power Down", hal.SELSeverityWarning},
+			{"AC lost / Power input lost", hal.SELSeverityCritical},
+			{"Soft Power Control Failure", hal.SELSeverityCritical},
+			{"Power Unit Failure detected", hal.SELSeverityCritical},
+			{"Predictive Failure", hal.SELSeverityWarning},
+		},
+		0x0C: {
+			{"Correctable ECC", hal.SELSeverityWarning},
+			{"Uncorrectable ECC", hal.SELSeverityCritical},
+			{"Parity", hal.SELSeverityCritical},
+			{"Memory Scrub Failed", hal.SELSeverityCritical},
+			{"Memory Device Disabled", hal.SELSeverityWarning},
+			{"Correctable ECC logging limit reached", hal.SELSeverityWarning},
+			{"Presence detected", hal.SELSeverityInfo},
+			{"Configuration error", hal.SELSeverityCritical},
+			{"Spare", hal.SELSeverityInfo},
+			{"Memory Automatically Throttled", hal.SELSeverityWarning},
+			{"Critical Overtemperature", hal.SELSeverityCritical},
+		},
+		0x0D: {
+			{"Drive Present", hal.SELSeverityInfo},
+			{"Drive Fault", hal.SELSeverityCritical},
+			{"Predictive Failure", hal.SELSeverityWarning},
+			{"Hot Spare", hal.SELSeverityInfo},
+			{"Consistency Check / Parity Check in progress", hal.SELSeverityInfo},
+			{"In Critical Array", hal.SELSeverityCritical},
+			{"In Failed Array", hal.SELSeverityCritical},
+			{"Rebuild/Remap in progress", hal.SELSeverityInfo},
+			{"Rebuild/Remap Aborted", hal.SELSeverityWarning},
+		},
+		0x0F: {
+			{"System Firmware Error", hal.SELSeverityCritical},
+			{"System Firmware Hang", hal.SELSeverityCritical},
+			{"System Firmware Progress", hal.SELSeverityInfo},
+		},
+		0x10: {
+			{"Correctable Memory Error Logging Disabled", hal.SELSeverityInfo},
+			{"Event Type Logging Disabled", hal.SELSeverityInfo},
+			{"Log Area Reset/Cleared", hal.SELSeverityInfo},
+			{"All Event Logging Disabled", hal.SELSeverityWarning},
+			{"SEL Full", hal.SELSeverityWarning},
+			{"SEL Almost Full", hal.SELSeverityWarning},
+			{"Correctable Machine Check Error Logging Disabled", hal.SELSeverityInfo},
+		},
+		0x12: {
+			{"System Reconfigured", hal.SELSeverityInfo},
+			{"OEM System Boot Event", hal.SELSeverityInfo},
+			{"Undetermined system hardware failure", hal.SELSeverityCritical},
+			{"Entry added to Auxiliary Log", hal.SELSeverityInfo},
+			{"PEF Action", hal.SELSeverityInfo},
+			{"Timestamp Clock Synch", hal.SELSeverityInfo},
+		},
+		0x13: {
+			{"Front Panel NMI / Diagnostic Interrupt", hal.SELSeverityCritical},
+			{"Bus Timeout", hal.SELSeverityCritical},
+			{"I/O channel check NMI", hal.SELSeverityCritical},
+			{"Software NMI", hal.SELSeverityCritical},
+			{"PCI PERR", hal.SELSeverityCritical},
+			{"PCI SERR", hal.SELSeverityCritical},
+			{"EISA Fail Safe Timeout", hal.SELSeverityCritical},
+			{"Bus Correctable Error", hal.SELSeverityWarning},
+			{"Bus Uncorrectable Error", hal.SELSeverityCritical},
+			{"Fatal NMI", hal.SELSeverityCritical},
+			{"Bus Fatal Error", hal.SELSeverityCritical},
+			{"Bus Degraded", hal.SELSeverityWarning},
+		},
+		0x14: {
+			{"Power Button pressed", hal.SELSeverityInfo},
+			{"Sleep Button pressed", hal.SELSeverityInfo},
+			{"Reset Button pressed", hal.SELSeverityInfo},
+			{"FRU latch open", hal.SELSeverityInfo},
+			{"FRU service request button pressed", hal.SELSeverityInfo},
+		},
+		0x1D: {
+			{"Initiated by power up", hal.SELSeverityInfo},
+			{"Initiated by hard reset", hal.SELSeverityInfo},
+			{"Initiated by warm reset", hal.SELSeverityInfo},
+			{"User requested PXE boot", hal.SELSeverityInfo},
+			{"Automatic boot to diagnostic", hal.SELSeverityInfo},
+			{"OS / run-time software initiated hard reset", hal.SELSeverityInfo},
+			{"OS / run-time software initiated warm reset", hal.SELSeverityInfo},
+			{"System Restart", hal.SELSeverityInfo},
+		},
+		0x1E: {
+			{"No bootable media", hal.SELSeverityCritical},
+			{"Non-bootable diskette left in drive", hal.SELSeverityWarning},
+			{"PXE Server not found", hal.SELSeverityCritical},
+			{"Invalid boot sector", hal.SELSeverityCritical},
+			{"Timeout waiting for user selection of boot source", hal.SELSeverityWarning},
+		},
+		0x1F: {
+			{"A: boot completed", hal.SELSeverityInfo},
+			{"C: boot completed", hal.SELSeverityInfo},
+			{"PXE boot completed", hal.SELSeverityInfo},
+			{"Diagnostic boot completed", hal.SELSeverityInfo},
+			{"CD-ROM boot completed", hal.SELSeverityInfo},
+			{"ROM boot completed", hal.SELSeverityInfo},
+			{"Boot completed - boot device not specified", hal.SELSeverityInfo},
+			{"Base OS/Hypervisor Installation started", hal.SELSeverityInfo},
+			{"Base OS/Hypervisor Installation completed", hal.SELSeverityInfo},
+			{"Base OS/Hypervisor Installation aborted", hal.SELSeverityWarning},
+			{"Base OS/Hypervisor Installation failed", hal.SELSeverityCritical},
+		},
+		0x20: {
+			{"Critical stop during OS load / initialization", hal.SELSeverityCritical},
+			{"Run-time Critical Stop", hal.SELSeverityCritical},
+			{"OS Graceful Stop", hal.SELSeverityInfo},
+			{"OS Graceful Shutdown", hal.SELSeverityInfo},
+			{"Soft Shutdown initiated by PEF", hal.SELSeverityInfo},
+			{"Agent Not Responding", hal.SELSeverityWarning},
+		},
+		0x23: {
+			{"Timer expired", hal.SELSeverityWarning},
+			{"Hard Reset", hal.SELSeverityWarning},
+			{"Power Down", hal.SELSeverityWarning},
+			{"Power Cycle", hal.SELSeverityWarning},
+		},
+		0x29: {
+			{"Battery low (predictive failure)", hal.SELSeverityWarning},
+			{"Battery failed", hal.SELSeverityCritical},
+			{"Battery presence detected", hal.SELSeverityInfo},
+		},
+	}
+)
+
+// DecodeSELRecord decodes a record as returned by Get SEL Entry
+func DecodeSELRecord(record []byte) (hal.SELEntry, error) {
+	if len(record) != SELRecordSize {
+		return hal.SELEntry{}, fmt.Errorf("invalid SEL record size %d, expected %d", len(record), SELRecordSize)
+	}
+
+	entry := hal.SELEntry{
+		RecordID: binary.LittleEndian.Uint16(record[0:2]),
+		Asserted: true,
+		Severity: hal.SELSeverityInfo,
+	}
+	recordType := record[2]
+	switch {
+	case recordType >= selFirstOEM:
+		entry.SensorType = "OEM"
+		entry.EventType = "OEM"
+		entry.Description = fmt.Sprintf("OEM record 0x%02x", recordType)
+		entry.EventData = slices.Clone(record[3:])
+		return entry, nil
+	case recordType >= selFirstOEMTimestamped:
+		entry.Timestamp = selTimestamp(record[3:7])
+		entry.SensorType = "OEM"
+		entry.EventType = "OEM"
+		entry.Description = fmt.Sprintf("OEM record 0x%02x of manufacturer 0x%06x", recordType, uint32(record[7])|uint32(record[8])<<8|uint32(record[9])<<16)
+		entry.EventData = slices.Clone(record[10:])
+		return entry, nil
+	case recordType != selSystemEventRecord:
+		return entry, fmt.Errorf("unknown SEL record type 0x%02x of record %d", recordType, entry.RecordID)
+	}
+
+	entry.Timestamp = selTimestamp(record[3:7])
+	sensorType := record[10]
+	entry.SensorNumber = record[11]
+	entry.Asserted = record[12]&0x80 == 0
+	eventType := record[12] & 0x7F
+	entry.EventData = slices.Clone(record[13:16])
+	offset := record[13] & 0x0F
+
+	entry.SensorType = sensorTypeName(sensorType)
+
+	var offsets []selOffset
+	switch {
+	case eventType == 0x01:
+		entry.EventType = "Threshold"
+		offsets = thresholdOffsets
+	case eventType >= 0x02 && eventType <= 0x0C:
+		entry.EventType = "Generic Discrete"
+		offsets = genericOffsets[eventType]
+	case eventType == 0x6F:
+		entry.EventType = "Sensor-specific"
+		offsets = sensorSpecificOffsets[sensorType]
+	case eventType >= 0x70 && eventType <= 0x7F:
+		entry.EventType = "OEM"
+	default:
+		entry.EventType = "Unspecified"
+	}
+
+	if int(offset) < len(offsets) {
+		entry.Description = offsets[offset].description
+		if entry.Asserted {
+			entry.Severity = offsets[offset].severity
+		}
+	} else {
+		entry.Description = fmt.Sprintf("Event offset 0x%02x", offset)
+	}
+	if !entry.Asserted {
+		entry.Description += " (deasserted)"
+	}
+	return entry, nil
+}
+
+// FilterSEL sorts the entries by record id and returns those with a record id greater than after
+func FilterSEL(entries []hal.SELEntry, after uint16) []hal.SELEntry {
+	slices.SortStableFunc(entries, func(a, b hal.SELEntry) int {
+		return int(a.RecordID) - int(b.RecordID)
+	})
+	return slices.DeleteFunc(entries, func(e hal.SELEntry) bool {
+		return e.RecordID <= after
+	})
+}
+
+func sensorTypeName(sensorType uint8) string {
+	if name, ok := sensorTypes[sensorType]; ok {
+		return name
+	}
+	if sensorType >= 0xC0 {
+		return fmt.Sprintf("OEM 0x%02x", sensorType)
+	}
+	return fmt.Sprintf("Unknown 0x%02x", sensorType)
+}
+
+// selTimestamp converts the seconds since 1970, timestamps relative to the BMC initialization are unknown
+func selTimestamp(b []byte) time.Time {
+	ts := binary.LittleEndian.Uint32(b)
+	if ts <= selPreInitTimestamp || ts == selUnspecifiedTime {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0).UTC()
+}
+
+// readSEL reads the whole SEL with raw commands and returns the entries with a record id greater than after
+func readSEL(ctx context.Context, run runFunc, after uint16) ([]hal.SELEntry, error) {
+	out, err := run(ctx, RawGetSELInfo()...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get sel info:%v %w", out, err)
+	}
+	info, err := parseRawOutput(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(info) < 3 {
+		return nil, fmt.Errorf("unexpected sel info response:%v", out)
+	}
+	if uint16(info[1])|uint16(info[2])<<8 == 0 {
+		return nil, nil
+	}
+
+	var entries []hal.SELEntry
+	for id := uint16(SELFirstRecord); id != SELLastRecord; {
+		out, err := run(ctx, RawGetSELEntry(0, id)...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get sel entry %d:%v %w", id, out, err)
+		}
+		resp, err := parseRawOutput(out)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) < 2+SELRecordSize {
+			return nil, fmt.Errorf("unexpected sel entry %d response:%v", id, out)
+		}
+		entry, err := DecodeSELRecord(resp[2 : 2+SELRecordSize])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		next := uint16(resp[0]) | uint16(resp[1])<<8
+		if next == id {
+			break
+		}
+		id = next
+	}
+	return FilterSEL(entries, after), nil
+}
+
+// clearSEL reserves and clears the SEL with raw commands
+func clearSEL(ctx context.Context, run runFunc) error {
+	out, err := run(ctx, RawReserveSEL()...)
+	if err != nil {
+		return fmt.Errorf("unable to reserve sel:%v %w", out, err)
+	}
+	resp, err := parseRawOutput(out)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 2 {
+		return fmt.Errorf("unexpected sel reservation response:%v", out)
+	}
+	out, err = run(ctx, RawClearSEL(uint16(resp[0])|uint16(resp[1])<<8)...)
+	if err != nil {
+		return fmt.Errorf("unable to clear sel:%v %w", out, err)
+	}
+	return nil
+}
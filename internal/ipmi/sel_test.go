@@ -0,0 +1,131 @@
+package ipmi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSELRecord(t *testing.T) {
+	ts := time.Unix(0x65000000, 0).UTC()
+	tests := []struct {
+		name    string
+		record  []byte
+		want    hal.SELEntry
+		wantErr bool
+	}{
+		{
+			name:   "threshold",
+			record: []byte{0x01, 0x00, 0x02, 0x00, 0x00, 0x00, 0x65, 0x20, 0x00, 0x04, 0x01, 0x30, 0x01, 0x59, 0x5A, 0x50},
+			want: hal.SELEntry{
+				RecordID:     1,
+				Timestamp:    ts,
+				SensorType:   "Temperature",
+				SensorNumber: 0x30,
+				EventType:    "Threshold",
+				Asserted:     true,
+				Severity:     hal.SELSeverityCritical,
+				Description:  "Upper Critical going high",
+				EventData:    []byte{0x59, 0x5A, 0x50},
+			},
+		},
+		{
+			name:   "deasserted threshold",
+			record: []byte{0x02, 0x00, 0x02, 0x00, 0x00, 0x00, 0x65, 0x20, 0x00, 0x04, 0x01, 0x30, 0x81, 0x59, 0x4B, 0x50},
+			want: hal.SELEntry{
+				RecordID:     2,
+				Timestamp:    ts,
+				SensorType:   "Temperature",
+				SensorNumber: 0x30,
+				EventType:    "Threshold",
+				Asserted:     false,
+				Severity:     hal.SELSeverityInfo,
+				Description:  "Upper Critical going high (deasserted)",
+				EventData:    []byte{0x59, 0x4B, 0x50},
+			},
+		},
+		{
+			name:   "sensor-specific",
+			record: []byte{0x03, 0x00, 0x02, 0x00, 0x00, 0x00, 0x65, 0x20, 0x00, 0x04, 0x0C, 0x10, 0x6F, 0xA0, 0x00, 0x01},
+			want: hal.SELEntry{
+				RecordID:     3,
+				Timestamp:    ts,
+				SensorType:   "Memory",
+				SensorNumber: 0x10,
+				EventType:    "Sensor-specific",
+				Asserted:     true,
+				Severity:     hal.SELSeverityWarning,
+				Description:  "Correctable ECC",
+				EventData:    []byte{0xA0, 0x00, 0x01},
+			},
+		},
+		{
+			name:   "before bmc initialization",
+			record: []byte{0x04, 0x00, 0x02, 0x00, 0x10, 0x00, 0x00, 0x20, 0x00, 0x04, 0x0C, 0x10, 0x6F, 0xA0, 0x00, 0x01},
+			want: hal.SELEntry{
+				RecordID:     4,
+				SensorType:   "Memory",
+				SensorNumber: 0x10,
+				EventType:    "Sensor-specific",
+				Asserted:     true,
+				Severity:     hal.SELSeverityWarning,
+				Description:  "Correctable ECC",
+				EventData:    []byte{0xA0, 0x00, 0x01},
+			},
+		},
+		{
+			name:   "oem timestamped",
+			record: []byte{0x05, 0x00, 0xC1, 0x00, 0x00, 0x00, 0x65, 0xA2, 0x02, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+			want: hal.SELEntry{
+				RecordID:    5,
+				Timestamp:   ts,
+				SensorType:  "OEM",
+				EventType:   "OEM",
+				Asserted:    true,
+				Severity:    hal.SELSeverityInfo,
+				Description: "OEM record 0xc1 of manufacturer 0x0002a2",
+				EventData:   []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+			},
+		},
+		{
+			name:    "unknown record type",
+			record:  []byte{0x06, 0x00, 0x05, 0x00, 0x00, 0x00, 0x65, 0x20, 0x00, 0x04, 0x01, 0x30, 0x01, 0x59, 0x5A, 0x50},
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			record:  []byte{0x07, 0x00, 0x02},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeSELRecord(tt.record)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFilterSEL(t *testing.T) {
+	entries := []hal.SELEntry{{RecordID: 3}, {RecordID: 1}, {RecordID: 4}, {RecordID: 2}}
+
+	require.Equal(t, []hal.SELEntry{{RecordID: 1}, {RecordID: 2}, {RecordID: 3}, {RecordID: 4}}, FilterSEL(entries, 0))
+	require.Equal(t, []hal.SELEntry{{RecordID: 3}, {RecordID: 4}}, FilterSEL(entries, 2))
+	require.Empty(t, FilterSEL(entries, 4))
+}
+
+func TestParseRawOutput(t *testing.T) {
+	got, err := parseRawOutput(" 51 05 00 e8 3f 00 00 00 65\n 0a 0b\n")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x51, 0x05, 0x00, 0xe8, 0x3f, 0x00, 0x00, 0x00, 0x65, 0x0a, 0x0b}, got)
+
+	_, err = parseRawOutput("Unable to send RAW command")
+	require.Error(t, err)
+}
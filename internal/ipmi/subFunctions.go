@@ -7,7 +7,8 @@ package ipmi
 type SetSystemBootOptionsFunction = uint8
 
 const (
-	ServicePartitionSelector SetSystemBootOptionsFunction = iota + 1
+	SetInProgress SetSystemBootOptionsFunction = iota
+	ServicePartitionSelector
 	ServicePartitionScan
 	ValidBitClearing
 	BootInfoAcknowledge
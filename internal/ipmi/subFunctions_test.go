@@ -6,6 +6,7 @@ import (
 )
 
 func TestSpecSubFunctions(t *testing.T) {
+	require.Equal(t, uint8(0), SetInProgress)
 	require.Equal(t, uint8(1), ServicePartitionSelector)
 	require.Equal(t, uint8(2), ServicePartitionScan)
 	require.Equal(t, uint8(3), ValidBitClearing)
@@ -0,0 +1,323 @@
+package ipmi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+// userManager creates and modifies IPMI users, run executes the ipmitool style commands
+type userManager struct {
+	run runFunc
+	log logger.Logger
+}
+
+type bmcRequest struct {
+	username                   string
+	uid                        string
+	privilege                  api.IpmiPrivilege
+	disableUserArgs            []string
+	enableUserArgs             []string
+	setUsernameArgs            []string
+	setUserPrivilegeArgs       []string
+	enableSOLPayloadAccessArgs []string
+	setPasswordFunc            func() (string, error)
+}
+
+func (u userManager) createUserRaw(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string, pc *api.PasswordConstraints) (string, error) {
+	userID, err := userID(user)
+	if err != nil {
+		return "", err
+	}
+	passwd, err := userPassword(user, password, pc)
+	if err != nil {
+		return "", err
+	}
+	cn := uint8(user.ChannelNumber) // nolint:gosec
+	return u.createUser(ctx, bmcRequest{
+		username:                   user.Name,
+		uid:                        user.Id,
+		privilege:                  privilege,
+		disableUserArgs:            RawDisableUser(userID),
+		enableUserArgs:             RawEnableUser(userID),
+		setUsernameArgs:            RawSetUserName(userID, user.Name),
+		setUserPrivilegeArgs:       RawUserAccess(cn, userID, privilege),
+		enableSOLPayloadAccessArgs: RawEnableUserSOLPayloadAccess(cn, userID),
+		setPasswordFunc: func() (string, error) {
+			return u.createPasswordRaw(ctx, user.Name, userID, passwd)
+		},
+	})
+}
+
+func (u userManager) changePasswordRaw(ctx context.Context, user api.BMCUser, newPassword string, pc *api.PasswordConstraints) error {
+	userID, err := userID(user)
+	if err != nil {
+		return err
+	}
+	err = validatePassword(user, newPassword, pc)
+	if err != nil {
+		return err
+	}
+	_, err = u.changePassword(ctx, bmcRequest{
+		username:        user.Name,
+		uid:             user.Id,
+		disableUserArgs: RawDisableUser(userID),
+		enableUserArgs:  RawEnableUser(userID),
+		setPasswordFunc: func() (string, error) {
+			return newPassword, nil
+		},
+	})
+	return err
+}
+
+func (u userManager) setUserEnabledRaw(ctx context.Context, user api.BMCUser, enabled bool) error {
+	userID, err := userID(user)
+	if err != nil {
+		return err
+	}
+	return u.setUserEnabled(ctx, bmcRequest{
+		username:        user.Name,
+		uid:             user.Id,
+		disableUserArgs: RawDisableUser(userID),
+		enableUserArgs:  RawEnableUser(userID),
+	}, enabled)
+}
+
+func (u userManager) createUser(ctx context.Context, req bmcRequest) (string, error) {
+	out, err := u.run(ctx, req.setUsernameArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed set username for user %s with id %s: %s %w", req.username, req.uid, out, err)
+	}
+
+	pw, err := u.changePassword(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	out, err = u.run(ctx, req.setUserPrivilegeArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to set privilege %d for user %s with id %s: %s %w", req.privilege, req.username, req.uid, out, err)
+	}
+
+	out, err = u.run(ctx, req.enableSOLPayloadAccessArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to set enable user SOL payload access for user %s with id %s: %s %w", req.username, req.uid, out, err)
+	}
+
+	return pw, nil
+}
+
+func (u userManager) changePassword(ctx context.Context, req bmcRequest) (string, error) {
+	pw, err := req.setPasswordFunc()
+	if err != nil {
+		return "", fmt.Errorf("failed to set password %s for user %s with id %s %w", pw, req.username, req.uid, err)
+	}
+
+	err = u.setUserEnabled(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+
+	return pw, nil
+}
+
+func (u userManager) setUserEnabled(ctx context.Context, req bmcRequest, enabled bool) error {
+	if enabled {
+		err := retry.Do(
+			func() error {
+				out, err := u.run(ctx, req.enableUserArgs...)
+				if err != nil {
+					return fmt.Errorf("failed to enable user %s with id %s: %s %w", req.username, req.uid, out, err)
+				}
+				return nil
+			},
+			retry.OnRetry(func(n uint, err error) {
+				u.log.Infow("retry ipmi enable user", "user", req.username, "id", req.uid, "retry", n, "cause", err)
+			}),
+			retry.Delay(1*time.Second),
+			retry.Attempts(30),
+			retry.Context(ctx),
+		)
+		return err
+	}
+
+	out, err := u.run(ctx, req.disableUserArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to disable user %s with id %s: %s %w", req.username, req.uid, out, err)
+	}
+
+	return nil
+}
+
+// userPassword returns the password to set for every attempt, the given password is validated against the constraints.
+// If it is empty, a new password is generated for every attempt because the BMC might have rejected the previous one.
+// Policy violations are reported before the BMC is touched.
+// validatePassword rejects a given password which violates the constraints before the bmc is touched,
+// without constraints every password is accepted
+func validatePassword(user api.BMCUser, passwd string, pc *api.PasswordConstraints) error {
+	if pc == nil {
+		return nil
+	}
+	return pc.Validate(user.Name, passwd)
+}
+
+func userPassword(user api.BMCUser, passwd string, pc *api.PasswordConstraints) (func() (string, error), error) {
+	given := func() (string, error) {
+		return passwd, nil
+	}
+	if pc == nil {
+		return given, nil
+	}
+	if passwd != "" {
+		return given, validatePassword(user, passwd, pc)
+	}
+	generate := func() (string, error) {
+		gen, err := pc.Generate(user.Name)
+		if err != nil {
+			return "", fmt.Errorf("password generation failed for user:%s id:%s %w", user.Name, user.Id, err)
+		}
+		return gen, nil
+	}
+	// constraints which no password satisfies are reported up front
+	_, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	return generate, nil
+}
+
+func (u userManager) createPassword(ctx context.Context, username, uid string, passwd func() (string, error)) (string, error) {
+	s := func(pw string) []string {
+		return []string{"user", "set", "password", uid, pw}
+	}
+	return u.createPw(ctx, username, uid, passwd, s)
+}
+
+func (u userManager) createPasswordRaw(ctx context.Context, username string, uid uint8, passwd func() (string, error)) (string, error) {
+	s := func(pw string) []string {
+		return RawSetUserPassword(uid, pw)
+	}
+	return u.createPw(ctx, username, strconv.Itoa(int(uid)), passwd, s)
+}
+
+func (u userManager) createPw(ctx context.Context, username, uid string, passwd func() (string, error), setPasswordArgs func(string) []string) (string, error) {
+	var created string
+	err := retry.Do(
+		func() error {
+			pwd, err := passwd()
+			if err != nil {
+				// the policy is not satisfied by another attempt either
+				return retry.Unrecoverable(err)
+			}
+			out, err := u.run(ctx, setPasswordArgs(pwd)...)
+			if err != nil {
+				return fmt.Errorf("ipmi password creation failed for user:%s id:%s output:%s %w", username, uid, out, err)
+			}
+			created = pwd
+			return nil
+		},
+		retry.OnRetry(func(n uint, err error) {
+			u.log.Infow("retry ipmi password creation", "user", username, "id", uid, "retry", n, "cause", err)
+		}),
+		retry.Delay(1*time.Second),
+		retry.Attempts(30),
+		retry.Context(ctx),
+	)
+	return created, err
+}
+
+// testPasswordSize returns the size of the password field used to test the password, IPMI stores passwords of at most 20 bytes
+func testPasswordSize(password string) (int, error) {
+	switch {
+	case len(password) == 0:
+		return 0, fmt.Errorf("password is empty")
+	case len(password) <= 16:
+		return 16, nil
+	case len(password) <= 20:
+		return 20, nil
+	}
+	return 0, fmt.Errorf("password of %d characters exceeds the maximum of 20", len(password))
+}
+
+func userID(user api.BMCUser) (uint8, error) {
+	id, err := strconv.Atoi(user.Id)
+	if err != nil {
+		return 0, fmt.Errorf("invalid uid of user %s: %s %w", user.Name, user.Id, err)
+	}
+	return uint8(id), nil // nolint:gosec
+}
+
+// 22.27 Get User Access Command, 22.29 Get User Name Command
+
+const (
+	userEnabled = 0x40
+	// noAccessPrivilege is the privilege of users without access to the channel
+	noAccessPrivilege = 0x0F
+)
+
+var privilegeRoles = map[uint8]api.BMCRole{
+	api.CallbackPrivilege:      api.BMCRoleReadOnly,
+	api.UserPrivilege:          api.BMCRoleReadOnly,
+	api.OperatorPrivilege:      api.BMCRoleOperator,
+	api.AdministratorPrivilege: api.BMCRoleAdministrator,
+	api.OEMPrivilege:           "OEM",
+	noAccessPrivilege:          api.BMCRoleNoAccess,
+}
+
+// readUsers lists the users of the LAN channel, slots without name are omitted unless they are enabled like the anonymous user might be
+func readUsers(send rawSender) ([]api.BMCAccount, error) {
+	channel, err := lanChannel(send)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := send(AppNetworkFunction, GetUserAccess, channel, 1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user access of channel %d %w", channel, err)
+	}
+	if len(resp) < 4 {
+		return nil, fmt.Errorf("unexpected user access response:%v", resp)
+	}
+	maxUsers := resp[0] & 0x3F
+
+	var accounts []api.BMCAccount
+	for uid := uint8(1); uid <= maxUsers; uid++ {
+		access, err := send(AppNetworkFunction, GetUserAccess, channel, uid)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get user access of user %d %w", uid, err)
+		}
+		if len(access) < 4 {
+			return nil, fmt.Errorf("unexpected user access response:%v", access)
+		}
+		name, err := send(AppNetworkFunction, GetUserName, uid)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get name of user %d %w", uid, err)
+		}
+		privilege, status := access[3]&0x0F, access[1]&0xC0
+		account := api.BMCAccount{
+			BMCUser: api.BMCUser{
+				Name:          string(bytes.TrimRight(name, "\x00")),
+				Id:            strconv.Itoa(int(uid)),
+				ChannelNumber: int(channel),
+			},
+			// older BMCs do not report the enable status, their users are enabled unless they have no access
+			Enabled: status == userEnabled || status == 0 && privilege != noAccessPrivilege,
+		}
+		role, ok := privilegeRoles[privilege]
+		if !ok {
+			role = api.BMCRole(fmt.Sprintf("privilege %d", privilege))
+		}
+		account.Role = role
+		if account.Name == "" && !account.Enabled {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,96 @@
+package ipmi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+func TestReadUsers(t *testing.T) {
+	// user 1 is the anonymous user, 3 is an unused slot, 4 is disabled and 5 reports no enable status
+	users := map[uint8]struct {
+		name   string
+		access []byte
+	}{
+		1: {name: "", access: []byte{0x05, 0x82, 0x01, 0x0F}},
+		2: {name: "root", access: []byte{0x05, 0x42, 0x01, 0x34}},
+		3: {name: "", access: []byte{0x05, 0x82, 0x01, 0x0F}},
+		4: {name: "metal", access: []byte{0x05, 0x82, 0x01, 0x33}},
+		5: {name: "monitor", access: []byte{0x05, 0x02, 0x01, 0x02}},
+	}
+	send := func(netFn NetworkFunction, command uint8, data ...uint8) ([]byte, error) {
+		switch {
+		case netFn == AppNetworkFunction && command == GetChannelInfo:
+			return []byte{data[0], channelMediumLAN, 0x01, 0x80, 0xF2, 0x1B, 0x00, 0x00, 0x00}, nil
+		case netFn == AppNetworkFunction && command == GetUserAccess:
+			require.Equal(t, uint8(1), data[0])
+			return users[data[1]].access, nil
+		case netFn == AppNetworkFunction && command == GetUserName:
+			name := make([]byte, 16)
+			copy(name, users[data[0]].name)
+			return name, nil
+		}
+		return nil, &CompletionCodeError{NetFn: netFn, Command: command, Code: 0xC1}
+	}
+
+	accounts, err := readUsers(send)
+	require.NoError(t, err)
+	require.Equal(t, []api.BMCAccount{
+		{BMCUser: api.BMCUser{Name: "root", Id: "2", ChannelNumber: 1}, Role: api.BMCRoleAdministrator, Enabled: true},
+		{BMCUser: api.BMCUser{Name: "metal", Id: "4", ChannelNumber: 1}, Role: api.BMCRoleOperator},
+		{BMCUser: api.BMCUser{Name: "monitor", Id: "5", ChannelNumber: 1}, Role: api.BMCRoleReadOnly, Enabled: true},
+	}, accounts)
+}
+
+func TestCreatePasswordRetriesWithNewPassword(t *testing.T) {
+	var passwords []string
+	u := userManager{
+		run: func(_ context.Context, args ...string) (string, error) {
+			passwords = append(passwords, args[len(args)-1])
+			if len(passwords) == 1 {
+				return "", errors.New("password rejected")
+			}
+			return "", nil
+		},
+		log: logger.NewSlog(slog.New(slog.DiscardHandler)),
+	}
+	user := api.BMCUser{Name: "metal", Id: "2"}
+
+	passwd, err := userPassword(user, "", api.VendorSupermicro.PasswordConstraints())
+	require.NoError(t, err)
+	created, err := u.createPassword(t.Context(), user.Name, user.Id, passwd)
+	require.NoError(t, err)
+	require.Len(t, passwords, 2)
+	require.NotEqual(t, passwords[0], passwords[1])
+	require.Equal(t, passwords[1], created)
+
+	// a given password which violates the policy is rejected before the bmc is touched
+	_, err = userPassword(user, "short", api.VendorSupermicro.PasswordConstraints())
+	var policyErr *api.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+}
+
+func TestPasswordPolicyCheckedBeforeBMC(t *testing.T) {
+	u := userManager{
+		run: func(_ context.Context, args ...string) (string, error) {
+			t.Fatalf("bmc must not be touched, got %v", args)
+			return "", nil
+		},
+		log: logger.NewSlog(slog.New(slog.DiscardHandler)),
+	}
+	user := api.BMCUser{Name: "metal", Id: "2"}
+	constraints := api.VendorSupermicro.PasswordConstraints()
+
+	var policyErr *api.PasswordPolicyError
+	_, err := u.createUserRaw(t.Context(), user, api.AdministratorPrivilege, "short", constraints)
+	require.ErrorAs(t, err, &policyErr)
+
+	err = u.changePasswordRaw(t.Context(), user, "short", constraints)
+	require.ErrorAs(t, err, &policyErr)
+}
@@ -1,6 +1,11 @@
 package outband
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/metal-stack/go-hal"
 	"github.com/metal-stack/go-hal/internal/ipmi"
 	"github.com/metal-stack/go-hal/internal/redfish"
 	"github.com/metal-stack/go-hal/pkg/api"
@@ -37,7 +42,7 @@ func ViaRedfishPlusSSH(r *redfish.APIClient, board *api.Board, user, password, i
 	}
 }
 
-// New returns an out-band connection that uses the given redfish client and ipmitool as well as a goipmi client
+// New returns an out-band connection that uses the given redfish client and ipmitool as well as a lanplus client
 func New(r *redfish.APIClient, ipmiTool ipmi.IpmiTool, board *api.Board, ip string, ipmiPort int, user, password string) *OutBand {
 	return &OutBand{
 		Redfish:  r,
@@ -50,7 +55,7 @@ func New(r *redfish.APIClient, ipmiTool ipmi.IpmiTool, board *api.Board, ip stri
 	}
 }
 
-// ViaGoipmi returns an out-band connection that uses a goipmi client
+// ViaGoipmi returns an out-band connection that uses a lanplus client only
 func ViaGoipmi(board *api.Board, ip string, ipmiPort int, user, password string) *OutBand {
 	return &OutBand{
 		board:    board,
@@ -69,15 +74,17 @@ func (ob *OutBand) IPMIConnection() (string, int, string, string) {
 	return ob.ip, ob.ipmiPort, ob.user, ob.password
 }
 
-func (ob *OutBand) Goipmi(f func(*ipmi.Client) error) error {
-	client, err := ipmi.OpenClientConnection(ob.IPMIConnection())
+// Goipmi opens a lanplus session and passes the client to f, the session is closed once f returned.
+// ctx bounds the session establishment and is meant to be passed to the client within f.
+func (ob *OutBand) Goipmi(ctx context.Context, f func(*ipmi.Client) error) error {
+	ip, port, user, password := ob.IPMIConnection()
+	client, err := ipmi.OpenClientConnection(ctx, ip, port, user, password)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		_ = client.Close()
 	}()
-
 	return f(client)
 }
 
@@ -96,3 +103,330 @@ func (ob *OutBand) GetIP() string {
 func (ob *OutBand) GetSSHPort() int {
 	return ob.sshPort
 }
+
+// Events streams the events of the BMC via Redfish
+func (ob *OutBand) Events(ctx context.Context) (<-chan hal.Event, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.Events(ctx)
+}
+
+// EventSubscriptions lists the Redfish event subscriptions of the BMC
+func (ob *OutBand) EventSubscriptions(ctx context.Context) ([]hal.EventSubscription, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.EventSubscriptions(ctx)
+}
+
+// CreateEventSubscription creates a Redfish event subscription
+func (ob *OutBand) CreateEventSubscription(ctx context.Context, s hal.EventSubscription) (string, error) {
+	if ob.Redfish == nil {
+		return "", hal.ErrNotSupported
+	}
+	return ob.Redfish.CreateEventSubscription(ctx, s)
+}
+
+// DeleteEventSubscription deletes a Redfish event subscription
+func (ob *OutBand) DeleteEventSubscription(ctx context.Context, id string) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.DeleteEventSubscription(ctx, id)
+}
+
+// VirtualMedia lists the virtual media slots of the BMC via Redfish
+func (ob *OutBand) VirtualMedia(ctx context.Context) ([]hal.VirtualMedia, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.VirtualMedia(ctx)
+}
+
+// InsertVirtualMedia inserts the image with the standard Redfish InsertMedia action
+func (ob *OutBand) InsertVirtualMedia(ctx context.Context, id string, image hal.VirtualMediaImage) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.InsertVirtualMedia(ctx, id, image)
+}
+
+// EjectVirtualMedia ejects the image with the standard Redfish EjectMedia action
+func (ob *OutBand) EjectVirtualMedia(ctx context.Context, id string) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.EjectVirtualMedia(ctx, id)
+}
+
+// BIOSAttributes reads the BIOS attributes and their attribute registry via Redfish
+func (ob *OutBand) BIOSAttributes(ctx context.Context) ([]hal.BIOSAttribute, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.BIOSAttributes(ctx)
+}
+
+// SetBIOSAttributes stages the BIOS attributes in the Redfish settings object of the Bios resource
+func (ob *OutBand) SetBIOSAttributes(ctx context.Context, attributes map[string]any, applyTime hal.ApplyTime) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.SetBIOSAttributes(ctx, attributes, applyTime)
+}
+
+// BIOSDrift compares the profile, keyed by Redfish attribute name, with the applied BIOS attributes
+func (ob *OutBand) BIOSDrift(ctx context.Context, profile hal.BIOSProfile) ([]hal.BIOSSettingDrift, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.BIOSDrift(ctx, profile.SettingsFor(ob.board.Model))
+}
+
+// ApplyBIOSProfile stages the BIOS attributes which differ from the profile via Redfish
+func (ob *OutBand) ApplyBIOSProfile(ctx context.Context, profile hal.BIOSProfile) (bool, error) {
+	if ob.Redfish == nil {
+		return false, hal.ErrNotSupported
+	}
+	return ob.Redfish.ApplyBIOSSettings(ctx, profile.SettingsFor(ob.board.Model))
+}
+
+// SetBootOverride sets the boot source override via Redfish and falls back to IPMI if the BMC has no Redfish API
+func (ob *OutBand) SetBootOverride(ctx context.Context, override hal.BootOverride) error {
+	if ob.Redfish != nil {
+		return ob.Redfish.SetBootTargetOverride(ctx, override)
+	}
+	if ob.ipmiPort == 0 {
+		return hal.ErrNotSupported
+	}
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetBootOverride(ctx, override, ob.board.Vendor)
+	})
+}
+
+// BootOverride reads the boot source override via Redfish and falls back to the IPMI boot flags if the BMC has no Redfish API
+func (ob *OutBand) BootOverride(ctx context.Context) (*hal.BootOverride, error) {
+	if ob.Redfish != nil {
+		return ob.Redfish.BootOverride(ctx)
+	}
+	if ob.ipmiPort == 0 {
+		return nil, hal.ErrNotSupported
+	}
+	var override *hal.BootOverride
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		override, err = client.BootOverride(ctx, ob.board.Vendor)
+		return err
+	})
+	return override, err
+}
+
+// BootConfiguration reads the boot configuration via Redfish and falls back to the IPMI boot flags if the BMC has no Redfish API,
+// IPMI does not expose the boot options
+func (ob *OutBand) BootConfiguration(ctx context.Context) (*hal.BootConfiguration, error) {
+	if ob.Redfish != nil {
+		return ob.Redfish.BootConfiguration(ctx)
+	}
+	override, err := ob.BootOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hal.BootConfiguration{Override: *override, Mode: override.Mode}, nil
+}
+
+// SEL reads the system event log via Redfish and falls back to IPMI if the BMC has no SEL log service
+func (ob *OutBand) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	if ob.Redfish != nil {
+		entries, err := ob.Redfish.SEL(ctx, after)
+		if !errors.Is(err, hal.ErrNotSupported) {
+			return entries, err
+		}
+	}
+	if ob.ipmiPort == 0 {
+		return nil, hal.ErrNotSupported
+	}
+	var entries []hal.SELEntry
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		entries, err = client.SEL(ctx, after)
+		return err
+	})
+	return entries, err
+}
+
+// ClearSEL clears the system event log via Redfish and falls back to IPMI if the BMC has no SEL log service
+func (ob *OutBand) ClearSEL(ctx context.Context) error {
+	if ob.Redfish != nil {
+		err := ob.Redfish.ClearSEL(ctx)
+		if !errors.Is(err, hal.ErrNotSupported) {
+			return err
+		}
+	}
+	if ob.ipmiPort == 0 {
+		return hal.ErrNotSupported
+	}
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.ClearSEL(ctx)
+	})
+}
+
+// Sensors reads the sensors via Redfish and falls back to the IPMI SDR if the BMC does not expose them
+func (ob *OutBand) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	if ob.Redfish != nil {
+		sensors, err := ob.Redfish.Sensors(ctx)
+		if !errors.Is(err, hal.ErrNotSupported) {
+			return sensors, err
+		}
+	}
+	if ob.ipmiPort == 0 {
+		return nil, hal.ErrNotSupported
+	}
+	var sensors []hal.Sensor
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		sensors, err = client.Sensors(ctx)
+		return err
+	})
+	return sensors, err
+}
+
+// FRUs reads the FRU inventory via IPMI, Redfish offers no equivalent
+func (ob *OutBand) FRUs(ctx context.Context) ([]api.FRU, error) {
+	if ob.ipmiPort == 0 {
+		return nil, hal.ErrNotSupported
+	}
+	var frus []api.FRU
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		frus, err = client.FRUs(ctx)
+		return err
+	})
+	return frus, err
+}
+
+// LanConfig reads the network configuration of the BMC via Redfish and falls back to IPMI if the BMC does not expose its ethernet interface
+func (ob *OutBand) LanConfig(ctx context.Context) (*api.BMCLanConfig, error) {
+	if ob.Redfish != nil {
+		config, err := ob.Redfish.LanConfig(ctx)
+		if !errors.Is(err, hal.ErrNotSupported) {
+			return config, err
+		}
+	}
+	if ob.ipmiPort == 0 {
+		return nil, hal.ErrNotSupported
+	}
+	var config *api.BMCLanConfig
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		config, err = client.LanConfig(ctx, ob.board.Vendor)
+		return err
+	})
+	return config, err
+}
+
+// SetLanConfig writes the network configuration of the BMC via Redfish and falls back to IPMI,
+// which is also used for the port selection because Redfish does not offer it
+func (ob *OutBand) SetLanConfig(ctx context.Context, config api.BMCLanConfig) error {
+	if ob.Redfish != nil && config.NIC == "" {
+		err := setLanConfig(config, func() (*api.BMCLanConfig, error) {
+			return ob.Redfish.LanConfig(ctx)
+		}, func(c api.BMCLanConfig) error {
+			return ob.Redfish.SetLanConfig(ctx, c)
+		})
+		if !errors.Is(err, hal.ErrNotSupported) {
+			return err
+		}
+	}
+	if ob.ipmiPort == 0 {
+		return hal.ErrNotSupported
+	}
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return setLanConfig(config, func() (*api.BMCLanConfig, error) {
+			return client.LanConfig(ctx, ob.board.Vendor)
+		}, func(c api.BMCLanConfig) error {
+			return client.SetLanConfig(ctx, ob.board.Vendor, c)
+		})
+	})
+}
+
+// setLanConfig writes the configuration and verifies it by reading it back.
+// Changes which cut off the connection itself can only be verified through a new connection and are not read back.
+func setLanConfig(config api.BMCLanConfig, read func() (*api.BMCLanConfig, error), write func(api.BMCLanConfig) error) error {
+	current, err := read()
+	if err != nil {
+		return err
+	}
+	err = write(config)
+	if err != nil {
+		return err
+	}
+	if config.IPSource != current.IPSource ||
+		config.IPSource == api.BMCIPSourceStatic && config.IP != current.IP ||
+		config.VLANID != current.VLANID ||
+		config.NIC != "" && config.NIC != current.NIC {
+		return nil
+	}
+	actual, err := read()
+	if err != nil {
+		return fmt.Errorf("unable to read back the lan config %w", err)
+	}
+	return config.Verify(actual)
+}
+
+// ListUsers lists the accounts of the BMC via the Redfish account service
+func (ob *OutBand) ListUsers(ctx context.Context) ([]api.BMCAccount, error) {
+	if ob.Redfish == nil {
+		return nil, hal.ErrNotSupported
+	}
+	return ob.Redfish.ListUsers(ctx)
+}
+
+// CreateUser creates an enabled account with the given role and password via the Redfish account service
+func (ob *OutBand) CreateUser(ctx context.Context, user api.BMCUser, role api.BMCRole, password string) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.CreateUser(ctx, user, role, password)
+}
+
+// DeleteUser deletes the account via the Redfish account service
+func (ob *OutBand) DeleteUser(ctx context.Context, user api.BMCUser) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.DeleteUser(ctx, user)
+}
+
+// ChangePassword changes the password of the account via the Redfish account service,
+// the connection keeps working if it is the account the connection itself uses
+func (ob *OutBand) ChangePassword(ctx context.Context, user api.BMCUser, newPassword string) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	err := ob.Redfish.ChangePassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+	// the redfish client logged in again if the password of its own user changed, ipmi must use it as well
+	if u, p := ob.Redfish.Credentials(); u == ob.user {
+		ob.password = p
+	}
+	return nil
+}
+
+// SetUserRole changes the role of the account via the Redfish account service
+func (ob *OutBand) SetUserRole(ctx context.Context, user api.BMCUser, role api.BMCRole) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.SetUserRole(ctx, user, role)
+}
+
+// SetUserEnabled enables or disables the account via the Redfish account service
+func (ob *OutBand) SetUserEnabled(ctx context.Context, user api.BMCUser, enabled bool) error {
+	if ob.Redfish == nil {
+		return hal.ErrNotSupported
+	}
+	return ob.Redfish.SetUserEnabled(ctx, user, enabled)
+}
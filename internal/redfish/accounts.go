@@ -0,0 +1,209 @@
+package redfish
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+type accountRequest struct {
+	UserName string      `json:"UserName,omitempty"`
+	Password string      `json:"Password,omitempty"`
+	RoleID   api.BMCRole `json:"RoleId,omitempty"`
+	Enabled  *bool       `json:"Enabled,omitempty"`
+}
+
+// reservedAccountID is the slot of the anonymous IPMI user, BMCs with fixed account slots do not allow to use it
+const reservedAccountID = "1"
+
+// accounts returns the path of the accounts collection and the accounts of the account service
+func (c *APIClient) accounts(ctx context.Context) (string, []*schemas.ManagerAccount, error) {
+	g := c.session().WithContext(ctx)
+	as, err := g.Service.AccountService()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to query account service: %w", err)
+	}
+	if as.ODataID == "" {
+		return "", nil, hal.ErrNotSupported
+	}
+	var service struct {
+		Accounts struct {
+			ODataID string `json:"@odata.id"`
+		}
+	}
+	_, err = c.GetJSON(ctx, as.ODataID, &service)
+	if err != nil {
+		return "", nil, err
+	}
+	accounts, err := as.Accounts()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to query accounts: %w", err)
+	}
+	// accounts are fetched concurrently, numeric ids are ordered by their length first
+	slices.SortFunc(accounts, func(a, b *schemas.ManagerAccount) int {
+		return cmp.Or(cmp.Compare(len(a.ID), len(b.ID)), cmp.Compare(a.ID, b.ID))
+	})
+	return service.Accounts.ODataID, accounts, nil
+}
+
+// account returns the account with the id of the user, or with its name if the id is empty
+func (c *APIClient) account(ctx context.Context, user api.BMCUser) (*schemas.ManagerAccount, error) {
+	_, accounts, err := c.accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accounts {
+		if user.Id != "" && a.ID == user.Id || user.Id == "" && a.UserName == user.Name {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("account of user %s with id %s not found", user.Name, user.Id)
+}
+
+// ListUsers returns the accounts of the account service, empty slots of BMCs with fixed slots are omitted
+func (c *APIClient) ListUsers(ctx context.Context) ([]api.BMCAccount, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	_, accounts, err := c.accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []api.BMCAccount
+	for _, a := range accounts {
+		if a.UserName == "" {
+			continue
+		}
+		result = append(result, api.BMCAccount{
+			BMCUser: api.BMCUser{Name: a.UserName, Id: a.ID},
+			Role:    api.BMCRole(a.RoleID),
+			Enabled: a.Enabled,
+			Locked:  a.Locked,
+		})
+	}
+	return result, nil
+}
+
+// CreateUser posts a new account to the accounts collection.
+// BMCs with fixed account slots, e.g. iDRAC, reject that and the first empty slot is used instead.
+func (c *APIClient) CreateUser(ctx context.Context, user api.BMCUser, role api.BMCRole, password string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	path, accounts, err := c.accounts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.UserName == user.Name {
+			return fmt.Errorf("account of user %s already exists with id %s", user.Name, a.ID)
+		}
+	}
+	enabled := true
+	payload := accountRequest{UserName: user.Name, Password: password, RoleID: role, Enabled: &enabled}
+
+	err = c.PostJSON(ctx, path, payload, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed {
+		return err
+	}
+	for _, a := range accounts {
+		if a.UserName == "" && a.ID != reservedAccountID {
+			return c.PatchWithETag(ctx, a.ODataID, payload)
+		}
+	}
+	return fmt.Errorf("no free account slot for user %s", user.Name)
+}
+
+// DeleteUser deletes the account, the slot is emptied on BMCs with fixed account slots
+func (c *APIClient) DeleteUser(ctx context.Context, user api.BMCUser) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	a, err := c.account(ctx, user)
+	if err != nil {
+		return err
+	}
+	err = c.Delete(ctx, a.ODataID)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusMethodNotAllowed {
+		return err
+	}
+	// the user name cannot be omitted as empty string, fixed slots are cleared with a raw payload
+	return c.PatchWithETag(ctx, a.ODataID, map[string]any{"UserName": "", "Enabled": false})
+}
+
+// ChangePassword sets the password of the account.
+// If it is the account of the client itself, the client logs in again with the new password because
+// the BMC rejects the previous credentials and might terminate the current session.
+func (c *APIClient) ChangePassword(ctx context.Context, user api.BMCUser, newPassword string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	a, err := c.account(ctx, user)
+	if err != nil {
+		return err
+	}
+	err = c.PatchWithETag(ctx, a.ODataID, accountRequest{Password: newPassword})
+	if err != nil || a.UserName != c.user {
+		return err
+	}
+	return c.login(ctx, newPassword)
+}
+
+// Credentials returns the user and the password the client is logged in with
+func (c *APIClient) Credentials() (string, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.user, c.password
+}
+
+// login replaces the session of the client by a new one with the given password of its user,
+// the previous session is logged out once it is not handed out anymore.
+func (c *APIClient) login(ctx context.Context, password string) error {
+	client, err := gofish.ConnectContext(ctx, gofish.ClientConfig{
+		Endpoint: c.endpoint,
+		Username: c.user,
+		Password: password,
+		Insecure: c.insecure,
+	})
+	if err != nil {
+		return fmt.Errorf("password of user %s changed but unable to log in again %w", c.user, err)
+	}
+	c.mu.Lock()
+	previous := c.client
+	c.client = client
+	c.Client = client.HTTPClient
+	c.password = password
+	c.basicAuth = base64.StdEncoding.EncodeToString([]byte(c.user + ":" + password))
+	c.mu.Unlock()
+
+	previous.Logout()
+	return nil
+}
+
+// SetUserRole sets the role of the account
+func (c *APIClient) SetUserRole(ctx context.Context, user api.BMCUser, role api.BMCRole) error {
+	return c.patchAccount(ctx, user, accountRequest{RoleID: role})
+}
+
+// SetUserEnabled enables or disables the account
+func (c *APIClient) SetUserEnabled(ctx context.Context, user api.BMCUser, enabled bool) error {
+	return c.patchAccount(ctx, user, accountRequest{Enabled: &enabled})
+}
+
+func (c *APIClient) patchAccount(ctx context.Context, user api.BMCUser, payload accountRequest) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	a, err := c.account(ctx, user)
+	if err != nil {
+		return err
+	}
+	return c.PatchWithETag(ctx, a.ODataID, payload)
+}
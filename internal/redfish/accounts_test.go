@@ -0,0 +1,156 @@
+package redfish_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_ListUsers(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/accounts.json")
+
+	users, err := c.ListUsers(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []api.BMCAccount{
+		{BMCUser: api.BMCUser{Name: "root", Id: "2"}, Role: api.BMCRoleAdministrator, Enabled: true},
+		{BMCUser: api.BMCUser{Name: "metal", Id: "3"}, Role: api.BMCRoleReadOnly, Locked: true},
+	}, users)
+}
+
+func TestAPIClient_CreateUser(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/accounts.json")
+
+	err := c.CreateUser(t.Context(), api.BMCUser{Name: "metal"}, api.BMCRoleAdministrator, "secret")
+	require.EqualError(t, err, "account of user metal already exists with id 3")
+
+	err = c.CreateUser(t.Context(), api.BMCUser{Name: "new"}, api.BMCRoleOperator, "secret")
+	require.NoError(t, err)
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "/redfish/v1/AccountService/Accounts", requests[0].Path)
+	require.JSONEq(t, `{"UserName": "new", "Password": "secret", "RoleId": "Operator", "Enabled": true}`, string(requests[0].Body))
+
+	// fixed account slots, the first empty slot after the reserved one is used
+	srv.Handle(http.MethodPost, "/redfish/v1/AccountService/Accounts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	err = c.CreateUser(t.Context(), api.BMCUser{Name: "new"}, api.BMCRoleOperator, "secret")
+	require.NoError(t, err)
+	requests = srv.Requests()
+	require.Len(t, requests, 3)
+	require.Equal(t, http.MethodPatch, requests[2].Method)
+	require.Equal(t, "/redfish/v1/AccountService/Accounts/4", requests[2].Path)
+	require.Equal(t, `"a4"`, requests[2].Header.Get("If-Match"))
+	require.JSONEq(t, `{"UserName": "new", "Password": "secret", "RoleId": "Operator", "Enabled": true}`, string(requests[2].Body))
+}
+
+func TestAPIClient_DeleteUser(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/accounts.json")
+
+	err := c.DeleteUser(t.Context(), api.BMCUser{Name: "metal"})
+	require.NoError(t, err)
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, http.MethodDelete, requests[0].Method)
+	require.Equal(t, "/redfish/v1/AccountService/Accounts/3", requests[0].Path)
+
+	srv.Handle(http.MethodDelete, "/redfish/v1/AccountService/Accounts/3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	err = c.DeleteUser(t.Context(), api.BMCUser{Id: "3"})
+	require.NoError(t, err)
+	requests = srv.Requests()
+	require.Len(t, requests, 3)
+	require.Equal(t, http.MethodPatch, requests[2].Method)
+	require.JSONEq(t, `{"UserName": "", "Enabled": false}`, string(requests[2].Body))
+
+	err = c.DeleteUser(t.Context(), api.BMCUser{Name: "unknown"})
+	require.EqualError(t, err, "account of user unknown with id  not found")
+}
+
+func TestAPIClient_PatchUser(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/accounts.json")
+	user := api.BMCUser{Name: "root", Id: "2"}
+
+	require.NoError(t, c.ChangePassword(t.Context(), user, "new-secret"))
+	require.NoError(t, c.SetUserRole(t.Context(), user, api.BMCRoleOperator))
+	require.NoError(t, c.SetUserEnabled(t.Context(), user, false))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 3)
+	for _, r := range requests {
+		require.Equal(t, http.MethodPatch, r.Method)
+		require.Equal(t, "/redfish/v1/AccountService/Accounts/2", r.Path)
+		require.Equal(t, `"a2"`, r.Header.Get("If-Match"))
+	}
+	require.JSONEq(t, `{"Password": "new-secret"}`, string(requests[0].Body))
+	require.JSONEq(t, `{"RoleId": "Operator"}`, string(requests[1].Body))
+	require.JSONEq(t, `{"Enabled": false}`, string(requests[2].Body))
+}
+
+func TestAPIClient_ChangeOwnPasswordWhileStreamingEvents(t *testing.T) {
+	srv := redfishtest.NewServer(t, "testdata/accounts.json")
+	srv.SetResource("/redfish/v1", map[string]any{
+		"@odata.id":      "/redfish/v1",
+		"@odata.type":    "#ServiceRoot.v1_15_0.ServiceRoot",
+		"Id":             "RootService",
+		"AccountService": map[string]any{"@odata.id": "/redfish/v1/AccountService"},
+		"EventService":   map[string]any{"@odata.id": "/redfish/v1/EventService"},
+		"SessionService": map[string]any{"@odata.id": "/redfish/v1/SessionService"},
+		"Links":          map[string]any{"Sessions": map[string]any{"@odata.id": "/redfish/v1/SessionService/Sessions"}},
+	})
+	srv.SetResource("/redfish/v1/EventService", map[string]any{
+		"@odata.id":          "/redfish/v1/EventService",
+		"@odata.type":        "#EventService.v1_7_0.EventService",
+		"Id":                 "EventService",
+		"ServiceEnabled":     true,
+		"ServerSentEventUri": "/redfish/v1/EventService/SSE",
+	})
+
+	// the bmc closes the stream right away, the client reconnects with the credentials of the current session
+	passwords := make(chan string, 1)
+	srv.Handle(http.MethodGet, "/redfish/v1/EventService/SSE", func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+		select {
+		case passwords <- password:
+		default:
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "retry: 1\n\n")
+	})
+
+	c, err := redfish.New(t.Context(), srv.URL, "root", "old", true, redfishtest.Logger(), nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	events, err := c.Events(ctx)
+	require.NoError(t, err)
+
+	user := api.BMCUser{Name: "root", Id: "2"}
+	for i := range 5 {
+		require.NoError(t, c.ChangePassword(t.Context(), user, fmt.Sprintf("new-%d", i)))
+	}
+	_, password := c.Credentials()
+	require.Equal(t, "new-4", password)
+
+	require.Eventually(t, func() bool {
+		select {
+		case password := <-passwords:
+			return password == "new-4"
+		default:
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	for range events {
+	}
+}
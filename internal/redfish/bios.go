@@ -0,0 +1,407 @@
+package redfish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/stmcginnis/gofish/schemas"
+)
+
+const registries = "/redfish/v1/Registries"
+
+var (
+	applyTimes = map[hal.ApplyTime]schemas.SettingsApplyTime{
+		hal.ApplyTimeOnReset:                  schemas.OnResetSettingsApplyTime,
+		hal.ApplyTimeImmediate:                schemas.ImmediateSettingsApplyTime,
+		hal.ApplyTimeAtMaintenanceWindowStart: schemas.AtMaintenanceWindowStartSettingsApplyTime,
+	}
+	biosAttributeTypes = map[schemas.AttributeType]hal.BIOSAttributeType{
+		schemas.EnumerationAttributeType: hal.BIOSAttributeTypeEnumeration,
+		schemas.StringAttributeType:      hal.BIOSAttributeTypeString,
+		schemas.IntegerAttributeType:     hal.BIOSAttributeTypeInteger,
+		schemas.BooleanAttributeType:     hal.BIOSAttributeTypeBoolean,
+		schemas.PasswordAttributeType:    hal.BIOSAttributeTypePassword,
+	}
+)
+
+type (
+	odataID struct {
+		ODataID string `json:"@odata.id"`
+	}
+	// bios is the part of the Bios resource and its settings object which is required to stage settings
+	bios struct {
+		AttributeRegistry string         `json:"AttributeRegistry"`
+		Attributes        map[string]any `json:"Attributes"`
+		Settings          struct {
+			SettingsObject      odataID                     `json:"SettingsObject"`
+			SupportedApplyTimes []schemas.SettingsApplyTime `json:"SupportedApplyTimes"`
+		} `json:"@Redfish.Settings"`
+	}
+	biosSettingsRequest struct {
+		Attributes map[string]any     `json:"Attributes"`
+		ApplyTime  *settingsApplyTime `json:"@Redfish.SettingsApplyTime,omitempty"`
+	}
+	settingsApplyTime struct {
+		ApplyTime schemas.SettingsApplyTime `json:"ApplyTime"`
+	}
+	registryCollection struct {
+		Members []odataID `json:"Members"`
+	}
+	registryFile struct {
+		ID       string `json:"Id"`
+		Registry string `json:"Registry"`
+		Location []struct {
+			URI string `json:"Uri"`
+		} `json:"Location"`
+	}
+	attributeRegistry struct {
+		RegistryEntries schemas.RegistryEntries `json:"RegistryEntries"`
+	}
+)
+
+// BIOSAttributes returns the attributes of the Bios resource, described by its attribute registry
+// and together with the values which are staged in its settings object
+func (c *APIClient) BIOSAttributes(ctx context.Context) ([]hal.BIOSAttribute, error) {
+	b, settingsPath, err := c.bios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var staged bios
+	_, err = c.GetJSON(ctx, settingsPath, &staged)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read staged bios settings: %w", err)
+	}
+	registry, err := c.attributeRegistry(ctx, b.AttributeRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []hal.BIOSAttribute
+	for name, value := range b.Attributes {
+		attribute := hal.BIOSAttribute{
+			Name:  name,
+			Value: value,
+		}
+		// some BMCs serve all attributes in the settings object, not only the changed ones
+		if pending, ok := staged.Attributes[name]; ok && !reflect.DeepEqual(pending, value) {
+			attribute.PendingValue = pending
+		}
+		if entry, ok := registry[name]; ok {
+			attribute.DisplayName = entry.DisplayName
+			attribute.Type = biosAttributeTypes[entry.Type]
+			for _, v := range entry.Value {
+				attribute.AllowedValues = append(attribute.AllowedValues, v.ValueName)
+			}
+			attribute.LowerBound = entry.LowerBound
+			attribute.UpperBound = entry.UpperBound
+			attribute.ReadOnly = entry.ReadOnly || entry.Immutable
+			attribute.ResetRequired = entry.ResetRequired
+		}
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Name < attributes[j].Name
+	})
+	return attributes, nil
+}
+
+// SetBIOSAttributes validates the attributes against the attribute registry and stages them in the settings object
+// of the Bios resource. The apply time is only sent if the BMC announces it, older firmware rejects the annotation.
+func (c *APIClient) SetBIOSAttributes(ctx context.Context, attributes map[string]any, applyTime hal.ApplyTime) error {
+	b, settingsPath, err := c.bios(ctx)
+	if err != nil {
+		return err
+	}
+	registry, err := c.attributeRegistry(ctx, b.AttributeRegistry)
+	if err != nil {
+		return err
+	}
+	if registry != nil {
+		err = validateBIOSAttributes(registry, attributes)
+		if err != nil {
+			return err
+		}
+	}
+
+	payload := biosSettingsRequest{
+		Attributes: attributes,
+	}
+	at, ok := applyTimes[applyTime]
+	if !ok {
+		return fmt.Errorf("unknown apply time %d", applyTime)
+	}
+	switch {
+	case slices.Contains(b.Settings.SupportedApplyTimes, at):
+		payload.ApplyTime = &settingsApplyTime{ApplyTime: at}
+	case len(b.Settings.SupportedApplyTimes) > 0:
+		return fmt.Errorf("bios settings can not be applied %s, supported are %v: %w", applyTime, b.Settings.SupportedApplyTimes, hal.ErrNotSupported)
+	case applyTime != hal.ApplyTimeOnReset:
+		payload.ApplyTime = &settingsApplyTime{ApplyTime: at}
+	}
+	c.log.Debugw("stage bios settings", "path", settingsPath, "attributes", attributes, "applytime", applyTime)
+	return c.PatchWithETag(ctx, settingsPath, payload)
+}
+
+// bios reads the Bios resource of the system and returns it together with the path of its settings object,
+// where changes must be staged. Firmware which does not link the settings object serves it below /Bios/Settings.
+func (c *APIClient) bios(ctx context.Context) (*bios, string, error) {
+	system, err := c.System(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	biosPath := strings.TrimSuffix(system.ODataID, "/") + "/Bios"
+
+	var b bios
+	_, err = c.GetJSON(ctx, biosPath, &b)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read bios: %w", err)
+	}
+	settingsPath := b.Settings.SettingsObject.ODataID
+	if settingsPath == "" {
+		settingsPath = biosPath + "/Settings"
+	}
+	return &b, settingsPath, nil
+}
+
+// attributeRegistry returns the entries of the registry with the given name by attribute name,
+// nil if the BMC does not serve it
+func (c *APIClient) attributeRegistry(ctx context.Context, name string) (map[string]schemas.Attributes, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var collection registryCollection
+	_, err := c.GetJSON(ctx, registries, &collection)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list registries: %w", err)
+	}
+
+	for _, member := range collection.Members {
+		var file registryFile
+		_, err = c.GetJSON(ctx, member.ODataID, &file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read registry: %w", err)
+		}
+		if file.ID != name && file.Registry != name {
+			continue
+		}
+		for _, location := range file.Location {
+			if location.URI == "" {
+				continue
+			}
+			var registry attributeRegistry
+			_, err = c.GetJSON(ctx, location.URI, &registry)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read attribute registry %s: %w", name, err)
+			}
+			entries := map[string]schemas.Attributes{}
+			for _, a := range registry.RegistryEntries.Attributes {
+				entries[a.AttributeName] = a
+			}
+			return entries, nil
+		}
+	}
+	c.log.Debugw("attribute registry not found", "registry", name)
+	return nil, nil
+}
+
+func isNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// validateBIOSAttributes checks that the attributes exist, are writable and that their values match the registry
+func validateBIOSAttributes(registry map[string]schemas.Attributes, attributes map[string]any) error {
+	var errs []error
+	for name, value := range attributes {
+		entry, ok := registry[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown bios attribute %s", name))
+			continue
+		}
+		if entry.ReadOnly || entry.Immutable {
+			errs = append(errs, fmt.Errorf("bios attribute %s is read only", name))
+			continue
+		}
+		err := validateBIOSAttribute(entry, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %v of bios attribute %s: %w", value, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateBIOSAttribute(entry schemas.Attributes, value any) error {
+	switch entry.Type {
+	case schemas.EnumerationAttributeType:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		for _, v := range entry.Value {
+			if v.ValueName == s {
+				return nil
+			}
+		}
+		var allowed []string
+		for _, v := range entry.Value {
+			allowed = append(allowed, v.ValueName)
+		}
+		return fmt.Errorf("allowed are %v", allowed)
+	case schemas.StringAttributeType, schemas.PasswordAttributeType:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		if entry.MinLength != nil && len(s) < *entry.MinLength {
+			return fmt.Errorf("must be at least %d characters", *entry.MinLength)
+		}
+		if entry.MaxLength != nil && len(s) > *entry.MaxLength {
+			return fmt.Errorf("must be at most %d characters", *entry.MaxLength)
+		}
+	case schemas.IntegerAttributeType:
+		n, ok := toInteger(value)
+		if !ok {
+			return fmt.Errorf("must be an integer")
+		}
+		if entry.LowerBound != nil && (n < 0 || uint64(n) < *entry.LowerBound) {
+			return fmt.Errorf("must be at least %d", *entry.LowerBound)
+		}
+		if entry.UpperBound != nil && n >= 0 && uint64(n) > *entry.UpperBound {
+			return fmt.Errorf("must be at most %d", *entry.UpperBound)
+		}
+	case schemas.BooleanAttributeType:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+	}
+	return nil
+}
+
+func toInteger(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// BIOSDrift compares the settings, keyed by attribute name, with the applied values of the BIOS attributes
+func (c *APIClient) BIOSDrift(ctx context.Context, settings map[string]string) ([]hal.BIOSSettingDrift, error) {
+	attributes, err := c.BIOSAttributes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return biosDrift(attributes, settings), nil
+}
+
+// ApplyBIOSSettings stages the settings which differ from the applied values and are not already pending,
+// they are applied on the next reset. It returns whether a reset is required for the settings to take effect.
+func (c *APIClient) ApplyBIOSSettings(ctx context.Context, settings map[string]string) (bool, error) {
+	attributes, err := c.BIOSAttributes(ctx)
+	if err != nil {
+		return false, err
+	}
+	byName := map[string]hal.BIOSAttribute{}
+	for _, a := range attributes {
+		byName[a.Name] = a
+	}
+
+	drift := biosDrift(attributes, settings)
+	changes := map[string]any{}
+	for _, d := range drift {
+		if d.Missing {
+			return false, fmt.Errorf("bios attribute %s does not exist", d.Path)
+		}
+		a := byName[d.Path]
+		if a.Pending() && formatAttributeValue(a.PendingValue) == d.Desired {
+			continue
+		}
+		value, err := parseAttributeValue(a, d.Desired)
+		if err != nil {
+			return false, fmt.Errorf("invalid value %s of bios attribute %s: %w", d.Desired, d.Path, err)
+		}
+		changes[d.Path] = value
+	}
+	if len(changes) > 0 {
+		err = c.SetBIOSAttributes(ctx, changes, hal.ApplyTimeOnReset)
+		if err != nil {
+			return false, err
+		}
+	}
+	return len(drift) > 0, nil
+}
+
+func biosDrift(attributes []hal.BIOSAttribute, settings map[string]string) []hal.BIOSSettingDrift {
+	byName := map[string]hal.BIOSAttribute{}
+	for _, a := range attributes {
+		byName[a.Name] = a
+	}
+	var drift []hal.BIOSSettingDrift
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		desired := settings[name]
+		a, ok := byName[name]
+		if !ok {
+			drift = append(drift, hal.BIOSSettingDrift{Path: name, Desired: desired, Missing: true})
+			continue
+		}
+		current := formatAttributeValue(a.Value)
+		if current != desired {
+			drift = append(drift, hal.BIOSSettingDrift{Path: name, Current: current, Desired: desired})
+		}
+	}
+	return drift
+}
+
+// formatAttributeValue renders a value as decoded from json the way it is written in a profile
+func formatAttributeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// parseAttributeValue converts the value of a profile to the type of the attribute,
+// the type of the applied value is taken if the attribute registry does not describe the attribute
+func parseAttributeValue(a hal.BIOSAttribute, value string) (any, error) {
+	switch a.Type {
+	case hal.BIOSAttributeTypeInteger:
+		return strconv.ParseInt(value, 10, 64)
+	case hal.BIOSAttributeTypeBoolean:
+		return strconv.ParseBool(value)
+	case hal.BIOSAttributeTypeUnknown:
+		switch a.Value.(type) {
+		case float64:
+			return strconv.ParseFloat(value, 64)
+		case bool:
+			return strconv.ParseBool(value)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,246 @@
+package redfish_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+)
+
+func TestAPIClient_BIOSAttributes(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/bios.json")
+
+	attributes, err := c.BIOSAttributes(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []hal.BIOSAttribute{
+		{
+			Name: "AcPwrRcvryUserDelay", DisplayName: "User Defined Delay (60s to 600s)", Type: hal.BIOSAttributeTypeInteger,
+			Value: float64(60), LowerBound: pointer.Pointer(uint64(60)), UpperBound: pointer.Pointer(uint64(600)), ResetRequired: true,
+		},
+		{
+			Name: "BootMode", DisplayName: "Boot Mode", Type: hal.BIOSAttributeTypeEnumeration,
+			Value: "Uefi", AllowedValues: []string{"Bios", "Uefi"}, ResetRequired: true,
+		},
+		{
+			Name: "NumLock", DisplayName: "Keyboard NumLock", Type: hal.BIOSAttributeTypeBoolean,
+			Value: true, ResetRequired: true,
+		},
+		{
+			Name: "PxeDev1EnDis", Value: "Enabled",
+		},
+		{
+			Name: "SerialComm", DisplayName: "Serial Communication", Type: hal.BIOSAttributeTypeEnumeration,
+			Value: "OnConRedir", AllowedValues: []string{"OnNoConRedir", "OnConRedir", "Off"}, ResetRequired: true,
+		},
+		{
+			Name: "SetupPassword", DisplayName: "Setup Password", Type: hal.BIOSAttributeTypePassword,
+			ResetRequired: true,
+		},
+		{
+			Name: "SriovGlobalEnable", DisplayName: "SR-IOV Global Enable", Type: hal.BIOSAttributeTypeEnumeration,
+			Value: "Disabled", PendingValue: "Enabled", AllowedValues: []string{"Enabled", "Disabled"}, ResetRequired: true,
+		},
+		{
+			Name: "SystemServiceTag", DisplayName: "Service Tag", Type: hal.BIOSAttributeTypeString,
+			Value: "ABC1234", ReadOnly: true,
+		},
+	}, attributes)
+}
+
+func TestAPIClient_SetBIOSAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]any
+		applyTime  hal.ApplyTime
+		applyTimes []string
+		wantBody   string
+		wantErr    string
+		wantErrIs  error
+	}{
+		{
+			name:       "on reset",
+			attributes: map[string]any{"SriovGlobalEnable": "Enabled", "AcPwrRcvryUserDelay": 120, "NumLock": false},
+			applyTime:  hal.ApplyTimeOnReset,
+			wantBody:   `{"Attributes":{"SriovGlobalEnable":"Enabled","AcPwrRcvryUserDelay":120,"NumLock":false},"@Redfish.SettingsApplyTime":{"ApplyTime":"OnReset"}}`,
+		},
+		{
+			name:       "immediate",
+			attributes: map[string]any{"SerialComm": "Off"},
+			applyTime:  hal.ApplyTimeImmediate,
+			wantBody:   `{"Attributes":{"SerialComm":"Off"},"@Redfish.SettingsApplyTime":{"ApplyTime":"Immediate"}}`,
+		},
+		{
+			name:       "maintenance window",
+			attributes: map[string]any{"SerialComm": "Off"},
+			applyTime:  hal.ApplyTimeAtMaintenanceWindowStart,
+			wantBody:   `{"Attributes":{"SerialComm":"Off"},"@Redfish.SettingsApplyTime":{"ApplyTime":"AtMaintenanceWindowStart"}}`,
+		},
+		{
+			name:       "apply time not offered",
+			attributes: map[string]any{"SerialComm": "Off"},
+			applyTime:  hal.ApplyTimeImmediate,
+			applyTimes: []string{"OnReset"},
+			wantErrIs:  hal.ErrNotSupported,
+		},
+		{
+			name:       "unknown attribute",
+			attributes: map[string]any{"HyperThreading": "Enabled"},
+			wantErr:    "unknown bios attribute HyperThreading",
+		},
+		{
+			name:       "read only attribute",
+			attributes: map[string]any{"SystemServiceTag": "XYZ"},
+			wantErr:    "bios attribute SystemServiceTag is read only",
+		},
+		{
+			name:       "value not allowed",
+			attributes: map[string]any{"BootMode": "Legacy"},
+			wantErr:    "invalid value Legacy of bios attribute BootMode: allowed are [Bios Uefi]",
+		},
+		{
+			name:       "integer out of bounds",
+			attributes: map[string]any{"AcPwrRcvryUserDelay": 30},
+			wantErr:    "invalid value 30 of bios attribute AcPwrRcvryUserDelay: must be at least 60",
+		},
+		{
+			name:       "wrong type",
+			attributes: map[string]any{"NumLock": "Enabled"},
+			wantErr:    "invalid value Enabled of bios attribute NumLock: must be a boolean",
+		},
+		{
+			name:       "password too long",
+			attributes: map[string]any{"SetupPassword": "0123456789012345678901234567890123456789"},
+			wantErr:    "must be at most 32 characters",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := redfishtest.NewClient(t, "testdata/bios.json")
+			if tt.applyTimes != nil {
+				srv.SetResource("/redfish/v1/Systems/1/Bios", map[string]any{
+					"@odata.id":         "/redfish/v1/Systems/1/Bios",
+					"AttributeRegistry": "BiosAttributeRegistry.v1_0_3",
+					"@Redfish.Settings": map[string]any{
+						"SettingsObject":      map[string]any{"@odata.id": "/redfish/v1/Systems/1/Bios/Settings"},
+						"SupportedApplyTimes": tt.applyTimes,
+					},
+				})
+			}
+
+			err := c.SetBIOSAttributes(t.Context(), tt.attributes, tt.applyTime)
+			if tt.wantErr != "" || tt.wantErrIs != nil {
+				if tt.wantErr != "" {
+					require.ErrorContains(t, err, tt.wantErr)
+				}
+				if tt.wantErrIs != nil {
+					require.ErrorIs(t, err, tt.wantErrIs)
+				}
+				// nothing must be staged if the attributes are rejected
+				require.Empty(t, srv.Requests())
+				return
+			}
+			require.NoError(t, err)
+
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			require.Equal(t, http.MethodPatch, requests[0].Method)
+			require.Equal(t, "/redfish/v1/Systems/1/Bios/Settings", requests[0].Path)
+			require.JSONEq(t, tt.wantBody, string(requests[0].Body))
+		})
+	}
+}
+
+func TestAPIClient_SetBIOSAttributesWithoutRegistry(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/bios.json")
+	srv.Handle(http.MethodGet, "/redfish/v1/Registries", http.NotFound)
+
+	// attributes are not validated without registry, the BMC has to reject them
+	require.NoError(t, c.SetBIOSAttributes(t.Context(), map[string]any{"HyperThreading": "Enabled"}, hal.ApplyTimeOnReset))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(requests[0].Body, &payload))
+	require.Equal(t, map[string]any{"HyperThreading": "Enabled"}, payload["Attributes"])
+}
+
+func TestAPIClient_BIOSDrift(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/bios.json")
+
+	drift, err := c.BIOSDrift(t.Context(), map[string]string{
+		"BootMode":            "Uefi",
+		"AcPwrRcvryUserDelay": "120",
+		"NumLock":             "true",
+		"SriovGlobalEnable":   "Enabled",
+		"HyperThreading":      "Enabled",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []hal.BIOSSettingDrift{
+		{Path: "AcPwrRcvryUserDelay", Current: "60", Desired: "120"},
+		{Path: "HyperThreading", Desired: "Enabled", Missing: true},
+		{Path: "SriovGlobalEnable", Current: "Disabled", Desired: "Enabled"},
+	}, drift)
+}
+
+func TestAPIClient_ApplyBIOSSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]string
+		wantReboot bool
+		wantBody   string
+		wantErr    string
+	}{
+		{
+			name:     "nothing to change",
+			settings: map[string]string{"BootMode": "Uefi", "NumLock": "true"},
+		},
+		{
+			name:       "only pending changes",
+			settings:   map[string]string{"SriovGlobalEnable": "Enabled"},
+			wantReboot: true,
+		},
+		{
+			name:       "changes are staged with their types",
+			settings:   map[string]string{"BootMode": "Uefi", "AcPwrRcvryUserDelay": "120", "NumLock": "false", "SerialComm": "Off"},
+			wantReboot: true,
+			wantBody:   `{"Attributes":{"AcPwrRcvryUserDelay":120,"NumLock":false,"SerialComm":"Off"},"@Redfish.SettingsApplyTime":{"ApplyTime":"OnReset"}}`,
+		},
+		{
+			name:     "missing attribute",
+			settings: map[string]string{"HyperThreading": "Enabled"},
+			wantErr:  "bios attribute HyperThreading does not exist",
+		},
+		{
+			name:     "invalid value",
+			settings: map[string]string{"NumLock": "on"},
+			wantErr:  "invalid value on of bios attribute NumLock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := redfishtest.NewClient(t, "testdata/bios.json")
+
+			reboot, err := c.ApplyBIOSSettings(t.Context(), tt.settings)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Empty(t, srv.Requests())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReboot, reboot)
+
+			requests := srv.Requests()
+			if tt.wantBody == "" {
+				require.Empty(t, requests)
+				return
+			}
+			require.Len(t, requests, 1)
+			require.JSONEq(t, tt.wantBody, string(requests[0].Body))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package redfish
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+)
+
+var (
+	bootSources = map[hal.BootTarget]schemas.BootSource{
+		hal.BootTargetPXE:       schemas.PxeBootSource,
+		hal.BootTargetDisk:      schemas.HddBootSource,
+		hal.BootTargetBIOS:      schemas.BiosSetupBootSource,
+		hal.BootTargetCD:        schemas.CdBootSource,
+		hal.BootTargetUSB:       schemas.UsbBootSource,
+		hal.BootTargetHTTP:      schemas.UefiHTTPBootSource,
+		hal.BootTargetUEFIShell: schemas.UefiShellBootSource,
+	}
+	bootSourceOverrideModes = map[hal.FirmwareMode]schemas.BootSourceOverrideMode{
+		hal.FirmwareModeLegacy: schemas.LegacyBootSourceOverrideMode,
+		hal.FirmwareModeUEFI:   schemas.UEFIBootSourceOverrideMode,
+	}
+)
+
+// BootSourceOverride returns the boot properties of the system which set the given override,
+// the mode is omitted for FirmwareModeUnknown which leaves it untouched
+func BootSourceOverride(override hal.BootOverride) (schemas.Boot, error) {
+	source, ok := bootSources[override.Target]
+	if !ok {
+		return schemas.Boot{}, fmt.Errorf("unknown boot target:%d", override.Target)
+	}
+	boot := schemas.Boot{
+		BootSourceOverrideEnabled: schemas.OnceBootSourceOverrideEnabled,
+		BootSourceOverrideTarget:  source,
+		BootSourceOverrideMode:    bootSourceOverrideModes[override.Mode],
+	}
+	if override.Persistent {
+		boot.BootSourceOverrideEnabled = schemas.ContinuousBootSourceOverrideEnabled
+	}
+	return boot, nil
+}
+
+// BootOverrideFromBoot returns the override which is set by the boot properties of a system
+func BootOverrideFromBoot(boot schemas.Boot) (*hal.BootOverride, error) {
+	switch boot.BootSourceOverrideEnabled {
+	case schemas.OnceBootSourceOverrideEnabled, schemas.ContinuousBootSourceOverrideEnabled:
+	default:
+		return &hal.BootOverride{}, nil
+	}
+	if boot.BootSourceOverrideTarget == "" || boot.BootSourceOverrideTarget == schemas.NoneBootSource {
+		return &hal.BootOverride{}, nil
+	}
+
+	override := &hal.BootOverride{
+		Persistent: boot.BootSourceOverrideEnabled == schemas.ContinuousBootSourceOverrideEnabled,
+	}
+	for target, source := range bootSources {
+		if source == boot.BootSourceOverrideTarget {
+			override.Target = target
+		}
+	}
+	if override.Target == 0 {
+		return nil, fmt.Errorf("unknown boot source override target:%s", boot.BootSourceOverrideTarget)
+	}
+	for mode, m := range bootSourceOverrideModes {
+		if m == boot.BootSourceOverrideMode {
+			override.Mode = mode
+		}
+	}
+	return override, nil
+}
+
+// BootConfiguration returns the boot override, the boot options in the boot order of the system and the boot mode.
+// Redfish has no property for the current boot mode, the mode of the boot source override is reported instead.
+func (c *APIClient) BootConfiguration(ctx context.Context) (*hal.BootConfiguration, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	// the boot options are queried through the system, it must be bound to this context
+	systems, err := c.session().WithContext(ctx).Service.Systems()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query systems: %w", err)
+	}
+	if len(systems) == 0 {
+		return nil, fmt.Errorf("no system found")
+	}
+	system := systems[0]
+	override, err := BootOverrideFromBoot(system.Boot)
+	if err != nil {
+		return nil, err
+	}
+	options, err := system.BootOptions()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query boot options: %w", err)
+	}
+
+	config := &hal.BootConfiguration{
+		Override: *override,
+		Mode:     hal.FirmwareModeUnknown,
+	}
+	for mode, m := range bootSourceOverrideModes {
+		if m == system.Boot.BootSourceOverrideMode {
+			config.Mode = mode
+		}
+	}
+	for _, option := range orderBootOptions(system.Boot.BootOrder, options) {
+		config.Options = append(config.Options, hal.BootOption{
+			ID:             cmp.Or(option.BootOptionReference, option.ID),
+			DisplayName:    option.DisplayName,
+			UEFIDevicePath: option.UefiDevicePath,
+			Enabled:        option.BootOptionEnabled,
+		})
+	}
+	return config, nil
+}
+
+// orderBootOptions sorts the boot options by the boot order which references them by BootOptionReference,
+// some BMCs use the Id instead. Options which are not part of the boot order follow sorted by Id.
+func orderBootOptions(order []string, options []*schemas.BootOption) []*schemas.BootOption {
+	position := func(option *schemas.BootOption) int {
+		for i, ref := range order {
+			if ref == option.BootOptionReference || ref == option.ID {
+				return i
+			}
+		}
+		return len(order)
+	}
+	sorted := slices.Clone(options)
+	slices.SortStableFunc(sorted, func(a, b *schemas.BootOption) int {
+		return cmp.Or(cmp.Compare(position(a), position(b)), cmp.Compare(a.ID, b.ID))
+	})
+	return sorted
+}
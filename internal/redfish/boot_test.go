@@ -0,0 +1,105 @@
+package redfish_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stmcginnis/gofish/schemas"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+)
+
+func TestBootSourceOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		override hal.BootOverride
+		want     schemas.Boot
+		wantErr  bool
+	}{
+		{
+			name:     "persistent pxe",
+			override: hal.DefaultBootOverride(hal.BootTargetPXE),
+			want:     schemas.Boot{BootSourceOverrideEnabled: "Continuous", BootSourceOverrideMode: "UEFI", BootSourceOverrideTarget: "Pxe"},
+		},
+		{
+			name:     "bios setup",
+			override: hal.DefaultBootOverride(hal.BootTargetBIOS),
+			want:     schemas.Boot{BootSourceOverrideEnabled: "Once", BootSourceOverrideMode: "UEFI", BootSourceOverrideTarget: "BiosSetup"},
+		},
+		{
+			name:     "legacy usb",
+			override: hal.BootOverride{Target: hal.BootTargetUSB, Persistent: true, Mode: hal.FirmwareModeLegacy},
+			want:     schemas.Boot{BootSourceOverrideEnabled: "Continuous", BootSourceOverrideMode: "Legacy", BootSourceOverrideTarget: "Usb"},
+		},
+		{
+			name:     "http boot keeps the mode",
+			override: hal.BootOverride{Target: hal.BootTargetHTTP},
+			want:     schemas.Boot{BootSourceOverrideEnabled: "Once", BootSourceOverrideTarget: "UefiHttp"},
+		},
+		{
+			name:     "unknown target",
+			override: hal.BootOverride{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redfish.BootSourceOverride(tt.override)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBootOverrideFromBoot(t *testing.T) {
+	override, err := redfish.BootOverrideFromBoot(schemas.Boot{BootSourceOverrideEnabled: "Disabled", BootSourceOverrideTarget: "Pxe"})
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{}, override)
+
+	override, err = redfish.BootOverrideFromBoot(schemas.Boot{BootSourceOverrideEnabled: "Continuous", BootSourceOverrideTarget: "UefiShell"})
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{Target: hal.BootTargetUEFIShell, Persistent: true}, override)
+
+	_, err = redfish.BootOverrideFromBoot(schemas.Boot{BootSourceOverrideEnabled: "Once", BootSourceOverrideTarget: "Floppy"})
+	require.Error(t, err)
+}
+
+func TestAPIClient_BootOverride(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/boot.json")
+
+	override, err := c.BootOverride(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootOverride{Target: hal.BootTargetCD, Mode: hal.FirmwareModeLegacy}, override)
+
+	err = c.SetBootTargetOverride(t.Context(), hal.BootOverride{Target: hal.BootTargetDisk, Persistent: true, Mode: hal.FirmwareModeUEFI})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	last := requests[len(requests)-1]
+	require.Equal(t, http.MethodPatch, last.Method)
+	require.Equal(t, "/redfish/v1/Systems/1", last.Path)
+	require.JSONEq(t, `{"Boot":{"BootSourceOverrideEnabled":"Continuous","BootSourceOverrideMode":"UEFI","BootSourceOverrideTarget":"Hdd"}}`, string(last.Body))
+}
+
+func TestAPIClient_BootConfiguration(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/boot.json")
+
+	config, err := c.BootConfiguration(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, &hal.BootConfiguration{
+		Override: hal.BootOverride{Target: hal.BootTargetCD, Mode: hal.FirmwareModeLegacy},
+		Options: []hal.BootOption{
+			{ID: "Boot0003", DisplayName: "debian", UEFIDevicePath: `HD(1,GPT,8F9C4A4D-5C3F-4A0B-8E3F-7F6A1B2C3D4E,0x800,0x100000)/\EFI\debian\grubx64.efi`, Enabled: true},
+			{ID: "Boot0001", DisplayName: "UEFI PXE IPv4 Intel(R) Ethernet Controller X710", UEFIDevicePath: "PciRoot(0x0)/Pci(0x1C,0x0)/Pci(0x0,0x0)/MAC(3CECEF000001,0x1)/IPv4(0.0.0.0)", Enabled: true},
+			{ID: "Boot0002", DisplayName: "UEFI Shell", UEFIDevicePath: "Fv(7CB8BDC9-F8EB-4F34-AAEA-3EE4AF6516A1)/FvFile(7C04A583-9E3E-4F1C-AD65-E05268D0B4D1)"},
+		},
+		Mode: hal.FirmwareModeLegacy,
+	}, config)
+}
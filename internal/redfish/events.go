@@ -0,0 +1,388 @@
+package redfish
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+)
+
+const (
+	// defaultEventContext is sent with the events of subscriptions which were created without a context
+	defaultEventContext = "go-hal"
+
+	sseMinRetry = time.Second
+	sseMaxRetry = time.Minute
+	// seenEvents is the number of event ids which are remembered to drop events the BMC repeats after a reconnect
+	seenEvents = 1024
+)
+
+type (
+	odataLink struct {
+		ODataID string `json:"@odata.id"`
+	}
+	// eventPayload is the Event resource a BMC sends on the SSE stream
+	eventPayload struct {
+		Events []eventRecord `json:"Events"`
+	}
+	eventRecord struct {
+		EventID           string    `json:"EventId"`
+		EventTimestamp    string    `json:"EventTimestamp"`
+		MessageID         string    `json:"MessageId"`
+		Message           string    `json:"Message"`
+		MessageArgs       []string  `json:"MessageArgs"`
+		MessageSeverity   string    `json:"MessageSeverity"`
+		Severity          string    `json:"Severity"`
+		OriginOfCondition odataLink `json:"OriginOfCondition"`
+		LogEntry          odataLink `json:"LogEntry"`
+	}
+
+	// eventStream consumes the SSE stream of the BMC and survives reconnects
+	eventStream struct {
+		c     *APIClient
+		uri   string
+		retry time.Duration
+		// lastID is the id of the last SSE message, sent as Last-Event-ID on reconnect
+		lastID string
+		seen   *seenSet
+	}
+
+	// seenSet remembers the most recent keys, the oldest are forgotten first
+	seenSet struct {
+		keys  []string
+		next  int
+		index map[string]struct{}
+	}
+)
+
+// taskStates maps the messages of the TaskEvent registry to the resulting task state
+var taskStates = map[string]schemas.TaskState{
+	"TaskStarted":          schemas.RunningTaskState,
+	"TaskProgressChanged":  schemas.RunningTaskState,
+	"TaskResumed":          schemas.RunningTaskState,
+	"TaskPaused":           schemas.SuspendedTaskState,
+	"TaskCompletedOK":      schemas.CompletedTaskState,
+	"TaskCompletedWarning": schemas.CompletedTaskState,
+	"TaskAborted":          schemas.ExceptionTaskState,
+	"TaskCancelled":        schemas.CancelledTaskState,
+	"TaskRemoved":          schemas.KilledTaskState,
+}
+
+func (c *APIClient) eventService(ctx context.Context) (*schemas.EventService, error) {
+	g := c.session().WithContext(ctx)
+	es, err := g.Service.EventService()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query event service: %w", err)
+	}
+	if es == nil || !es.ServiceEnabled {
+		return nil, hal.ErrNotSupported
+	}
+	return es, nil
+}
+
+// EventSubscriptions lists the push subscriptions of the event service
+func (c *APIClient) EventSubscriptions(ctx context.Context) ([]hal.EventSubscription, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	es, err := c.eventService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	destinations, err := es.Subscriptions()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query event subscriptions: %w", err)
+	}
+	var subscriptions []hal.EventSubscription
+	for _, d := range destinations {
+		subscriptions = append(subscriptions, hal.EventSubscription{
+			ID:               d.ODataID,
+			Destination:      d.Destination,
+			Context:          d.Context,
+			RegistryPrefixes: d.RegistryPrefixes,
+			ResourceTypes:    d.ResourceTypes,
+		})
+	}
+	return subscriptions, nil
+}
+
+// CreateEventSubscription creates a push subscription and returns its id
+func (c *APIClient) CreateEventSubscription(ctx context.Context, s hal.EventSubscription) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	es, err := c.eventService(ctx)
+	if err != nil {
+		return "", err
+	}
+	eventContext := s.Context
+	if eventContext == "" {
+		eventContext = defaultEventContext
+	}
+	id, err := es.CreateEventSubscriptionInstance(s.Destination, s.RegistryPrefixes, s.ResourceTypes, nil, schemas.RedfishEventDestinationProtocol, eventContext, "", nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create event subscription for %s: %w", s.Destination, err)
+	}
+	return id, nil
+}
+
+// DeleteEventSubscription deletes the push subscription with the given id
+func (c *APIClient) DeleteEventSubscription(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	es, err := c.eventService(ctx)
+	if err != nil {
+		return err
+	}
+	err = es.DeleteEventSubscription(id)
+	if err != nil {
+		return fmt.Errorf("unable to delete event subscription %s: %w", id, err)
+	}
+	return nil
+}
+
+// Events consumes the server-sent events stream of the event service until ctx is done
+func (c *APIClient) Events(ctx context.Context) (<-chan hal.Event, error) {
+	lookupCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	es, err := c.eventService(lookupCtx)
+	if err != nil {
+		return nil, err
+	}
+	if es.ServerSentEventURI == "" {
+		return nil, hal.ErrNotSupported
+	}
+
+	s := &eventStream{
+		c:     c,
+		uri:   es.ServerSentEventURI,
+		retry: sseMinRetry,
+		seen:  newSeenSet(seenEvents),
+	}
+	events := make(chan hal.Event)
+	go s.run(ctx, events)
+	return events, nil
+}
+
+func (s *eventStream) run(ctx context.Context, events chan<- hal.Event) {
+	defer close(events)
+
+	delay := s.retry
+	for {
+		connected, err := s.receive(ctx, events)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = s.retry
+		}
+		s.c.log.Warnw("event stream interrupted, reconnecting", "uri", s.uri, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, sseMaxRetry)
+	}
+}
+
+// receive reads the stream until it is closed and reports whether the connection was established at all
+func (s *eventStream) receive(ctx context.Context, events chan<- hal.Event) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.c.endpoint+s.uri, nil)
+	if err != nil {
+		return false, err
+	}
+	s.c.addHeadersAndAuth(req)
+	req.Header.Set("Accept", "text/event-stream")
+	if s.lastID != "" {
+		req.Header.Set("Last-Event-ID", s.lastID)
+	}
+
+	resp, err := s.c.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return false, &StatusError{Method: req.Method, Path: s.uri, StatusCode: resp.StatusCode}
+	}
+
+	var (
+		id   string
+		data []string
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if id != "" {
+				s.lastID = id
+			}
+			if len(data) > 0 {
+				err = s.dispatch(ctx, id, strings.Join(data, "\n"), events)
+				if err != nil {
+					return true, err
+				}
+			}
+			id, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment, often used as keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			ms, err := strconv.Atoi(value)
+			if err == nil && ms > 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, fmt.Errorf("stream closed by bmc")
+}
+
+// dispatch delivers all events of a SSE message which were not delivered before
+func (s *eventStream) dispatch(ctx context.Context, id, data string, events chan<- hal.Event) error {
+	var payload eventPayload
+	err := json.Unmarshal([]byte(data), &payload)
+	if err != nil {
+		s.c.log.Warnw("ignoring malformed event", "id", id, "error", err)
+		return nil
+	}
+	if len(payload.Events) == 0 {
+		// some BMCs send the event record itself
+		var record eventRecord
+		if json.Unmarshal([]byte(data), &record) == nil && record.MessageID != "" {
+			payload.Events = append(payload.Events, record)
+		}
+	}
+
+	for i, record := range payload.Events {
+		key := record.EventID
+		if key == "" && id != "" {
+			key = id + "/" + strconv.Itoa(i)
+		}
+		if key != "" && !s.seen.add(key) {
+			s.c.log.Debugw("dropping already delivered event", "id", key)
+			continue
+		}
+
+		e := toEvent(record)
+		if e.ID == "" {
+			e.ID = key
+		}
+		select {
+		case events <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// toEvent classifies the event record by its message registry and origin
+func toEvent(r eventRecord) hal.Event {
+	e := hal.Event{
+		ID:          r.EventID,
+		Kind:        hal.EventKindOther,
+		Severity:    r.MessageSeverity,
+		MessageID:   r.MessageID,
+		Message:     r.Message,
+		MessageArgs: r.MessageArgs,
+		Origin:      r.OriginOfCondition.ODataID,
+	}
+	if e.Severity == "" {
+		e.Severity = r.Severity
+	}
+	ts, err := time.Parse(time.RFC3339, r.EventTimestamp)
+	if err == nil {
+		e.Timestamp = ts
+	}
+
+	registry, _, _ := strings.Cut(r.MessageID, ".")
+	message := r.MessageID[strings.LastIndex(r.MessageID, ".")+1:]
+	switch {
+	case registry == "TaskEvent":
+		e.Kind = hal.EventKindTask
+		e.TaskState = string(taskStates[message])
+	case r.LogEntry.ODataID != "" || strings.Contains(e.Origin, "/LogServices/"):
+		e.Kind = hal.EventKindLog
+		e.LogEntry = r.LogEntry.ODataID
+		if e.LogEntry == "" {
+			e.LogEntry = e.Origin
+		}
+	case isPowerStateMessage(message):
+		e.Kind = hal.EventKindPowerState
+		e.PowerState = eventPowerState(message, r.MessageArgs)
+	}
+	return e
+}
+
+// isPowerStateMessage detects the power state messages of the various vendor registries,
+// e.g. ServerPoweredOn of iLO or PowerStateChanged, but not power supply messages
+func isPowerStateMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, s := range []string{"poweredon", "poweredoff", "poweron", "poweroff", "powerstate"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func eventPowerState(message string, args []string) hal.PowerState {
+	message = strings.ToLower(message)
+	switch {
+	case strings.HasSuffix(message, "off"):
+		return hal.PowerOffState
+	case strings.HasSuffix(message, "on"):
+		return hal.PowerOnState
+	}
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "off":
+			return hal.PowerOffState
+		case "on":
+			return hal.PowerOnState
+		}
+	}
+	return hal.PowerUnknownState
+}
+
+func newSeenSet(size int) *seenSet {
+	return &seenSet{
+		keys:  make([]string, size),
+		index: make(map[string]struct{}, size),
+	}
+}
+
+// add remembers the key and returns false if it was already known
+func (s *seenSet) add(key string) bool {
+	if _, ok := s.index[key]; ok {
+		return false
+	}
+	delete(s.index, s.keys[s.next])
+	s.keys[s.next] = key
+	s.index[key] = struct{}{}
+	s.next = (s.next + 1) % len(s.keys)
+	return true
+}
@@ -0,0 +1,160 @@
+package redfish_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	powerOffEvent = `{"@odata.type":"#Event.v1_7_0.Event","Id":"1","Name":"Event","Events":[` +
+		`{"EventId":"101","EventTimestamp":"2026-10-01T08:00:00+00:00","MessageId":"iLOEvents.2.1.ServerPoweredOff","Message":"Server power removed.","MessageSeverity":"OK","OriginOfCondition":{"@odata.id":"/redfish/v1/Systems/1"}},` +
+		`{"EventId":"102","EventTimestamp":"2026-10-01T08:00:01+00:00","MessageId":"EventLog.1.0.NewEntry","Message":"Power Supply 2 lost AC input","MessageSeverity":"Warning","LogEntry":{"@odata.id":"/redfish/v1/Systems/1/LogServices/SEL/Entries/17"}}]}`
+	taskEvent = `{"@odata.type":"#Event.v1_7_0.Event","Id":"2","Name":"Event","Events":[` +
+		`{"EventId":"103","MessageId":"TaskEvent.1.0.TaskCompletedOK","Message":"The task with Id '5' has completed.","MessageArgs":["5"],"MessageSeverity":"OK","OriginOfCondition":{"@odata.id":"/redfish/v1/TaskService/Tasks/5"}}]}`
+)
+
+func TestAPIClient_Events(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/events.json")
+
+	connections := 0
+	lastEventIDs := make(chan string, 2)
+	srv.Handle(http.MethodGet, "/redfish/v1/EventService/SSE", func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch connections {
+		case 1:
+			// the bmc drops the connection right after the first message
+			_, _ = fmt.Fprintf(w, "retry: 10\n: keep-alive\n\nid: 1\ndata: %s\n\n", powerOffEvent)
+		default:
+			// and repeats it after the reconnect
+			_, _ = fmt.Fprintf(w, "id: 1\ndata: %s\n\nid: 2\ndata: %s\n\n", powerOffEvent, taskEvent)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	events, err := c.Events(ctx)
+	require.NoError(t, err)
+
+	var got []hal.Event
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received only %d events", len(got))
+		}
+	}
+
+	require.Equal(t, []hal.Event{
+		{
+			ID:         "101",
+			Kind:       hal.EventKindPowerState,
+			Timestamp:  time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+			Severity:   "OK",
+			MessageID:  "iLOEvents.2.1.ServerPoweredOff",
+			Message:    "Server power removed.",
+			Origin:     "/redfish/v1/Systems/1",
+			PowerState: hal.PowerOffState,
+		},
+		{
+			ID:        "102",
+			Kind:      hal.EventKindLog,
+			Timestamp: time.Date(2026, 10, 1, 8, 0, 1, 0, time.UTC),
+			Severity:  "Warning",
+			MessageID: "EventLog.1.0.NewEntry",
+			Message:   "Power Supply 2 lost AC input",
+			LogEntry:  "/redfish/v1/Systems/1/LogServices/SEL/Entries/17",
+		},
+		{
+			ID:          "103",
+			Kind:        hal.EventKindTask,
+			Severity:    "OK",
+			MessageID:   "TaskEvent.1.0.TaskCompletedOK",
+			Message:     "The task with Id '5' has completed.",
+			MessageArgs: []string{"5"},
+			Origin:      "/redfish/v1/TaskService/Tasks/5",
+			TaskState:   "Completed",
+		},
+	}, normalizeTimestamps(got))
+	require.Empty(t, <-lastEventIDs)
+	require.Equal(t, "1", <-lastEventIDs)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestAPIClient_EventsNotSupported(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/events.json")
+	srv.SetResource("/redfish/v1/EventService", map[string]any{
+		"@odata.id":      "/redfish/v1/EventService",
+		"Id":             "EventService",
+		"ServiceEnabled": true,
+	})
+
+	_, err := c.Events(t.Context())
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+}
+
+func TestAPIClient_EventSubscriptions(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/events.json")
+
+	subscriptions, err := c.EventSubscriptions(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []hal.EventSubscription{
+		{
+			ID:               "/redfish/v1/EventService/Subscriptions/1",
+			Destination:      "https://metal-bmc.example.com/events",
+			Context:          "metal-bmc",
+			RegistryPrefixes: []string{"TaskEvent"},
+			ResourceTypes:    []string{},
+		},
+	}, subscriptions)
+
+	srv.Handle(http.MethodPost, "/redfish/v1/EventService/Subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/redfish/v1/EventService/Subscriptions/2")
+		w.WriteHeader(http.StatusCreated)
+	})
+	id, err := c.CreateEventSubscription(t.Context(), hal.EventSubscription{
+		Destination:   "https://metal-bmc.example.com/events",
+		ResourceTypes: []string{"ComputerSystem"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/redfish/v1/EventService/Subscriptions/2", id)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(requests[0].Body, &payload))
+	require.Equal(t, "https://metal-bmc.example.com/events", payload["Destination"])
+	require.Equal(t, "go-hal", payload["Context"])
+	require.Equal(t, "Redfish", payload["Protocol"])
+	require.Equal(t, []any{"ComputerSystem"}, payload["ResourceTypes"])
+
+	require.NoError(t, c.DeleteEventSubscription(t.Context(), id))
+	requests = srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, http.MethodDelete, requests[1].Method)
+	require.Equal(t, "/redfish/v1/EventService/Subscriptions/2", requests[1].Path)
+}
+
+// normalizeTimestamps makes the parsed timestamps comparable with time.Date
+func normalizeTimestamps(events []hal.Event) []hal.Event {
+	for i := range events {
+		if !events[i].Timestamp.IsZero() {
+			events[i].Timestamp = events[i].Timestamp.UTC()
+		}
+	}
+	return events
+}
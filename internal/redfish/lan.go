@@ -0,0 +1,155 @@
+package redfish
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/api"
+)
+
+type ethernetInterfaceRequest struct {
+	DHCPv4              dhcpv4Request       `json:"DHCPv4"`
+	IPv4StaticAddresses []ipv4StaticAddress `json:"IPv4StaticAddresses,omitempty"`
+	VLAN                vlanRequest         `json:"VLAN"`
+	// IPv6StaticAddresses contains null for every present address which is removed
+	IPv6StaticAddresses []*ipv6StaticAddress `json:"IPv6StaticAddresses"`
+}
+
+type dhcpv4Request struct {
+	DHCPEnabled bool `json:"DHCPEnabled"`
+}
+
+type ipv4StaticAddress struct {
+	Address    string `json:"Address"`
+	SubnetMask string `json:"SubnetMask"`
+	Gateway    string `json:"Gateway"`
+}
+
+type vlanRequest struct {
+	VLANEnable bool   `json:"VLANEnable"`
+	VLANID     uint16 `json:"VLANId,omitempty"`
+}
+
+type ipv6StaticAddress struct {
+	Address      string `json:"Address"`
+	PrefixLength int    `json:"PrefixLength"`
+}
+
+// managerEthernetInterface returns the interface which connects the BMC to the management network.
+// Host interfaces are virtual and skipped, enabled interfaces are preferred.
+func (c *APIClient) managerEthernetInterface(ctx context.Context) (*schemas.EthernetInterface, error) {
+	g := c.session().WithContext(ctx)
+	managers, err := g.Service.Managers()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query managers: %w", err)
+	}
+	var candidates []*schemas.EthernetInterface
+	for _, manager := range managers {
+		interfaces, err := manager.EthernetInterfaces()
+		if err != nil {
+			c.log.Warnw("unable to query ethernet interfaces", "manager", manager.ODataID, "error", err)
+			continue
+		}
+		for _, ei := range interfaces {
+			if ei.EthernetInterfaceType != schemas.VirtualEthernetDeviceType {
+				candidates = append(candidates, ei)
+			}
+		}
+	}
+	for _, ei := range candidates {
+		if ei.InterfaceEnabled {
+			return ei, nil
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], nil
+	}
+	return nil, hal.ErrNotSupported
+}
+
+// LanConfig returns the network configuration of the ethernet interface of the manager, the port selection is not exposed by Redfish
+func (c *APIClient) LanConfig(ctx context.Context) (*api.BMCLanConfig, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	ei, err := c.managerEthernetInterface(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toLanConfig(ei), nil
+}
+
+func toLanConfig(ei *schemas.EthernetInterface) *api.BMCLanConfig {
+	config := &api.BMCLanConfig{
+		IPSource: api.BMCIPSourceStatic,
+		MAC:      ei.MACAddress,
+	}
+	if mac, err := net.ParseMAC(ei.MACAddress); err == nil {
+		config.MAC = mac.String()
+	}
+	if ei.DHCPv4.DHCPEnabled {
+		config.IPSource = api.BMCIPSourceDHCP
+	}
+	if len(ei.IPv4Addresses) > 0 {
+		a := ei.IPv4Addresses[0]
+		// older BMCs do not implement DHCPv4 and only report the origin of the address
+		if a.AddressOrigin == schemas.DHCPIPv4AddressOrigin {
+			config.IPSource = api.BMCIPSourceDHCP
+		}
+		config.IP = a.Address
+		config.Netmask = a.SubnetMask
+		config.Gateway = a.Gateway
+	}
+	if ei.VLAN.VLANEnable {
+		config.VLANID = ei.VLAN.VLANID
+	}
+	for _, a := range ei.IPv6StaticAddresses {
+		addr, err := netip.ParseAddr(a.Address)
+		if err != nil {
+			continue
+		}
+		config.IPv6Addresses = append(config.IPv6Addresses, netip.PrefixFrom(addr, int(a.PrefixLength)).String())
+	}
+	return config
+}
+
+// SetLanConfig patches the ethernet interface of the manager
+func (c *APIClient) SetLanConfig(ctx context.Context, config api.BMCLanConfig) error {
+	if config.NIC != "" {
+		return fmt.Errorf("nic selection via redfish %w", hal.ErrNotSupported)
+	}
+	payload := ethernetInterfaceRequest{
+		DHCPv4:              dhcpv4Request{DHCPEnabled: config.IPSource == api.BMCIPSourceDHCP},
+		VLAN:                vlanRequest{VLANEnable: config.VLANID != 0, VLANID: config.VLANID},
+		IPv6StaticAddresses: []*ipv6StaticAddress{},
+	}
+	switch config.IPSource {
+	case api.BMCIPSourceDHCP:
+	case api.BMCIPSourceStatic:
+		payload.IPv4StaticAddresses = []ipv4StaticAddress{{Address: config.IP, SubnetMask: config.Netmask, Gateway: config.Gateway}}
+	default:
+		return fmt.Errorf("unknown ip source %q", config.IPSource)
+	}
+	for _, a := range config.IPv6Addresses {
+		prefix, err := netip.ParsePrefix(a)
+		if err != nil || !prefix.Addr().Is6() {
+			return fmt.Errorf("invalid ipv6 address %q", a)
+		}
+		payload.IPv6StaticAddresses = append(payload.IPv6StaticAddresses, &ipv6StaticAddress{Address: prefix.Addr().String(), PrefixLength: prefix.Bits()})
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	ei, err := c.managerEthernetInterface(ctx)
+	if err != nil {
+		return err
+	}
+	for len(payload.IPv6StaticAddresses) < len(ei.IPv6StaticAddresses) {
+		payload.IPv6StaticAddresses = append(payload.IPv6StaticAddresses, nil)
+	}
+	return c.PatchWithETag(ctx, ei.ODataID, payload)
+}
@@ -0,0 +1,59 @@
+package redfish_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_LanConfig(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/lan.json")
+
+	config, err := c.LanConfig(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, &api.BMCLanConfig{
+		IPSource:      api.BMCIPSourceStatic,
+		IP:            "10.0.0.42",
+		Netmask:       "255.255.255.0",
+		Gateway:       "10.0.0.1",
+		MAC:           "3c:ec:ef:01:02:03",
+		VLANID:        100,
+		IPv6Addresses: []string{"2001:db8::10/64", "2001:db8::11/64"},
+	}, config)
+}
+
+func TestAPIClient_SetLanConfig(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/lan.json")
+
+	err := c.SetLanConfig(t.Context(), api.BMCLanConfig{
+		IPSource:      api.BMCIPSourceStatic,
+		IP:            "10.0.1.42",
+		Netmask:       "255.255.0.0",
+		Gateway:       "10.0.0.1",
+		IPv6Addresses: []string{"2001:db8::20/56"},
+	})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, http.MethodPatch, requests[0].Method)
+	require.Equal(t, "/redfish/v1/Managers/1/EthernetInterfaces/1", requests[0].Path)
+	require.Equal(t, `"1f3c"`, requests[0].Header.Get("If-Match"))
+	// the second static ipv6 address is removed
+	require.JSONEq(t, `{
+		"DHCPv4": {"DHCPEnabled": false},
+		"IPv4StaticAddresses": [{"Address": "10.0.1.42", "SubnetMask": "255.255.0.0", "Gateway": "10.0.0.1"}],
+		"VLAN": {"VLANEnable": false},
+		"IPv6StaticAddresses": [{"Address": "2001:db8::20", "PrefixLength": 56}, null]
+	}`, string(requests[0].Body))
+
+	err = c.SetLanConfig(t.Context(), api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, NIC: api.BMCNICShared})
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	err = c.SetLanConfig(t.Context(), api.BMCLanConfig{IPSource: api.BMCIPSourceDHCP, IPv6Addresses: []string{"10.0.0.1/24"}})
+	require.EqualError(t, err, `invalid ipv6 address "10.0.0.1/24"`)
+	require.Len(t, srv.Requests(), 1)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/metal-stack/go-hal"
@@ -20,12 +21,17 @@ import (
 )
 
 type APIClient struct {
+	// mu guards the session, it is replaced if the client changes the password of its own user
+	mu     sync.RWMutex
 	client *gofish.APIClient
 	*http.Client
+	password  string
+	basicAuth string
+
+	endpoint          string
 	urlPrefix         string
 	user              string
-	password          string
-	basicAuth         string
+	insecure          bool
 	log               logger.Logger
 	connectionTimeout time.Duration
 }
@@ -44,7 +50,7 @@ type indicatorLEDRequest struct {
 	IndicatorLED schemas.IndicatorLED `json:"IndicatorLED"`
 }
 
-func New(url, user, password string, insecure bool, log logger.Logger, connectionTimeout *time.Duration) (*APIClient, error) {
+func New(ctx context.Context, url, user, password string, insecure bool, log logger.Logger, connectionTimeout *time.Duration) (*APIClient, error) {
 	// Create a new instance of gofish and redfish client, ignoring self-signed certs
 	config := gofish.ClientConfig{
 		Endpoint: url,
@@ -58,7 +64,7 @@ func New(url, user, password string, insecure bool, log logger.Logger, connectio
 		timeout = *connectionTimeout
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	c, err := gofish.ConnectContext(ctx, config)
@@ -71,16 +77,23 @@ func New(url, user, password string, insecure bool, log logger.Logger, connectio
 		user:              user,
 		password:          password,
 		basicAuth:         base64.StdEncoding.EncodeToString([]byte(user + ":" + password)),
+		insecure:          insecure,
+		endpoint:          url,
 		urlPrefix:         fmt.Sprintf("%s/redfish/v1", url),
 		log:               log,
 		connectionTimeout: timeout,
 	}, nil
 }
 
-func (c *APIClient) BoardInfo() (*api.Board, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+// withTimeout bounds the given context by the connection timeout of this client
+func (c *APIClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.connectionTimeout)
+}
+
+func (c *APIClient) BoardInfo(ctx context.Context) (*api.Board, error) {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	// Query the chassis data using the session token
 	if g.Service == nil {
 		return nil, fmt.Errorf("gofish service root is not available most likely due to missing username")
@@ -125,7 +138,7 @@ func (c *APIClient) BoardInfo() (*api.Board, error) {
 			var powerSupplies []api.PowerSupply
 			if err != nil {
 				c.log.Warnw("ignoring power detection", "error", err)
-			} else {
+			} else if power != nil {
 				for _, pc := range power.PowerControl {
 					pm := pc.PowerMetrics
 					if pm.AverageConsumedWatts == nil && pm.IntervalInMin == nil {
@@ -183,10 +196,10 @@ func toMetalLEDState(state schemas.IndicatorLED) string {
 }
 
 // MachineUUID retrieves a unique uuid for this (hardware) machine
-func (c *APIClient) MachineUUID() (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+func (c *APIClient) MachineUUID(ctx context.Context) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		c.log.Errorw("error during system query, unable to detect uuid", "error", err.Error())
@@ -200,10 +213,43 @@ func (c *APIClient) MachineUUID() (string, error) {
 	return "", fmt.Errorf("failed to detect machine UUID")
 }
 
-func (c *APIClient) PowerState() (hal.PowerState, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+// System returns the primary system of the BMC, which is the first one if the BMC manages several
+func (c *APIClient) System(ctx context.Context) (*schemas.ComputerSystem, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	g := c.session().WithContext(ctx)
+	systems, err := g.Service.Systems()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query systems: %w", err)
+	}
+	if len(systems) == 0 {
+		return nil, fmt.Errorf("no system found")
+	}
+	return systems[0], nil
+}
+
+// SerialConsoleSSH returns the ssh serial console service of the first system which exposes one,
+// nil if no system does
+func (c *APIClient) SerialConsoleSSH(ctx context.Context) (*schemas.SerialConsoleProtocol, error) {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
+	systems, err := g.Service.Systems()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query systems: %w", err)
+	}
+	for _, system := range systems {
+		if system.SerialConsole.SSH.ServiceEnabled {
+			return &system.SerialConsole.SSH, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *APIClient) PowerState(ctx context.Context) (hal.PowerState, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		c.log.Warnw("ignore system query", "error", err.Error())
@@ -216,26 +262,31 @@ func (c *APIClient) PowerState() (hal.PowerState, error) {
 	return hal.PowerUnknownState, nil
 }
 
-func (c *APIClient) PowerOn() error {
-	return c.setPower(schemas.ForceOnResetType)
+func (c *APIClient) PowerOn(ctx context.Context) error {
+	return c.setPower(ctx, schemas.ForceOnResetType)
 }
 
-func (c *APIClient) PowerOff() error {
-	return c.setPower(schemas.ForceOffResetType)
+func (c *APIClient) PowerOff(ctx context.Context) error {
+	return c.setPower(ctx, schemas.ForceOffResetType)
 }
 
-func (c *APIClient) PowerReset() error {
-	return c.setPower(schemas.ForceRestartResetType)
+func (c *APIClient) PowerReset(ctx context.Context) error {
+	return c.setPower(ctx, schemas.ForceRestartResetType)
 }
 
-func (c *APIClient) PowerCycle() error {
-	return c.setPower(schemas.PowerCycleResetType)
+func (c *APIClient) PowerCycle(ctx context.Context) error {
+	return c.setPower(ctx, schemas.PowerCycleResetType)
 }
 
-func (c *APIClient) setPower(resetType schemas.ResetType) error {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+// Reset issues the given reset type on the system, for BMCs which do not support the reset types used by PowerOn and friends
+func (c *APIClient) Reset(ctx context.Context, resetType schemas.ResetType) error {
+	return c.setPower(ctx, resetType)
+}
+
+func (c *APIClient) setPower(ctx context.Context, resetType schemas.ResetType) error {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		c.log.Warnw("ignore system query", "error", err.Error())
@@ -249,12 +300,12 @@ func (c *APIClient) setPower(resetType schemas.ResetType) error {
 }
 
 // SetChassisIdentifyLEDState sets the chassis identify LED to given state
-func (c *APIClient) SetChassisIdentifyLEDState(state hal.IdentifyLEDState) error {
+func (c *APIClient) SetChassisIdentifyLEDState(ctx context.Context, state hal.IdentifyLEDState) error {
 	switch state {
 	case hal.IdentifyLEDStateOff:
-		return c.SetChassisIdentifyLEDOff()
+		return c.SetChassisIdentifyLEDOff(ctx)
 	case hal.IdentifyLEDStateOn:
-		return c.SetChassisIdentifyLEDOn()
+		return c.SetChassisIdentifyLEDOn(ctx)
 	case hal.IdentifyLEDStateUnknown:
 		fallthrough
 	default:
@@ -263,7 +314,7 @@ func (c *APIClient) SetChassisIdentifyLEDState(state hal.IdentifyLEDState) error
 }
 
 // SetChassisIdentifyLEDOn turns on the chassis identify LED
-func (c *APIClient) SetChassisIdentifyLEDOn() error {
+func (c *APIClient) SetChassisIdentifyLEDOn(ctx context.Context) error {
 	payload := indicatorLEDRequest{
 		IndicatorLED: schemas.LitIndicatorLED,
 	}
@@ -272,7 +323,7 @@ func (c *APIClient) SetChassisIdentifyLEDOn() error {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch, fmt.Sprintf("%s/Chassis/1", c.urlPrefix), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/Chassis/1", c.urlPrefix), bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -289,7 +340,7 @@ func (c *APIClient) SetChassisIdentifyLEDOn() error {
 }
 
 // SetChassisIdentifyLEDOff turns off the chassis identify LED
-func (c *APIClient) SetChassisIdentifyLEDOff() error {
+func (c *APIClient) SetChassisIdentifyLEDOff(ctx context.Context) error {
 	payload := indicatorLEDRequest{
 		IndicatorLED: schemas.OffIndicatorLED,
 	}
@@ -298,7 +349,7 @@ func (c *APIClient) SetChassisIdentifyLEDOff() error {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch, fmt.Sprintf("%s/Chassis/1", c.urlPrefix), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/Chassis/1", c.urlPrefix), bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -314,45 +365,25 @@ func (c *APIClient) SetChassisIdentifyLEDOff() error {
 	return nil
 }
 
-func (c *APIClient) SetBootTarget(target hal.BootTarget) error {
-	switch target {
-	case hal.BootTargetBIOS:
-		return c.setNextBootBIOS()
-	case hal.BootTargetDisk:
-		return c.setPersistentHDD()
-	case hal.BootTargetPXE:
-		fallthrough
-	default:
-		return c.setPersistentPXE()
-	}
-}
-
-func (c *APIClient) setPersistentPXE() error {
-	payload := bootOverrideRequest{
-		Boot: schemas.Boot{
-			BootSourceOverrideEnabled: schemas.ContinuousBootSourceOverrideEnabled,
-			BootSourceOverrideMode:    schemas.UEFIBootSourceOverrideMode,
-			BootSourceOverrideTarget:  schemas.PxeBootSource,
-		},
-	}
-	return c.setBootTargetOverride(payload)
+// SetBootTarget sets the boot override BootFrom applies for the given target
+func (c *APIClient) SetBootTarget(ctx context.Context, target hal.BootTarget) error {
+	return c.SetBootTargetOverride(ctx, hal.DefaultBootOverride(target))
 }
 
-func (c *APIClient) setPersistentHDD() error {
-	payload := bootOverrideRequest{
-		Boot: schemas.Boot{
-			BootSourceOverrideEnabled: schemas.ContinuousBootSourceOverrideEnabled,
-			BootSourceOverrideMode:    schemas.UEFIBootSourceOverrideMode,
-			BootSourceOverrideTarget:  schemas.HddBootSource,
-		},
+// SetBootTargetOverride sets the boot source override of the system to the given override
+func (c *APIClient) SetBootTargetOverride(ctx context.Context, override hal.BootOverride) error {
+	boot, err := BootSourceOverride(override)
+	if err != nil {
+		return err
 	}
-	return c.setBootTargetOverride(payload)
+	return c.SetBootOverride(ctx, boot)
 }
 
-func (c *APIClient) setBootTargetOverride(payload bootOverrideRequest) error {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+// SetBootOverride sets the given boot source override on the system, unset fields are left untouched by the BMC
+func (c *APIClient) SetBootOverride(ctx context.Context, boot schemas.Boot) error {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		return fmt.Errorf("unable to query systems: %w", err)
@@ -369,49 +400,65 @@ func (c *APIClient) setBootTargetOverride(payload bootOverrideRequest) error {
 	// Assuming there's typically one primary system.
 	system := systems[0]
 
-	body, err := json.Marshal(payload)
+	body, err := json.Marshal(bootOverrideRequest{Boot: boot})
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch, fmt.Sprintf("%s/Systems/%s", c.urlPrefix, system.ID), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/Systems/%s", c.urlPrefix, system.ID), bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	c.addHeadersAndAuth(req)
 
 	resp, err := c.Do(req)
-	_ = resp.Body.Close()
 	if err != nil {
 		return fmt.Errorf("unable to override boot order %w", err)
 	}
+	_ = resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unable to override boot order, http status: %s", resp.Status)
 	}
 	return nil
 }
 
+// BootOverride returns the boot source override which is currently set on the system
+func (c *APIClient) BootOverride(ctx context.Context) (*hal.BootOverride, error) {
+	system, err := c.System(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return BootOverrideFromBoot(system.Boot)
+}
+
 func (c *APIClient) addHeadersAndAuth(req *http.Request) {
+	c.mu.RLock()
+	basicAuth, password := c.basicAuth, c.password
+	c.mu.RUnlock()
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Basic "+c.basicAuth)
+	req.Header.Add("Authorization", "Basic "+basicAuth)
 	req.Header.Add("If-Match", "*")
-	req.SetBasicAuth(c.user, c.password)
+	req.SetBasicAuth(c.user, password)
 }
 
-func (c *APIClient) setNextBootBIOS() error {
-	payload := bootOverrideRequest{
-		Boot: schemas.Boot{
-			BootSourceOverrideEnabled: schemas.OnceBootSourceOverrideEnabled,
-			BootSourceOverrideMode:    schemas.UEFIBootSourceOverrideMode,
-			BootSourceOverrideTarget:  schemas.BiosSetupBootSource,
-		},
-	}
-	return c.setBootTargetOverride(payload)
+// session returns the gofish client of the current session
+func (c *APIClient) session() *gofish.APIClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
 }
 
-func (c *APIClient) BMC() (*api.BMC, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+// Do sends the request with the http client of the current session
+func (c *APIClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	client := c.Client
+	c.mu.RUnlock()
+	return client.Do(req)
+}
+
+func (c *APIClient) BMC(ctx context.Context) (*api.BMC, error) {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		c.log.Warnw("ignore system query", "error", err.Error())
@@ -448,11 +495,11 @@ func (c *APIClient) BMC() (*api.BMC, error) {
 	return bmc, nil
 }
 
-func (c *APIClient) GetBootOptions() ([]*schemas.BootOption, error) {
+func (c *APIClient) GetBootOptions(ctx context.Context) ([]*schemas.BootOption, error) {
 	// The curl command here would be curl -k -u <user>:<pwd> https://10.1.1.18/redfish/v1/Systems/System.Embedded.1/BootOptions
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		c.log.Warnw("ignore system query", "error", err.Error())
@@ -478,10 +525,10 @@ func (c *APIClient) GetBootOptions() ([]*schemas.BootOption, error) {
 }
 
 // SetBootOrder sets the boot order to match the sequence of the boot option entries
-func (c *APIClient) SetBootOrder(entries []*schemas.BootOption) error {
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectionTimeout)
+func (c *APIClient) SetBootOrder(ctx context.Context, entries []*schemas.BootOption) error {
+	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
-	g := c.client.WithContext(ctx)
+	g := c.session().WithContext(ctx)
 	systems, err := g.Service.Systems()
 	if err != nil {
 		return fmt.Errorf("unable to query systems: %w", err)
@@ -514,16 +561,16 @@ func (c *APIClient) SetBootOrder(entries []*schemas.BootOption) error {
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch, fmt.Sprintf("%s/Systems/%s", c.urlPrefix, system.ID), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/Systems/%s", c.urlPrefix, system.ID), bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	c.addHeadersAndAuth(req)
 	resp, err := c.Do(req)
-	_ = resp.Body.Close()
 	if err != nil {
 		return fmt.Errorf("unable to set boot order: %w", err)
 	}
+	_ = resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unable to set boot order, http status: %s", resp.Status)
 	}
@@ -533,7 +580,7 @@ func (c *APIClient) SetBootOrder(entries []*schemas.BootOption) error {
 
 // UpdateFirmware triggers a firmware update using the given URL
 // BMC analyzes the file and chooses the right component to update
-func (c *APIClient) UpdateFirmware(url string) error {
+func (c *APIClient) UpdateFirmware(ctx context.Context, url string) error {
 	// TODO NEEDS TESTING !!!
 	updateURL := c.urlPrefix + "/UpdateService/Actions/UpdateService.SimpleUpdate"
 
@@ -548,7 +595,7 @@ func (c *APIClient) UpdateFirmware(url string) error {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, updateURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, updateURL, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -574,3 +621,8 @@ func (c *APIClient) UpdateFirmware(url string) error {
 	}
 	return nil
 }
+
+// Logout deletes the session of this client
+func (c *APIClient) Logout() {
+	c.session().Logout()
+}
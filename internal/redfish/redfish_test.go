@@ -62,11 +62,11 @@ func TestAPIClient_BoardInfo(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := redfish.New(tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
+			c, err := redfish.New(t.Context(), tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
 			if err != nil {
 				t.Fatalf("could not construct receiver type: %v", err)
 			}
-			got, err := c.BoardInfo()
+			got, err := c.BoardInfo(t.Context())
 			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
 				t.Errorf("diff = %s", diff)
 			}
@@ -105,11 +105,11 @@ func TestAPIClient_MachineUUID(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := redfish.New(tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
+			c, err := redfish.New(t.Context(), tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
 			if err != nil {
 				t.Fatalf("could not construct receiver type: %v", err)
 			}
-			got, err := c.MachineUUID()
+			got, err := c.MachineUUID(t.Context())
 			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
 				t.Errorf("diff = %s", diff)
 			}
@@ -148,11 +148,11 @@ func TestAPIClient_SetChassisIdentifyLEDState(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := redfish.New(tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
+			c, err := redfish.New(t.Context(), tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
 			if err != nil {
 				t.Fatalf("could not construct receiver type: %v", err)
 			}
-			err = c.SetChassisIdentifyLEDState(tt.state)
+			err = c.SetChassisIdentifyLEDState(t.Context(), tt.state)
 			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
 				t.Errorf("diff = %s", diff)
 			}
@@ -187,11 +187,11 @@ func TestAPIClient_setPower(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := redfish.New(tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
+			c, err := redfish.New(t.Context(), tt.url, tt.user, tt.password, tt.insecure, tt.log, connectionTimeout)
 			if err != nil {
 				t.Fatalf("could not construct receiver type: %v", err)
 			}
-			err = c.SetChassisIdentifyLEDState(tt.state)
+			err = c.SetChassisIdentifyLEDState(t.Context(), tt.state)
 			if diff := cmp.Diff(err, tt.wantErr); diff != "" {
 				t.Errorf("diff = %s", diff)
 			}
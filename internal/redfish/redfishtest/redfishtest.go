@@ -0,0 +1,174 @@
+// Package redfishtest provides a Redfish service for tests which serves static resources
+// and records every modifying request, so vendor implementations can be tested without a real BMC.
+package redfishtest
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+const (
+	sessions = "/redfish/v1/SessionService/Sessions"
+
+	// User and Password the credentials of the clients returned by NewClient
+	User     = "admin"
+	Password = "secret"
+)
+
+// Request a modifying request the server received
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Server serves the resources of a fixture file over TLS.
+// The fixture is a json object which maps the resource path to the resource, e.g. "/redfish/v1/Systems/1": {...}.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	resources map[string]json.RawMessage
+	handlers  map[string]http.HandlerFunc
+	requests  []Request
+}
+
+// NewServer starts a server which serves the resources of the given fixture file, it is closed when the test ends.
+func NewServer(t testing.TB, fixture string) *Server {
+	t.Helper()
+
+	raw, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("unable to read fixture %s: %v", fixture, err)
+	}
+	s := &Server{
+		handlers: map[string]http.HandlerFunc{},
+	}
+	err = json.Unmarshal(raw, &s.resources)
+	if err != nil {
+		t.Fatalf("unable to parse fixture %s: %v", fixture, err)
+	}
+
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewClient starts a server for the given fixture like NewServer and returns a client which is connected to it
+func NewClient(t testing.TB, fixture string) (*redfish.APIClient, *Server) {
+	t.Helper()
+
+	s := NewServer(t, fixture)
+	c, err := redfish.New(t.Context(), s.URL, User, Password, true, Logger(), nil)
+	if err != nil {
+		t.Fatalf("unable to connect to redfish test server: %v", err)
+	}
+	return c, s
+}
+
+// NewBoardClient starts a server for the given fixture like NewClient and returns the board the fixture describes
+// with the vendor guessed from its manufacturer, as connect does
+func NewBoardClient(t testing.TB, fixture string) (*redfish.APIClient, *api.Board, *Server) {
+	t.Helper()
+
+	c, s := NewClient(t, fixture)
+	b, err := c.BoardInfo(t.Context())
+	if err != nil {
+		t.Fatalf("unable to read board of fixture %s: %v", fixture, err)
+	}
+	b.Vendor = api.GuessVendor(b.VendorString)
+	return c, b, s
+}
+
+// Logger returns a logger which discards everything, tests verify the recorded requests instead
+func Logger() logger.Logger {
+	return logger.NewSlog(slog.New(slog.DiscardHandler))
+}
+
+// Handle registers a handler for the given method and path which replaces the default behavior
+func (s *Server) Handle(method, path string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method+" "+path] = h
+}
+
+// SetResource replaces or adds the resource at the given path
+func (s *Server) SetResource(path string, resource any) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[path] = raw
+}
+
+// Requests returns all modifying requests received so far, sessions are omitted
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	s.mu.Lock()
+	h, ok := s.handlers[r.Method+" "+path]
+	resource, found := s.resources[path]
+	s.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	if r.Method != http.MethodGet && !strings.HasPrefix(path, sessions) {
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: path, Header: r.Header.Clone(), Body: body})
+		s.mu.Unlock()
+	}
+
+	if ok {
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		h(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		var meta struct {
+			ETag string `json:"@odata.etag"`
+		}
+		_ = json.Unmarshal(resource, &meta)
+		if meta.ETag != "" {
+			w.Header().Set("ETag", meta.ETag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resource)
+	case http.MethodPost:
+		if path == sessions {
+			w.Header().Set("X-Auth-Token", "token")
+			w.Header().Set("Location", sessions+"/1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}
+}
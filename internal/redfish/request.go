@@ -0,0 +1,133 @@
+package redfish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// etagRetries is the number of attempts of PatchWithETag
+const etagRetries = 3
+
+// StatusError is returned if the BMC answered a request with a non successful http status
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// GetJSON reads the resource at path, e.g. /redfish/v1/Systems/1, into v and returns its ETag if the BMC sent one
+func (c *APIClient) GetJSON(ctx context.Context, path string, v any) (string, error) {
+	resp, body, err := c.do(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return "", err
+	}
+	if v != nil && len(body) > 0 {
+		err = json.Unmarshal(body, v)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse %s: %w", path, err)
+		}
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// PostJSON sends payload to path, typically an action target, and reads the response into result if it is not nil
+func (c *APIClient) PostJSON(ctx context.Context, path string, payload, result any) error {
+	_, body, err := c.do(ctx, http.MethodPost, path, payload, "")
+	if err != nil {
+		return err
+	}
+	if result != nil && len(body) > 0 {
+		err = json.Unmarshal(body, result)
+		if err != nil {
+			return fmt.Errorf("unable to parse response of %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// PatchJSON modifies the resource at path, etag is sent as If-Match precondition, an empty etag matches any version
+func (c *APIClient) PatchJSON(ctx context.Context, path string, payload any, etag string) error {
+	_, _, err := c.do(ctx, http.MethodPatch, path, payload, etag)
+	return err
+}
+
+// Delete removes the resource at path
+func (c *APIClient) Delete(ctx context.Context, path string) error {
+	_, _, err := c.do(ctx, http.MethodDelete, path, nil, "")
+	return err
+}
+
+func (c *APIClient) do(ctx context.Context, method, path string, payload any, etag string) (*http.Response, []byte, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var reader io.Reader
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.addHeadersAndAuth(req)
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read response of %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, &StatusError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, body, nil
+}
+
+// PatchWithETag modifies the resource at path and sends the ETag of its current version as If-Match precondition.
+// Some BMCs, e.g. AMI MegaRAC, reject the wildcard precondition. If the resource was modified concurrently,
+// the BMC answers with 412 and the patch is retried with the new ETag.
+func (c *APIClient) PatchWithETag(ctx context.Context, path string, payload any) error {
+	var err error
+	for range etagRetries {
+		var meta struct {
+			ETag string `json:"@odata.etag"`
+		}
+		etag, getErr := c.GetJSON(ctx, path, &meta)
+		if getErr != nil {
+			return getErr
+		}
+		if etag == "" {
+			etag = meta.ETag
+		}
+
+		err = c.PatchJSON(ctx, path, payload, etag)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusPreconditionFailed {
+			return err
+		}
+		c.log.Debugw("resource was modified concurrently, retrying", "path", path, "etag", etag)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package redfish
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// selLogService returns the log service which holds the SEL, the one of the system is preferred over the ones of the managers
+func (c *APIClient) selLogService(ctx context.Context) (*schemas.LogService, error) {
+	g := c.session().WithContext(ctx)
+	var services []*schemas.LogService
+
+	systems, err := g.Service.Systems()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query systems: %w", err)
+	}
+	for _, system := range systems {
+		ls, err := system.LogServices()
+		if err != nil {
+			c.log.Warnw("unable to query log services", "system", system.ODataID, "error", err)
+			continue
+		}
+		services = append(services, ls...)
+	}
+	managers, err := g.Service.Managers()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query managers: %w", err)
+	}
+	for _, manager := range managers {
+		ls, err := manager.LogServices()
+		if err != nil {
+			c.log.Warnw("unable to query log services", "manager", manager.ODataID, "error", err)
+			continue
+		}
+		services = append(services, ls...)
+	}
+
+	for _, ls := range services {
+		// iLO calls its SEL the integrated management log
+		if ls.LogEntryType == schemas.SELLogEntryTypes || strings.EqualFold(ls.ID, "SEL") || strings.EqualFold(ls.ID, "IML") {
+			return ls, nil
+		}
+	}
+	return nil, hal.ErrNotSupported
+}
+
+// SEL returns the entries of the SEL log service with a record id greater than after
+func (c *APIClient) SEL(ctx context.Context, after uint16) ([]hal.SELEntry, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	ls, err := c.selLogService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logEntries, err := ls.Entries()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query entries of log service %s: %w", ls.ODataID, err)
+	}
+
+	var entries []hal.SELEntry
+	for _, le := range logEntries {
+		id, err := strconv.ParseUint(le.ID, 10, 16)
+		if err != nil {
+			c.log.Debugw("ignoring log entry without numeric id", "entry", le.ODataID)
+			continue
+		}
+		if uint16(id) <= after {
+			continue
+		}
+		entries = append(entries, toSELEntry(uint16(id), le))
+	}
+	slices.SortFunc(entries, func(a, b hal.SELEntry) int { return int(a.RecordID) - int(b.RecordID) })
+	return entries, nil
+}
+
+// ClearSEL clears the SEL log service
+func (c *APIClient) ClearSEL(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	ls, err := c.selLogService(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ls.ClearLog("")
+	if err != nil {
+		return fmt.Errorf("unable to clear log service %s: %w", ls.ODataID, err)
+	}
+	return nil
+}
+
+func toSELEntry(id uint16, le *schemas.LogEntry) hal.SELEntry {
+	e := hal.SELEntry{
+		RecordID:    id,
+		SensorType:  string(le.SensorType),
+		EventType:   string(le.EntryType),
+		Asserted:    le.EntryCode != schemas.DeassertLogEntryCode,
+		Description: le.Message,
+	}
+	if le.SensorNumber != nil {
+		e.SensorNumber = uint8(*le.SensorNumber)
+	}
+	ts, err := time.Parse(time.RFC3339, le.Created)
+	if err == nil {
+		e.Timestamp = ts
+	}
+	switch le.Severity {
+	case schemas.CriticalEventSeverity:
+		e.Severity = hal.SELSeverityCritical
+	case schemas.WarningEventSeverity:
+		e.Severity = hal.SELSeverityWarning
+	default:
+		e.Severity = hal.SELSeverityInfo
+	}
+	return e
+}
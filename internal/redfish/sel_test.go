@@ -0,0 +1,88 @@
+package redfish_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_SEL(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/sel.json")
+
+	entries, err := c.SEL(t.Context(), 0)
+	require.NoError(t, err)
+	require.Equal(t, []hal.SELEntry{
+		{
+			RecordID:     1,
+			Timestamp:    time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+			SensorType:   "Memory",
+			SensorNumber: 16,
+			EventType:    "SEL",
+			Asserted:     true,
+			Severity:     hal.SELSeverityWarning,
+			Description:  "DIMM A1 correctable ECC",
+		},
+		{
+			RecordID:     2,
+			Timestamp:    time.Date(2026, 10, 1, 8, 1, 0, 0, time.UTC),
+			SensorType:   "Temperature",
+			SensorNumber: 48,
+			EventType:    "SEL",
+			Asserted:     true,
+			Severity:     hal.SELSeverityCritical,
+			Description:  "CPU1 Temp upper critical going high",
+		},
+		{
+			RecordID:     3,
+			Timestamp:    time.Date(2026, 10, 1, 8, 5, 0, 0, time.UTC),
+			SensorType:   "Temperature",
+			SensorNumber: 48,
+			EventType:    "SEL",
+			Asserted:     false,
+			Severity:     hal.SELSeverityInfo,
+			Description:  "CPU1 Temp upper critical going high deasserted",
+		},
+	}, toUTC(entries))
+
+	entries, err = c.SEL(t.Context(), 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, uint16(3), entries[0].RecordID)
+}
+
+func TestAPIClient_SELNotSupported(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/sel.json")
+	srv.SetResource("/redfish/v1/Managers/1/LogServices/Log1", map[string]any{
+		"@odata.id":    "/redfish/v1/Managers/1/LogServices/Log1",
+		"@odata.type":  "#LogService.v1_5_0.LogService",
+		"Id":           "Log1",
+		"Name":         "Audit Log",
+		"LogEntryType": "Event",
+	})
+
+	_, err := c.SEL(t.Context(), 0)
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	require.ErrorIs(t, c.ClearSEL(t.Context()), hal.ErrNotSupported)
+}
+
+func TestAPIClient_ClearSEL(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/sel.json")
+
+	require.NoError(t, c.ClearSEL(t.Context()))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "/redfish/v1/Managers/1/LogServices/Log1/Actions/LogService.ClearLog", requests[0].Path)
+}
+
+func toUTC(entries []hal.SELEntry) []hal.SELEntry {
+	for i := range entries {
+		entries[i].Timestamp = entries[i].Timestamp.UTC()
+	}
+	return entries
+}
@@ -0,0 +1,212 @@
+package redfish
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// Sensors returns the temperature, fan, voltage and current sensors of all chassis.
+// The Sensors collection is preferred, the deprecated Thermal and Power resources are read if a chassis has none.
+func (c *APIClient) Sensors(ctx context.Context) ([]hal.Sensor, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	g := c.session().WithContext(ctx)
+
+	chassis, err := g.Service.Chassis()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query chassis: %w", err)
+	}
+
+	var (
+		sensors []hal.Sensor
+		found   bool
+	)
+	for _, chass := range chassis {
+		ss, err := chass.Sensors()
+		if err != nil {
+			c.log.Warnw("unable to query sensors, trying thermal and power", "chassis", chass.ODataID, "error", err)
+		}
+		if len(ss) > 0 {
+			found = true
+			for _, s := range ss {
+				sensor, ok := fromSensor(s)
+				if ok {
+					sensors = append(sensors, sensor)
+				}
+			}
+			continue
+		}
+
+		thermal, err := chass.Thermal()
+		if err != nil {
+			c.log.Warnw("unable to query thermal", "chassis", chass.ODataID, "error", err)
+		} else if thermal != nil {
+			found = true
+			sensors = append(sensors, fromThermal(thermal)...)
+		}
+		power, err := chass.Power()
+		if err != nil {
+			c.log.Warnw("unable to query power", "chassis", chass.ODataID, "error", err)
+		} else if power != nil {
+			found = true
+			sensors = append(sensors, fromPower(power)...)
+		}
+	}
+	if !found {
+		return nil, hal.ErrNotSupported
+	}
+	// the members of collections are fetched concurrently
+	slices.SortStableFunc(sensors, func(a, b hal.Sensor) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), strings.Compare(a.Name, b.Name))
+	})
+	return sensors, nil
+}
+
+func fromSensor(s *schemas.Sensor) (hal.Sensor, bool) {
+	var sensorType hal.SensorType
+	switch {
+	case s.ReadingType == schemas.TemperatureReadingType:
+		sensorType = hal.SensorTypeTemperature
+	case s.ReadingType == schemas.VoltageReadingType:
+		sensorType = hal.SensorTypeVoltage
+	case s.ReadingType == schemas.CurrentReadingType:
+		sensorType = hal.SensorTypeCurrent
+	case s.ReadingType == schemas.RotationalReadingType,
+		s.ReadingType == schemas.PercentReadingType && s.PhysicalContext == schemas.FanPhysicalContext:
+		sensorType = hal.SensorTypeFan
+	default:
+		return hal.Sensor{}, false
+	}
+	if s.Status.State == schemas.AbsentState {
+		return hal.Sensor{}, false
+	}
+
+	unit := s.ReadingUnits
+	if unit == "Cel" {
+		unit = "C"
+	}
+	t := s.Thresholds
+	return newSensor(s.Name, nil, sensorType, unit, s.Reading, s.Status, hal.SensorThresholds{
+		LowerNonCritical:    t.LowerCaution.Reading,
+		LowerCritical:       t.LowerCritical.Reading,
+		LowerNonRecoverable: t.LowerFatal.Reading,
+		UpperNonCritical:    t.UpperCaution.Reading,
+		UpperCritical:       t.UpperCritical.Reading,
+		UpperNonRecoverable: t.UpperFatal.Reading,
+	}), true
+}
+
+func fromThermal(thermal *schemas.Thermal) []hal.Sensor {
+	var sensors []hal.Sensor
+	for _, t := range thermal.Temperatures {
+		if t.Status.State == schemas.AbsentState {
+			continue
+		}
+		sensors = append(sensors, newSensor(t.Name, t.SensorNumber, hal.SensorTypeTemperature, "C", t.ReadingCelsius, t.Status, hal.SensorThresholds{
+			LowerNonCritical:    t.LowerThresholdNonCritical,
+			LowerCritical:       t.LowerThresholdCritical,
+			LowerNonRecoverable: t.LowerThresholdFatal,
+			UpperNonCritical:    t.UpperThresholdNonCritical,
+			UpperCritical:       t.UpperThresholdCritical,
+			UpperNonRecoverable: t.UpperThresholdFatal,
+		}))
+	}
+	for _, f := range thermal.Fans {
+		if f.Status.State == schemas.AbsentState {
+			continue
+		}
+		name := f.Name
+		if name == "" {
+			name = f.FanName //nolint:staticcheck
+		}
+		unit := "RPM"
+		if f.ReadingUnits == schemas.PercentReadingUnits {
+			unit = "%"
+		}
+		sensors = append(sensors, newSensor(name, f.SensorNumber, hal.SensorTypeFan, unit, toFloat64(f.Reading), f.Status, hal.SensorThresholds{
+			LowerNonCritical:    toFloat64(f.LowerThresholdNonCritical),
+			LowerCritical:       toFloat64(f.LowerThresholdCritical),
+			LowerNonRecoverable: toFloat64(f.LowerThresholdFatal),
+			UpperNonCritical:    toFloat64(f.UpperThresholdNonCritical),
+			UpperCritical:       toFloat64(f.UpperThresholdCritical),
+			UpperNonRecoverable: toFloat64(f.UpperThresholdFatal),
+		}))
+	}
+	return sensors
+}
+
+func fromPower(power *schemas.Power) []hal.Sensor {
+	var sensors []hal.Sensor
+	for _, v := range power.Voltages {
+		if v.Status.State == schemas.AbsentState {
+			continue
+		}
+		sensors = append(sensors, newSensor(v.Name, v.SensorNumber, hal.SensorTypeVoltage, "V", toFloat64(v.ReadingVolts), v.Status, hal.SensorThresholds{
+			LowerNonCritical:    toFloat64(v.LowerThresholdNonCritical),
+			LowerCritical:       toFloat64(v.LowerThresholdCritical),
+			LowerNonRecoverable: toFloat64(v.LowerThresholdFatal),
+			UpperNonCritical:    toFloat64(v.UpperThresholdNonCritical),
+			UpperCritical:       toFloat64(v.UpperThresholdCritical),
+			UpperNonRecoverable: toFloat64(v.UpperThresholdFatal),
+		}))
+	}
+	return sensors
+}
+
+func newSensor(name string, number *int, sensorType hal.SensorType, unit string, reading *float64, status schemas.Status, thresholds hal.SensorThresholds) hal.Sensor {
+	s := hal.Sensor{
+		Name:       name,
+		Type:       sensorType,
+		Unit:       unit,
+		Status:     hal.SensorStatusUnknown,
+		Thresholds: thresholds,
+	}
+	if number != nil {
+		s.Number = uint8(*number)
+	}
+	if reading == nil || (status.State != "" && status.State != schemas.EnabledState) {
+		return s
+	}
+	s.Reading = *reading
+	s.Status = sensorStatus(s.Reading, status.Health, thresholds)
+	return s
+}
+
+// sensorStatus trusts the health reported by the BMC, only a crossed fatal threshold is not expressible as health.
+// Without health the status is derived from the thresholds.
+func sensorStatus(reading float64, health schemas.Health, t hal.SensorThresholds) hal.SensorStatus {
+	below := func(threshold *float64) bool { return threshold != nil && reading <= *threshold }
+	above := func(threshold *float64) bool { return threshold != nil && reading >= *threshold }
+
+	switch {
+	case below(t.LowerNonRecoverable) || above(t.UpperNonRecoverable):
+		return hal.SensorStatusNonRecoverable
+	case health == schemas.CriticalHealth:
+		return hal.SensorStatusCritical
+	case health == schemas.WarningHealth:
+		return hal.SensorStatusWarning
+	case health == schemas.OKHealth:
+		return hal.SensorStatusOK
+	case below(t.LowerCritical) || above(t.UpperCritical):
+		return hal.SensorStatusCritical
+	case below(t.LowerNonCritical) || above(t.UpperNonCritical):
+		return hal.SensorStatusWarning
+	default:
+		return hal.SensorStatusOK
+	}
+}
+
+func toFloat64[T int | float32](v *T) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
@@ -0,0 +1,70 @@
+package redfish_test
+
+import (
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_Sensors(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/sensors.json")
+	ptr := func(f float64) *float64 { return &f }
+
+	sensors, err := c.Sensors(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []hal.Sensor{
+		{
+			Name: "CPU1 Temp", Type: hal.SensorTypeTemperature, Reading: 97, Unit: "C", Status: hal.SensorStatusCritical,
+			Thresholds: hal.SensorThresholds{UpperNonCritical: ptr(90), UpperCritical: ptr(95), UpperNonRecoverable: ptr(100)},
+		},
+		{
+			Name: "Inlet Temp", Number: 4, Type: hal.SensorTypeTemperature, Reading: 24, Unit: "C", Status: hal.SensorStatusOK,
+			Thresholds: hal.SensorThresholds{UpperNonCritical: ptr(40), UpperCritical: ptr(45)},
+		},
+		{
+			Name: "FAN1", Type: hal.SensorTypeFan, Reading: 5400, Unit: "RPM", Status: hal.SensorStatusOK,
+			Thresholds: hal.SensorThresholds{LowerNonCritical: ptr(500), LowerCritical: ptr(300)},
+		},
+		{
+			Name: "Fan 1", Number: 6, Type: hal.SensorTypeFan, Reading: 35, Unit: "%", Status: hal.SensorStatusWarning,
+		},
+		{
+			Name: "12V", Number: 32, Type: hal.SensorTypeVoltage, Reading: 12.5, Unit: "V", Status: hal.SensorStatusOK,
+			Thresholds: hal.SensorThresholds{LowerCritical: ptr(10.8), UpperCritical: ptr(13.2)},
+		},
+		{
+			Name: "VBAT", Number: 33, Type: hal.SensorTypeVoltage, Reading: 2.1, Unit: "V", Status: hal.SensorStatusNonRecoverable,
+			Thresholds: hal.SensorThresholds{LowerNonCritical: ptr(2.7), LowerCritical: ptr(2.4), LowerNonRecoverable: ptr(2.2)},
+		},
+		{
+			Name: "PSU1 Current", Type: hal.SensorTypeCurrent, Reading: 1.5, Unit: "A", Status: hal.SensorStatusOK,
+		},
+	}, roundSensors(sensors))
+}
+
+// roundSensors drops the float32 noise of the deprecated Power resource
+func roundSensors(sensors []hal.Sensor) []hal.Sensor {
+	round := func(f *float64) *float64 {
+		if f == nil {
+			return nil
+		}
+		r := float64(float32(*f))
+		r = float64(int64(r*1000+0.5)) / 1000
+		return &r
+	}
+	for i := range sensors {
+		s := &sensors[i]
+		s.Reading = *round(&s.Reading)
+		s.Thresholds = hal.SensorThresholds{
+			LowerNonCritical:    round(s.Thresholds.LowerNonCritical),
+			LowerCritical:       round(s.Thresholds.LowerCritical),
+			LowerNonRecoverable: round(s.Thresholds.LowerNonRecoverable),
+			UpperNonCritical:    round(s.Thresholds.UpperNonCritical),
+			UpperCritical:       round(s.Thresholds.UpperCritical),
+			UpperNonRecoverable: round(s.Thresholds.UpperNonRecoverable),
+		}
+	}
+	return sensors
+}
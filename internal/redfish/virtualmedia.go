@@ -0,0 +1,241 @@
+package redfish
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+)
+
+type (
+	actionTarget struct {
+		Target string `json:"target"`
+	}
+	virtualMediaResource struct {
+		ODataID        string   `json:"@odata.id"`
+		ID             string   `json:"Id"`
+		Name           string   `json:"Name"`
+		MediaTypes     []string `json:"MediaTypes"`
+		Image          string   `json:"Image"`
+		Inserted       bool     `json:"Inserted"`
+		WriteProtected bool     `json:"WriteProtected"`
+		Actions        struct {
+			InsertMedia actionTarget `json:"#VirtualMedia.InsertMedia"`
+			EjectMedia  actionTarget `json:"#VirtualMedia.EjectMedia"`
+		} `json:"Actions"`
+	}
+	collection struct {
+		Members []odataLink `json:"Members"`
+	}
+	insertMediaRequest struct {
+		Image                string `json:"Image"`
+		Inserted             bool   `json:"Inserted"`
+		WriteProtected       bool   `json:"WriteProtected"`
+		TransferProtocolType string `json:"TransferProtocolType,omitempty"`
+		UserName             string `json:"UserName,omitempty"`
+		Password             string `json:"Password,omitempty"`
+	}
+)
+
+// VirtualMediaSlot is a virtual media resource together with the targets of its actions,
+// the targets are empty if the BMC does not implement the standard actions
+type VirtualMediaSlot struct {
+	hal.VirtualMedia
+	ODataID      string
+	InsertTarget string
+	EjectTarget  string
+}
+
+// transferProtocols maps url schemes to the TransferProtocolType of the InsertMedia action
+var transferProtocols = map[string]schemas.VirtualMediaTransferProtocolType{
+	"http":  schemas.HTTPVirtualMediaTransferProtocolType,
+	"https": schemas.HTTPSVirtualMediaTransferProtocolType,
+	"nfs":   schemas.NFSVirtualMediaTransferProtocolType,
+	"cifs":  schemas.CIFSVirtualMediaTransferProtocolType,
+	"smb":   schemas.CIFSVirtualMediaTransferProtocolType,
+}
+
+// TransferProtocol returns the transfer protocol of the image url
+func TransferProtocol(imageURL string) (schemas.VirtualMediaTransferProtocolType, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid image url %q: %w", imageURL, err)
+	}
+	protocol, ok := transferProtocols[strings.ToLower(u.Scheme)]
+	if !ok {
+		return "", fmt.Errorf("unsupported scheme of image url %q", imageURL)
+	}
+	return protocol, nil
+}
+
+// virtualMediaSlots returns the virtual media of the system, older BMCs only link them at the manager
+func (c *APIClient) virtualMediaSlots(ctx context.Context) ([]VirtualMediaSlot, error) {
+	system, err := c.System(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	var links struct {
+		VirtualMedia odataLink `json:"VirtualMedia"`
+	}
+	_, err = c.GetJSON(ctx, system.ODataID, &links)
+	if err != nil {
+		return nil, err
+	}
+	if links.VirtualMedia.ODataID != "" {
+		paths = append(paths, links.VirtualMedia.ODataID)
+	} else {
+		managers, err := c.session().WithContext(ctx).Service.Managers()
+		if err != nil {
+			return nil, fmt.Errorf("unable to query managers: %w", err)
+		}
+		for _, manager := range managers {
+			_, err = c.GetJSON(ctx, manager.ODataID, &links)
+			if err != nil {
+				return nil, err
+			}
+			if links.VirtualMedia.ODataID != "" {
+				paths = append(paths, links.VirtualMedia.ODataID)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, hal.ErrNotSupported
+	}
+
+	var slots []VirtualMediaSlot
+	for _, path := range paths {
+		var members collection
+		_, err = c.GetJSON(ctx, path, &members)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members.Members {
+			var vm virtualMediaResource
+			_, err = c.GetJSON(ctx, member.ODataID, &vm)
+			if err != nil {
+				return nil, err
+			}
+			slots = append(slots, VirtualMediaSlot{
+				VirtualMedia: hal.VirtualMedia{
+					ID:             vm.ID,
+					Name:           vm.Name,
+					MediaTypes:     vm.MediaTypes,
+					Image:          vm.Image,
+					Inserted:       vm.Inserted,
+					WriteProtected: vm.WriteProtected,
+				},
+				ODataID:      vm.ODataID,
+				InsertTarget: vm.Actions.InsertMedia.Target,
+				EjectTarget:  vm.Actions.EjectMedia.Target,
+			})
+		}
+	}
+	return slots, nil
+}
+
+// VirtualMedia lists the virtual media slots
+func (c *APIClient) VirtualMedia(ctx context.Context) ([]hal.VirtualMedia, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	slots, err := c.virtualMediaSlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []hal.VirtualMedia
+	for _, slot := range slots {
+		result = append(result, slot.VirtualMedia)
+	}
+	return result, nil
+}
+
+// VirtualMediaSlot returns the slot with the given id, an empty id selects the first slot which emulates a CD or DVD
+func (c *APIClient) VirtualMediaSlot(ctx context.Context, id string) (*VirtualMediaSlot, error) {
+	slots, err := c.virtualMediaSlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, slot := range slots {
+		if id == "" && (slices.Contains(slot.MediaTypes, string(schemas.CDVirtualMediaType)) || slices.Contains(slot.MediaTypes, string(schemas.DVDVirtualMediaType))) {
+			return &slot, nil
+		}
+		if id != "" && slot.ID == id {
+			return &slot, nil
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("no virtual media slot for cds found")
+	}
+	return nil, fmt.Errorf("virtual media slot %s not found", id)
+}
+
+// InsertVirtualMedia inserts the image write protected with the InsertMedia action and sets the next boot to the virtual CD if requested
+func (c *APIClient) InsertVirtualMedia(ctx context.Context, id string, image hal.VirtualMediaImage) error {
+	protocol, err := TransferProtocol(image.URL)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	slot, err := c.VirtualMediaSlot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if slot.InsertTarget == "" {
+		return fmt.Errorf("insert media into slot %s %w", slot.ID, hal.ErrNotSupported)
+	}
+	if slot.Inserted {
+		return fmt.Errorf("virtual media slot %s already contains %s, eject it first", slot.ID, slot.Image)
+	}
+	err = c.PostJSON(ctx, slot.InsertTarget, insertMediaRequest{
+		Image:                image.URL,
+		Inserted:             true,
+		WriteProtected:       true,
+		TransferProtocolType: string(protocol),
+		UserName:             image.UserName,
+		Password:             image.Password,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to insert %s into virtual media slot %s: %w", image.URL, slot.ID, err)
+	}
+	if !image.BootOnce {
+		return nil
+	}
+	return c.BootOnce(ctx, schemas.CdBootSource)
+}
+
+// EjectVirtualMedia ejects the image of the slot with the EjectMedia action, empty slots are left alone
+func (c *APIClient) EjectVirtualMedia(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	slot, err := c.VirtualMediaSlot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !slot.Inserted {
+		return nil
+	}
+	if slot.EjectTarget == "" {
+		return fmt.Errorf("eject media of slot %s %w", slot.ID, hal.ErrNotSupported)
+	}
+	return c.PostJSON(ctx, slot.EjectTarget, struct{}{}, nil)
+}
+
+// BootOnce sets the next boot of the server to the given source, most BMCs present virtual CDs as Cd
+func (c *APIClient) BootOnce(ctx context.Context, source schemas.BootSource) error {
+	system, err := c.System(ctx)
+	if err != nil {
+		return err
+	}
+	return c.PatchWithETag(ctx, system.ODataID, bootOverrideRequest{
+		Boot: schemas.Boot{
+			BootSourceOverrideEnabled: schemas.OnceBootSourceOverrideEnabled,
+			BootSourceOverrideTarget:  source,
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package redfish_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_VirtualMedia(t *testing.T) {
+	c, _ := redfishtest.NewClient(t, "testdata/virtualmedia.json")
+
+	media, err := c.VirtualMedia(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []hal.VirtualMedia{
+		{ID: "1", Name: "Virtual Removable Media", MediaTypes: []string{"USBStick"}, Image: "http://10.0.0.1/images/firmware.img", Inserted: true, WriteProtected: true},
+		{ID: "2", Name: "Virtual CD", MediaTypes: []string{"CD", "DVD"}, WriteProtected: true},
+	}, media)
+}
+
+func TestAPIClient_InsertVirtualMedia(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/virtualmedia.json")
+
+	err := c.InsertVirtualMedia(t.Context(), "", hal.VirtualMediaImage{
+		URL:      "nfs://10.0.0.1/export/rescue.iso",
+		UserName: "metal",
+		Password: "secret",
+		BootOnce: true,
+	})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "/redfish/v1/Systems/1/VirtualMedia/2/Actions/VirtualMedia.InsertMedia", requests[0].Path)
+	require.JSONEq(t, `{
+		"Image": "nfs://10.0.0.1/export/rescue.iso",
+		"Inserted": true,
+		"WriteProtected": true,
+		"TransferProtocolType": "NFS",
+		"UserName": "metal",
+		"Password": "secret"
+	}`, string(requests[0].Body))
+	require.Equal(t, http.MethodPatch, requests[1].Method)
+	require.Equal(t, "/redfish/v1/Systems/1", requests[1].Path)
+	require.Equal(t, `"s1"`, requests[1].Header.Get("If-Match"))
+	require.JSONEq(t, `{"Boot": {"BootSourceOverrideEnabled": "Once", "BootSourceOverrideTarget": "Cd"}}`, string(requests[1].Body))
+
+	err = c.InsertVirtualMedia(t.Context(), "1", hal.VirtualMediaImage{URL: "http://10.0.0.1/rescue.iso"})
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	err = c.InsertVirtualMedia(t.Context(), "", hal.VirtualMediaImage{URL: "ftp://10.0.0.1/rescue.iso"})
+	require.EqualError(t, err, `unsupported scheme of image url "ftp://10.0.0.1/rescue.iso"`)
+	err = c.InsertVirtualMedia(t.Context(), "3", hal.VirtualMediaImage{URL: "http://10.0.0.1/rescue.iso"})
+	require.EqualError(t, err, "virtual media slot 3 not found")
+	require.Len(t, srv.Requests(), 2)
+}
+
+func TestAPIClient_EjectVirtualMedia(t *testing.T) {
+	c, srv := redfishtest.NewClient(t, "testdata/virtualmedia.json")
+
+	// the cd slot is empty
+	require.NoError(t, c.EjectVirtualMedia(t.Context(), ""))
+	require.Empty(t, srv.Requests())
+
+	require.NoError(t, c.EjectVirtualMedia(t.Context(), "1"))
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/redfish/v1/Systems/1/VirtualMedia/1/Actions/VirtualMedia.EjectMedia", requests[0].Path)
+	require.JSONEq(t, `{}`, string(requests[0].Body))
+}
@@ -1,6 +1,7 @@
 package dell
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -19,11 +20,6 @@ import (
 	"github.com/metal-stack/go-hal/pkg/logger"
 )
 
-var (
-	// errorNotImplemented for all functions that are not implemented yet
-	errorNotImplemented = fmt.Errorf("not implemented yet")
-)
-
 const (
 	vendor  = api.VendorDell
 	sshPort = 22 // default SSH port for Dell BMCs
@@ -46,8 +42,8 @@ type (
 )
 
 // InBand creates an inband connection to a Dell server.
-func InBand(board *api.Board, log logger.Logger) (hal.InBand, error) {
-	ib, err := inband.New(board, true, log)
+func InBand(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error) {
+	ib, err := inband.New(ctx, board, true, log)
 	if err != nil {
 		return nil, err
 	}
@@ -66,35 +62,67 @@ func OutBand(r *redfish.APIClient, board *api.Board, user, password, ip string,
 
 // InBand
 func (ib *inBand) PowerOff() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerDown)
+	return ib.PowerOffContext(context.Background())
+}
+
+func (ib *inBand) PowerOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerDown)
 }
 
 func (ib *inBand) PowerCycle() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerCycle)
+	return ib.PowerCycleContext(context.Background())
+}
+
+func (ib *inBand) PowerCycleContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerCycle)
 }
 
 func (ib *inBand) PowerReset() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlHardReset)
+	return ib.PowerResetContext(context.Background())
+}
+
+func (ib *inBand) PowerResetContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlHardReset)
 }
 
 func (ib *inBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ib.IpmiTool.SetChassisIdentifyLEDState(state)
+	return ib.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ib *inBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDState(ctx, state)
 }
 
 func (ib *inBand) IdentifyLEDOn() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOn()
+	return ib.IdentifyLEDOnContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOn(ctx)
 }
 
 func (ib *inBand) IdentifyLEDOff() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOff()
+	return ib.IdentifyLEDOffContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOff(ctx)
 }
 
 func (ib *inBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ib.IpmiTool.SetBootOrder(bootTarget, vendor)
+	return ib.BootFromContext(context.Background(), bootTarget)
 }
 
-func (ib *inBand) SetFirmware(hal.FirmwareMode) error {
-	return errorNotImplemented
+func (ib *inBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ib.IpmiTool.SetBootOrder(ctx, bootTarget, vendor)
+}
+
+func (ib *inBand) SetFirmware(mode hal.FirmwareMode) error {
+	return ib.SetFirmwareContext(context.Background(), mode)
+}
+
+func (ib *inBand) SetFirmwareContext(context.Context, hal.FirmwareMode) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) Describe() string {
@@ -108,7 +136,7 @@ func (ib *inBand) BMCConnection() api.BMCConnection {
 }
 
 func (c *bmcConnection) BMC() (*api.BMC, error) {
-	return c.IpmiTool.BMC()
+	return c.IpmiTool.BMC(context.Background())
 }
 
 func (c *bmcConnection) PresentSuperUser() api.BMCUser {
@@ -140,38 +168,61 @@ func (c *bmcConnection) Present() bool {
 }
 
 func (c *bmcConnection) CreateUserAndPassword(user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
-	return c.IpmiTool.CreateUser(user, privilege, "", c.Board().Vendor.PasswordConstraints(), ipmi.HighLevel)
+	return c.CreateUserAndPasswordContext(context.Background(), user, privilege)
+}
+
+func (c *bmcConnection) CreateUserAndPasswordContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.IpmiTool.CreateUser(ctx, user, privilege, "", c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
-	_, err := c.IpmiTool.CreateUser(user, privilege, password, nil, ipmi.HighLevel)
+	return c.CreateUserContext(context.Background(), user, privilege, password)
+}
+
+func (c *bmcConnection) CreateUserContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	_, err := c.IpmiTool.CreateUser(ctx, user, privilege, password, c.Board().PasswordConstraints(), ipmi.HighLevel)
 	return err
 }
 
 func (c *bmcConnection) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
-	return c.IpmiTool.NeedsPasswordChange(user, password)
+	return c.NeedsPasswordChangeContext(context.Background(), user, password)
+}
+
+func (c *bmcConnection) NeedsPasswordChangeContext(ctx context.Context, user api.BMCUser, password string) (bool, error) {
+	return c.IpmiTool.NeedsPasswordChange(ctx, user, password)
 }
 
 func (c *bmcConnection) ChangePassword(user api.BMCUser, newPassword string) error {
-	return c.IpmiTool.ChangePassword(user, newPassword, ipmi.HighLevel)
+	return c.ChangePasswordContext(context.Background(), user, newPassword)
+}
+
+func (c *bmcConnection) ChangePasswordContext(ctx context.Context, user api.BMCUser, newPassword string) error {
+	return c.IpmiTool.ChangePassword(ctx, user, newPassword, c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) SetUserEnabled(user api.BMCUser, enabled bool) error {
-	return c.IpmiTool.SetUserEnabled(user, enabled, ipmi.HighLevel)
+	return c.SetUserEnabledContext(context.Background(), user, enabled)
+}
+
+func (c *bmcConnection) SetUserEnabledContext(ctx context.Context, user api.BMCUser, enabled bool) error {
+	return c.IpmiTool.SetUserEnabled(ctx, user, enabled, ipmi.HighLevel)
 }
 
 func (ib *inBand) ConfigureBIOS() (bool, error) {
-	//return false, errorNotImplemented // do not throw an error to not break manual tests
-	return false, nil //TODO https://github.com/metal-stack/go-hal/issues/11
+	return ib.ConfigureBIOSContext(context.Background())
 }
 
-func (ib *inBand) EnsureBootOrder(bootloaderID string) error {
-	return nil
+func (ib *inBand) ConfigureBIOSContext(context.Context) (bool, error) {
+	return false, hal.ErrNotSupported
 }
 
 // OutBand
 func (ob *outBand) UUID() (*uuid.UUID, error) {
-	u, err := ob.Redfish.MachineUUID()
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
+	u, err := ob.Redfish.MachineUUID(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -183,35 +234,67 @@ func (ob *outBand) UUID() (*uuid.UUID, error) {
 }
 
 func (ob *outBand) PowerState() (hal.PowerState, error) {
-	return ob.Redfish.PowerState()
+	return ob.PowerStateContext(context.Background())
+}
+
+func (ob *outBand) PowerStateContext(ctx context.Context) (hal.PowerState, error) {
+	return ob.Redfish.PowerState(ctx)
 }
 
 func (ob *outBand) PowerOff() error {
-	return ob.Redfish.PowerOff()
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Redfish.PowerOff(ctx)
 }
 
 func (ob *outBand) PowerOn() error {
-	return ob.Redfish.PowerReset() // PowerOn is not supported
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx) // PowerOn is not supported
 }
 
 func (ob *outBand) PowerReset() error {
-	return ob.Redfish.PowerReset()
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx)
 }
 
 func (ob *outBand) PowerCycle() error {
-	return ob.Redfish.PowerReset() // PowerCycle is not supported
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx) // PowerCycle is not supported
 }
 
 func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ob.Redfish.SetChassisIdentifyLEDState(state)
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ob.Redfish.SetChassisIdentifyLEDState(ctx, state)
 }
 
 func (ob *outBand) IdentifyLEDOn() error {
-	return ob.Redfish.SetChassisIdentifyLEDOn()
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOn(ctx)
 }
 
 func (ob *outBand) IdentifyLEDOff() error {
-	return ob.Redfish.SetChassisIdentifyLEDOff()
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOff(ctx)
 }
 
 func cutVersion(ver string) string {
@@ -223,6 +306,10 @@ func cutVersion(ver string) string {
 }
 
 func (ob *outBand) BootFrom(target hal.BootTarget) error {
+	return ob.BootFromContext(context.Background(), target)
+}
+
+func (ob *outBand) BootFromContext(ctx context.Context, target hal.BootTarget) error {
 	// cut the version to ensure it adheres to semver
 	currentBiosVersion, errVersion := semver.NewVersion(cutVersion(ob.Board().BiosVersion))
 	if errVersion != nil {
@@ -235,21 +322,22 @@ func (ob *outBand) BootFrom(target hal.BootTarget) error {
 		ob.log.Infow("failed to parse BIOS version constraint: %w, falling back to legacy boot device setup", errConstraint)
 	}
 	if errVersion == nil && errConstraint == nil && neededBiosVersionCheck.Check(currentBiosVersion) {
-		return ob.Redfish.SetBootTarget(target)
+		return ob.Redfish.SetBootTarget(ctx, target)
 	}
 	// Dell has a bug in the implementation of setting the BootSourceOverrideEnabled to "Continuous". It only survives a single reboot
 	// Mentioned here under 159467: https://www.dell.com/support/manuals/en-us/dell-dss-7500/idrac8_2.75.75.75_rn/automation-api-and-cli?guid=guid-156e2423-80df-46f9-9d00-cb1018c6e227&lang=en-us
 	// And in this changelog: https://www.dell.com/support/home/en-us/drivers/driversdetails?driverid=krcxx
 
 	// As a workaround we modify the BootOrder directly
-	bootOptions, err := ob.Redfish.GetBootOptions()
+	bootOptions, err := ob.Redfish.GetBootOptions(ctx)
 	if err != nil {
 		return err
 	}
 
 	switch target {
-	case hal.BootTargetBIOS:
-		return ob.Redfish.SetBootTarget(target)
+	case hal.BootTargetBIOS, hal.BootTargetCD, hal.BootTargetUSB, hal.BootTargetHTTP, hal.BootTargetUEFIShell:
+		// one-time overrides are not affected
+		return ob.Redfish.SetBootTarget(ctx, target)
 	case hal.BootTargetDisk:
 		var hdOptions []*schemas.BootOption
 		for _, option := range bootOptions {
@@ -260,7 +348,7 @@ func (ob *outBand) BootFrom(target hal.BootTarget) error {
 		if len(hdOptions) == 0 {
 			return fmt.Errorf("no hard disk boot option found")
 		}
-		return ob.Redfish.SetBootOrder(hdOptions)
+		return ob.Redfish.SetBootOrder(ctx, hdOptions)
 	case hal.BootTargetPXE:
 		fallthrough
 	default:
@@ -273,7 +361,7 @@ func (ob *outBand) BootFrom(target hal.BootTarget) error {
 		if len(nicOptions) == 0 {
 			return fmt.Errorf("no PXE boot option found")
 		}
-		return ob.Redfish.SetBootOrder(nicOptions)
+		return ob.Redfish.SetBootOrder(ctx, nicOptions)
 	}
 }
 
@@ -281,16 +369,69 @@ func (ob *outBand) Describe() string {
 	return "OutBand connected to Dell"
 }
 
+func (ob *outBand) Capabilities() hal.Capabilities {
+	return hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportRedfish,
+			hal.OperationPowerState:        hal.TransportRedfish,
+			hal.OperationEvents:            hal.TransportRedfish,
+			hal.OperationSEL:               hal.TransportRedfish,
+			hal.OperationSensors:           hal.TransportRedfish,
+			hal.OperationIdentifyLED:       hal.TransportRedfish,
+			hal.OperationConsole:           hal.TransportSSH,
+			hal.OperationVirtualMedia:      hal.TransportRedfish,
+			hal.OperationBootConfiguration: hal.TransportRedfish,
+			hal.OperationBIOSAttributes:    hal.TransportRedfish,
+			hal.OperationBIOSProfile:       hal.TransportRedfish,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:       hal.TransportRedfish,
+			hal.BootTargetDisk:      hal.TransportRedfish,
+			hal.BootTargetBIOS:      hal.TransportRedfish,
+			hal.BootTargetCD:        hal.TransportRedfish,
+			hal.BootTargetUSB:       hal.TransportRedfish,
+			hal.BootTargetHTTP:      hal.TransportRedfish,
+			hal.BootTargetUEFIShell: hal.TransportRedfish,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportRedfish,
+			hal.PowerActionOff:   hal.TransportRedfish,
+			hal.PowerActionReset: hal.TransportRedfish,
+			hal.PowerActionCycle: hal.TransportRedfish,
+		},
+		EmulatedPowerActions: map[hal.PowerAction]hal.PowerAction{
+			hal.PowerActionOn:    hal.PowerActionReset,
+			hal.PowerActionCycle: hal.PowerActionReset,
+		},
+		UpdateMethods: map[hal.UpdateMethod]hal.Transport{
+			hal.UpdateMethodBIOS: hal.TransportRedfish,
+			hal.UpdateMethodBMC:  hal.TransportRedfish,
+		},
+	}
+}
+
 func (ob *outBand) Console(s ssh.Session) error {
-	return console.OverSSH(ob.log, s, ob.GetUsername(), ob.GetPassword(), ob.GetIP(), ob.GetSSHPort(), "console com2")
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(ctx context.Context, s ssh.Session) error {
+	return console.OverSSH(ctx, ob.log, s, ob.GetUsername(), ob.GetPassword(), ob.GetIP(), ob.GetSSHPort(), "console com2")
 }
 
 func (ob *outBand) UpdateBIOS(url string) error {
-	return ob.Redfish.UpdateFirmware(url)
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(ctx context.Context, url string) error {
+	return ob.Redfish.UpdateFirmware(ctx, url)
 }
 
 func (ob *outBand) UpdateBMC(url string) error {
-	return ob.Redfish.UpdateFirmware(url)
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(ctx context.Context, url string) error {
+	return ob.Redfish.UpdateFirmware(ctx, url)
 }
 
 func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
@@ -300,5 +441,5 @@ func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
 }
 
 func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
-	return c.Redfish.BMC()
+	return c.Redfish.BMC(context.Background())
 }
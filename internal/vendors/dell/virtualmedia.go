@@ -0,0 +1,71 @@
+package dell
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/metal-stack/go-hal"
+)
+
+const (
+	importSystemConfiguration = "/redfish/v1/Managers/iDRAC.Embedded.1/Actions/Oem/EID_674_Manager.ImportSystemConfiguration"
+	// bootOnceVirtualCD is a server configuration profile which boots the virtual CD once,
+	// the Cd boot source of the system addresses the physical drive only
+	bootOnceVirtualCD = `<SystemConfiguration><Component FQDD="iDRAC.Embedded.1">` +
+		`<Attribute Name="ServerBoot.1#BootOnce">Enabled</Attribute>` +
+		`<Attribute Name="ServerBoot.1#FirstBootDevice">VCD-DVD</Attribute>` +
+		`</Component></SystemConfiguration>`
+)
+
+type (
+	// insertMediaRequest omits the TransferProtocolType, older iDRAC firmware rejects it and detects the protocol itself
+	insertMediaRequest struct {
+		Image          string `json:"Image"`
+		Inserted       bool   `json:"Inserted"`
+		WriteProtected bool   `json:"WriteProtected"`
+		UserName       string `json:"UserName,omitempty"`
+		Password       string `json:"Password,omitempty"`
+	}
+	importSystemConfigurationRequest struct {
+		ImportBuffer    string          `json:"ImportBuffer"`
+		ShareParameters shareParameters `json:"ShareParameters"`
+	}
+	shareParameters struct {
+		Target string `json:"Target"`
+	}
+)
+
+// InsertVirtualMedia inserts the image with the InsertMedia action and boots the virtual CD once via a server configuration profile
+func (ob *outBand) InsertVirtualMedia(ctx context.Context, id string, image hal.VirtualMediaImage) error {
+	slot, err := ob.Redfish.VirtualMediaSlot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if slot.InsertTarget == "" {
+		return fmt.Errorf("insert media into slot %s %w", slot.ID, hal.ErrNotSupported)
+	}
+	if slot.Inserted {
+		return fmt.Errorf("virtual media slot %s already contains %s, eject it first", slot.ID, slot.Image)
+	}
+	err = ob.Redfish.PostJSON(ctx, slot.InsertTarget, insertMediaRequest{
+		Image:          image.URL,
+		Inserted:       true,
+		WriteProtected: true,
+		UserName:       image.UserName,
+		Password:       image.Password,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to insert %s into virtual media slot %s: %w", image.URL, slot.ID, err)
+	}
+	if !image.BootOnce {
+		return nil
+	}
+	err = ob.Redfish.PostJSON(ctx, importSystemConfiguration, importSystemConfigurationRequest{
+		ImportBuffer:    bootOnceVirtualCD,
+		ShareParameters: shareParameters{Target: "ALL"},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to boot once from the virtual cd: %w", err)
+	}
+	return nil
+}
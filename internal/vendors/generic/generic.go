@@ -0,0 +1,220 @@
+package generic
+
+import (
+	"context"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/console"
+	"github.com/metal-stack/go-hal/internal/outband"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+	"github.com/stmcginnis/gofish/schemas"
+)
+
+const (
+	sshPort = 22
+)
+
+type (
+	outBand struct {
+		*outband.OutBand
+		log logger.Logger
+		// serialConsole is the ssh serial console service of the system, nil if it is not exposed
+		serialConsole *schemas.SerialConsoleProtocol
+	}
+	bmcConnectionOutBand struct {
+		*outBand
+	}
+)
+
+// OutBand creates an outband connection to a BMC which is only expected to follow the Redfish standard.
+// It is used for all vendors without a dedicated implementation.
+func OutBand(ctx context.Context, r *redfish.APIClient, board *api.Board, user, password, ip string, log logger.Logger) hal.OutBand {
+	serialConsole, err := r.SerialConsoleSSH(ctx)
+	if err != nil {
+		log.Warnw("unable to detect the serial console, console is not available", "error", err)
+	}
+
+	port := sshPort
+	if serialConsole != nil && serialConsole.Port != nil {
+		port = int(*serialConsole.Port) // nolint:gosec
+	}
+
+	return &outBand{
+		OutBand:       outband.ViaRedfishPlusSSH(r, board, user, password, ip, port),
+		log:           log,
+		serialConsole: serialConsole,
+	}
+}
+
+func (ob *outBand) UUID() (*uuid.UUID, error) {
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
+	u, err := ob.Redfish.MachineUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	us, err := uuid.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	return &us, nil
+}
+
+func (ob *outBand) PowerState() (hal.PowerState, error) {
+	return ob.PowerStateContext(context.Background())
+}
+
+func (ob *outBand) PowerStateContext(ctx context.Context) (hal.PowerState, error) {
+	return ob.Redfish.PowerState(ctx)
+}
+
+func (ob *outBand) PowerOff() error {
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Redfish.PowerOff(ctx)
+}
+
+func (ob *outBand) PowerOn() error {
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Redfish.PowerOn(ctx)
+}
+
+func (ob *outBand) PowerReset() error {
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx)
+}
+
+func (ob *outBand) PowerCycle() error {
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Redfish.PowerCycle(ctx)
+}
+
+func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ob.Redfish.SetChassisIdentifyLEDState(ctx, state)
+}
+
+func (ob *outBand) IdentifyLEDOn() error {
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOn(ctx)
+}
+
+func (ob *outBand) IdentifyLEDOff() error {
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOff(ctx)
+}
+
+func (ob *outBand) BootFrom(target hal.BootTarget) error {
+	return ob.BootFromContext(context.Background(), target)
+}
+
+func (ob *outBand) BootFromContext(ctx context.Context, target hal.BootTarget) error {
+	return ob.Redfish.SetBootTarget(ctx, target)
+}
+
+func (ob *outBand) Describe() string {
+	return "OutBand connected to a generic Redfish BMC"
+}
+
+func (ob *outBand) Capabilities() hal.Capabilities {
+	c := hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportRedfish,
+			hal.OperationPowerState:        hal.TransportRedfish,
+			hal.OperationEvents:            hal.TransportRedfish,
+			hal.OperationSEL:               hal.TransportRedfish,
+			hal.OperationSensors:           hal.TransportRedfish,
+			hal.OperationIdentifyLED:       hal.TransportRedfish,
+			hal.OperationVirtualMedia:      hal.TransportRedfish,
+			hal.OperationBootConfiguration: hal.TransportRedfish,
+			hal.OperationBIOSAttributes:    hal.TransportRedfish,
+			hal.OperationBIOSProfile:       hal.TransportRedfish,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:       hal.TransportRedfish,
+			hal.BootTargetDisk:      hal.TransportRedfish,
+			hal.BootTargetBIOS:      hal.TransportRedfish,
+			hal.BootTargetCD:        hal.TransportRedfish,
+			hal.BootTargetUSB:       hal.TransportRedfish,
+			hal.BootTargetHTTP:      hal.TransportRedfish,
+			hal.BootTargetUEFIShell: hal.TransportRedfish,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportRedfish,
+			hal.PowerActionOff:   hal.TransportRedfish,
+			hal.PowerActionReset: hal.TransportRedfish,
+			hal.PowerActionCycle: hal.TransportRedfish,
+		},
+		UpdateMethods: map[hal.UpdateMethod]hal.Transport{
+			hal.UpdateMethodBIOS: hal.TransportRedfish,
+			hal.UpdateMethodBMC:  hal.TransportRedfish,
+		},
+	}
+	if ob.serialConsole != nil {
+		c.Operations[hal.OperationConsole] = hal.TransportSSH
+	}
+	return c
+}
+
+func (ob *outBand) Console(s ssh.Session) error {
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(ctx context.Context, s ssh.Session) error {
+	if ob.serialConsole == nil {
+		return hal.ErrNotSupported
+	}
+	return console.OverSSH(ctx, ob.log, s, ob.GetUsername(), ob.GetPassword(), ob.GetIP(), ob.GetSSHPort(), ob.serialConsole.ConsoleEntryCommand)
+}
+
+func (ob *outBand) UpdateBIOS(url string) error {
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(ctx context.Context, url string) error {
+	return ob.Redfish.UpdateFirmware(ctx, url)
+}
+
+func (ob *outBand) UpdateBMC(url string) error {
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(ctx context.Context, url string) error {
+	return ob.Redfish.UpdateFirmware(ctx, url)
+}
+
+func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
+	return &bmcConnectionOutBand{
+		outBand: ob,
+	}
+}
+
+func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
+	return c.Redfish.BMC(context.Background())
+}
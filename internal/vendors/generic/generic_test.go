@@ -0,0 +1,72 @@
+package generic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutBand(t *testing.T, r *redfish.APIClient) *outBand {
+	ob, ok := OutBand(t.Context(), r, &api.Board{VendorString: "Acme Compute"}, redfishtest.User, redfishtest.Password, "127.0.0.1", redfishtest.Logger()).(*outBand)
+	require.True(t, ok)
+	return ob
+}
+
+func TestOutBand(t *testing.T) {
+	r, srv := redfishtest.NewClient(t, "testdata/redfish.json")
+	ob := newOutBand(t, r)
+
+	u, err := ob.UUIDContext(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "38947555-7742-3448-3784-823347823834", u.String())
+
+	state, err := ob.PowerStateContext(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, hal.PowerOnState, state)
+
+	require.Equal(t, 2200, ob.GetSSHPort())
+	require.Equal(t, hal.TransportSSH, ob.Capabilities().Operations[hal.OperationConsole])
+
+	tests := []struct {
+		name      string
+		power     func() error
+		resetType string
+	}{
+		{name: "on", power: ob.PowerOn, resetType: "ForceOn"},
+		{name: "off", power: ob.PowerOff, resetType: "ForceOff"},
+		{name: "reset", power: ob.PowerReset, resetType: "ForceRestart"},
+		{name: "cycle", power: ob.PowerCycle, resetType: "PowerCycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.power())
+
+			requests := srv.Requests()
+			require.NotEmpty(t, requests)
+			last := requests[len(requests)-1]
+			require.Equal(t, "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", last.Path)
+
+			var payload struct{ ResetType string }
+			require.NoError(t, json.Unmarshal(last.Body, &payload))
+			require.Equal(t, tt.resetType, payload.ResetType)
+		})
+	}
+}
+
+func TestOutBand_NoSerialConsole(t *testing.T) {
+	r, srv := redfishtest.NewClient(t, "testdata/redfish.json")
+	srv.SetResource("/redfish/v1/Systems/1", map[string]any{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"Id":        "1",
+		"UUID":      "38947555-7742-3448-3784-823347823834",
+	})
+	ob := newOutBand(t, r)
+
+	require.False(t, ob.Capabilities().Supports(hal.OperationConsole))
+	require.ErrorIs(t, ob.ConsoleContext(t.Context(), nil), hal.ErrNotSupported)
+}
@@ -1,7 +1,7 @@
 package gigabyte
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/gliderlabs/ssh"
 	"github.com/google/uuid"
@@ -15,11 +15,6 @@ import (
 	"github.com/metal-stack/go-hal/pkg/logger"
 )
 
-var (
-	// errorNotImplemented for all functions that are not implemented yet
-	errorNotImplemented = fmt.Errorf("not implemented yet")
-)
-
 const (
 	vendor = api.VendorGigabyte
 )
@@ -40,8 +35,8 @@ type (
 )
 
 // InBand creates an inband connection to a Gigabyte server.
-func InBand(board *api.Board, log logger.Logger) (hal.InBand, error) {
-	ib, err := inband.New(board, true, log)
+func InBand(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error) {
+	ib, err := inband.New(ctx, board, true, log)
 	if err != nil {
 		return nil, err
 	}
@@ -59,35 +54,67 @@ func OutBand(r *redfish.APIClient, board *api.Board) hal.OutBand {
 
 // InBand
 func (ib *inBand) PowerOff() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerDown)
+	return ib.PowerOffContext(context.Background())
+}
+
+func (ib *inBand) PowerOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerDown)
 }
 
 func (ib *inBand) PowerCycle() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerCycle)
+	return ib.PowerCycleContext(context.Background())
+}
+
+func (ib *inBand) PowerCycleContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerCycle)
 }
 
 func (ib *inBand) PowerReset() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlHardReset)
+	return ib.PowerResetContext(context.Background())
+}
+
+func (ib *inBand) PowerResetContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlHardReset)
 }
 
 func (ib *inBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ib.IpmiTool.SetChassisIdentifyLEDState(state)
+	return ib.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ib *inBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDState(ctx, state)
 }
 
 func (ib *inBand) IdentifyLEDOn() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOn()
+	return ib.IdentifyLEDOnContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOn(ctx)
 }
 
 func (ib *inBand) IdentifyLEDOff() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOff()
+	return ib.IdentifyLEDOffContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOff(ctx)
 }
 
 func (ib *inBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ib.IpmiTool.SetBootOrder(bootTarget, vendor)
+	return ib.BootFromContext(context.Background(), bootTarget)
 }
 
-func (ib *inBand) SetFirmware(hal.FirmwareMode) error {
-	return errorNotImplemented
+func (ib *inBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ib.IpmiTool.SetBootOrder(ctx, bootTarget, vendor)
+}
+
+func (ib *inBand) SetFirmware(mode hal.FirmwareMode) error {
+	return ib.SetFirmwareContext(context.Background(), mode)
+}
+
+func (ib *inBand) SetFirmwareContext(context.Context, hal.FirmwareMode) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) Describe() string {
@@ -101,7 +128,7 @@ func (ib *inBand) BMCConnection() api.BMCConnection {
 }
 
 func (c *bmcConnection) BMC() (*api.BMC, error) {
-	return c.IpmiTool.BMC()
+	return c.IpmiTool.BMC(context.Background())
 }
 
 func (c *bmcConnection) PresentSuperUser() api.BMCUser {
@@ -133,38 +160,61 @@ func (c *bmcConnection) Present() bool {
 }
 
 func (c *bmcConnection) CreateUserAndPassword(user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
-	return c.IpmiTool.CreateUser(user, privilege, "", c.Board().Vendor.PasswordConstraints(), ipmi.HighLevel)
+	return c.CreateUserAndPasswordContext(context.Background(), user, privilege)
+}
+
+func (c *bmcConnection) CreateUserAndPasswordContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.IpmiTool.CreateUser(ctx, user, privilege, "", c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
-	_, err := c.IpmiTool.CreateUser(user, privilege, password, nil, ipmi.HighLevel)
+	return c.CreateUserContext(context.Background(), user, privilege, password)
+}
+
+func (c *bmcConnection) CreateUserContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	_, err := c.IpmiTool.CreateUser(ctx, user, privilege, password, c.Board().PasswordConstraints(), ipmi.HighLevel)
 	return err
 }
 
 func (c *bmcConnection) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
-	return c.IpmiTool.NeedsPasswordChange(user, password)
+	return c.NeedsPasswordChangeContext(context.Background(), user, password)
+}
+
+func (c *bmcConnection) NeedsPasswordChangeContext(ctx context.Context, user api.BMCUser, password string) (bool, error) {
+	return c.IpmiTool.NeedsPasswordChange(ctx, user, password)
 }
 
 func (c *bmcConnection) ChangePassword(user api.BMCUser, newPassword string) error {
-	return c.IpmiTool.ChangePassword(user, newPassword, ipmi.HighLevel)
+	return c.ChangePasswordContext(context.Background(), user, newPassword)
+}
+
+func (c *bmcConnection) ChangePasswordContext(ctx context.Context, user api.BMCUser, newPassword string) error {
+	return c.IpmiTool.ChangePassword(ctx, user, newPassword, c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) SetUserEnabled(user api.BMCUser, enabled bool) error {
-	return c.IpmiTool.SetUserEnabled(user, enabled, ipmi.HighLevel)
+	return c.SetUserEnabledContext(context.Background(), user, enabled)
+}
+
+func (c *bmcConnection) SetUserEnabledContext(ctx context.Context, user api.BMCUser, enabled bool) error {
+	return c.IpmiTool.SetUserEnabled(ctx, user, enabled, ipmi.HighLevel)
 }
 
 func (ib *inBand) ConfigureBIOS() (bool, error) {
-	//return false, errorNotImplemented // do not throw an error to not break manual tests
-	return false, nil //TODO https://github.com/metal-stack/go-hal/issues/11
+	return ib.ConfigureBIOSContext(context.Background())
 }
 
-func (ib *inBand) EnsureBootOrder(bootloaderID string) error {
-	return nil
+func (ib *inBand) ConfigureBIOSContext(context.Context) (bool, error) {
+	return false, hal.ErrNotSupported
 }
 
 // OutBand
 func (ob *outBand) UUID() (*uuid.UUID, error) {
-	u, err := ob.Redfish.MachineUUID()
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
+	u, err := ob.Redfish.MachineUUID(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -176,55 +226,139 @@ func (ob *outBand) UUID() (*uuid.UUID, error) {
 }
 
 func (ob *outBand) PowerState() (hal.PowerState, error) {
-	return ob.Redfish.PowerState()
+	return ob.PowerStateContext(context.Background())
+}
+
+func (ob *outBand) PowerStateContext(ctx context.Context) (hal.PowerState, error) {
+	return ob.Redfish.PowerState(ctx)
 }
 
 func (ob *outBand) PowerOff() error {
-	return ob.Redfish.PowerOff()
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Redfish.PowerOff(ctx)
 }
 
 func (ob *outBand) PowerOn() error {
-	return ob.Redfish.PowerReset() // PowerOn is not supported
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx) // PowerOn is not supported
 }
 
 func (ob *outBand) PowerReset() error {
-	return ob.Redfish.PowerReset()
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx)
 }
 
 func (ob *outBand) PowerCycle() error {
-	return ob.Redfish.PowerReset() // PowerCycle is not supported
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx) // PowerCycle is not supported
 }
 
 func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ob.Redfish.SetChassisIdentifyLEDState(state)
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ob.Redfish.SetChassisIdentifyLEDState(ctx, state)
 }
 
 func (ob *outBand) IdentifyLEDOn() error {
-	return ob.Redfish.SetChassisIdentifyLEDOn()
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOn(ctx)
 }
 
 func (ob *outBand) IdentifyLEDOff() error {
-	return ob.Redfish.SetChassisIdentifyLEDOff()
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOff(ctx)
 }
 
 func (ob *outBand) BootFrom(target hal.BootTarget) error {
-	return ob.Redfish.SetBootTarget(target)
+	return ob.BootFromContext(context.Background(), target)
+}
+
+func (ob *outBand) BootFromContext(ctx context.Context, target hal.BootTarget) error {
+	return ob.Redfish.SetBootTarget(ctx, target)
 }
 
 func (ob *outBand) Describe() string {
 	return "OutBand connected to Gigabyte"
 }
 
+func (ob *outBand) Capabilities() hal.Capabilities {
+	return hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportRedfish,
+			hal.OperationPowerState:        hal.TransportRedfish,
+			hal.OperationEvents:            hal.TransportRedfish,
+			hal.OperationSEL:               hal.TransportRedfish,
+			hal.OperationSensors:           hal.TransportRedfish,
+			hal.OperationIdentifyLED:       hal.TransportRedfish,
+			hal.OperationVirtualMedia:      hal.TransportRedfish,
+			hal.OperationBootConfiguration: hal.TransportRedfish,
+			hal.OperationBIOSAttributes:    hal.TransportRedfish,
+			hal.OperationBIOSProfile:       hal.TransportRedfish,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:       hal.TransportRedfish,
+			hal.BootTargetDisk:      hal.TransportRedfish,
+			hal.BootTargetBIOS:      hal.TransportRedfish,
+			hal.BootTargetCD:        hal.TransportRedfish,
+			hal.BootTargetUSB:       hal.TransportRedfish,
+			hal.BootTargetHTTP:      hal.TransportRedfish,
+			hal.BootTargetUEFIShell: hal.TransportRedfish,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportRedfish,
+			hal.PowerActionOff:   hal.TransportRedfish,
+			hal.PowerActionReset: hal.TransportRedfish,
+			hal.PowerActionCycle: hal.TransportRedfish,
+		},
+		EmulatedPowerActions: map[hal.PowerAction]hal.PowerAction{
+			hal.PowerActionOn:    hal.PowerActionReset,
+			hal.PowerActionCycle: hal.PowerActionReset,
+		},
+	}
+}
+
 func (ob *outBand) Console(s ssh.Session) error {
-	return errorNotImplemented
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(context.Context, ssh.Session) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) UpdateBIOS(url string) error {
-	return nil
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(context.Context, string) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) UpdateBMC(url string) error {
-	return nil
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(context.Context, string) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
@@ -234,5 +368,5 @@ func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
 }
 
 func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
-	return c.Redfish.BMC()
+	return c.Redfish.BMC(context.Background())
 }
@@ -0,0 +1,410 @@
+package hpe
+
+import (
+	"context"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/console"
+	"github.com/metal-stack/go-hal/internal/inband"
+	"github.com/metal-stack/go-hal/internal/ipmi"
+	"github.com/metal-stack/go-hal/internal/outband"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+const (
+	vendor  = api.VendorHPE
+	sshPort = 22 // default SSH port of iLO
+
+	// virtualSerialPort is the iLO cli command which attaches to the virtual serial port of the server
+	virtualSerialPort = "vsp"
+	// addFromURI is the iLO action which downloads a firmware image into the iLO repository and optionally flashes it
+	addFromURI = "/redfish/v1/UpdateService/Actions/Oem/Hpe/HpeiLOUpdateServiceExt.AddFromUri"
+)
+
+type (
+	inBand struct {
+		*inband.InBand
+	}
+	outBand struct {
+		*outband.OutBand
+		log logger.Logger
+	}
+	bmcConnection struct {
+		*inBand
+	}
+	bmcConnectionOutBand struct {
+		*outBand
+	}
+
+	addFromURIRequest struct {
+		ImageURI string `json:"ImageURI"`
+		// UpdateRepository keeps the image in the iLO repository, so it can be reapplied or rolled back to
+		UpdateRepository bool `json:"UpdateRepository"`
+		// UpdateTarget flashes the image right away
+		UpdateTarget bool `json:"UpdateTarget"`
+		// TPMOverrideFlag is required to flash the system ROM on servers with an active TPM
+		TPMOverrideFlag bool `json:"TPMOverrideFlag"`
+	}
+)
+
+// InBand creates an inband connection to a HPE server.
+func InBand(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error) {
+	ib, err := inband.New(ctx, board, true, log)
+	if err != nil {
+		return nil, err
+	}
+	return &inBand{
+		InBand: ib,
+	}, nil
+}
+
+// OutBand creates an outband connection to a HPE server.
+func OutBand(r *redfish.APIClient, board *api.Board, user, password, ip string, log logger.Logger) hal.OutBand {
+	return &outBand{
+		OutBand: outband.ViaRedfishPlusSSH(r, board, user, password, ip, sshPort),
+		log:     log,
+	}
+}
+
+// InBand
+func (ib *inBand) PowerOff() error {
+	return ib.PowerOffContext(context.Background())
+}
+
+func (ib *inBand) PowerOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerDown)
+}
+
+func (ib *inBand) PowerCycle() error {
+	return ib.PowerCycleContext(context.Background())
+}
+
+func (ib *inBand) PowerCycleContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerCycle)
+}
+
+func (ib *inBand) PowerReset() error {
+	return ib.PowerResetContext(context.Background())
+}
+
+func (ib *inBand) PowerResetContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlHardReset)
+}
+
+func (ib *inBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
+	return ib.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ib *inBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDState(ctx, state)
+}
+
+func (ib *inBand) IdentifyLEDOn() error {
+	return ib.IdentifyLEDOnContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOn(ctx)
+}
+
+func (ib *inBand) IdentifyLEDOff() error {
+	return ib.IdentifyLEDOffContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOff(ctx)
+}
+
+func (ib *inBand) BootFrom(bootTarget hal.BootTarget) error {
+	return ib.BootFromContext(context.Background(), bootTarget)
+}
+
+func (ib *inBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ib.IpmiTool.SetBootOrder(ctx, bootTarget, vendor)
+}
+
+func (ib *inBand) SetFirmware(mode hal.FirmwareMode) error {
+	return ib.SetFirmwareContext(context.Background(), mode)
+}
+
+func (ib *inBand) SetFirmwareContext(context.Context, hal.FirmwareMode) error {
+	return hal.ErrNotSupported
+}
+
+func (ib *inBand) Describe() string {
+	return "InBand connected to HPE"
+}
+
+func (ib *inBand) BMCConnection() api.BMCConnection {
+	return &bmcConnection{
+		inBand: ib,
+	}
+}
+
+func (c *bmcConnection) BMC() (*api.BMC, error) {
+	return c.IpmiTool.BMC(context.Background())
+}
+
+func (c *bmcConnection) PresentSuperUser() api.BMCUser {
+	return api.BMCUser{
+		Name:          "Administrator",
+		Id:            "1",
+		ChannelNumber: 2,
+	}
+}
+
+func (c *bmcConnection) SuperUser() api.BMCUser {
+	return api.BMCUser{
+		Name:          "root",
+		Id:            "2",
+		ChannelNumber: 2,
+	}
+}
+
+func (c *bmcConnection) User() api.BMCUser {
+	return api.BMCUser{
+		Name:          "metal",
+		Id:            "3",
+		ChannelNumber: 2,
+	}
+}
+
+func (c *bmcConnection) Present() bool {
+	return c.IpmiTool.DevicePresent()
+}
+
+func (c *bmcConnection) CreateUserAndPassword(user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.CreateUserAndPasswordContext(context.Background(), user, privilege)
+}
+
+func (c *bmcConnection) CreateUserAndPasswordContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.IpmiTool.CreateUser(ctx, user, privilege, "", c.Board().PasswordConstraints(), ipmi.HighLevel)
+}
+
+func (c *bmcConnection) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	return c.CreateUserContext(context.Background(), user, privilege, password)
+}
+
+func (c *bmcConnection) CreateUserContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	_, err := c.IpmiTool.CreateUser(ctx, user, privilege, password, c.Board().PasswordConstraints(), ipmi.HighLevel)
+	return err
+}
+
+func (c *bmcConnection) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
+	return c.NeedsPasswordChangeContext(context.Background(), user, password)
+}
+
+func (c *bmcConnection) NeedsPasswordChangeContext(ctx context.Context, user api.BMCUser, password string) (bool, error) {
+	return c.IpmiTool.NeedsPasswordChange(ctx, user, password)
+}
+
+func (c *bmcConnection) ChangePassword(user api.BMCUser, newPassword string) error {
+	return c.ChangePasswordContext(context.Background(), user, newPassword)
+}
+
+func (c *bmcConnection) ChangePasswordContext(ctx context.Context, user api.BMCUser, newPassword string) error {
+	return c.IpmiTool.ChangePassword(ctx, user, newPassword, c.Board().PasswordConstraints(), ipmi.HighLevel)
+}
+
+func (c *bmcConnection) SetUserEnabled(user api.BMCUser, enabled bool) error {
+	return c.SetUserEnabledContext(context.Background(), user, enabled)
+}
+
+func (c *bmcConnection) SetUserEnabledContext(ctx context.Context, user api.BMCUser, enabled bool) error {
+	return c.IpmiTool.SetUserEnabled(ctx, user, enabled, ipmi.HighLevel)
+}
+
+func (ib *inBand) ConfigureBIOS() (bool, error) {
+	return ib.ConfigureBIOSContext(context.Background())
+}
+
+func (ib *inBand) ConfigureBIOSContext(context.Context) (bool, error) {
+	return false, hal.ErrNotSupported
+}
+
+// OutBand
+func (ob *outBand) UUID() (*uuid.UUID, error) {
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
+	u, err := ob.Redfish.MachineUUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	us, err := uuid.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	return &us, nil
+}
+
+func (ob *outBand) PowerState() (hal.PowerState, error) {
+	return ob.PowerStateContext(context.Background())
+}
+
+func (ob *outBand) PowerStateContext(ctx context.Context) (hal.PowerState, error) {
+	return ob.Redfish.PowerState(ctx)
+}
+
+func (ob *outBand) PowerOff() error {
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Redfish.PowerOff(ctx)
+}
+
+func (ob *outBand) PowerOn() error {
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Redfish.Reset(ctx, schemas.OnResetType) // iLO does not know ForceOn
+}
+
+func (ob *outBand) PowerReset() error {
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx)
+}
+
+func (ob *outBand) PowerCycle() error {
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Redfish.PowerReset(ctx) // PowerCycle is not supported by iLO
+}
+
+func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ob.Redfish.SetChassisIdentifyLEDState(ctx, state)
+}
+
+func (ob *outBand) IdentifyLEDOn() error {
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOn(ctx)
+}
+
+func (ob *outBand) IdentifyLEDOff() error {
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ob.Redfish.SetChassisIdentifyLEDOff(ctx)
+}
+
+func (ob *outBand) BootFrom(target hal.BootTarget) error {
+	return ob.BootFromContext(context.Background(), target)
+}
+
+// BootFromContext sets the default boot override of the target but leaves the override mode untouched,
+// iLO rejects changing it together with the target on older firmware and only boots the configured mode anyway.
+func (ob *outBand) BootFromContext(ctx context.Context, target hal.BootTarget) error {
+	override := hal.DefaultBootOverride(target)
+	override.Mode = hal.FirmwareModeUnknown
+	return ob.SetBootOverride(ctx, override)
+}
+
+func (ob *outBand) Describe() string {
+	return "OutBand connected to HPE"
+}
+
+func (ob *outBand) Capabilities() hal.Capabilities {
+	return hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportRedfish,
+			hal.OperationPowerState:        hal.TransportRedfish,
+			hal.OperationEvents:            hal.TransportRedfish,
+			hal.OperationSEL:               hal.TransportRedfish,
+			hal.OperationSensors:           hal.TransportRedfish,
+			hal.OperationIdentifyLED:       hal.TransportRedfish,
+			hal.OperationConsole:           hal.TransportSSH,
+			hal.OperationVirtualMedia:      hal.TransportRedfish,
+			hal.OperationBootConfiguration: hal.TransportRedfish,
+			hal.OperationBIOSAttributes:    hal.TransportRedfish,
+			hal.OperationBIOSProfile:       hal.TransportRedfish,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:       hal.TransportRedfish,
+			hal.BootTargetDisk:      hal.TransportRedfish,
+			hal.BootTargetBIOS:      hal.TransportRedfish,
+			hal.BootTargetCD:        hal.TransportRedfish,
+			hal.BootTargetUSB:       hal.TransportRedfish,
+			hal.BootTargetHTTP:      hal.TransportRedfish,
+			hal.BootTargetUEFIShell: hal.TransportRedfish,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportRedfish,
+			hal.PowerActionOff:   hal.TransportRedfish,
+			hal.PowerActionReset: hal.TransportRedfish,
+			hal.PowerActionCycle: hal.TransportRedfish,
+		},
+		EmulatedPowerActions: map[hal.PowerAction]hal.PowerAction{
+			hal.PowerActionCycle: hal.PowerActionReset,
+		},
+		UpdateMethods: map[hal.UpdateMethod]hal.Transport{
+			hal.UpdateMethodBIOS: hal.TransportRedfish,
+			hal.UpdateMethodBMC:  hal.TransportRedfish,
+		},
+	}
+}
+
+func (ob *outBand) Console(s ssh.Session) error {
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(ctx context.Context, s ssh.Session) error {
+	return console.OverSSH(ctx, ob.log, s, ob.GetUsername(), ob.GetPassword(), ob.GetIP(), ob.GetSSHPort(), virtualSerialPort)
+}
+
+func (ob *outBand) UpdateBIOS(url string) error {
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(ctx context.Context, url string) error {
+	return ob.updateFirmware(ctx, url)
+}
+
+func (ob *outBand) UpdateBMC(url string) error {
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(ctx context.Context, url string) error {
+	return ob.updateFirmware(ctx, url)
+}
+
+// updateFirmware lets iLO download the image into its repository and flash it, iLO detects the component itself
+func (ob *outBand) updateFirmware(ctx context.Context, url string) error {
+	return ob.Redfish.PostJSON(ctx, addFromURI, addFromURIRequest{
+		ImageURI:         url,
+		UpdateRepository: true,
+		UpdateTarget:     true,
+		TPMOverrideFlag:  true,
+	}, nil)
+}
+
+func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
+	return &bmcConnectionOutBand{
+		outBand: ob,
+	}
+}
+
+func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
+	return c.Redfish.BMC(context.Background())
+}
@@ -0,0 +1,92 @@
+package hpe
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutBand(t *testing.T) (*outBand, *redfishtest.Server) {
+	r, b, srv := redfishtest.NewBoardClient(t, "testdata/ilo5.json")
+	require.Equal(t, api.VendorHPE, b.Vendor)
+
+	ob, ok := OutBand(r, b, redfishtest.User, redfishtest.Password, "127.0.0.1", redfishtest.Logger()).(*outBand)
+	require.True(t, ok)
+	return ob, srv
+}
+
+func lastRequest(t *testing.T, srv *redfishtest.Server, v any) redfishtest.Request {
+	requests := srv.Requests()
+	require.NotEmpty(t, requests)
+	last := requests[len(requests)-1]
+	require.NoError(t, json.Unmarshal(last.Body, v))
+	return last
+}
+
+func TestOutBand_BootFrom(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	tests := []struct {
+		name        string
+		target      hal.BootTarget
+		wantTarget  string
+		wantEnabled string
+	}{
+		{name: "pxe", target: hal.BootTargetPXE, wantTarget: "Pxe", wantEnabled: "Continuous"},
+		{name: "disk", target: hal.BootTargetDisk, wantTarget: "Hdd", wantEnabled: "Continuous"},
+		{name: "bios", target: hal.BootTargetBIOS, wantTarget: "BiosSetup", wantEnabled: "Once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ob.BootFromContext(t.Context(), tt.target))
+
+			var payload struct {
+				Boot map[string]any
+			}
+			last := lastRequest(t, srv, &payload)
+			require.Equal(t, "/redfish/v1/Systems/1", last.Path)
+			require.Equal(t, tt.wantTarget, payload.Boot["BootSourceOverrideTarget"])
+			require.Equal(t, tt.wantEnabled, payload.Boot["BootSourceOverrideEnabled"])
+			require.NotContains(t, payload.Boot, "BootSourceOverrideMode")
+		})
+	}
+}
+
+func TestOutBand_PowerOn(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	require.NoError(t, ob.PowerOnContext(t.Context()))
+
+	var payload struct{ ResetType string }
+	last := lastRequest(t, srv, &payload)
+	require.Equal(t, "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", last.Path)
+	require.Equal(t, "On", payload.ResetType)
+}
+
+func TestOutBand_UpdateBIOS(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	require.NoError(t, ob.UpdateBIOSContext(t.Context(), "http://images.example.com/U32_2.80.fwpkg"))
+
+	var payload addFromURIRequest
+	last := lastRequest(t, srv, &payload)
+	require.Equal(t, addFromURI, last.Path)
+	require.Equal(t, addFromURIRequest{
+		ImageURI:         "http://images.example.com/U32_2.80.fwpkg",
+		UpdateRepository: true,
+		UpdateTarget:     true,
+		TPMOverrideFlag:  true,
+	}, payload)
+}
+
+func TestOutBand_UUID(t *testing.T) {
+	ob, _ := newOutBand(t)
+
+	u, err := ob.UUIDContext(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "36373839-3935-435a-3230-323152525431", u.String())
+}
@@ -0,0 +1,184 @@
+package megarac
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/outband"
+	"github.com/metal-stack/go-hal/internal/redfish"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutBand(t *testing.T) (*outBand, *redfishtest.Server) {
+	r, b, srv := redfishtest.NewBoardClient(t, "testdata/megarac.json")
+	require.Equal(t, api.VendorASRockRack, b.Vendor)
+
+	// ipmitool is not required for the redfish based operations
+	return &outBand{
+		OutBand: outband.New(r, nil, b, "127.0.0.1", 623, redfishtest.User, redfishtest.Password),
+		log:     redfishtest.Logger(),
+	}, srv
+}
+
+func TestOutBand_BootFrom(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	tests := []struct {
+		name        string
+		target      hal.BootTarget
+		wantTarget  string
+		wantEnabled string
+	}{
+		{name: "pxe", target: hal.BootTargetPXE, wantTarget: "Pxe", wantEnabled: "Continuous"},
+		{name: "disk", target: hal.BootTargetDisk, wantTarget: "Hdd", wantEnabled: "Continuous"},
+		{name: "bios", target: hal.BootTargetBIOS, wantTarget: "BiosSetup", wantEnabled: "Once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ob.BootFromContext(t.Context(), tt.target))
+
+			requests := srv.Requests()
+			last := requests[len(requests)-1]
+			require.Equal(t, http.MethodPatch, last.Method)
+			require.Equal(t, "/redfish/v1/Systems/Self", last.Path)
+			require.Equal(t, `"1700213582"`, last.Header.Get("If-Match"))
+
+			var payload struct {
+				Boot map[string]any
+			}
+			require.NoError(t, json.Unmarshal(last.Body, &payload))
+			require.Equal(t, tt.wantTarget, payload.Boot["BootSourceOverrideTarget"])
+			require.Equal(t, tt.wantEnabled, payload.Boot["BootSourceOverrideEnabled"])
+			require.Equal(t, "UEFI", payload.Boot["BootSourceOverrideMode"])
+		})
+	}
+}
+
+func TestOutBand_BootFromRetriesOnPreconditionFailed(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	attempts := 0
+	srv.Handle(http.MethodPatch, "/redfish/v1/Systems/Self", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// the system was modified in between, the next read returns the new version
+			srv.SetResource("/redfish/v1/Systems/Self", map[string]any{
+				"@odata.id":   "/redfish/v1/Systems/Self",
+				"@odata.etag": `"1700214001"`,
+				"Id":          "Self",
+			})
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	require.NoError(t, ob.BootFromContext(t.Context(), hal.BootTargetPXE))
+	require.Equal(t, 2, attempts)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, `"1700213582"`, requests[0].Header.Get("If-Match"))
+	require.Equal(t, `"1700214001"`, requests[1].Header.Get("If-Match"))
+}
+
+func TestOutBand_BootFromGivesUpOnPreconditionFailed(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	srv.Handle(http.MethodPatch, "/redfish/v1/Systems/Self", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+	})
+
+	err := ob.BootFromContext(t.Context(), hal.BootTargetPXE)
+	var statusErr *redfish.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusPreconditionFailed, statusErr.StatusCode)
+	require.Len(t, srv.Requests(), 3)
+}
+
+func TestOutBand_SetBootOverride(t *testing.T) {
+	ob, srv := newOutBand(t)
+
+	err := ob.SetBootOverride(t.Context(), hal.BootOverride{Target: hal.BootTargetCD, Mode: hal.FirmwareModeLegacy})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	last := requests[len(requests)-1]
+	require.Equal(t, http.MethodPatch, last.Method)
+	require.Equal(t, `"1700213582"`, last.Header.Get("If-Match"))
+	require.JSONEq(t, `{"Boot":{"BootSourceOverrideEnabled":"Once","BootSourceOverrideMode":"Legacy","BootSourceOverrideTarget":"Cd"}}`, string(last.Body))
+}
+
+func TestOutBand_BIOSAttributes(t *testing.T) {
+	ob, _ := newOutBand(t)
+
+	attributes, err := ob.BIOSAttributes(t.Context())
+	require.NoError(t, err)
+	require.Len(t, attributes, 5)
+
+	var pending []hal.BIOSAttribute
+	for _, a := range attributes {
+		if a.Pending() {
+			pending = append(pending, a)
+		}
+	}
+	require.Equal(t, []hal.BIOSAttribute{{Name: "IPv6PXESupport", Value: "Disabled", PendingValue: "Enabled"}}, pending)
+}
+
+func TestOutBand_SetBIOSAttributes(t *testing.T) {
+	tests := []struct {
+		name          string
+		bios          map[string]any
+		wantPath      string
+		wantETag      string
+		wantApplyTime bool
+	}{
+		{
+			name:          "settings object and apply time announced",
+			wantPath:      "/redfish/v1/Systems/Self/Bios/SD",
+			wantETag:      `"1700213914"`,
+			wantApplyTime: true,
+		},
+		{
+			name: "older firmware",
+			bios: map[string]any{
+				"@odata.id":  "/redfish/v1/Systems/Self/Bios",
+				"Attributes": map[string]any{"IPv6PXESupport": "Disabled"},
+			},
+			wantPath:      "/redfish/v1/Systems/Self/Bios/Settings",
+			wantApplyTime: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, srv := newOutBand(t)
+			if tt.bios != nil {
+				srv.SetResource("/redfish/v1/Systems/Self/Bios", tt.bios)
+				srv.SetResource("/redfish/v1/Systems/Self/Bios/Settings", map[string]any{})
+			}
+
+			require.NoError(t, ob.SetBIOSAttributes(t.Context(), map[string]any{"IPv6PXESupport": "Enabled"}, hal.ApplyTimeOnReset))
+
+			requests := srv.Requests()
+			require.Len(t, requests, 1)
+			require.Equal(t, http.MethodPatch, requests[0].Method)
+			require.Equal(t, tt.wantPath, requests[0].Path)
+			if tt.wantETag != "" {
+				require.Equal(t, tt.wantETag, requests[0].Header.Get("If-Match"))
+			}
+
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(requests[0].Body, &payload))
+			require.Equal(t, map[string]any{"IPv6PXESupport": "Enabled"}, payload["Attributes"])
+			if tt.wantApplyTime {
+				require.Equal(t, map[string]any{"ApplyTime": "OnReset"}, payload["@Redfish.SettingsApplyTime"])
+			} else {
+				require.NotContains(t, payload, "@Redfish.SettingsApplyTime")
+			}
+		})
+	}
+}
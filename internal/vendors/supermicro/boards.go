@@ -0,0 +1,195 @@
+package supermicro
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// defaultBoards the catalog of the boards which are known to work with SUM
+//
+//go:embed boards.json
+var defaultBoards []byte
+
+// bootOptionCount SUM does not complain or fail if more boot options are given than actually available
+const bootOptionCount = 9
+
+// board describes how the BIOS of a board model is configured with SUM, all settings are addressed by their
+// path in the BIOS configuration as described in hal.BIOSProfile
+type board struct {
+	// Description is only for humans reading the catalog
+	Description string `json:"description,omitempty"`
+	// UEFIOnly boards are left alone by ConfigureBIOS if the machine already booted with UEFI
+	UEFIOnly bool `json:"uefiOnly,omitempty"`
+	// SkipBootOrder boards are left alone by EnsureBootOrder
+	SkipBootOrder bool `json:"skipBootOrder,omitempty"`
+	// SecureBoot is the path of the secure boot setting, empty if secure boot is not available in the BIOS
+	SecureBoot string `json:"secureBoot,omitempty"`
+	// BootOption is the path of the boot options with %d in place of the number of the option, e.g. "Boot/Boot Option #%d[1]"
+	BootOption string `json:"bootOption,omitempty"`
+	// UEFIBootOption is the path of the boot options set together with UEFIBoot if it differs from BootOption
+	UEFIBootOption string `json:"uefiBootOption,omitempty"`
+	// UEFIBoot are the settings besides the boot options which switch the board to UEFI
+	UEFIBoot map[string]string `json:"uefiBoot,omitempty"`
+}
+
+var (
+	boardsLock sync.RWMutex
+	boards     = mustParseBoards(defaultBoards)
+)
+
+// LoadBoards reads a board catalog from the given file in the format of boards.json,
+// its entries are added to the built-in catalog and replace built-in entries of the same board model.
+func LoadBoards(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open board catalog %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	catalog, err := parseBoards(f)
+	if err != nil {
+		return fmt.Errorf("invalid board catalog %s %w", path, err)
+	}
+
+	boardsLock.Lock()
+	defer boardsLock.Unlock()
+	merged := maps.Clone(boards)
+	maps.Copy(merged, catalog)
+	boards = merged
+	return nil
+}
+
+// lookupBoard returns the catalog entry of the board model
+func lookupBoard(model string) (*board, bool) {
+	boardsLock.RLock()
+	defer boardsLock.RUnlock()
+	b, ok := boards[model]
+	return b, ok
+}
+
+func mustParseBoards(data []byte) map[string]*board {
+	catalog, err := parseBoards(bytes.NewReader(data))
+	if err != nil {
+		panic(fmt.Sprintf("built-in board catalog is invalid %v", err))
+	}
+	return catalog
+}
+
+func parseBoards(r io.Reader) (map[string]*board, error) {
+	var catalog map[string]*board
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for model, b := range catalog {
+		if b == nil {
+			errs = append(errs, fmt.Errorf("board %s has no definition", model))
+			continue
+		}
+		err := b.validate()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("board %s %w", model, err))
+		}
+	}
+	return catalog, errors.Join(errs...)
+}
+
+func (b *board) validate() error {
+	var errs []error
+	if b.SecureBoot != "" {
+		_, err := parseSettingPath(b.SecureBoot)
+		errs = append(errs, err)
+	}
+	if b.BootOption != "" {
+		errs = append(errs, validateBootOption(b.BootOption))
+	} else if !b.SkipBootOrder || len(b.UEFIBoot) > 0 {
+		errs = append(errs, errors.New("boot option is required to configure the boot order"))
+	}
+	if b.UEFIBootOption != "" {
+		errs = append(errs, validateBootOption(b.UEFIBootOption))
+	}
+	for path := range b.UEFIBoot {
+		_, err := parseSettingPath(path)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func validateBootOption(option string) error {
+	if strings.Count(option, "%d") != 1 || strings.Count(option, "%") != 1 {
+		return fmt.Errorf("boot option %q must contain exactly one %%d", option)
+	}
+	_, err := parseSettingPath(fmt.Sprintf(option, 1))
+	return err
+}
+
+// uefiBootSettings switch the board to UEFI boot from the network
+func (b *board) uefiBootSettings() map[string]string {
+	if len(b.UEFIBoot) == 0 {
+		return nil
+	}
+	return withBootOptions(maps.Clone(b.UEFIBoot), cmp.Or(b.UEFIBootOption, b.BootOption), uefiNetworkBootOptionPlaceholder)
+}
+
+// bootOrderSettings boot the installed operating system first and fall back to the network
+func (b *board) bootOrderSettings() map[string]string {
+	return withBootOptions(map[string]string{}, b.BootOption, uefiHardDiskBootOption, uefiNetworkBootOptionPlaceholder)
+}
+
+// withBootOptions adds all boot options at the path of the format to the settings, the first boot options are set
+// to the given options, all others are disabled.
+func withBootOptions(settings map[string]string, format string, options ...string) map[string]string {
+	for i := range bootOptionCount {
+		option := "Disabled"
+		if i < len(options) {
+			option = options[i]
+		}
+		settings[fmt.Sprintf(format, i+1)] = option
+	}
+	return settings
+}
+
+// matches reports whether the current value of a catalog setting is the desired one.
+// SUM decorates boot options, e.g. "UEFI Network:IPv4 ...", a boot option matches if it contains the desired one.
+func (b *board) matches(path, current, desired string) bool {
+	if b.isBootOption(path) {
+		return strings.Contains(current, desired)
+	}
+	return current == desired
+}
+
+func (b *board) isBootOption(path string) bool {
+	for _, format := range []string{b.BootOption, b.UEFIBootOption} {
+		if format == "" {
+			continue
+		}
+		for i := range bootOptionCount {
+			if path == fmt.Sprintf(format, i+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errUnknownBoard the board model is not in the board catalog, its BIOS can not be configured
+func errUnknownBoard(model string) error {
+	return fmt.Errorf("%w: board %q is not in the supermicro board catalog", hal.ErrNotSupported, model)
+}
@@ -0,0 +1,152 @@
+package supermicro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+func TestDefaultBoards(t *testing.T) {
+	bigtwin, ok := lookupBoard("X11DPT-B")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"Boot/Boot mode select":                              "UEFI",
+		"Boot/LEGACY to EFI support":                         "Disabled",
+		"Security/SMC Secure Boot Configuration/Secure Boot": "Enabled",
+		"Boot/Boot Option #1[1]":                             uefiNetworkBootOptionPlaceholder,
+		"Boot/Boot Option #2[1]":                             "Disabled",
+		"Boot/Boot Option #3[1]":                             "Disabled",
+		"Boot/Boot Option #4[1]":                             "Disabled",
+		"Boot/Boot Option #5[1]":                             "Disabled",
+		"Boot/Boot Option #6[1]":                             "Disabled",
+		"Boot/Boot Option #7[1]":                             "Disabled",
+		"Boot/Boot Option #8[1]":                             "Disabled",
+		"Boot/Boot Option #9[1]":                             "Disabled",
+	}, bigtwin.uefiBootSettings())
+
+	s2, ok := lookupBoard("X11SDV-8C-TP8F")
+	require.True(t, ok)
+	require.True(t, s2.UEFIOnly)
+	require.Empty(t, s2.SecureBoot)
+	require.NotContains(t, s2.uefiBootSettings(), "Security/SMC Secure Boot Configuration/Secure Boot", "the S2 BIOS has no secure boot setting")
+	settings := s2.bootOrderSettings()
+	require.Len(t, settings, bootOptionCount)
+	require.Equal(t, uefiHardDiskBootOption, settings["Boot/UEFI Boot Option #1"])
+	require.Equal(t, uefiNetworkBootOptionPlaceholder, settings["Boot/UEFI Boot Option #2"])
+	require.Equal(t, "Disabled", settings["Boot/UEFI Boot Option #3"])
+
+	// the uefi boot options of the N1 are set without order, only the boot order selects the first one
+	n1, ok := lookupBoard("X11SDD-8C-F")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"Boot/Boot mode select":      "UEFI",
+		"Boot/Legacy To EFI Support": "Disabled",
+		"Boot/Boot Option #1":        uefiNetworkBootOptionPlaceholder,
+		"Boot/Boot Option #2":        "Disabled",
+		"Boot/Boot Option #3":        "Disabled",
+		"Boot/Boot Option #4":        "Disabled",
+		"Boot/Boot Option #5":        "Disabled",
+		"Boot/Boot Option #6":        "Disabled",
+		"Boot/Boot Option #7":        "Disabled",
+		"Boot/Boot Option #8":        "Disabled",
+		"Boot/Boot Option #9":        "Disabled",
+	}, n1.uefiBootSettings())
+	settings = n1.bootOrderSettings()
+	require.Equal(t, uefiHardDiskBootOption, settings["Boot/Boot Option #1[1]"])
+	require.Equal(t, uefiNetworkBootOptionPlaceholder, settings["Boot/Boot Option #2[1]"])
+
+	gpu, ok := lookupBoard("X13DDW-A")
+	require.True(t, ok)
+	require.True(t, gpu.SkipBootOrder)
+	require.Nil(t, gpu.uefiBootSettings())
+}
+
+func TestLoadBoards(t *testing.T) {
+	builtin := boards
+	t.Cleanup(func() { boards = builtin })
+
+	path := filepath.Join(t.TempDir(), "boards.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "X11DPT-B": {"uefiOnly": true, "skipBootOrder": true},
+  "X14DBT-B": {
+    "description": "not yet built-in",
+    "secureBoot": "Security/Secure Boot/Secure Boot",
+    "bootOption": "Boot/UEFI Boot Option #%d",
+    "uefiBoot": {"Boot/Boot mode select": "UEFI"}
+  }
+}`), 0600))
+	require.NoError(t, LoadBoards(path))
+
+	b, ok := lookupBoard("X14DBT-B")
+	require.True(t, ok)
+	require.Equal(t, "Security/Secure Boot/Secure Boot", b.SecureBoot)
+	b, ok = lookupBoard("X11DPT-B")
+	require.True(t, ok)
+	require.True(t, b.UEFIOnly)
+	_, ok = lookupBoard("X11DPU")
+	require.True(t, ok, "built-in boards must be kept")
+	require.NotContains(t, builtin, "X14DBT-B", "the built-in catalog must not be modified")
+}
+
+func TestLoadBoardsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+		wantErr string
+	}{
+		{
+			name:    "unknown field",
+			catalog: `{"X11DPT-B": {"bootOrder": "Boot/Boot Option #%d"}}`,
+			wantErr: `unknown field "bootOrder"`,
+		},
+		{
+			name:    "boot option without number",
+			catalog: `{"X11DPT-B": {"bootOption": "Boot/Boot Option #1"}}`,
+			wantErr: "board X11DPT-B boot option \"Boot/Boot Option #1\" must contain exactly one %d",
+		},
+		{
+			name:    "uefi boot option without number",
+			catalog: `{"X11DPT-B": {"bootOption": "Boot/Boot Option #%d", "uefiBootOption": "Boot/Boot Option"}}`,
+			wantErr: "board X11DPT-B boot option \"Boot/Boot Option\" must contain exactly one %d",
+		},
+		{
+			name:    "boot option missing",
+			catalog: `{"X11DPT-B": {"uefiBoot": {"Boot/Boot mode select": "UEFI"}}}`,
+			wantErr: "board X11DPT-B boot option is required to configure the boot order",
+		},
+		{
+			name:    "invalid setting path",
+			catalog: `{"X11DPT-B": {"skipBootOrder": true, "secureBoot": "Secure Boot"}}`,
+			wantErr: "must consist of at least one menu and the setting",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builtin := boards
+			t.Cleanup(func() { boards = builtin })
+
+			path := filepath.Join(t.TempDir(), "boards.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.catalog), 0600))
+			err := LoadBoards(path)
+			require.ErrorContains(t, err, tt.wantErr)
+			require.Equal(t, builtin, boards)
+		})
+	}
+}
+
+func TestUnknownBoard(t *testing.T) {
+	s, err := newSum("/bin/true", "X99UNKNOWN", logger.New())
+	require.NoError(t, err)
+
+	_, err = s.ConfigureBIOS(t.Context())
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	require.ErrorContains(t, err, `board "X99UNKNOWN" is not in the supermicro board catalog`)
+
+	err = s.EnsureBootOrder(t.Context(), "metal-ubuntu")
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+}
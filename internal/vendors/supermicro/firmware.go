@@ -1,51 +1,52 @@
 package supermicro
 
 import (
-	"bytes"
+	"context"
 	"io"
 	"os"
 )
 
 // UpdateBIOS updates given BIOS
-func (s *sum) UpdateBIOS(reader io.Reader) error {
-	return s.updateFirmware(reader, "UpdateBios", "--reboot", "--preserve_setting")
+func (s *sum) UpdateBIOS(ctx context.Context, reader io.Reader) error {
+	return s.updateFirmware(ctx, reader, "UpdateBios", "--reboot", "--preserve_setting")
 }
 
 // UpdateBMC updates given BMC
-func (s *sum) UpdateBMC(reader io.Reader) error {
-	return s.updateFirmware(reader, "UpdateBmc")
+func (s *sum) UpdateBMC(ctx context.Context, reader io.Reader) error {
+	return s.updateFirmware(ctx, reader, "UpdateBmc")
 }
 
 // updateFirmware updates given firmware
-func (s *sum) updateFirmware(reader io.Reader, command string, additionalArgs ...string) error {
-	firmwareUpdate, err := writeFirmwareUpdate(reader)
+func (s *sum) updateFirmware(ctx context.Context, reader io.Reader, command string, additionalArgs ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.workDir()
+	if err != nil {
+		return err
+	}
+	defer s.removeWorkDir(dir)
+
+	firmwareUpdate, err := writeFirmwareUpdate(dir, reader)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = os.Remove(firmwareUpdate)
-	}()
 
 	args := []string{"-c", command, "--file", firmwareUpdate}
 	args = append(args, additionalArgs...)
 
-	return s.execute(args...)
+	return s.execute(ctx, args...)
 }
 
-func writeFirmwareUpdate(reader io.Reader) (string, error) {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(reader)
-	if err != nil {
-		return "", err
-	}
-
-	tmp, err := os.CreateTemp(".", "firmware.update-")
+func writeFirmwareUpdate(dir string, reader io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(dir, "firmware.update-")
 	if err != nil {
 		return "", err
 	}
 
-	_, err = tmp.Write(buf.Bytes())
+	_, err = io.Copy(tmp, reader)
 	if err != nil {
+		_ = tmp.Close()
 		return "", err
 	}
 
@@ -0,0 +1,131 @@
+package supermicro
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+// maxOutputLines the number of lines of the output of SUM which are kept for error reports
+const maxOutputLines = 50
+
+const redacted = "<redacted>"
+
+var (
+	// ErrAuthentication SUM was not able to log into the BMC with the given user and password
+	ErrAuthentication = errors.New("sum authentication failed")
+	// ErrUnsupportedBoard SUM does not support the board or the command on this board
+	ErrUnsupportedBoard = fmt.Errorf("%w: sum does not support this board", hal.ErrNotSupported)
+	// ErrChecksumMismatch the checksum of the file passed to SUM, e.g. a firmware update, does not match
+	ErrChecksumMismatch = errors.New("sum file checksum mismatch")
+
+	// sumFailures map the messages SUM prints on known failures to the errors above
+	sumFailures = []struct {
+		message *regexp.Regexp
+		err     error
+	}{
+		{message: regexp.MustCompile(`(?i)(authentication|login|log in) (has )?fail|(invalid|incorrect|wrong) (user ?name|password)|unauthorized`), err: ErrAuthentication},
+		{message: regexp.MustCompile(`(?i)checksum (error|mismatch|fail|is (invalid|incorrect|wrong))|(invalid|incorrect|wrong|bad) checksum`), err: ErrChecksumMismatch},
+		{message: regexp.MustCompile(`(?i)(board|platform|system|product|motherboard|command|function|feature) (is )?not supported|unsupported (board|platform|system|product|motherboard)`), err: ErrUnsupportedBoard},
+	}
+)
+
+// SumError is returned if SUM exited with a failure, it wraps one of the errors above if the failure is known
+type SumError struct {
+	Command  string
+	ExitCode int
+	// Output are the last lines SUM printed with the password redacted
+	Output []string
+
+	reason error
+}
+
+func (e *SumError) Error() string {
+	msg := fmt.Sprintf("sum command %s failed with exit code %d", e.Command, e.ExitCode)
+	if e.reason != nil {
+		msg += ": " + e.reason.Error()
+	}
+	if len(e.Output) > 0 {
+		msg += "\n" + strings.Join(e.Output, "\n")
+	}
+	return msg
+}
+
+func (e *SumError) Unwrap() error {
+	return e.reason
+}
+
+// newSumError classifies the failure of SUM by its output, the exit code is only reported
+func newSumError(command string, exitCode int, output []string) *SumError {
+	e := &SumError{Command: command, ExitCode: exitCode, Output: output}
+	for _, line := range output {
+		for _, f := range sumFailures {
+			if f.message.MatchString(line) {
+				e.reason = f.err
+				return e
+			}
+		}
+	}
+	return e
+}
+
+// sumOutput logs the output of SUM line by line as it is printed, including its progress,
+// and keeps the last lines for the error report.
+// It is used for stdout and stderr at once, exec then writes to it from a single goroutine.
+type sumOutput struct {
+	log    logger.Logger
+	fields []any
+	redact func(string) string
+
+	partial []byte
+	lines   []string
+}
+
+func (o *sumOutput) Write(p []byte) (int, error) {
+	for _, b := range p {
+		// progress is updated in place with a carriage return
+		if b == '\n' || b == '\r' {
+			o.flush()
+			continue
+		}
+		o.partial = append(o.partial, b)
+	}
+	return len(p), nil
+}
+
+// flush finishes the current line
+func (o *sumOutput) flush() {
+	line := strings.TrimSpace(o.redact(string(o.partial)))
+	o.partial = o.partial[:0]
+	if line == "" {
+		return
+	}
+	o.log.Infow("sum", slices.Concat(o.fields, []any{"output", line})...)
+	o.lines = append(o.lines, line)
+	if len(o.lines) > maxOutputLines {
+		o.lines = o.lines[len(o.lines)-maxOutputLines:]
+	}
+}
+
+// redact removes the password from text which is logged or returned in an error
+func (s *sum) redact(text string) string {
+	if s.password == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, s.password, redacted)
+}
+
+// sumCommand returns the command given with -c for logs and errors
+func sumCommand(args []string) string {
+	for i, arg := range args {
+		if arg == "-c" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return strings.Join(args, " ")
+}
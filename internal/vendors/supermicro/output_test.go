@@ -0,0 +1,119 @@
+package supermicro
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+func TestSumExecuteErrors(t *testing.T) {
+	bin := fakeSum(t)
+
+	tests := []struct {
+		name         string
+		ip           string
+		wantErrIs    error
+		wantExitCode int
+		wantOutput   []string
+	}{
+		{
+			name:         "authentication failure",
+			ip:           "auth",
+			wantErrIs:    ErrAuthentication,
+			wantExitCode: 12,
+		},
+		{
+			name:         "unsupported board",
+			ip:           "unsupported",
+			wantErrIs:    ErrUnsupportedBoard,
+			wantExitCode: 13,
+		},
+		{
+			name:         "checksum mismatch",
+			ip:           "checksum",
+			wantErrIs:    ErrChecksumMismatch,
+			wantExitCode: 14,
+			wantOutput: []string{
+				"Supermicro Update Manager",
+				"Connecting to checksum with password <redacted>",
+				"Uploading 10%",
+				"Uploading 100%",
+				"ERROR: File checksum error.",
+			},
+		},
+		{
+			name:         "authentication failure with generic exit code",
+			ip:           "auth-message",
+			wantErrIs:    ErrAuthentication,
+			wantExitCode: 1,
+		},
+		{
+			name:         "unknown failure",
+			ip:           "broken",
+			wantExitCode: 1,
+		},
+		{
+			name:         "exit code without a known message",
+			ip:           "silent",
+			wantExitCode: 12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+			log := logger.NewSlog(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			s, err := NewRemoteSum(bin, "X11DPT-B", tt.ip, "ADMIN", "s3cr3t", log)
+			require.NoError(t, err)
+			s.tmpDir = t.TempDir()
+
+			err = s.UpdateBMC(t.Context(), strings.NewReader("bmc"))
+			require.Error(t, err)
+
+			var sumErr *SumError
+			require.ErrorAs(t, err, &sumErr)
+			require.Equal(t, "UpdateBmc", sumErr.Command)
+			require.Equal(t, tt.wantExitCode, sumErr.ExitCode)
+			if tt.wantErrIs != nil {
+				require.ErrorIs(t, err, tt.wantErrIs)
+			} else {
+				require.NoError(t, sumErr.Unwrap())
+			}
+			if tt.wantOutput != nil {
+				require.Equal(t, tt.wantOutput, sumErr.Output)
+			}
+
+			require.NotContains(t, err.Error(), "s3cr3t")
+			require.NotContains(t, logs.String(), "s3cr3t")
+			require.Contains(t, logs.String(), `"output":"Connecting to `+tt.ip+` with password <redacted>"`)
+		})
+	}
+}
+
+func TestSumUnsupportedBoardIsNotSupported(t *testing.T) {
+	err := newSumError("GetCurrentBiosCfg", 13, []string{"ERROR: The platform is not supported."})
+	require.ErrorIs(t, err, ErrUnsupportedBoard)
+	require.ErrorIs(t, err, hal.ErrNotSupported)
+	require.Equal(t, "sum command GetCurrentBiosCfg failed with exit code 13: operation not supported: sum does not support this board\nERROR: The platform is not supported.", err.Error())
+}
+
+func TestSumOutputKeepsLastLines(t *testing.T) {
+	o := &sumOutput{log: logger.NewSlog(slog.New(slog.DiscardHandler)), redact: func(s string) string { return s }}
+	for i := range maxOutputLines + 10 {
+		_, err := o.Write([]byte("line " + strings.Repeat("x", i) + "\n"))
+		require.NoError(t, err)
+	}
+	_, err := o.Write([]byte("last line without newline"))
+	require.NoError(t, err)
+	o.flush()
+
+	require.Len(t, o.lines, maxOutputLines)
+	require.Equal(t, "last line without newline", o.lines[maxOutputLines-1])
+}
@@ -0,0 +1,288 @@
+package supermicro
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/metal-stack/go-hal"
+)
+
+// biosCfgHeader SUM expects the configuration in the encoding it writes it
+const biosCfgHeader = `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>` + "\n"
+
+// checkBoxType settings of this type are either "Checked" or "Unchecked"
+const checkBoxType = "CheckBox"
+
+// pathElement is a menu or setting of a setting path, order selects it if several with the same name exist
+type pathElement struct {
+	name  string
+	order string
+}
+
+func (e pathElement) matches(name, order string) bool {
+	return e.name == name && (e.order == "" || e.order == order)
+}
+
+// parseSettingPath splits a path like "Boot/Boot Option #1[1]" into its menus and the setting
+func parseSettingPath(path string) ([]pathElement, error) {
+	var (
+		elements []pathElement
+		name     strings.Builder
+		escaped  bool
+	)
+	for _, r := range path {
+		switch {
+		case escaped:
+			name.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '/':
+			elements = append(elements, newPathElement(name.String()))
+			name.Reset()
+		default:
+			name.WriteRune(r)
+		}
+	}
+	elements = append(elements, newPathElement(name.String()))
+
+	if len(elements) < 2 {
+		return nil, fmt.Errorf("setting path %q must consist of at least one menu and the setting", path)
+	}
+	for _, e := range elements {
+		if e.name == "" {
+			return nil, fmt.Errorf("setting path %q contains an empty menu or setting", path)
+		}
+	}
+	return elements, nil
+}
+
+func newPathElement(s string) pathElement {
+	if !strings.HasSuffix(s, "]") {
+		return pathElement{name: s}
+	}
+	i := strings.LastIndex(s, "[")
+	if i <= 0 {
+		return pathElement{name: s}
+	}
+	order := s[i+1 : len(s)-1]
+	if _, err := strconv.Atoi(order); err != nil {
+		return pathElement{name: s}
+	}
+	return pathElement{name: s[:i], order: order}
+}
+
+// findSetting returns the setting at path together with the menus leading to it, nil if it does not exist
+func findSetting(menus []Menu, path []pathElement) ([]Menu, *Setting) {
+	for i := range menus {
+		m := &menus[i]
+		if !path[0].matches(m.Name, m.Order) {
+			continue
+		}
+		parent := Menu{Name: m.Name, Order: m.Order}
+		if len(path) == 2 {
+			for j := range m.Settings {
+				if path[1].matches(m.Settings[j].Name, m.Settings[j].Order) {
+					return []Menu{parent}, &m.Settings[j]
+				}
+			}
+			continue
+		}
+		parents, setting := findSetting(m.Menus, path[1:])
+		if setting != nil {
+			return append([]Menu{parent}, parents...), setting
+		}
+	}
+	return nil, nil
+}
+
+// value returns the selected option of the setting, or the checked status of a checkbox
+func (s *Setting) value() string {
+	if s.Type == checkBoxType {
+		return s.CheckedStatus
+	}
+	// SUM pads some options, e.g. the names of legacy boot devices
+	return strings.TrimSpace(s.SelectedOption)
+}
+
+// withValue returns a copy of the setting as written to a BIOS configuration which changes it to value
+func (s *Setting) withValue(value string) Setting {
+	changed := Setting{Name: s.Name, Order: s.Order, Type: cmp.Or(s.Type, "Option")}
+	if s.Type == checkBoxType {
+		changed.CheckedStatus = value
+	} else {
+		changed.SelectedOption = value
+	}
+	return changed
+}
+
+// add puts the setting into the configuration below the given menus, menus which already exist are reused
+func (c *BiosCfg) add(menus []Menu, setting Setting) {
+	children := &c.Menus
+	var menu *Menu
+	for _, m := range menus {
+		i := slices.IndexFunc(*children, func(c Menu) bool { return c.Name == m.Name && c.Order == m.Order })
+		if i < 0 {
+			*children = append(*children, Menu{Name: m.Name, Order: m.Order})
+			i = len(*children) - 1
+		}
+		menu = &(*children)[i]
+		children = &menu.Menus
+	}
+	menu.Settings = append(menu.Settings, setting)
+}
+
+func (c *BiosCfg) marshal() (string, error) {
+	raw, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	encoded, err := charmap.ISO8859_1.NewEncoder().String(biosCfgHeader + string(raw))
+	if err != nil {
+		return "", fmt.Errorf("bios configuration can not be encoded in ISO-8859-1 %w", err)
+	}
+	return encoded, nil
+}
+
+// resolve replaces the placeholders in the value of a setting
+func (s *sum) resolve(value string) (string, error) {
+	if strings.Contains(value, uefiNetworkBootOptionPlaceholder) {
+		if s.uefiNetworkBootOption == "" {
+			err := s.findUEFINetworkBootOption()
+			if err != nil {
+				return "", err
+			}
+		}
+		value = strings.ReplaceAll(value, uefiNetworkBootOptionPlaceholder, s.uefiNetworkBootOption)
+	}
+	if s.bootloaderID != "" {
+		value = strings.ReplaceAll(value, bootloaderIDPlaceholder, s.bootloaderID)
+	}
+	return value, nil
+}
+
+// drift compares the settings with the BIOS configuration which was read before
+func (s *sum) drift(settings map[string]string) ([]hal.BIOSSettingDrift, error) {
+	return s.driftWith(settings, func(_, current, desired string) bool {
+		return current == desired
+	})
+}
+
+// driftWith compares the settings with the BIOS configuration which was read before,
+// matches reports whether the current value of the setting at path is the desired one
+func (s *sum) driftWith(settings map[string]string, matches func(path, current, desired string) bool) ([]hal.BIOSSettingDrift, error) {
+	var drift []hal.BIOSSettingDrift
+	for _, path := range slices.Sorted(maps.Keys(settings)) {
+		elements, err := parseSettingPath(path)
+		if err != nil {
+			return nil, err
+		}
+		desired, err := s.resolve(settings[path])
+		if err != nil {
+			return nil, err
+		}
+		_, setting := findSetting(s.biosCfg.Menus, elements)
+		if setting == nil {
+			drift = append(drift, hal.BIOSSettingDrift{Path: path, Desired: desired, Missing: true})
+			continue
+		}
+		current := setting.value()
+		if !matches(path, current, desired) {
+			drift = append(drift, hal.BIOSSettingDrift{Path: path, Current: current, Desired: desired})
+		}
+	}
+	return drift, nil
+}
+
+// catalogDrift compares the settings of the board catalog with the BIOS configuration which was read before.
+// Settings which do not exist in the BIOS of the board are skipped with a warning, like SUM ignores unknown settings,
+// whereas the settings of a BIOS profile must exist.
+func (s *sum) catalogDrift(settings map[string]string) ([]hal.BIOSSettingDrift, error) {
+	drift, err := s.driftWith(settings, s.board.matches)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	drift = slices.DeleteFunc(drift, func(d hal.BIOSSettingDrift) bool {
+		if d.Missing {
+			missing = append(missing, d.Path)
+		}
+		return d.Missing
+	})
+	if len(missing) > 0 {
+		s.log.Warnw("settings of the board catalog do not exist in the bios, skipping them", "board", s.boardName, "settings", missing)
+	}
+	return drift, nil
+}
+
+// applyDrift changes only the drifted settings and returns whether the server must be rebooted
+func (s *sum) applyDrift(ctx context.Context, drift []hal.BIOSSettingDrift) (bool, error) {
+	fragment, err := s.fragment(drift)
+	if err != nil {
+		return false, err
+	}
+	if fragment == "" {
+		return false, nil
+	}
+	s.log.Infow("change bios settings", "board", s.boardName, "drift", drift)
+	return true, s.changeBiosCfg(ctx, fragment)
+}
+
+// fragment returns the BIOS configuration which corrects the drift, it is empty if there is no drift
+func (s *sum) fragment(drift []hal.BIOSSettingDrift) (string, error) {
+	if len(drift) == 0 {
+		return "", nil
+	}
+	var fragment BiosCfg
+	for _, d := range drift {
+		if d.Missing {
+			return "", fmt.Errorf("bios setting %s does not exist on board %s", d.Path, s.boardName)
+		}
+		elements, err := parseSettingPath(d.Path)
+		if err != nil {
+			return "", err
+		}
+		menus, setting := findSetting(s.biosCfg.Menus, elements)
+		if setting == nil {
+			return "", fmt.Errorf("bios setting %s does not exist on board %s", d.Path, s.boardName)
+		}
+		fragment.add(menus, setting.withValue(d.Desired))
+	}
+	return fragment.marshal()
+}
+
+// BIOSDrift reads the BIOS configuration and compares it with the settings
+func (s *sum) BIOSDrift(ctx context.Context, settings map[string]string) ([]hal.BIOSSettingDrift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.readBiosCfg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.drift(settings)
+}
+
+// ApplyBIOSSettings changes the settings which differ from the BIOS configuration and returns whether the server must be rebooted
+func (s *sum) ApplyBIOSSettings(ctx context.Context, settings map[string]string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.readBiosCfg(ctx)
+	if err != nil {
+		return false, err
+	}
+	drift, err := s.drift(settings)
+	if err != nil {
+		return false, err
+	}
+	return s.applyDrift(ctx, drift)
+}
@@ -0,0 +1,107 @@
+package supermicro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal"
+)
+
+func TestParseSettingPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []pathElement
+		wantErr string
+	}{
+		{
+			name: "menu and setting",
+			path: "Boot/Boot mode select",
+			want: []pathElement{{name: "Boot"}, {name: "Boot mode select"}},
+		},
+		{
+			name: "ordered setting",
+			path: "Boot/Boot Option #1[1]",
+			want: []pathElement{{name: "Boot"}, {name: "Boot Option #1", order: "1"}},
+		},
+		{
+			name: "escaped slash",
+			path: `Advanced/Chipset Configuration/Intel® VT for Directed I\/O (VT-d)`,
+			want: []pathElement{{name: "Advanced"}, {name: "Chipset Configuration"}, {name: "Intel® VT for Directed I/O (VT-d)"}},
+		},
+		{
+			name: "brackets without order",
+			path: "Boot/Option [all]",
+			want: []pathElement{{name: "Boot"}, {name: "Option [all]"}},
+		},
+		{
+			name:    "setting without menu",
+			path:    "Boot mode select",
+			wantErr: "must consist of at least one menu and the setting",
+		},
+		{
+			name:    "empty menu",
+			path:    "Advanced//Quiet Boot",
+			wantErr: "contains an empty menu or setting",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSettingPath(tt.path)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSumDrift(t *testing.T) {
+	s := &sum{boardName: "X11SDV-8C-TP8F"}
+	s.biosCfgXML = testS2BiosCfg
+	require.NoError(t, s.unmarshalBiosCfg())
+
+	drift, err := s.drift(map[string]string{
+		"Boot/Boot mode select":            "UEFI",
+		"Boot/UEFI Boot Option #1":         uefiNetworkBootOptionPlaceholder,
+		"Advanced/Boot Feature/Quiet Boot": "Checked",
+		"Advanced/Boot Feature/Fast Boot":  "Enabled",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []hal.BIOSSettingDrift{
+		{Path: "Advanced/Boot Feature/Fast Boot", Desired: "Enabled", Missing: true},
+		{Path: "Boot/Boot mode select", Current: "Dual", Desired: "UEFI"},
+		{Path: "Boot/UEFI Boot Option #1", Current: "UEFI Hard Disk:metal-ubuntu", Desired: "UEFI Network:UEFI: PXE IPv4 Intel(R) I350 Gigabit Network Connection"},
+	}, drift)
+
+	_, err = s.fragment(drift)
+	require.EqualError(t, err, "bios setting Advanced/Boot Feature/Fast Boot does not exist on board X11SDV-8C-TP8F")
+
+	fragment, err := s.fragment(drift[1:])
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
+<BiosCfg>
+  <Menu name="Boot">
+    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #1" selectedOption="UEFI Network:UEFI: PXE IPv4 Intel(R) I350 Gigabit Network Connection" type="Option"></Setting>
+  </Menu>
+</BiosCfg>`, fragment)
+
+	fragment, err = s.fragment([]hal.BIOSSettingDrift{{Path: "Advanced/Boot Feature/Quiet Boot", Current: "Checked", Desired: "Unchecked"}})
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
+<BiosCfg>
+  <Menu name="Advanced">
+    <Menu name="Boot Feature">
+      <Setting name="Quiet Boot" checkedStatus="Unchecked" type="CheckBox"></Setting>
+    </Menu>
+  </Menu>
+</BiosCfg>`, fragment)
+
+	fragment, err = s.fragment(nil)
+	require.NoError(t, err)
+	require.Empty(t, fragment)
+}
@@ -2,14 +2,17 @@ package supermicro
 
 import (
 	"bufio"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
-	"syscall"
+	"sync"
 
 	"golang.org/x/net/html/charset"
 
@@ -17,208 +20,29 @@ import (
 	"github.com/metal-stack/go-hal/pkg/logger"
 )
 
-type boardModel int
-
 const (
-	// Bigtwin
-	X11DPT_B boardModel = iota
-	// S2 Storage
-	X11SDV_8C_TP8F
-	// S3
-	X11DPU
-	// N1 Firewall
-	X11SDD_8C_F
-	// Newer C1/M1 machines aka C2
-	X12DPT_B6
-	// G1 GPU machine
-	X13DDW_A
-	// Newer Microclouds
-	X13SCD_F
-	// Newer Microclouds
-	H13SRD_F
-	// AMD Workstation Board
-	H13SRH
-	// New storage machines
-	H13SSH
-)
-
-var (
-	boardModels = map[string]boardModel{
-		// Bigtwin
-		"X11DPT-B": X11DPT_B,
-		// S2 Storage
-		"X11SDV-8C-TP8F": X11SDV_8C_TP8F,
-		// S3
-		"X11DPU": X11DPU,
-		// N1 Firewall
-		"X11SDD-8C-F": X11SDD_8C_F,
-		// Newer C1/M1 machines aka C2
-		"X12DPT-B6": X12DPT_B6,
-		// G1 GPU Machine
-		"X13DDW-A": X13DDW_A,
-		// Newer Microclouds
-		"X13SCD-F": X13SCD_F,
-		// Newer Microclouds
-		"H13SRD-F": H13SRD_F,
-		// AMD Workstation Board
-		"H13SRH": H13SRH,
-		// New storage machines
-		"H13SSH": H13SSH,
-	}
-
-	// SUM does not complain or fail if more boot options are given than actually available
-	uefiBootXMLFragmentTemplates = map[boardModel]string{
-		X11DPT_B: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"/>
-    <Setting name="LEGACY to EFI support" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #1" order="1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #2" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #3" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" order="1" selectedOption="Disabled" type="Option"/>
-  </Menu>
-  <Menu name="Security">
-    <Menu name="SMC Secure Boot Configuration">
-      <Setting name="Secure Boot" selectedOption="Enabled" type="Option"/>
-    </Menu>
-  </Menu>
-</BiosCfg>`,
-		X11DPU: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"/>
-    <Setting name="LEGACY to EFI support" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #1" order="1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #2" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #3" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" order="1" selectedOption="Disabled" type="Option"/>
-  </Menu>
-  <Menu name="Security">
-    <Menu name="SMC Secure Boot Configuration">
-      <Setting name="Secure Boot" selectedOption="Enabled" type="Option"/>
-    </Menu>
-  </Menu>
-</BiosCfg>`,
-		X11SDV_8C_TP8F: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"/>
-    <Setting name="Legacy to EFI support" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="UEFI Boot Option #2" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #3" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #4" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #5" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #6" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #7" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #8" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #9" selectedOption="Disabled" type="Option"/>
-  </Menu>
-  <Menu name="Security">
-    <Menu name="SMC Secure Boot Configuration">
-      <Setting name="Secure Boot" selectedOption="Enabled" type="Option"/>
-    </Menu>
-  </Menu>
-</BiosCfg>`,
-		X11SDD_8C_F: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"/>
-    <Setting name="Legacy To EFI Support" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #2" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #3" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" selectedOption="Disabled" type="Option"/>
-  </Menu>
-</BiosCfg>`,
-	}
-
-	bootOrderXMLFragmentTemplates = map[boardModel]string{
-		X11DPT_B: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot Option #1" order="1" selectedOption="UEFI Hard Disk:BOOTLOADER_ID" type="Option"/>
-    <Setting name="Boot Option #2" order="1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #3" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" order="1" selectedOption="Disabled" type="Option"/>
-  </Menu>
-</BiosCfg>`,
-		X11DPU: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot Option #1" order="1" selectedOption="UEFI Hard Disk:BOOTLOADER_ID" type="Option"/>
-    <Setting name="Boot Option #2" order="1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #3" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" order="1" selectedOption="Disabled" type="Option"/>
-  </Menu>
-</BiosCfg>`,
-		X11SDD_8C_F: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="Boot Option #1" order="1" selectedOption="UEFI Hard Disk:BOOTLOADER_ID" type="Option"/>
-    <Setting name="Boot Option #2" order="1" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="Boot Option #3" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #4" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #5" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #6" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #7" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #8" order="1" selectedOption="Disabled" type="Option"/>
-    <Setting name="Boot Option #9" order="1" selectedOption="Disabled" type="Option"/>
-  </Menu>
-</BiosCfg>`,
-		X11SDV_8C_TP8F: `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
-<BiosCfg>
-  <Menu name="Boot">
-    <Setting name="UEFI Boot Option #1" selectedOption="UEFI Hard Disk:BOOTLOADER_ID" type="Option"/>
-    <Setting name="UEFI Boot Option #2" selectedOption="UEFI_NETWORK_BOOT_OPTION" type="Option"/>
-    <Setting name="UEFI Boot Option #3" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #4" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #5" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #6" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #7" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #8" selectedOption="Disabled" type="Option"/>
-    <Setting name="UEFI Boot Option #9" selectedOption="Disabled" type="Option"/>
-  </Menu>
-</BiosCfg>`,
-	}
+	// uefiNetworkBootOptionPlaceholder is replaced with the UEFI network boot option found in the BIOS configuration
+	uefiNetworkBootOptionPlaceholder = "UEFI_NETWORK_BOOT_OPTION"
+	// bootloaderIDPlaceholder is replaced with the id of the bootloader passed to EnsureBootOrder
+	bootloaderIDPlaceholder = "BOOTLOADER_ID"
+	uefiHardDiskBootOption  = "UEFI Hard Disk:" + bootloaderIDPlaceholder
 )
 
 type Menu struct {
-	XMLName  xml.Name `xml:"Menu"`
-	Name     string   `xml:"name,attr"`
-	Settings []struct {
-		XMLName        xml.Name `xml:"Setting"`
-		Name           string   `xml:"name,attr"`
-		Order          string   `xml:"order,attr,omitempty"`
-		SelectedOption string   `xml:"selectedOption,attr"`
-	} `xml:"Setting"`
-	Menus []Menu `xml:"Menu"`
+	XMLName  xml.Name  `xml:"Menu"`
+	Name     string    `xml:"name,attr"`
+	Order    string    `xml:"order,attr,omitempty"`
+	Settings []Setting `xml:"Setting"`
+	Menus    []Menu    `xml:"Menu"`
+}
+
+type Setting struct {
+	XMLName        xml.Name `xml:"Setting"`
+	Name           string   `xml:"name,attr"`
+	Order          string   `xml:"order,attr,omitempty"`
+	SelectedOption string   `xml:"selectedOption,attr,omitempty"`
+	CheckedStatus  string   `xml:"checkedStatus,attr,omitempty"`
+	Type           string   `xml:"type,attr,omitempty"`
 }
 
 type BiosCfg struct {
@@ -232,15 +56,21 @@ type sum struct {
 	ip       string
 	user     string
 	password string
+	// tmpDir is the directory in which every operation creates its own work directory, empty for the default
+	tmpDir string
 
+	// mu serializes the operations, they share the BIOS configuration read last
+	mu                    sync.Mutex
 	bootloaderID          string
 	biosCfgXML            string
 	biosCfg               BiosCfg
-	boardModel            boardModel
+	board                 *board
 	boardName             string
 	uefiNetworkBootOption string
 	secureBootEnabled     bool
-	log                   logger.Logger
+	// firmware returns the firmware the machine booted with
+	firmware func() kernel.FirmwareMode
+	log      logger.Logger
 }
 
 func newSum(sumBin, boardName string, log logger.Logger) (*sum, error) {
@@ -251,14 +81,10 @@ func newSum(sumBin, boardName string, log logger.Logger) (*sum, error) {
 	sum := &sum{
 		binary:    sumBin,
 		boardName: boardName,
+		firmware:  kernel.Firmware,
 		log:       log,
 	}
-	bm, ok := boardModels[boardName]
-	if ok {
-		sum.boardModel = bm
-	} else {
-		sum.boardModel = X11DPT_B
-	}
+	sum.board, _ = lookupBoard(boardName)
 	return sum, nil
 }
 
@@ -276,24 +102,28 @@ func NewRemoteSum(sumBin, boardName, ip, user, password string, log logger.Logge
 
 // ConfigureBIOS updates BIOS to UEFI boot and disables CSM-module if required.
 // If returns whether machine needs to be rebooted or not.
-func (s *sum) ConfigureBIOS() (bool, error) {
-	firmware := kernel.Firmware()
-	s.log.Infow("firmware", "is", firmware, "board", s.boardModel, "boardname", s.boardName)
-
-	// We must not configure the Bios if UEFI is already activated and the board is one of the following.
-	if firmware == kernel.EFI {
-		switch s.boardModel {
-		case X11SDV_8C_TP8F, X11SDD_8C_F, X12DPT_B6, X13DDW_A, X13SCD_F, H13SRD_F, H13SRH, H13SSH:
-			s.log.Infow("UEFI only board detected, skip bios configuration", "board", s.boardName, "model", s.boardModel)
-			return false, nil
-		}
+func (s *sum) ConfigureBIOS(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	firmware := s.firmware()
+	s.log.Infow("firmware", "is", firmware, "boardname", s.boardName)
+
+	if s.board == nil {
+		return false, errUnknownBoard(s.boardName)
+	}
+
+	// We must not configure the Bios if UEFI is already activated and the board only supports UEFI.
+	if firmware == kernel.EFI && s.board.UEFIOnly {
+		s.log.Infow("UEFI only board detected, skip bios configuration", "board", s.boardName)
+		return false, nil
 	}
 
-	err := s.prepare()
+	err := s.prepare(ctx)
 	if err != nil {
 		return false, err
 	}
-	s.log.Infow("firmware", "is", firmware, "board", s.boardModel, "boardname", s.boardName, "secureboot", s.secureBootEnabled)
+	s.log.Infow("firmware", "is", firmware, "boardname", s.boardName, "secureboot", s.secureBootEnabled)
 
 	// Secureboot can be set for specific bigtwins, called CSM Support in the bios
 	// This is so far only possible on these machines, detection requires sum call which downloads the bios.xml
@@ -301,49 +131,61 @@ func (s *sum) ConfigureBIOS() (bool, error) {
 		return false, nil
 	}
 
-	fragment, ok := uefiBootXMLFragmentTemplates[s.boardModel]
-	if !ok {
-		return false, fmt.Errorf("no boot xml fragment found for boardmodel:%d", s.boardModel)
+	settings := s.board.uefiBootSettings()
+	if settings == nil {
+		return false, fmt.Errorf("no uefi boot settings found for board:%s", s.boardName)
 	}
-	fragment = strings.ReplaceAll(fragment, "UEFI_NETWORK_BOOT_OPTION", s.uefiNetworkBootOption)
-
-	return true, s.changeBiosCfg(fragment)
+	drift, err := s.catalogDrift(settings)
+	if err != nil {
+		return false, err
+	}
+	return s.applyDrift(ctx, drift)
 }
 
 // EnsureBootOrder ensures BIOS boot order so that boot from the given allocated OS image is attempted before PXE boot.
-func (s *sum) EnsureBootOrder(bootloaderID string) error {
+func (s *sum) EnsureBootOrder(ctx context.Context, bootloaderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.bootloaderID = bootloaderID
 
-	switch s.boardModel {
-	case X13DDW_A, X12DPT_B6, X13SCD_F, H13SRD_F, H13SRH, H13SSH:
-		s.log.Infow("GPU board detected, skip bios modification", "board", s.boardName)
+	if s.board == nil {
+		return errUnknownBoard(s.boardName)
+	}
+	if s.board.SkipBootOrder {
+		s.log.Infow("board without boot order configuration detected, skip bios modification", "board", s.boardName)
 		return nil
 	}
 
-	err := s.prepare()
+	err := s.prepare(ctx)
 	if err != nil {
 		s.log.Warnw("BIOS updates for this machine type are intentionally not supported, skipping EnsureBootOrder", "error", err)
 		return nil
 	}
 
-	ok := s.bootOrderProperlySet()
-	if ok {
+	drift, err := s.catalogDrift(s.board.bootOrderSettings())
+	if err != nil {
+		return err
+	}
+	if len(drift) == 0 {
 		s.log.Infow("sum", "message", "boot order is already configured")
 		return nil
 	}
+	_, err = s.applyDrift(ctx, drift)
+	return err
+}
 
-	fragment, ok := bootOrderXMLFragmentTemplates[s.boardModel]
-	if !ok {
-		return fmt.Errorf("no boot order xml fragment found for boardmodel:%d", s.boardModel)
+func (s *sum) prepare(ctx context.Context) error {
+	err := s.readBiosCfg(ctx)
+	if err != nil {
+		return err
 	}
-	fragment = strings.ReplaceAll(fragment, "BOOTLOADER_ID", s.bootloaderID)
-	fragment = strings.ReplaceAll(fragment, "UEFI_NETWORK_BOOT_OPTION", s.uefiNetworkBootOption)
-
-	return s.changeBiosCfg(fragment)
+	return s.findUEFINetworkBootOption()
 }
 
-func (s *sum) prepare() error {
-	err := s.getCurrentBiosCfg()
+// readBiosCfg reads the current BIOS configuration, the UEFI network boot option is looked up on demand
+func (s *sum) readBiosCfg(ctx context.Context) error {
+	err := s.getCurrentBiosCfg(ctx)
 	if err != nil {
 		return err
 	}
@@ -353,16 +195,20 @@ func (s *sum) prepare() error {
 		return fmt.Errorf("unable to unmarshal BIOS configuration:\n%s %w", s.biosCfgXML, err)
 	}
 
+	s.uefiNetworkBootOption = ""
 	s.determineSecureBoot()
-
-	return s.findUEFINetworkBootOption()
+	return nil
 }
 
-func (s *sum) getCurrentBiosCfg() error {
-	biosCfgXML := "biosCfg.xml"
-	_ = os.Remove(biosCfgXML)
+func (s *sum) getCurrentBiosCfg(ctx context.Context) error {
+	dir, err := s.workDir()
+	if err != nil {
+		return err
+	}
+	defer s.removeWorkDir(dir)
 
-	err := s.execute("-c", "GetCurrentBiosCfg", "--file", biosCfgXML)
+	biosCfgXML := filepath.Join(dir, "biosCfg.xml")
+	err = s.execute(ctx, "-c", "GetCurrentBiosCfg", "--file", biosCfgXML)
 	if err != nil {
 		return fmt.Errorf("unable to get BIOS configuration via:%s -c GetCurrentBiosCfg --file %s %w", s.binary, biosCfgXML, err)
 	}
@@ -377,24 +223,17 @@ func (s *sum) getCurrentBiosCfg() error {
 }
 
 func (s *sum) determineSecureBoot() {
-	if s.boardModel == X11SDV_8C_TP8F || s.boardModel == X11SDD_8C_F { // secure boot option is not available in S2 BIOS
+	s.secureBootEnabled = false
+	if s.board == nil || s.board.SecureBoot == "" { // secure boot option is not available in this BIOS, e.g. on S2
 		return
 	}
-	for _, menu := range s.biosCfg.Menus {
-		if menu.Name != "Security" {
-			continue
-		}
-		for _, m := range menu.Menus {
-			if m.Name != "SMC Secure Boot Configuration" {
-				continue
-			}
-			for _, setting := range m.Settings {
-				if setting.Name == "Secure Boot" {
-					s.secureBootEnabled = setting.SelectedOption == "Enabled"
-					return
-				}
-			}
-		}
+	path, err := parseSettingPath(s.board.SecureBoot)
+	if err != nil {
+		return
+	}
+	_, setting := findSetting(s.biosCfg.Menus, path)
+	if setting != nil {
+		s.secureBootEnabled = setting.value() == "Enabled"
 	}
 }
 
@@ -421,83 +260,74 @@ func (s *sum) findUEFINetworkBootOption() error {
 	return fmt.Errorf("cannot find PXE boot option in BIOS configuration:\n%s", s.biosCfgXML)
 }
 
-func (s *sum) bootOrderProperlySet() bool {
-	if !s.checkBootOptionAt(1, s.bootloaderID) {
-		return false
-	}
-	if !s.checkBootOptionAt(2, s.uefiNetworkBootOption) {
-		return false
-	}
-	for i := 2; i <= 9; i++ {
-		if !s.checkBootOptionAt(i, "Disabled") {
-			return false
-		}
+func (s *sum) changeBiosCfg(ctx context.Context, fragment string) error {
+	dir, err := s.workDir()
+	if err != nil {
+		return err
 	}
-	return true
-}
+	defer s.removeWorkDir(dir)
 
-func (s *sum) checkBootOptionAt(index int, bootOption string) bool {
-	for _, menu := range s.biosCfg.Menus {
-		if menu.Name != "Boot" {
-			continue
-		}
-		for _, setting := range menu.Settings {
-			switch s.boardModel {
-			case X11DPT_B, X11DPU, X11SDD_8C_F:
-				if setting.Order != "1" {
-					continue
-				}
-				if setting.Name != fmt.Sprintf("Boot Option #%d", index) {
-					continue
-				}
-			case X11SDV_8C_TP8F:
-				if setting.Name != fmt.Sprintf("UEFI Boot Option #%d", index) {
-					continue
-				}
-			case X13DDW_A, X12DPT_B6, X13SCD_F, H13SRD_F:
-				// FIXME
-			}
-
-			return strings.Contains(setting.SelectedOption, bootOption)
-		}
+	biosCfgUpdateXML := filepath.Join(dir, "biosCfgUpdate.xml")
+	err = os.WriteFile(biosCfgUpdateXML, []byte(fragment), 0600)
+	if err != nil {
+		return err
 	}
 
-	return false
+	return s.execute(ctx, "-c", "ChangeBiosCfg", "--file", biosCfgUpdateXML)
 }
 
-func (s *sum) changeBiosCfg(fragment string) error {
-	biosCfgUpdateXML := "biosCfgUpdate.xml"
-	err := os.WriteFile(biosCfgUpdateXML, []byte(fragment), 0600)
+// workDir creates the directory for the files exchanged with SUM during one operation,
+// concurrent operations against different machines must not overwrite each other's files
+func (s *sum) workDir() (string, error) {
+	dir, err := os.MkdirTemp(s.tmpDir, "sum-")
 	if err != nil {
-		return err
+		return "", fmt.Errorf("unable to create work directory for sum %w", err)
 	}
+	return dir, nil
+}
 
-	return s.execute("-c", "ChangeBiosCfg", "--file", biosCfgUpdateXML)
+func (s *sum) removeWorkDir(dir string) {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		s.log.Warnw("unable to remove work directory of sum", "dir", dir, "error", err)
+	}
 }
 
-func (s *sum) execute(args ...string) error {
+// execute runs SUM and returns a *SumError if it fails, its output is logged line by line
+func (s *sum) execute(ctx context.Context, args ...string) error {
+	command := s.redact(sumCommand(args))
 	if s.remote {
 		args = append(args, "-i", s.ip, "-u", s.user, "-p", s.password)
 	}
+	output := s.output(command)
 	// #nosec G204
-	cmd := exec.Command(s.binary, args...)
-	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Credential: &syscall.Credential{
-			Uid:    uint32(0),
-			Gid:    uint32(0),
-			Groups: []uint32{0},
-		},
-	}
-	return cmd.Run()
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	cmd.Stdout, cmd.Stderr = output, output
+	err := cmd.Run()
+	output.flush()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("sum command %s aborted %w", command, ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return newSumError(command, exitErr.ExitCode(), output.lines)
+	}
+	return fmt.Errorf("unable to run sum command %s %w", command, err)
 }
 
-func (s *sum) executeAsync(args ...string) (io.ReadCloser, error) {
+// executeAsync runs SUM and returns its stdout, the output on stderr is logged
+func (s *sum) executeAsync(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	command := s.redact(sumCommand(args))
 	if s.remote {
 		args = append(args, "-i", s.ip, "-u", s.user, "-p", s.password)
 	}
+	stderr := s.output(command)
 	// #nosec G204
-	cmd := exec.Command(s.binary, args...)
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	cmd.Stderr = stderr
 	out, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("could not initiate sum command to get dmi data from ip:%s, err: %w", s.ip, err)
@@ -507,7 +337,8 @@ func (s *sum) executeAsync(args ...string) (io.ReadCloser, error) {
 		return nil, fmt.Errorf("could not start sum command to get dmi data from ip:%s, err: %w", s.ip, err)
 	}
 	go func() {
-		err = cmd.Wait()
+		err := cmd.Wait()
+		stderr.flush()
 		if err != nil {
 			s.log.Infow("wait for sum command failed ip", "ip", s.ip, "error", err)
 		}
@@ -515,8 +346,17 @@ func (s *sum) executeAsync(args ...string) (io.ReadCloser, error) {
 	return out, nil
 }
 
-func (s *sum) uuidRemote() (string, error) {
-	out, err := s.executeAsync("--no_banner", "--no_progress", "--journal_level", "0", "-c", "GetDmiInfo")
+// output creates the writer which logs the output of the given SUM command
+func (s *sum) output(command string) *sumOutput {
+	fields := []any{"command", command}
+	if s.remote {
+		fields = append(fields, "ip", s.ip)
+	}
+	return &sumOutput{log: s.log, fields: fields, redact: s.redact}
+}
+
+func (s *sum) uuidRemote(ctx context.Context) (string, error) {
+	out, err := s.executeAsync(ctx, "--no_banner", "--no_progress", "--journal_level", "0", "-c", "GetDmiInfo")
 	if err != nil {
 		return "", err
 	}
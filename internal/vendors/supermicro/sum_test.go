@@ -1,8 +1,13 @@
 package supermicro
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/kernel"
 	"github.com/metal-stack/go-hal/pkg/logger"
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +30,8 @@ func TestUnmarshalS2BiosCfg(t *testing.T) {
 	err := s.unmarshalBiosCfg()
 
 	// then
-	require.Equal(t, X11SDV_8C_TP8F, s.boardModel)
+	require.True(t, s.board.UEFIOnly)
+	require.Empty(t, s.board.SecureBoot)
 
 	// then
 	require.NoError(t, err)
@@ -40,14 +46,14 @@ func TestUnmarshalS2BiosCfg(t *testing.T) {
 
 func TestUnmarshalBigTwinBiosCfg(t *testing.T) {
 	// given
-	s := &sum{}
+	s, _ := newSum("/bin/true", "X11DPT-B", logger.New())
 	s.biosCfgXML = testBigTwinBiosCfg
 
 	// when
 	err := s.unmarshalBiosCfg()
 
 	// then
-	require.Equal(t, X11DPT_B, s.boardModel)
+	require.False(t, s.board.UEFIOnly)
 
 	// when
 	s.determineSecureBoot()
@@ -66,6 +72,85 @@ func TestUnmarshalBigTwinBiosCfg(t *testing.T) {
 	require.Equal(t, "UEFI Network:UEFI: PXE IP4 Intel(R) Ethernet Controller XXV710 for 25GbE SFP28", s.uefiNetworkBootOption)
 }
 
+func TestSumConfigureBIOSS2(t *testing.T) {
+	bin := fakeSum(t)
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(bin), "s2.biosCfg.xml"), []byte(testS2BiosCfg), 0600))
+
+	s, err := NewRemoteSum(bin, "X11SDV-8C-TP8F", "s2", "ADMIN", "secret", logger.New())
+	require.NoError(t, err)
+	s.tmpDir = t.TempDir()
+
+	// the S2 is only configured if it did not boot with UEFI yet
+	s.firmware = func() kernel.FirmwareMode { return kernel.EFI }
+	changed, err := s.ConfigureBIOS(t.Context())
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	s.firmware = func() kernel.FirmwareMode { return kernel.BIOS }
+	changed, err = s.ConfigureBIOS(t.Context())
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	fragment, err := os.ReadFile(filepath.Join(filepath.Dir(bin), "s2.ChangeBiosCfg"))
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
+<BiosCfg>
+  <Menu name="Boot">
+    <Setting name="Boot mode select" selectedOption="UEFI" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #1" selectedOption="UEFI Network:UEFI: PXE IPv4 Intel(R) I350 Gigabit Network Connection" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #2" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #3" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #4" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #5" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #6" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #7" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #8" selectedOption="Disabled" type="Option"></Setting>
+    <Setting name="UEFI Boot Option #9" selectedOption="Disabled" type="Option"></Setting>
+  </Menu>
+</BiosCfg>`, string(fragment))
+}
+
+func TestSumBootOrderDecoratedBootOption(t *testing.T) {
+	s, err := newSum("/bin/true", "X11SDV-8C-TP8F", logger.New())
+	require.NoError(t, err)
+	// SUM reports the boot option of the installed operating system decorated with its device
+	s.biosCfgXML = strings.Replace(testS2BiosCfg,
+		`<Setting name="UEFI Boot Option #1" selectedOption="UEFI Hard Disk:metal-ubuntu"`,
+		`<Setting name="UEFI Boot Option #1" selectedOption="UEFI Hard Disk:metal-ubuntu(SATA,Port:4)"`, 1)
+	require.NoError(t, s.unmarshalBiosCfg())
+	s.bootloaderID = "metal-ubuntu"
+
+	drift, err := s.catalogDrift(s.board.bootOrderSettings())
+	require.NoError(t, err)
+	require.NotEmpty(t, drift)
+	for _, d := range drift {
+		require.NotEqual(t, "Boot/UEFI Boot Option #1", d.Path)
+	}
+
+	// profiles still compare exactly
+	drift, err = s.drift(map[string]string{"Boot/UEFI Boot Option #1": uefiHardDiskBootOption})
+	require.NoError(t, err)
+	require.Equal(t, []hal.BIOSSettingDrift{
+		{Path: "Boot/UEFI Boot Option #1", Current: "UEFI Hard Disk:metal-ubuntu(SATA,Port:4)", Desired: "UEFI Hard Disk:metal-ubuntu"},
+	}, drift)
+}
+
+func TestSumCatalogSkipsMissingSettings(t *testing.T) {
+	s, err := newSum("/bin/true", "X11SDV-8C-TP8F", logger.New())
+	require.NoError(t, err)
+	s.biosCfgXML = testS2BiosCfg
+	require.NoError(t, s.unmarshalBiosCfg())
+
+	settings := s.board.uefiBootSettings()
+	settings["Security/SMC Secure Boot Configuration/Secure Boot"] = "Enabled"
+	drift, err := s.catalogDrift(settings)
+	require.NoError(t, err)
+	require.NotEmpty(t, drift)
+	for _, d := range drift {
+		require.False(t, d.Missing, d.Path)
+	}
+}
+
 const (
 	testS2BiosCfg = `<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>
 <BiosCfg>
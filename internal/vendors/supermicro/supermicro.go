@@ -1,7 +1,7 @@
 package supermicro
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"net/http"
 
@@ -15,12 +15,6 @@ import (
 	uuidendian "github.com/metal-stack/go-hal/internal/uuid-endianness"
 	"github.com/metal-stack/go-hal/pkg/api"
 	"github.com/metal-stack/go-hal/pkg/logger"
-	goipmi "github.com/vmware/goipmi"
-)
-
-var (
-	// errorNotImplemented for all functions that are not implemented yet
-	errorNotImplemented = fmt.Errorf("not implemented yet")
 )
 
 const (
@@ -46,12 +40,12 @@ type (
 )
 
 // InBand creates an inband connection to a supermicro server.
-func InBand(board *api.Board, log logger.Logger) (hal.InBand, error) {
+func InBand(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error) {
 	s, err := newSum(sumBin, board.Model, log)
 	if err != nil {
 		return nil, err
 	}
-	ib, err := inband.New(board, true, log)
+	ib, err := inband.New(ctx, board, true, log)
 	if err != nil {
 		return nil, err
 	}
@@ -79,41 +73,81 @@ func OutBand(r *redfish.APIClient, board *api.Board, ip string, ipmiPort int, us
 
 // InBand
 func (ib *inBand) PowerOff() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerDown)
+	return ib.PowerOffContext(context.Background())
+}
+
+func (ib *inBand) PowerOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerDown)
 }
 
 func (ib *inBand) PowerCycle() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerCycle)
+	return ib.PowerCycleContext(context.Background())
+}
+
+func (ib *inBand) PowerCycleContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerCycle)
 }
 
 func (ib *inBand) PowerReset() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlHardReset)
+	return ib.PowerResetContext(context.Background())
+}
+
+func (ib *inBand) PowerResetContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlHardReset)
 }
 
 func (ib *inBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ib.IpmiTool.SetChassisIdentifyLEDState(state)
+	return ib.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ib *inBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDState(ctx, state)
 }
 
 func (ib *inBand) IdentifyLEDOn() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOn()
+	return ib.IdentifyLEDOnContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOn(ctx)
 }
 
 func (ib *inBand) IdentifyLEDOff() error {
-	return ib.IpmiTool.SetChassisIdentifyLEDOff()
+	return ib.IdentifyLEDOffContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisIdentifyLEDOff(ctx)
 }
 
 func (ib *inBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ib.IpmiTool.SetBootOrder(bootTarget, vendor)
+	return ib.BootFromContext(context.Background(), bootTarget)
 }
 
-func (ib *inBand) SetFirmware(hal.FirmwareMode) error {
-	return errorNotImplemented //TODO
+func (ib *inBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ib.IpmiTool.SetBootOrder(ctx, bootTarget, vendor)
+}
+
+func (ib *inBand) SetFirmware(mode hal.FirmwareMode) error {
+	return ib.SetFirmwareContext(context.Background(), mode)
+}
+
+func (ib *inBand) SetFirmwareContext(context.Context, hal.FirmwareMode) error {
+	return hal.ErrNotSupported //TODO
 }
 
 func (ib *inBand) Describe() string {
 	return "InBand connected to Supermicro"
 }
 
+func (ib *inBand) Capabilities() hal.Capabilities {
+	c := ib.InBand.Capabilities()
+	c.Operations[hal.OperationConfigureBIOS] = hal.TransportSUM
+	c.Operations[hal.OperationEnsureBootOrder] = hal.TransportSUM
+	c.Operations[hal.OperationBIOSProfile] = hal.TransportSUM
+	return c
+}
+
 func (ib *inBand) BMCConnection() api.BMCConnection {
 	return &bmcConnection{
 		inBand: ib,
@@ -121,7 +155,7 @@ func (ib *inBand) BMCConnection() api.BMCConnection {
 }
 
 func (c *bmcConnection) BMC() (*api.BMC, error) {
-	return c.IpmiTool.BMC()
+	return c.IpmiTool.BMC(context.Background())
 }
 
 func (c *bmcConnection) PresentSuperUser() api.BMCUser {
@@ -153,39 +187,81 @@ func (c *bmcConnection) Present() bool {
 }
 
 func (c *bmcConnection) CreateUserAndPassword(user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
-	return c.IpmiTool.CreateUser(user, privilege, "", c.Board().Vendor.PasswordConstraints(), ipmi.HighLevel)
+	return c.CreateUserAndPasswordContext(context.Background(), user, privilege)
+}
+
+func (c *bmcConnection) CreateUserAndPasswordContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.IpmiTool.CreateUser(ctx, user, privilege, "", c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
-	_, err := c.IpmiTool.CreateUser(user, privilege, password, nil, ipmi.HighLevel)
+	return c.CreateUserContext(context.Background(), user, privilege, password)
+}
+
+func (c *bmcConnection) CreateUserContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	_, err := c.IpmiTool.CreateUser(ctx, user, privilege, password, c.Board().PasswordConstraints(), ipmi.HighLevel)
 	return err
 }
 
 func (c *bmcConnection) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
+	return c.NeedsPasswordChangeContext(context.Background(), user, password)
+}
+
+func (c *bmcConnection) NeedsPasswordChangeContext(ctx context.Context, user api.BMCUser, password string) (bool, error) {
 	return true, nil
 }
 
 func (c *bmcConnection) ChangePassword(user api.BMCUser, newPassword string) error {
-	return c.IpmiTool.ChangePassword(user, newPassword, ipmi.HighLevel)
+	return c.ChangePasswordContext(context.Background(), user, newPassword)
+}
+
+func (c *bmcConnection) ChangePasswordContext(ctx context.Context, user api.BMCUser, newPassword string) error {
+	return c.IpmiTool.ChangePassword(ctx, user, newPassword, c.Board().PasswordConstraints(), ipmi.HighLevel)
 }
 
 func (c *bmcConnection) SetUserEnabled(user api.BMCUser, enabled bool) error {
-	return c.IpmiTool.SetUserEnabled(user, enabled, ipmi.HighLevel)
+	return c.SetUserEnabledContext(context.Background(), user, enabled)
+}
+
+func (c *bmcConnection) SetUserEnabledContext(ctx context.Context, user api.BMCUser, enabled bool) error {
+	return c.IpmiTool.SetUserEnabled(ctx, user, enabled, ipmi.HighLevel)
 }
 
 func (ib *inBand) ConfigureBIOS() (bool, error) {
-	return ib.sum.ConfigureBIOS()
+	return ib.ConfigureBIOSContext(context.Background())
+}
+
+func (ib *inBand) ConfigureBIOSContext(ctx context.Context) (bool, error) {
+	return ib.sum.ConfigureBIOS(ctx)
 }
 
 func (ib *inBand) EnsureBootOrder(bootloaderID string) error {
-	return ib.sum.EnsureBootOrder(bootloaderID)
+	return ib.EnsureBootOrderContext(context.Background(), bootloaderID)
+}
+
+func (ib *inBand) EnsureBootOrderContext(ctx context.Context, bootloaderID string) error {
+	return ib.sum.EnsureBootOrder(ctx, bootloaderID)
+}
+
+// BIOSDrift compares the profile with the BIOS configuration read by SUM
+func (ib *inBand) BIOSDrift(ctx context.Context, profile hal.BIOSProfile) ([]hal.BIOSSettingDrift, error) {
+	return ib.sum.BIOSDrift(ctx, profile.SettingsFor(ib.Board().Model))
+}
+
+// ApplyBIOSProfile changes the settings which differ from the profile with SUM
+func (ib *inBand) ApplyBIOSProfile(ctx context.Context, profile hal.BIOSProfile) (bool, error) {
+	return ib.sum.ApplyBIOSSettings(ctx, profile.SettingsFor(ib.Board().Model))
 }
 
 // OutBand
 func (ob *outBand) UUID() (*uuid.UUID, error) {
-	u, err := ob.Redfish.MachineUUID()
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(ctx context.Context) (*uuid.UUID, error) {
+	u, err := ob.Redfish.MachineUUID(ctx)
 	if err != nil {
-		u, err = ob.sum.uuidRemote()
+		u, err = ob.sum.uuidRemote(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -211,85 +287,222 @@ func (ob *outBand) UUID() (*uuid.UUID, error) {
 }
 
 func (ob *outBand) PowerState() (hal.PowerState, error) {
-	return ob.Redfish.PowerState()
+	return ob.PowerStateContext(context.Background())
+}
+
+func (ob *outBand) PowerStateContext(ctx context.Context) (hal.PowerState, error) {
+	return ob.Redfish.PowerState(ctx)
 }
 
 func (ob *outBand) PowerOff() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerDown)
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerDown)
 	})
 }
 
 func (ob *outBand) PowerOn() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerUp)
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerUp)
 	})
 }
 
 func (ob *outBand) PowerReset() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerHardReset)
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlHardReset)
 	})
 }
 
 func (ob *outBand) PowerCycle() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerCycle)
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerCycle)
 	})
 }
 
 func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.SetChassisIdentifyLEDState(state)
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(ctx context.Context, state hal.IdentifyLEDState) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetChassisIdentifyLEDState(ctx, state)
 	})
 }
 
 func (ob *outBand) IdentifyLEDOn() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.SetChassisIdentifyLEDOn()
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetChassisIdentifyLEDOn(ctx)
 	})
 }
 
 func (ob *outBand) IdentifyLEDOff() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.SetChassisIdentifyLEDOff()
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetChassisIdentifyLEDOff(ctx)
 	})
 }
 
 func (ob *outBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.SetBootOrder(bootTarget, vendor)
+	return ob.BootFromContext(context.Background(), bootTarget)
+}
+
+func (ob *outBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetBootOrder(ctx, bootTarget, vendor)
+	})
+}
+
+// SetBootOverride sets the boot flags via IPMI like BootFrom does
+func (ob *outBand) SetBootOverride(ctx context.Context, override hal.BootOverride) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetBootOverride(ctx, override, vendor)
+	})
+}
+
+// BootConfiguration reads the boot options via Redfish and the override from the IPMI boot flags which BootFrom sets
+func (ob *outBand) BootConfiguration(ctx context.Context) (*hal.BootConfiguration, error) {
+	config, err := ob.OutBand.BootConfiguration(ctx)
+	if err != nil {
+		return nil, err
+	}
+	override, err := ob.BootOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.Override = *override
+	return config, nil
+}
+
+// BootOverride reads the boot flags via IPMI
+func (ob *outBand) BootOverride(ctx context.Context) (*hal.BootOverride, error) {
+	var override *hal.BootOverride
+	err := ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		var err error
+		override, err = client.BootOverride(ctx, vendor)
+		return err
 	})
+	return override, err
 }
 
 func (ob *outBand) Describe() string {
 	return "OutBand connected to Supermicro"
 }
 
+// BIOSDrift compares the profile with the BIOS configuration read by SUM from the BMC,
+// the paths of Supermicro profiles are the menu paths of SUM and not Redfish attribute names
+func (ob *outBand) BIOSDrift(ctx context.Context, profile hal.BIOSProfile) ([]hal.BIOSSettingDrift, error) {
+	return ob.sum.BIOSDrift(ctx, profile.SettingsFor(ob.Board().Model))
+}
+
+// ApplyBIOSProfile changes the settings which differ from the profile with SUM through the BMC
+func (ob *outBand) ApplyBIOSProfile(ctx context.Context, profile hal.BIOSProfile) (bool, error) {
+	return ob.sum.ApplyBIOSSettings(ctx, profile.SettingsFor(ob.Board().Model))
+}
+
+func (ob *outBand) Capabilities() hal.Capabilities {
+	return hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationUUID:              hal.TransportRedfish,
+			hal.OperationPowerState:        hal.TransportRedfish,
+			hal.OperationEvents:            hal.TransportRedfish,
+			hal.OperationSEL:               hal.TransportRedfish,
+			hal.OperationSensors:           hal.TransportRedfish,
+			hal.OperationFRU:               hal.TransportIPMI,
+			hal.OperationIdentifyLED:       hal.TransportIPMI,
+			hal.OperationConsole:           hal.TransportIPMI,
+			hal.OperationVirtualMedia:      hal.TransportRedfish,
+			hal.OperationBootConfiguration: hal.TransportRedfish,
+			hal.OperationBIOSAttributes:    hal.TransportRedfish,
+			hal.OperationBIOSProfile:       hal.TransportSUM,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:  hal.TransportIPMI,
+			hal.BootTargetDisk: hal.TransportIPMI,
+			hal.BootTargetBIOS: hal.TransportIPMI,
+			hal.BootTargetCD:   hal.TransportIPMI,
+			hal.BootTargetUSB:  hal.TransportIPMI,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportIPMI,
+			hal.PowerActionOff:   hal.TransportIPMI,
+			hal.PowerActionReset: hal.TransportIPMI,
+			hal.PowerActionCycle: hal.TransportIPMI,
+		},
+		UpdateMethods: map[hal.UpdateMethod]hal.Transport{
+			hal.UpdateMethodBIOS: hal.TransportSUM,
+			hal.UpdateMethodBMC:  hal.TransportSUM,
+		},
+	}
+}
+
 func (ob *outBand) Console(s ssh.Session) error {
-	return ob.IpmiTool.OpenConsole(s)
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(ctx context.Context, s ssh.Session) error {
+	return ob.IpmiTool.OpenConsole(ctx, s)
 }
 
 func (ob *outBand) UpdateBIOS(url string) error {
-	update, err := ob.downloadFirmwareUpdate(url)
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(ctx context.Context, url string) error {
+	update, err := ob.downloadFirmwareUpdate(ctx, url)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = update.Close()
+	}()
 
-	return ob.sum.UpdateBIOS(update)
+	return ob.sum.UpdateBIOS(ctx, update)
 }
 
 func (ob *outBand) UpdateBMC(url string) error {
-	update, err := ob.downloadFirmwareUpdate(url)
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(ctx context.Context, url string) error {
+	update, err := ob.downloadFirmwareUpdate(ctx, url)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = update.Close()
+	}()
 
-	return ob.sum.UpdateBMC(update)
+	return ob.sum.UpdateBMC(ctx, update)
 }
 
-func (ob *outBand) downloadFirmwareUpdate(url string) (io.Reader, error) {
-	resp, err := http.Get(url) // nolint:gosec,bodyclose,noctx
+func (ob *outBand) downloadFirmwareUpdate(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req) // nolint:gosec,bodyclose
 	if err != nil {
 		return nil, err
 	}
@@ -304,5 +517,5 @@ func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
 }
 
 func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
-	return c.IpmiTool.BMC()
+	return c.IpmiTool.BMC(context.Background())
 }
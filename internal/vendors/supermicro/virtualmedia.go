@@ -0,0 +1,89 @@
+package supermicro
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/stmcginnis/gofish/schemas"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/redfish"
+)
+
+const (
+	// cfgCD is the virtual media slot of X11 and older boards, it is configured with a patch and mounted with OEM actions
+	cfgCD = "CfgCD"
+	// usbCdBootSource is the boot source of the virtual CD, Supermicro attaches it via USB
+	usbCdBootSource schemas.BootSource = "UsbCd"
+)
+
+type isoConfigRequest struct {
+	Host     string `json:"Host"`
+	Path     string `json:"Path"`
+	Username string `json:"Username,omitempty"`
+	Password string `json:"Password,omitempty"`
+}
+
+// InsertVirtualMedia uses the InsertMedia action where the BMC offers it and the OEM IsoConfig.Mount action of the CfgCD slot otherwise,
+// which only mounts images from cifs shares.
+func (ob *outBand) InsertVirtualMedia(ctx context.Context, id string, image hal.VirtualMediaImage) error {
+	slot, err := ob.Redfish.VirtualMediaSlot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if slot.InsertTarget != "" {
+		bootOnce := image.BootOnce
+		image.BootOnce = false
+		err = ob.OutBand.InsertVirtualMedia(ctx, slot.ID, image)
+		if err != nil || !bootOnce {
+			return err
+		}
+		return ob.Redfish.BootOnce(ctx, usbCdBootSource)
+	}
+	if slot.ID != cfgCD {
+		return fmt.Errorf("insert media into slot %s %w", slot.ID, hal.ErrNotSupported)
+	}
+
+	protocol, err := redfish.TransferProtocol(image.URL)
+	if err != nil {
+		return err
+	}
+	if protocol != schemas.CIFSVirtualMediaTransferProtocolType {
+		return fmt.Errorf("slot %s only mounts images from cifs shares, got %s", cfgCD, image.URL)
+	}
+	u, err := url.Parse(image.URL)
+	if err != nil {
+		return err
+	}
+	err = ob.Redfish.PatchJSON(ctx, slot.ODataID, isoConfigRequest{
+		Host:     u.Host,
+		Path:     strings.ReplaceAll(u.Path, "/", `\`),
+		Username: image.UserName,
+		Password: image.Password,
+	}, "")
+	if err != nil {
+		return fmt.Errorf("unable to configure %s: %w", cfgCD, err)
+	}
+	err = ob.Redfish.PostJSON(ctx, slot.ODataID+"/Actions/IsoConfig.Mount", struct{}{}, nil)
+	if err != nil {
+		return fmt.Errorf("unable to mount %s: %w", image.URL, err)
+	}
+	if !image.BootOnce {
+		return nil
+	}
+	return ob.Redfish.BootOnce(ctx, usbCdBootSource)
+}
+
+// EjectVirtualMedia uses the EjectMedia action where the BMC offers it and the OEM IsoConfig.UMount action of the CfgCD slot otherwise
+func (ob *outBand) EjectVirtualMedia(ctx context.Context, id string) error {
+	slot, err := ob.Redfish.VirtualMediaSlot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if slot.EjectTarget != "" || slot.ID != cfgCD {
+		return ob.OutBand.EjectVirtualMedia(ctx, slot.ID)
+	}
+	return ob.Redfish.PostJSON(ctx, slot.ODataID+"/Actions/IsoConfig.UMount", struct{}{}, nil)
+}
@@ -0,0 +1,43 @@
+package supermicro
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/metal-stack/go-hal"
+	"github.com/metal-stack/go-hal/internal/outband"
+	"github.com/metal-stack/go-hal/internal/redfish/redfishtest"
+	"github.com/metal-stack/go-hal/pkg/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutBand_VirtualMediaCfgCD(t *testing.T) {
+	r, srv := redfishtest.NewClient(t, "testdata/cfgcd.json")
+	ob := &outBand{OutBand: outband.ViaRedfish(r, &api.Board{Vendor: api.VendorSupermicro})}
+
+	err := ob.InsertVirtualMedia(t.Context(), "", hal.VirtualMediaImage{URL: "http://10.0.0.1/rescue.iso"})
+	require.EqualError(t, err, "slot CfgCD only mounts images from cifs shares, got http://10.0.0.1/rescue.iso")
+
+	err = ob.InsertVirtualMedia(t.Context(), "", hal.VirtualMediaImage{
+		URL:      "smb://10.0.0.1/share/images/rescue.iso",
+		UserName: "metal",
+		Password: "secret",
+		BootOnce: true,
+	})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 3)
+	require.Equal(t, http.MethodPatch, requests[0].Method)
+	require.Equal(t, "/redfish/v1/Managers/1/VirtualMedia/CfgCD", requests[0].Path)
+	require.JSONEq(t, `{"Host": "10.0.0.1", "Path": "\\share\\images\\rescue.iso", "Username": "metal", "Password": "secret"}`, string(requests[0].Body))
+	require.Equal(t, http.MethodPost, requests[1].Method)
+	require.Equal(t, "/redfish/v1/Managers/1/VirtualMedia/CfgCD/Actions/IsoConfig.Mount", requests[1].Path)
+	require.Equal(t, "/redfish/v1/Systems/1", requests[2].Path)
+	require.JSONEq(t, `{"Boot": {"BootSourceOverrideEnabled": "Once", "BootSourceOverrideTarget": "UsbCd"}}`, string(requests[2].Body))
+
+	require.NoError(t, ob.EjectVirtualMedia(t.Context(), ""))
+	requests = srv.Requests()
+	require.Len(t, requests, 4)
+	require.Equal(t, "/redfish/v1/Managers/1/VirtualMedia/CfgCD/Actions/IsoConfig.UMount", requests[3].Path)
+}
@@ -0,0 +1,137 @@
+package supermicro
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metal-stack/go-hal/pkg/logger"
+)
+
+// fakeSumScript behaves like SUM for the commands which exchange files, the BIOS configuration it returns
+// is the file <ip>.biosCfg.xml next to the script or contains the ip of the BMC,
+// the files it receives are kept next to the script by ip and command
+const fakeSumScript = `#!/bin/sh
+out="$(dirname "$0")"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -c) command="$2"; shift ;;
+    --file) file="$2"; shift ;;
+    -i) ip="$2"; shift ;;
+    -p) password="$2"; shift ;;
+  esac
+  shift
+done
+echo "Supermicro Update Manager"
+echo "Connecting to $ip with password $password"
+case "$ip" in
+  broken)
+    exit 1 ;;
+  auth)
+    echo "ERROR: Authentication failed!" >&2
+    exit 12 ;;
+  unsupported)
+    echo "ERROR: This board is not supported by $command." >&2
+    exit 13 ;;
+  checksum)
+    printf 'Uploading 10%%\rUploading 100%%\n'
+    echo "ERROR: File checksum error." >&2
+    exit 14 ;;
+  silent)
+    exit 12 ;;
+  auth-message)
+    echo "ERROR: Authentication failed!" >&2
+    exit 1 ;;
+esac
+# give concurrent invocations the chance to interfere
+sleep 0.05
+case "$command" in
+  GetCurrentBiosCfg)
+    if [ -f "$out/$ip.biosCfg.xml" ]; then
+      cp "$out/$ip.biosCfg.xml" "$file"
+    else
+      printf '<?xml version="1.0" encoding="ISO-8859-1" standalone="yes"?>\n<BiosCfg><Menu name="Host"><Setting name="IP" selectedOption="%s" type="Option"/></Menu></BiosCfg>\n' "$ip" > "$file"
+    fi ;;
+  ChangeBiosCfg|UpdateBios|UpdateBmc)
+    cp "$file" "$out/$ip.$command" ;;
+  *)
+    exit 1 ;;
+esac
+`
+
+func fakeSum(t *testing.T) string {
+	bin := filepath.Join(t.TempDir(), "sum")
+	require.NoError(t, os.WriteFile(bin, []byte(fakeSumScript), 0700)) // #nosec G306
+	return bin
+}
+
+func TestSumConcurrentRemoteOperations(t *testing.T) {
+	bin := fakeSum(t)
+	tmpDir := t.TempDir()
+
+	t.Run("hosts", func(t *testing.T) {
+		for i := range 8 {
+			ip := fmt.Sprintf("10.0.0.%d", i+1)
+			t.Run(ip, func(t *testing.T) {
+				t.Parallel()
+
+				s, err := NewRemoteSum(bin, "X11DPT-B", ip, "ADMIN", "secret", logger.New())
+				require.NoError(t, err)
+				s.tmpDir = tmpDir
+
+				drift, err := s.BIOSDrift(t.Context(), map[string]string{"Host/IP": ip})
+				require.NoError(t, err)
+				require.Empty(t, drift)
+
+				changed, err := s.ApplyBIOSSettings(t.Context(), map[string]string{"Host/IP": ip + "-changed"})
+				require.NoError(t, err)
+				require.True(t, changed)
+
+				fragment, err := os.ReadFile(filepath.Join(filepath.Dir(bin), ip+".ChangeBiosCfg"))
+				require.NoError(t, err)
+				require.Contains(t, string(fragment), fmt.Sprintf(`<Setting name="IP" selectedOption="%s-changed" type="Option">`, ip))
+
+				require.NoError(t, s.UpdateBIOS(t.Context(), strings.NewReader("bios for "+ip)))
+				require.NoError(t, s.UpdateBMC(t.Context(), strings.NewReader("bmc for "+ip)))
+
+				update, err := os.ReadFile(filepath.Join(filepath.Dir(bin), ip+".UpdateBios"))
+				require.NoError(t, err)
+				require.Equal(t, "bios for "+ip, string(update))
+				update, err = os.ReadFile(filepath.Join(filepath.Dir(bin), ip+".UpdateBmc"))
+				require.NoError(t, err)
+				require.Equal(t, "bmc for "+ip, string(update))
+			})
+		}
+	})
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "work directories must be removed")
+
+	for _, f := range []string{"biosCfg.xml", "biosCfgUpdate.xml"} {
+		_, err = os.Stat(f)
+		require.ErrorIs(t, err, fs.ErrNotExist, "sum must not write into the working directory of the process")
+	}
+}
+
+func TestSumWorkDirRemovedOnFailure(t *testing.T) {
+	bin := fakeSum(t)
+	tmpDir := t.TempDir()
+
+	s, err := NewRemoteSum(bin, "X11DPT-B", "broken", "ADMIN", "secret", logger.New())
+	require.NoError(t, err)
+	s.tmpDir = tmpDir
+
+	_, err = s.BIOSDrift(t.Context(), map[string]string{"Host/IP": "10.0.0.1"})
+	require.Error(t, err)
+	require.Error(t, s.UpdateBIOS(t.Context(), strings.NewReader("bios")))
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
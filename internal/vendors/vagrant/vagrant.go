@@ -1,6 +1,7 @@
 package vagrant
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
@@ -14,7 +15,6 @@ import (
 	"github.com/metal-stack/go-hal/internal/outband"
 	"github.com/metal-stack/go-hal/pkg/api"
 	"github.com/metal-stack/go-hal/pkg/logger"
-	goipmi "github.com/vmware/goipmi"
 )
 
 const (
@@ -37,8 +37,8 @@ type (
 )
 
 // InBand creates an inband connection to a vagrant VM.
-func InBand(board *api.Board, log logger.Logger) (hal.InBand, error) {
-	ib, err := inband.New(board, false, log)
+func InBand(ctx context.Context, board *api.Board, log logger.Logger) (hal.InBand, error) {
+	ib, err := inband.New(ctx, board, false, log)
 	if err != nil {
 		return nil, err
 	}
@@ -56,41 +56,99 @@ func OutBand(board *api.Board, ip string, ipmiPort int, user, password string) h
 
 // InBand
 func (ib *inBand) PowerOff() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerDown)
+	return ib.PowerOffContext(context.Background())
+}
+
+func (ib *inBand) PowerOffContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerDown)
 }
 
 func (ib *inBand) PowerCycle() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlPowerCycle)
+	return ib.PowerCycleContext(context.Background())
+}
+
+func (ib *inBand) PowerCycleContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlPowerCycle)
 }
 
 func (ib *inBand) PowerReset() error {
-	return ib.IpmiTool.SetChassisControl(ipmi.ChassisControlHardReset)
+	return ib.PowerResetContext(context.Background())
+}
+
+func (ib *inBand) PowerResetContext(ctx context.Context) error {
+	return ib.IpmiTool.SetChassisControl(ctx, ipmi.ChassisControlHardReset)
 }
 
 func (ib *inBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return nil
+	return ib.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ib *inBand) IdentifyLEDStateContext(context.Context, hal.IdentifyLEDState) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) IdentifyLEDOn() error {
-	return nil
+	return ib.IdentifyLEDOnContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOnContext(context.Context) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) IdentifyLEDOff() error {
-	return nil
+	return ib.IdentifyLEDOffContext(context.Background())
+}
+
+func (ib *inBand) IdentifyLEDOffContext(context.Context) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ib.IpmiTool.SetBootOrder(bootTarget, vendor)
+	return ib.BootFromContext(context.Background(), bootTarget)
 }
 
-func (ib *inBand) SetFirmware(hal.FirmwareMode) error {
-	return nil
+func (ib *inBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ib.IpmiTool.SetBootOrder(ctx, bootTarget, vendor)
+}
+
+func (ib *inBand) SetFirmware(mode hal.FirmwareMode) error {
+	return ib.SetFirmwareContext(context.Background(), mode)
+}
+
+func (ib *inBand) SetFirmwareContext(context.Context, hal.FirmwareMode) error {
+	return hal.ErrNotSupported
 }
 
 func (ib *inBand) Describe() string {
 	return "InBand connected to Vagrant"
 }
 
+func (ib *inBand) Capabilities() hal.Capabilities {
+	c := ib.InBand.Capabilities()
+	delete(c.Operations, hal.OperationIdentifyLED)
+	delete(c.Operations, hal.OperationSEL)
+	delete(c.Operations, hal.OperationSensors)
+	delete(c.Operations, hal.OperationFRU)
+	delete(c.Operations, hal.OperationEnsureBootOrder)
+	return c
+}
+
+func (ib *inBand) SEL(context.Context, uint16) ([]hal.SELEntry, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (ib *inBand) ClearSEL(context.Context) error {
+	return hal.ErrNotSupported
+}
+
+func (ib *inBand) Sensors(context.Context) ([]hal.Sensor, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (ib *inBand) FRUs(context.Context) ([]api.FRU, error) {
+	return nil, hal.ErrNotSupported
+}
+
 func (ib *inBand) BMCConnection() api.BMCConnection {
 	return &bmcConnection{
 		inBand: ib,
@@ -118,81 +176,163 @@ func (c *bmcConnection) Present() bool {
 }
 
 func (c *bmcConnection) CreateUserAndPassword(user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
+	return c.CreateUserAndPasswordContext(context.Background(), user, privilege)
+}
+
+func (c *bmcConnection) CreateUserAndPasswordContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege) (string, error) {
 	return "", nil
 }
 
 func (c *bmcConnection) CreateUser(user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
+	return c.CreateUserContext(context.Background(), user, privilege, password)
+}
+
+func (c *bmcConnection) CreateUserContext(ctx context.Context, user api.BMCUser, privilege api.IpmiPrivilege, password string) error {
 	return nil
 }
 
 func (c *bmcConnection) NeedsPasswordChange(user api.BMCUser, password string) (bool, error) {
+	return c.NeedsPasswordChangeContext(context.Background(), user, password)
+}
+
+func (c *bmcConnection) NeedsPasswordChangeContext(ctx context.Context, user api.BMCUser, password string) (bool, error) {
 	return true, nil
 }
 
 func (c *bmcConnection) ChangePassword(user api.BMCUser, newPassword string) error {
+	return c.ChangePasswordContext(context.Background(), user, newPassword)
+}
+
+func (c *bmcConnection) ChangePasswordContext(ctx context.Context, user api.BMCUser, newPassword string) error {
 	return nil
 }
 
 func (c *bmcConnection) SetUserEnabled(user api.BMCUser, enabled bool) error {
+	return c.SetUserEnabledContext(context.Background(), user, enabled)
+}
+
+func (c *bmcConnection) SetUserEnabledContext(ctx context.Context, user api.BMCUser, enabled bool) error {
 	return nil
 }
 
+func (c *bmcConnection) LanConfig(context.Context) (*api.BMCLanConfig, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (c *bmcConnection) SetLanConfig(context.Context, api.BMCLanConfig) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnection) ListUsers(context.Context) ([]api.BMCAccount, error) {
+	return nil, hal.ErrNotSupported
+}
+
 func (ib *inBand) ConfigureBIOS() (bool, error) {
-	return false, nil
+	return ib.ConfigureBIOSContext(context.Background())
+}
+
+func (ib *inBand) ConfigureBIOSContext(context.Context) (bool, error) {
+	return false, hal.ErrNotSupported
 }
 
 func (ib *inBand) EnsureBootOrder(bootloaderID string) error {
-	return nil
+	return ib.EnsureBootOrderContext(context.Background(), bootloaderID)
+}
+
+func (ib *inBand) EnsureBootOrderContext(ctx context.Context, bootloaderID string) error {
+	return hal.ErrNotSupported
 }
 
 // OutBand
 func (ob *outBand) UUID() (*uuid.UUID, error) {
-	return nil, nil
+	return ob.UUIDContext(context.Background())
+}
+
+func (ob *outBand) UUIDContext(context.Context) (*uuid.UUID, error) {
+	return nil, hal.ErrNotSupported
 }
 
 func (ob *outBand) PowerState() (hal.PowerState, error) {
+	return ob.PowerStateContext(context.Background())
+}
+
+// PowerStateContext does not query the VM, vagrant machines are always reported to be powered on,
+// therefore it is not listed in the capabilities.
+func (ob *outBand) PowerStateContext(context.Context) (hal.PowerState, error) {
 	return hal.PowerOnState, nil
 }
 
 func (ob *outBand) PowerOff() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerDown)
+	return ob.PowerOffContext(context.Background())
+}
+
+func (ob *outBand) PowerOffContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerDown)
 	})
 }
 
 func (ob *outBand) PowerOn() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerUp)
+	return ob.PowerOnContext(context.Background())
+}
+
+func (ob *outBand) PowerOnContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerUp)
 	})
 }
 
 func (ob *outBand) PowerReset() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerHardReset)
+	return ob.PowerResetContext(context.Background())
+}
+
+func (ob *outBand) PowerResetContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlHardReset)
 	})
 }
 
 func (ob *outBand) PowerCycle() error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.Control(goipmi.ControlPowerCycle)
+	return ob.PowerCycleContext(context.Background())
+}
+
+func (ob *outBand) PowerCycleContext(ctx context.Context) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.Control(ctx, ipmi.ChassisControlPowerCycle)
 	})
 }
 
 func (ob *outBand) IdentifyLEDState(state hal.IdentifyLEDState) error {
-	return nil
+	return ob.IdentifyLEDStateContext(context.Background(), state)
+}
+
+func (ob *outBand) IdentifyLEDStateContext(context.Context, hal.IdentifyLEDState) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) IdentifyLEDOn() error {
-	return nil
+	return ob.IdentifyLEDOnContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOnContext(context.Context) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) IdentifyLEDOff() error {
-	return nil
+	return ob.IdentifyLEDOffContext(context.Background())
+}
+
+func (ob *outBand) IdentifyLEDOffContext(context.Context) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) BootFrom(bootTarget hal.BootTarget) error {
-	return ob.Goipmi(func(client *ipmi.Client) error {
-		return client.SetBootOrder(bootTarget, vendor)
+	return ob.BootFromContext(context.Background(), bootTarget)
+}
+
+func (ob *outBand) BootFromContext(ctx context.Context, bootTarget hal.BootTarget) error {
+	return ob.Goipmi(ctx, func(client *ipmi.Client) error {
+		return client.SetBootOrder(ctx, bootTarget, vendor)
 	})
 }
 
@@ -200,23 +340,75 @@ func (ob *outBand) Describe() string {
 	return "OutBand connected to Vagrant"
 }
 
-func (ob *outBand) Console(s ssh.Session) error { //Virsh console
+func (ob *outBand) Capabilities() hal.Capabilities {
+	return hal.Capabilities{
+		Operations: map[hal.Operation]hal.Transport{
+			hal.OperationConsole:           hal.TransportLocal,
+			hal.OperationBootConfiguration: hal.TransportIPMI,
+		},
+		BootTargets: map[hal.BootTarget]hal.Transport{
+			hal.BootTargetPXE:  hal.TransportIPMI,
+			hal.BootTargetDisk: hal.TransportIPMI,
+			hal.BootTargetBIOS: hal.TransportIPMI,
+			hal.BootTargetCD:   hal.TransportIPMI,
+			hal.BootTargetUSB:  hal.TransportIPMI,
+		},
+		PowerActions: map[hal.PowerAction]hal.Transport{
+			hal.PowerActionOn:    hal.TransportIPMI,
+			hal.PowerActionOff:   hal.TransportIPMI,
+			hal.PowerActionReset: hal.TransportIPMI,
+			hal.PowerActionCycle: hal.TransportIPMI,
+		},
+	}
+}
+
+// SEL is not emulated by virtualbmc
+func (ob *outBand) SEL(context.Context, uint16) ([]hal.SELEntry, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (ob *outBand) ClearSEL(context.Context) error {
+	return hal.ErrNotSupported
+}
+
+// Sensors are not emulated by virtualbmc
+func (ob *outBand) Sensors(context.Context) ([]hal.Sensor, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (ob *outBand) FRUs(context.Context) ([]api.FRU, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (ob *outBand) Console(s ssh.Session) error {
+	return ob.ConsoleContext(s.Context(), s)
+}
+
+func (ob *outBand) ConsoleContext(ctx context.Context, s ssh.Session) error { //Virsh console
 	_, err := io.WriteString(s, "Exit with '<Ctrl> 5'\n")
 	if err != nil {
 		return fmt.Errorf("failed to write to console %w", err)
 	}
 	ip, port, _, _ := ob.IPMIConnection()
 	addr := fmt.Sprintf("%s:%d", ip, port)
-	cmd := exec.Command("virsh", "console", addr, "--force")
+	cmd := exec.CommandContext(ctx, "virsh", "console", addr, "--force")
 	return console.Open(s, cmd)
 }
 
 func (ob *outBand) UpdateBIOS(url string) error {
-	return nil
+	return ob.UpdateBIOSContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBIOSContext(context.Context, string) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) UpdateBMC(url string) error {
-	return nil
+	return ob.UpdateBMCContext(context.Background(), url)
+}
+
+func (ob *outBand) UpdateBMCContext(context.Context, string) error {
+	return hal.ErrNotSupported
 }
 
 func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
@@ -228,3 +420,35 @@ func (ob *outBand) BMCConnection() api.OutBandBMCConnection {
 func (c *bmcConnectionOutBand) BMC() (*api.BMC, error) {
 	return api.VagrantBoard.BMC, nil
 }
+
+func (c *bmcConnectionOutBand) LanConfig(context.Context) (*api.BMCLanConfig, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) SetLanConfig(context.Context, api.BMCLanConfig) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) ListUsers(context.Context) ([]api.BMCAccount, error) {
+	return nil, hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) CreateUser(context.Context, api.BMCUser, api.BMCRole, string) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) DeleteUser(context.Context, api.BMCUser) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) ChangePassword(context.Context, api.BMCUser, string) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) SetUserRole(context.Context, api.BMCUser, api.BMCRole) error {
+	return hal.ErrNotSupported
+}
+
+func (c *bmcConnectionOutBand) SetUserEnabled(context.Context, api.BMCUser, bool) error {
+	return hal.ErrNotSupported
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"time"
+)
+
+// FRU the inventory information of a field replaceable unit, e.g. the mainboard, a power supply or a backplane
+type FRU struct {
+	// DeviceID is the logical FRU device id, 0 is the FRU of the BMC which usually describes the mainboard
+	DeviceID uint8
+	// Name is taken from the FRU device locator of the SDR, e.g. PSU1 FRU
+	Name string
+	// Chassis, Board and Product are nil if the FRU has no such area
+	Chassis      *FRUChassis
+	Board        *FRUBoard
+	Product      *FRUProduct
+	MultiRecords []FRUMultiRecord
+}
+
+// FRUChassis the chassis info area of a FRU
+type FRUChassis struct {
+	// Type is the SMBIOS chassis type, e.g. Rack Mount Chassis
+	Type         string
+	PartNumber   string
+	SerialNumber string
+	Custom       []string
+}
+
+// FRUBoard the board info area of a FRU
+type FRUBoard struct {
+	// MfgDate is zero if unspecified
+	MfgDate      time.Time
+	Manufacturer string
+	ProductName  string
+	SerialNumber string
+	PartNumber   string
+	FRUFileID    string
+	Custom       []string
+}
+
+// FRUProduct the product info area of a FRU
+type FRUProduct struct {
+	Manufacturer string
+	Name         string
+	PartNumber   string
+	Version      string
+	SerialNumber string
+	AssetTag     string
+	FRUFileID    string
+	Custom       []string
+}
+
+// FRUMultiRecord a record of the multi record area, e.g. the power supply information of a PSU
+type FRUMultiRecord struct {
+	// Type is the record type id, e.g. 0x00 for power supply information
+	Type uint8
+	// Data is the undecoded record data
+	Data []byte
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BMCIPSource is how the BMC obtains its IPv4 address
+type BMCIPSource string
+
+const (
+	// BMCIPSourceStatic the address, netmask and gateway are configured statically
+	BMCIPSourceStatic BMCIPSource = "static"
+	// BMCIPSourceDHCP the address is obtained via DHCP
+	BMCIPSourceDHCP BMCIPSource = "dhcp"
+)
+
+// BMCNIC is the network port the BMC is reachable through
+type BMCNIC string
+
+const (
+	// BMCNICDedicated the dedicated management port
+	BMCNICDedicated BMCNIC = "dedicated"
+	// BMCNICShared a LAN port of the mainboard which is shared with the host
+	BMCNICShared BMCNIC = "shared"
+	// BMCNICFailover the dedicated port if it has a link, the shared one otherwise
+	BMCNICFailover BMCNIC = "failover"
+)
+
+// BMCLanConfig is the network configuration of the BMC.
+// SetLanConfig applies all fields except MAC, so read the configuration, modify it and pass it back.
+type BMCLanConfig struct {
+	IPSource BMCIPSource
+	// IP, Netmask and Gateway are only applied if IPSource is static
+	IP      string
+	Netmask string
+	Gateway string
+	// MAC is read only
+	MAC string
+	// VLANID is 0 if VLAN tagging is disabled
+	VLANID uint16
+	// IPv6Addresses are the static IPv6 addresses in CIDR notation, e.g. 2001:db8::10/64
+	IPv6Addresses []string
+	// NIC is empty if the BMC does not support or report the port selection, it is left unchanged then
+	NIC BMCNIC
+}
+
+func (c *BMCLanConfig) String() string {
+	return fmt.Sprintf("source:%s ip:%s netmask:%s gateway:%s mac:%s vlan:%d ipv6:%s nic:%s",
+		c.IPSource, c.IP, c.Netmask, c.Gateway, c.MAC, c.VLANID, strings.Join(c.IPv6Addresses, ","), c.NIC)
+}
+
+// Verify returns an error naming the fields of c which the actual configuration read back from the BMC differs in
+func (c *BMCLanConfig) Verify(actual *BMCLanConfig) error {
+	var mismatches []string
+	mismatch := func(field string, want, got any) {
+		mismatches = append(mismatches, fmt.Sprintf("%s is %v instead of %v", field, got, want))
+	}
+	if c.IPSource != actual.IPSource {
+		mismatch("ip source", c.IPSource, actual.IPSource)
+	}
+	if c.IPSource == BMCIPSourceStatic {
+		if c.IP != actual.IP {
+			mismatch("ip", c.IP, actual.IP)
+		}
+		if c.Netmask != actual.Netmask {
+			mismatch("netmask", c.Netmask, actual.Netmask)
+		}
+		if c.Gateway != actual.Gateway {
+			mismatch("gateway", c.Gateway, actual.Gateway)
+		}
+	}
+	if c.VLANID != actual.VLANID {
+		mismatch("vlan id", c.VLANID, actual.VLANID)
+	}
+	want, got := slices.Sorted(slices.Values(c.IPv6Addresses)), slices.Sorted(slices.Values(actual.IPv6Addresses))
+	if !slices.Equal(want, got) {
+		mismatch("ipv6 addresses", want, got)
+	}
+	if c.NIC != "" && c.NIC != actual.NIC {
+		mismatch("nic", c.NIC, actual.NIC)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("bmc lan config not applied, %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sethvargo/go-password/password"
+)
+
+// ipmiMaxPasswordLength is the longest password IPMI 2.0 is able to store
+const ipmiMaxPasswordLength = 20
+
+// PasswordConstraints holds the password policy of a BMC.
+// Length, NumDigits, NumSymbols, NoUpper and AllowRepeat configure generated passwords,
+// the remaining fields describe which passwords the BMC accepts.
+type PasswordConstraints struct {
+	// Vendor the policy belongs to
+	Vendor Vendor
+
+	Length      int
+	NumDigits   int
+	NumSymbols  int
+	NoUpper     bool
+	AllowRepeat bool
+
+	MinLength int
+	MaxLength int
+	// MinCharClasses is the number of character classes out of upper case, lower case, digits and symbols a password must contain
+	MinCharClasses int
+	// RequireDigit demands at least one digit
+	RequireDigit bool
+	// Symbols are the symbols the BMC accepts, empty means the symbols of the password generator
+	Symbols string
+	// NoUserName rejects passwords which contain the name of the user
+	NoUserName bool
+}
+
+// PasswordPolicyError is returned if a password or the constraints themselves violate the password policy of a vendor
+type PasswordPolicyError struct {
+	Vendor Vendor
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("password violates the policy of %s: %s", e.Vendor, e.Reason)
+}
+
+// PasswordConstraints returns the password policy of the vendor, use Board.PasswordConstraints if the board is known
+func (v Vendor) PasswordConstraints() *PasswordConstraints {
+	pc := &PasswordConstraints{
+		Vendor:    v,
+		Length:    10,
+		NumDigits: 3,
+		MinLength: 8,
+		MaxLength: ipmiMaxPasswordLength,
+	}
+	switch v {
+	case VendorLenovo:
+		// XCC requires at least one letter and one digit and checks against the user name
+		pc.Length = 12
+		pc.MinLength = 10
+		pc.RequireDigit = true
+		pc.MinCharClasses = 2
+		pc.NoUserName = true
+	case VendorDell:
+		// iDRAC only accepts a subset of symbols, its strong password policy demands all character classes but symbols
+		pc.Length = 12
+		pc.NumSymbols = 1
+		pc.Symbols = "!#$%*+-.=?@^_"
+		pc.MinCharClasses = 3
+		pc.NoUserName = true
+	case VendorHPE:
+		pc.Length = 12
+	case VendorGigabyte, VendorASRockRack:
+		// MegaRAC password complexity requires upper and lower case letters and digits
+		pc.MinCharClasses = 3
+		pc.RequireDigit = true
+	}
+	return pc
+}
+
+// PasswordConstraints returns the password policy of the vendor of the board refined by its generation
+func (b *Board) PasswordConstraints() *PasswordConstraints {
+	pc := b.Vendor.PasswordConstraints()
+	if b.Vendor == VendorSupermicro && (strings.HasPrefix(b.Model, "X9") || strings.HasPrefix(b.Model, "X10")) {
+		// the ATEN firmware of older boards stores 16 byte passwords only
+		pc.MaxLength = 16
+	}
+	return pc
+}
+
+// Validate returns a PasswordPolicyError if the password of the given user is not accepted by the policy
+func (pc *PasswordConstraints) Validate(userName, passwd string) error {
+	violation := func(format string, args ...any) error {
+		return &PasswordPolicyError{Vendor: pc.Vendor, Reason: fmt.Sprintf(format, args...)}
+	}
+	length := len(passwd)
+	if length < pc.MinLength {
+		return violation("at least %d characters required, got %d", pc.MinLength, length)
+	}
+	if pc.MaxLength > 0 && length > pc.MaxLength {
+		return violation("at most %d characters allowed, got %d", pc.MaxLength, length)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range passwd {
+		switch {
+		case r > unicode.MaxASCII || !unicode.IsPrint(r) || r == ' ':
+			return violation("only printable ascii characters without spaces are allowed")
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			if pc.Symbols != "" && !strings.ContainsRune(pc.Symbols, r) {
+				return violation("symbol %q is not allowed, allowed are %q", r, pc.Symbols)
+			}
+			symbol = true
+		}
+	}
+	if pc.RequireDigit && !digit {
+		return violation("at least one digit required")
+	}
+	classes := 0
+	for _, present := range []bool{upper, lower, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < pc.MinCharClasses {
+		return violation("at least %d of upper case, lower case, digits and symbols required, got %d", pc.MinCharClasses, classes)
+	}
+	if pc.NoUserName && userName != "" && strings.Contains(strings.ToLower(passwd), strings.ToLower(userName)) {
+		return violation("must not contain the user name")
+	}
+	return nil
+}
+
+// Generate returns a random password for the given user which satisfies the policy
+func (pc *PasswordConstraints) Generate(userName string) (string, error) {
+	if pc.Length < pc.MinLength || pc.MaxLength > 0 && pc.Length > pc.MaxLength {
+		return "", &PasswordPolicyError{Vendor: pc.Vendor, Reason: fmt.Sprintf("generated length %d is not within %d and %d", pc.Length, pc.MinLength, pc.MaxLength)}
+	}
+	g, err := password.NewGenerator(&password.GeneratorInput{Symbols: pc.Symbols})
+	if err != nil {
+		return "", err
+	}
+	// character classes are chosen randomly, retry for the rare passwords without enough of them
+	var lastErr error
+	for range 10 {
+		passwd, err := g.Generate(pc.Length, pc.NumDigits, pc.NumSymbols, pc.NoUpper, pc.AllowRepeat)
+		if err != nil {
+			return "", fmt.Errorf("password generation failed: %w", err)
+		}
+		lastErr = pc.Validate(userName, passwd)
+		if lastErr == nil {
+			return passwd, nil
+		}
+	}
+	return "", lastErr
+}
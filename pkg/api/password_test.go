@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordConstraints_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      *PasswordConstraints
+		user    string
+		passwd  string
+		wantErr string
+	}{
+		{name: "default", pc: VendorSupermicro.PasswordConstraints(), passwd: "abcdefgh"},
+		{name: "too short", pc: VendorSupermicro.PasswordConstraints(), passwd: "abc", wantErr: "password violates the policy of Supermicro: at least 8 characters required, got 3"},
+		{name: "too long", pc: VendorHPE.PasswordConstraints(), passwd: "abcdefghijklmnopqrstu", wantErr: "password violates the policy of HPE: at most 20 characters allowed, got 21"},
+		{name: "old supermicro", pc: (&Board{Vendor: VendorSupermicro, Model: "X10DRW-iT"}).PasswordConstraints(), passwd: "abcdefghijklmnopq", wantErr: "password violates the policy of Supermicro: at most 16 characters allowed, got 17"},
+		{name: "space", pc: VendorSupermicro.PasswordConstraints(), passwd: "abcd efgh", wantErr: "password violates the policy of Supermicro: only printable ascii characters without spaces are allowed"},
+		{name: "lenovo digit", pc: VendorLenovo.PasswordConstraints(), passwd: "abcdefghijK", wantErr: "password violates the policy of Lenovo: at least one digit required"},
+		{name: "lenovo user name", pc: VendorLenovo.PasswordConstraints(), user: "Metal", passwd: "metal12345", wantErr: "password violates the policy of Lenovo: must not contain the user name"},
+		{name: "lenovo", pc: VendorLenovo.PasswordConstraints(), user: "metal", passwd: "abcdefgh12"},
+		{name: "dell classes", pc: VendorDell.PasswordConstraints(), passwd: "abcdefgh12", wantErr: "password violates the policy of Dell: at least 3 of upper case, lower case, digits and symbols required, got 2"},
+		{name: "dell symbol", pc: VendorDell.PasswordConstraints(), passwd: "abcdefGH12&", wantErr: `password violates the policy of Dell: symbol '&' is not allowed, allowed are "!#$%*+-.=?@^_"`},
+		{name: "dell", pc: VendorDell.PasswordConstraints(), passwd: "abcdefGH12!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pc.Validate(tt.user, tt.passwd)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error %v", err)
+				}
+				return
+			}
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Validate() error = %v, want a PasswordPolicyError", err)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordConstraints_Generate(t *testing.T) {
+	for _, v := range []Vendor{VendorSupermicro, VendorLenovo, VendorDell, VendorHPE, VendorGigabyte, VendorUnknown} {
+		pc := v.PasswordConstraints()
+		for range 50 {
+			passwd, err := pc.Generate("metal")
+			if err != nil {
+				t.Fatalf("Generate() for %s error = %v", v, err)
+			}
+			if len(passwd) != pc.Length {
+				t.Errorf("Generate() for %s length = %d, want %d", v, len(passwd), pc.Length)
+			}
+		}
+	}
+
+	pc := VendorSupermicro.PasswordConstraints()
+	pc.Length = 24
+	_, err := pc.Generate("metal")
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Errorf("Generate() error = %v, want a PasswordPolicyError", err)
+	}
+}
@@ -1,21 +1,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/metal-stack/go-hal/internal/kernel"
 )
 
-// PasswordConstraints holds the constraints that are ensured for generated passwords
-type PasswordConstraints struct {
-	Length      int
-	NumDigits   int
-	NumSymbols  int
-	NoUpper     bool
-	AllowRepeat bool
-}
-
 // Privilege of an IPMI user
 type IpmiPrivilege = uint8
 
@@ -49,6 +42,8 @@ type Board struct {
 	IndicatorLED  string
 	PowerMetric   *PowerMetric
 	PowerSupplies []PowerSupply
+	// DMI holds the raw fields of /sys/class/dmi/id by file name, only available in-band
+	DMI map[string]string
 }
 
 type PowerMetric struct {
@@ -89,6 +84,30 @@ type BMCUser struct {
 	ChannelNumber int
 }
 
+// BMCRole the role of a BMC account, the IPMI privileges map to the predefined Redfish roles
+type BMCRole string
+
+const (
+	// BMCRoleAdministrator may do everything, the IPMI administrator privilege
+	BMCRoleAdministrator BMCRole = "Administrator"
+	// BMCRoleOperator may control the server but not the BMC, the IPMI operator privilege
+	BMCRoleOperator BMCRole = "Operator"
+	// BMCRoleReadOnly may only read, the IPMI user and callback privileges
+	BMCRoleReadOnly BMCRole = "ReadOnly"
+	// BMCRoleNoAccess may not log in, the IPMI no access privilege
+	BMCRoleNoAccess BMCRole = "NoAccess"
+)
+
+// BMCAccount a user account of the BMC as listed by ListUsers
+type BMCAccount struct {
+	BMCUser
+	// Role is the Redfish role id, which may also be a custom role, respectively the IPMI privilege on the channel
+	Role    BMCRole
+	Enabled bool
+	// Locked is true if the BMC locked the account after failed logins, IPMI does not report it
+	Locked bool
+}
+
 // BMCConnection offers methods to add/update BMC users and retrieve BMC details
 type BMCConnection interface {
 	// BMC returns the actual BMC details
@@ -97,6 +116,7 @@ type BMCConnection interface {
 	PresentSuperUser() BMCUser
 	// NeedsPasswordChange checks if a password change is required
 	NeedsPasswordChange(user BMCUser, password string) (bool, error)
+	NeedsPasswordChangeContext(ctx context.Context, user BMCUser, password string) (bool, error)
 	// SuperUser returns the details of the preset metal bmc superuser
 	SuperUser() BMCUser
 	// User returns the details of the preset metal bmc user
@@ -105,18 +125,46 @@ type BMCConnection interface {
 	Present() bool
 	// Creates the given BMC user and returns generated password
 	CreateUserAndPassword(user BMCUser, privilege IpmiPrivilege) (string, error)
+	CreateUserAndPasswordContext(ctx context.Context, user BMCUser, privilege IpmiPrivilege) (string, error)
 	// Creates the given BMC user with the given password
 	CreateUser(user BMCUser, privilege IpmiPrivilege, password string) error
+	CreateUserContext(ctx context.Context, user BMCUser, privilege IpmiPrivilege, password string) error
 	// Changes the password of the given BMC user
 	ChangePassword(user BMCUser, newPassword string) error
+	ChangePasswordContext(ctx context.Context, user BMCUser, newPassword string) error
 	// Enables/Disables the given BMC user
 	SetUserEnabled(user BMCUser, enabled bool) error
+	SetUserEnabledContext(ctx context.Context, user BMCUser, enabled bool) error
+	// LanConfig returns the network configuration of the BMC
+	LanConfig(ctx context.Context) (*BMCLanConfig, error)
+	// SetLanConfig applies the network configuration to the BMC and verifies it by reading it back
+	SetLanConfig(ctx context.Context, config BMCLanConfig) error
+	// ListUsers returns the accounts of the LAN channel, empty user slots are omitted
+	ListUsers(ctx context.Context) ([]BMCAccount, error)
 }
 
-// OutBandBMCConnection offers a method to retrieve BMC details
+// OutBandBMCConnection offers methods to retrieve BMC details, to configure the BMC network and to manage the BMC users.
+// The user methods identify the account by the id of the given user, or by its name if the id is empty.
 type OutBandBMCConnection interface {
 	// BMC returns the actual BMC details
 	BMC() (*BMC, error)
+	// LanConfig returns the network configuration of the BMC
+	LanConfig(ctx context.Context) (*BMCLanConfig, error)
+	// SetLanConfig applies the network configuration to the BMC and verifies it by reading it back,
+	// unless the address of the connection itself changed
+	SetLanConfig(ctx context.Context, config BMCLanConfig) error
+	// ListUsers returns the accounts of the BMC, empty account slots are omitted
+	ListUsers(ctx context.Context) ([]BMCAccount, error)
+	// CreateUser creates an enabled account with the given role, the id of the user is ignored
+	CreateUser(ctx context.Context, user BMCUser, role BMCRole, password string) error
+	// DeleteUser deletes the account
+	DeleteUser(ctx context.Context, user BMCUser) error
+	// ChangePassword changes the password of the account
+	ChangePassword(ctx context.Context, user BMCUser, newPassword string) error
+	// SetUserRole changes the role of the account
+	SetUserRole(ctx context.Context, user BMCUser, role BMCRole) error
+	// SetUserEnabled enables or disables the account
+	SetUserEnabled(ctx context.Context, user BMCUser, enabled bool) error
 }
 
 // BMC Base Management Controller details
@@ -177,16 +225,6 @@ type (
 	Vendor int
 )
 
-func (v Vendor) PasswordConstraints() *PasswordConstraints {
-	return &PasswordConstraints{
-		Length:      10,
-		NumDigits:   3,
-		NumSymbols:  0,
-		NoUpper:     false,
-		AllowRepeat: false,
-	}
-}
-
 const (
 	// VendorUnknown is a unknown Vendor
 	VendorUnknown Vendor = iota
@@ -202,10 +240,15 @@ const (
 	VendorVagrant
 	// VendorGigabyte identifies all Gigabyte servers
 	VendorGigabyte
+	// VendorHPE identifies all HPE servers
+	VendorHPE
+	// VendorASRockRack identifies all ASRock Rack servers
+	VendorASRockRack
 )
 
 var (
-	vendors = [...]string{
+	vendorsLock sync.RWMutex
+	vendors     = []string{
 		VendorSupermicro: "Supermicro",
 		VendorNovarion:   "Novarion-Systems",
 		VendorLenovo:     "Lenovo",
@@ -213,17 +256,47 @@ var (
 		VendorVagrant:    "Vagrant",
 		VendorUnknown:    "UNKNOWN",
 		VendorGigabyte:   "Giga Computing",
+		VendorHPE:        "HPE",
+		VendorASRockRack: "ASRockRack",
 	}
-	allVendors = [...]Vendor{VendorSupermicro, VendorNovarion, VendorLenovo, VendorDell, VendorVagrant, VendorUnknown, VendorGigabyte}
+	allVendors = []Vendor{VendorSupermicro, VendorNovarion, VendorLenovo, VendorDell, VendorVagrant, VendorUnknown, VendorGigabyte, VendorHPE, VendorASRockRack}
 )
 
-func (v Vendor) String() string { return vendors[v] }
+func (v Vendor) String() string {
+	vendorsLock.RLock()
+	defer vendorsLock.RUnlock()
+	if int(v) < 0 || int(v) >= len(vendors) {
+		return vendors[VendorUnknown]
+	}
+	return vendors[v]
+}
+
+// RegisterVendor adds a vendor which is not built into go-hal and returns its identifier.
+// GuessVendor detects the vendor by name afterwards, registering a known name returns the existing identifier.
+func RegisterVendor(name string) Vendor {
+	if strings.TrimSpace(name) == "" {
+		return VendorUnknown
+	}
+	vendorsLock.Lock()
+	defer vendorsLock.Unlock()
+	for i, known := range vendors {
+		if strings.EqualFold(strings.TrimSpace(known), strings.TrimSpace(name)) {
+			return Vendor(i)
+		}
+	}
+	vendors = append(vendors, name)
+	v := Vendor(len(vendors) - 1)
+	allVendors = append(allVendors, v)
+	return v
+}
 
 // GuessVendor will try to guess from vendor string
 func GuessVendor(vendor string) Vendor {
+	vendorsLock.RLock()
+	defer vendorsLock.RUnlock()
+	givenVendor := strings.TrimSpace(strings.ToLower(vendor))
 	for _, v := range allVendors {
-		givenVendor := strings.TrimSpace(strings.ToLower(vendor))
-		possibleVendor := strings.TrimSpace(strings.ToLower(v.String()))
+		possibleVendor := strings.TrimSpace(strings.ToLower(vendors[v]))
 		if strings.Contains(givenVendor, possibleVendor) {
 			return v
 		}
@@ -39,6 +39,8 @@ func TestGuessVendor(t *testing.T) {
 		{name: "unknown", vendor: "unknown", want: VendorUnknown},
 		{name: "vagrant", vendor: "vagrant", want: VendorVagrant},
 		{name: "dell", vendor: "Dell", want: VendorDell},
+		{name: "hpe", vendor: "HPE", want: VendorHPE},
+		{name: "asrockrack", vendor: "ASRockRack", want: VendorASRockRack},
 	}
 	for i := range tests {
 		tt := tests[i]
@@ -49,3 +51,25 @@ func TestGuessVendor(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterVendor(t *testing.T) {
+	acme := RegisterVendor("Acme Servers")
+	if acme <= VendorASRockRack {
+		t.Fatalf("RegisterVendor() = %d, want a new vendor", acme)
+	}
+	if got := acme.String(); got != "Acme Servers" {
+		t.Errorf("Vendor.String() = %v, want %v", got, "Acme Servers")
+	}
+	if got := GuessVendor("ACME Servers Inc."); got != acme {
+		t.Errorf("GuessVendor() = %v, want %v", got, acme)
+	}
+	if got := RegisterVendor("acme servers"); got != acme {
+		t.Errorf("RegisterVendor() of a known name = %v, want %v", got, acme)
+	}
+	if got := RegisterVendor("Dell"); got != VendorDell {
+		t.Errorf("RegisterVendor() of a builtin vendor = %v, want %v", got, VendorDell)
+	}
+	if got := RegisterVendor(" "); got != VendorUnknown {
+		t.Errorf("RegisterVendor() of an empty name = %v, want %v", got, VendorUnknown)
+	}
+}
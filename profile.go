@@ -0,0 +1,39 @@
+package hal
+
+import "maps"
+
+// BIOSProfile describes the desired state of BIOS settings.
+// Settings are keyed by their path: on Supermicro the menu path in the BIOS configuration of SUM, e.g. "Boot/Boot mode select",
+// where a menu or setting which exists several times is selected by its order, e.g. "Boot/Boot Option #1[1]",
+// and a slash within a name is escaped, e.g. "Intel® VT for Directed I\/O (VT-d)". Via Redfish the path is the attribute name.
+type BIOSProfile struct {
+	// Settings maps the path of a setting to its desired value
+	Settings map[string]string
+	// Boards overrides settings per board model, e.g. X11DPT-B. An empty value removes the setting for the board.
+	Boards map[string]map[string]string
+}
+
+// SettingsFor returns the settings of the profile with the overrides of the given board model applied
+func (p BIOSProfile) SettingsFor(board string) map[string]string {
+	settings := maps.Clone(p.Settings)
+	if settings == nil {
+		settings = map[string]string{}
+	}
+	for path, value := range p.Boards[board] {
+		if value == "" {
+			delete(settings, path)
+			continue
+		}
+		settings[path] = value
+	}
+	return settings
+}
+
+// BIOSSettingDrift a setting whose current value differs from the profile
+type BIOSSettingDrift struct {
+	Path    string
+	Current string
+	Desired string
+	// Missing the setting does not exist in the BIOS, such a profile can not be applied
+	Missing bool
+}
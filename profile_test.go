@@ -0,0 +1,36 @@
+package hal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBIOSProfile_SettingsFor(t *testing.T) {
+	profile := BIOSProfile{
+		Settings: map[string]string{
+			"Boot/Boot mode select":            "UEFI",
+			"Advanced/Boot Feature/Quiet Boot": "Unchecked",
+		},
+		Boards: map[string]map[string]string{
+			"X11DPT-B": {
+				"Boot/Boot mode select":            "",
+				"Advanced/Boot Feature/Quiet Boot": "Checked",
+				"Security/Secure Boot/Secure Boot": "Disabled",
+			},
+		},
+	}
+
+	require.Equal(t, map[string]string{
+		"Boot/Boot mode select":            "UEFI",
+		"Advanced/Boot Feature/Quiet Boot": "Unchecked",
+	}, profile.SettingsFor("X11SDV-8C-TP8F"))
+	require.Equal(t, map[string]string{
+		"Advanced/Boot Feature/Quiet Boot": "Checked",
+		"Security/Secure Boot/Secure Boot": "Disabled",
+	}, profile.SettingsFor("X11DPT-B"))
+
+	// the overrides must not leak into the profile
+	require.Len(t, profile.Settings, 2)
+	require.Empty(t, BIOSProfile{}.SettingsFor("X11DPT-B"))
+}
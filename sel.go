@@ -0,0 +1,43 @@
+package hal
+
+import (
+	"time"
+)
+
+// SELSeverity the severity of a system event log entry
+type SELSeverity int
+
+const (
+	// SELSeverityInfo the entry is informational, e.g. a deasserted event
+	SELSeverityInfo SELSeverity = iota
+	// SELSeverityWarning the entry reports a non-critical condition
+	SELSeverityWarning
+	// SELSeverityCritical the entry reports a critical or non-recoverable condition
+	SELSeverityCritical
+)
+
+var selSeverities = [...]string{
+	SELSeverityInfo:     "INFO",
+	SELSeverityWarning:  "WARNING",
+	SELSeverityCritical: "CRITICAL",
+}
+
+func (s SELSeverity) String() string { return selSeverities[s] }
+
+// SELEntry a record of the system event log of the BMC
+type SELEntry struct {
+	// RecordID identifies the entry, record ids start over once the log was cleared
+	RecordID uint16
+	// Timestamp is the time the entry was added, zero if the BMC did not know the time yet
+	Timestamp    time.Time
+	SensorType   string
+	SensorNumber uint8
+	// EventType is the IPMI event/reading type, e.g. Threshold or Sensor-specific
+	EventType string
+	// Asserted is false if the entry reports that a condition went away
+	Asserted    bool
+	Severity    SELSeverity
+	Description string
+	// EventData holds the raw event data bytes of IPMI records, empty for entries read via Redfish
+	EventData []byte
+}
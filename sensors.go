@@ -0,0 +1,74 @@
+package hal
+
+// SensorType the kind of quantity a sensor measures
+type SensorType int
+
+const (
+	// SensorTypeTemperature measures degrees celsius
+	SensorTypeTemperature SensorType = iota
+	// SensorTypeFan measures the speed of a fan, usually in RPM
+	SensorTypeFan
+	// SensorTypeVoltage measures volts
+	SensorTypeVoltage
+	// SensorTypeCurrent measures amperes
+	SensorTypeCurrent
+)
+
+var sensorTypes = [...]string{
+	SensorTypeTemperature: "TEMPERATURE",
+	SensorTypeFan:         "FAN",
+	SensorTypeVoltage:     "VOLTAGE",
+	SensorTypeCurrent:     "CURRENT",
+}
+
+func (t SensorType) String() string { return sensorTypes[t] }
+
+// SensorStatus the state of a sensor reading compared to its thresholds
+type SensorStatus int
+
+const (
+	// SensorStatusUnknown the sensor could not be read, e.g. the component is absent or powered off
+	SensorStatusUnknown SensorStatus = iota
+	// SensorStatusOK the reading is within all thresholds
+	SensorStatusOK
+	// SensorStatusWarning the reading crossed a non-critical threshold
+	SensorStatusWarning
+	// SensorStatusCritical the reading crossed a critical threshold
+	SensorStatusCritical
+	// SensorStatusNonRecoverable the reading crossed a non-recoverable threshold, the hardware is likely damaged
+	SensorStatusNonRecoverable
+)
+
+var sensorStatuses = [...]string{
+	SensorStatusUnknown:        "UNKNOWN",
+	SensorStatusOK:             "OK",
+	SensorStatusWarning:        "WARNING",
+	SensorStatusCritical:       "CRITICAL",
+	SensorStatusNonRecoverable: "NONRECOVERABLE",
+}
+
+func (s SensorStatus) String() string { return sensorStatuses[s] }
+
+// SensorThresholds the thresholds of a sensor, a threshold is nil if the BMC does not report it
+type SensorThresholds struct {
+	LowerNonCritical    *float64
+	LowerCritical       *float64
+	LowerNonRecoverable *float64
+	UpperNonCritical    *float64
+	UpperCritical       *float64
+	UpperNonRecoverable *float64
+}
+
+// Sensor a reading of a temperature, fan, voltage or current sensor
+type Sensor struct {
+	Name string
+	// Number is the IPMI sensor number, zero if the BMC does not report it
+	Number uint8
+	Type   SensorType
+	// Reading is only valid if Status is not SensorStatusUnknown
+	Reading float64
+	// Unit of the reading and thresholds, e.g. C, RPM, V, A or %
+	Unit       string
+	Status     SensorStatus
+	Thresholds SensorThresholds
+}
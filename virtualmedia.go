@@ -0,0 +1,23 @@
+package hal
+
+// VirtualMedia a slot of the BMC which presents a remote image as a drive to the server
+type VirtualMedia struct {
+	ID   string
+	Name string
+	// MediaTypes are the drives the slot is able to emulate, e.g. CD, DVD or USBStick
+	MediaTypes []string
+	// Image is the url of the inserted image, empty if the slot is empty
+	Image          string
+	Inserted       bool
+	WriteProtected bool
+}
+
+// VirtualMediaImage an image to insert into a virtual media slot
+type VirtualMediaImage struct {
+	// URL of the image, http, https, nfs and cifs (smb) urls are supported
+	URL      string
+	UserName string
+	Password string
+	// BootOnce sets the next boot of the server to the virtual CD
+	BootOnce bool
+}